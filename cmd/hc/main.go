@@ -0,0 +1,238 @@
+// Command hc is a small curl-like client built on httpclient, so retries, expectations
+// and auth can be used from scripts
+//
+//	hc -X POST -json -d '{"name":"rex"}' -expect-status 201 https://petstore.example.com/v1/pets
+//	hc -bearer $TOKEN -retry 3 -o users.json https://api.example.com/users
+//	hc -curl -H 'X-Debug: 1' https://api.example.com/users
+//
+// The exit status is 0 on success, 1 when the request fails or doesn't meet an
+// expectation and 2 for usage errors
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// listFlag collects every value of a flag given more than once
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+type config struct {
+	method       string
+	headers      listFlag
+	data         string
+	json         bool
+	user         string
+	bearer       string
+	retries      int
+	retryBackoff time.Duration
+	timeout      time.Duration
+	expectStatus listFlag
+	output       string
+	include      bool
+	curl         bool
+	har          string
+	verbose      bool
+	url          string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parse(args, stderr)
+	if err != nil {
+		return 2
+	}
+	opts, err := cfg.options(stdin, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, "hc:", err)
+		return 2
+	}
+	var rec *httpclient.HARRecorder
+	if cfg.har != "" {
+		rec = httpclient.NewHARRecorder()
+		opts = append(opts, httpclient.RecordHAR(rec))
+	}
+	req, _, err := httpclient.New(opts...)
+	if err != nil {
+		fmt.Fprintln(stderr, "hc:", err)
+		return 2
+	}
+	if cfg.curl {
+		cmd, err := req.ToCurl()
+		if err != nil {
+			fmt.Fprintln(stderr, "hc:", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, cmd)
+		return 0
+	}
+	res, err := req.Send(context.Background())
+	if rec != nil {
+		if werr := rec.WriteFile(cfg.har); werr != nil {
+			fmt.Fprintln(stderr, "hc:", werr)
+		}
+	}
+	if res != nil {
+		if werr := cfg.write(res, stdout); werr != nil {
+			fmt.Fprintln(stderr, "hc:", werr)
+			return 1
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "hc:", err)
+		return 1
+	}
+	return 0
+}
+
+func parse(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{}
+	fs := flag.NewFlagSet("hc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hc [flags] url")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.method, "X", "", "request method, defaults to GET or POST when -d is set")
+	fs.Var(&cfg.headers, "H", "header to send as `Name: value`, can be repeated")
+	fs.StringVar(&cfg.data, "d", "", "request body, @file reads a file and @- reads stdin")
+	fs.BoolVar(&cfg.json, "json", false, "send and accept json")
+	fs.StringVar(&cfg.user, "u", "", "basic auth credentials as `user:password`")
+	fs.StringVar(&cfg.bearer, "bearer", "", "bearer token to send")
+	fs.IntVar(&cfg.retries, "retry", 0, "number of times to retry failed requests")
+	fs.DurationVar(&cfg.retryBackoff, "retry-backoff", time.Second, "base delay between retries")
+	fs.DurationVar(&cfg.timeout, "timeout", 0, "overall request timeout")
+	fs.Var(&cfg.expectStatus, "expect-status", "expected status code or comma separated codes, can be repeated")
+	fs.StringVar(&cfg.output, "o", "", "write the body to a file instead of stdout")
+	fs.BoolVar(&cfg.include, "i", false, "include the status line and headers in the output")
+	fs.BoolVar(&cfg.curl, "curl", false, "print the request as a curl command instead of sending it")
+	fs.StringVar(&cfg.har, "har", "", "write the exchange to a HAR file")
+	fs.BoolVar(&cfg.verbose, "v", false, "log requests and responses to stderr")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return nil, flag.ErrHelp
+	}
+	cfg.url = fs.Arg(0)
+	return cfg, nil
+}
+
+// options turns the flags into request options
+func (cfg *config) options(stdin io.Reader, stderr io.Writer) ([]httpclient.RequestOption, error) {
+	method := cfg.method
+	if method == "" {
+		method = "GET"
+		if cfg.data != "" {
+			method = "POST"
+		}
+	}
+	opts := []httpclient.RequestOption{httpclient.Method(strings.ToUpper(method)), httpclient.URL(cfg.url)}
+	if cfg.json {
+		opts = append(opts, httpclient.JSON())
+	}
+	headers := map[string]string{}
+	for _, h := range cfg.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("header %q is not `Name: value`", h)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	if len(headers) > 0 {
+		opts = append(opts, httpclient.AddHeaders(headers))
+	}
+	if cfg.data != "" {
+		body, err := readData(cfg.data, stdin)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpclient.WithBody(bytes.NewReader(body)))
+	}
+	if cfg.user != "" {
+		user, password, _ := strings.Cut(cfg.user, ":")
+		opts = append(opts, httpclient.BasicAuth(user, password))
+	}
+	if cfg.bearer != "" {
+		opts = append(opts, httpclient.BearerToken(cfg.bearer))
+	}
+	if cfg.retries > 0 {
+		opts = append(opts, httpclient.Retry(cfg.retries, cfg.retryBackoff))
+	}
+	if cfg.timeout > 0 {
+		opts = append(opts, httpclient.Timeout(cfg.timeout))
+	}
+	for _, list := range cfg.expectStatus {
+		for _, s := range strings.Split(list, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("invalid status %q", s)
+			}
+			opts = append(opts, httpclient.ExpectStatus(code))
+		}
+	}
+	if cfg.verbose {
+		logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		opts = append(opts, httpclient.WithLogger(logger), httpclient.Debug())
+	}
+	return opts, nil
+}
+
+// readData returns the body given with -d
+func readData(data string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case data == "@-":
+		return ioutil.ReadAll(stdin)
+	case strings.HasPrefix(data, "@"):
+		return ioutil.ReadFile(data[1:])
+	}
+	return []byte(data), nil
+}
+
+// write prints the response to stdout or the -o file
+func (cfg *config) write(res *httpclient.Response, stdout io.Writer) error {
+	if cfg.include {
+		fmt.Fprintf(stdout, "%s %d\n", res.Proto, res.Status)
+		names := make([]string, 0, len(res.Headers))
+		for name := range res.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range res.Headers[name] {
+				fmt.Fprintf(stdout, "%s: %s\n", name, v)
+			}
+		}
+		fmt.Fprintln(stdout)
+	}
+	if cfg.output != "" {
+		return ioutil.WriteFile(cfg.output, res.Body, 0644)
+	}
+	_, err := stdout.Write(res.Body)
+	return err
+}
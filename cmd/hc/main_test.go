@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hc(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func echoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":        r.Method,
+			"body":          string(body),
+			"x-debug":       r.Header.Get("X-Debug"),
+			"authorization": r.Header.Get("Authorization"),
+			"content-type":  r.Header.Get("Content-Type"),
+		})
+	}))
+}
+
+func TestRunPost(t *testing.T) {
+	ts := echoServer()
+	defer ts.Close()
+	code, out, errOut := hc(t, "", "-json", "-d", `{"a":1}`, "-H", "X-Debug: 1", "-bearer", "tok", "-expect-status", "200,201", ts.URL)
+	require.Equal(t, 0, code, errOut)
+	var got map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &got))
+	assert.Equal(t, map[string]string{
+		"method":        "POST",
+		"body":          `{"a":1}`,
+		"x-debug":       "1",
+		"authorization": "Bearer tok",
+		"content-type":  "application/json",
+	}, got)
+}
+
+func TestRunBodyFromStdinToFile(t *testing.T) {
+	ts := echoServer()
+	defer ts.Close()
+	out := filepath.Join(t.TempDir(), "out.json")
+	code, stdout, errOut := hc(t, "from stdin", "-X", "put", "-d", "@-", "-u", "user:pass", "-o", out, "-i", ts.URL)
+	require.Equal(t, 0, code, errOut)
+	assert.True(t, strings.HasPrefix(stdout, "HTTP/1.1 201\nContent-Length:"), stdout)
+	data, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"body":"from stdin"`)
+	assert.Contains(t, string(data), `"method":"PUT"`)
+	assert.Contains(t, string(data), `"authorization":"Basic dXNlcjpwYXNz"`)
+}
+
+func TestRunExpectStatus(t *testing.T) {
+	ts := echoServer()
+	defer ts.Close()
+	code, out, errOut := hc(t, "", "-expect-status", "200", ts.URL)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, `"method":"GET"`)
+	assert.Contains(t, errOut, "invalid status code")
+}
+
+func TestRunRetry(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	code, out, errOut := hc(t, "", "-retry", "3", "-retry-backoff", "1ms", ts.URL)
+	require.Equal(t, 0, code, errOut)
+	assert.Equal(t, "ok", out)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&n))
+}
+
+func TestRunCurl(t *testing.T) {
+	code, out, _ := hc(t, "", "-curl", "-H", "X-Debug: 1", "-d", "abc", "http://example.com/things")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "curl -X POST 'http://example.com/things'")
+	assert.Contains(t, out, "-H 'X-Debug: 1'")
+	assert.Contains(t, out, "--data-binary 'abc'")
+}
+
+func TestRunHAR(t *testing.T) {
+	ts := echoServer()
+	defer ts.Close()
+	har := filepath.Join(t.TempDir(), "run.har")
+	code, _, errOut := hc(t, "", "-har", har, ts.URL)
+	require.Equal(t, 0, code, errOut)
+	data, err := ioutil.ReadFile(har)
+	require.NoError(t, err)
+	var doc struct {
+		Log struct {
+			Entries []json.RawMessage `json:"entries"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Len(t, doc.Log.Entries, 1)
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, errOut := hc(t, "")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errOut, "usage: hc")
+	code, _, _ = hc(t, "", "-H", "nocolon", "http://example.com")
+	assert.Equal(t, 2, code)
+}
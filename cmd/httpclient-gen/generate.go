@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const schemaPrefix = "#/components/schemas/"
+
+// methods is the order operations on the same path are generated in
+var methods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE"}
+
+// initialisms are written in upper case in generated names, like golint expects
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// reserved are names used by the generated methods that a parameter can't take
+var reserved = map[string]bool{"body": true, "c": true, "ctx": true, "err": true, "opts": true, "out": true}
+
+// operation is an api operation with the parameters from its path item merged in
+type operation struct {
+	name   string
+	method string
+	path   string
+	op     *openapi3.Operation
+	params []*openapi3.Parameter
+}
+
+type generator struct {
+	doc     *openapi3.T
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// Generate returns the source of a package named pkg with a typed client for doc
+func Generate(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := &generator{doc: doc, imports: map[string]bool{}}
+	g.schemas()
+	ops, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		g.operation(op)
+	}
+	body := g.buf.Bytes()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by httpclient-gen. DO NOT EDIT.\n\n")
+	title := "the api"
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = doc.Info.Title
+		if doc.Info.Version != "" {
+			title += " " + doc.Info.Version
+		}
+	}
+	fmt.Fprintf(&out, "// Package %s is a client for %s\npackage %s\n\n", pkg, title, pkg)
+	out.WriteString("import (\n\t\"context\"\n\t\"encoding/json\"\n")
+	for _, imp := range []string{"fmt", "strings"} {
+		if g.imports[imp] {
+			fmt.Fprintf(&out, "\t%q\n", imp)
+		}
+	}
+	out.WriteString("\n\thttpclient \"github.com/lusis/go-experiments/pkg/funcopts/http\"\n)\n\n")
+	out.WriteString(clientSource)
+	out.Write(body)
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %w", err)
+	}
+	return src, nil
+}
+
+const clientSource = `// Client calls the api with an *httpclient.Client
+type Client struct {
+	client *httpclient.Client
+}
+
+// New returns a Client for the api at baseURL. opts are applied to every request
+func New(baseURL string, opts ...httpclient.RequestOption) *Client {
+	return &Client{client: httpclient.NewClient(append([]httpclient.RequestOption{httpclient.BaseURL(baseURL)}, opts...)...)}
+}
+
+// do sends a request and decodes a json response into out
+func (c *Client) do(ctx context.Context, method, path string, params map[string]string, body, out interface{}, opts []httpclient.RequestOption) error {
+	reqOpts := []httpclient.RequestOption{
+		httpclient.Method(method),
+		httpclient.Path(path),
+		httpclient.PathParams(params),
+		httpclient.Accept(httpclient.ContentTypeJSON),
+		httpclient.ExpectSuccess(),
+	}
+	if body != nil {
+		reqOpts = append(reqOpts, httpclient.WithTypedBody(body))
+	}
+	req, _, err := c.client.New(append(reqOpts, opts...)...)
+	if err != nil {
+		return err
+	}
+	res, err := req.Send(ctx)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(res.Body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Body, out)
+}
+`
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// comment writes text as a comment, one line per line of text
+func (g *generator) comment(text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		g.printf("// %s\n", strings.TrimRight(line, " "))
+	}
+}
+
+// schemas writes a type for every schema in the document's components
+func (g *generator) schemas() {
+	if g.doc.Components == nil {
+		return
+	}
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ref := g.doc.Components.Schemas[name]
+		typeName := goName(name, true)
+		g.printf("\n// %s is generated from the %s schema\n", typeName, name)
+		if ref.Value != nil && ref.Value.Description != "" {
+			g.printf("//\n")
+			g.comment(ref.Value.Description)
+		}
+		if ref.Ref == "" && isStruct(ref.Value) {
+			g.structType(typeName, ref.Value)
+			continue
+		}
+		g.printf("type %s %s\n", typeName, g.goType(ref))
+	}
+}
+
+func (g *generator) structType(name string, s *openapi3.Schema) {
+	required := map[string]bool{}
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	props := make([]string, 0, len(s.Properties))
+	for p := range s.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	g.printf("type %s struct {\n", name)
+	for _, p := range props {
+		ref := s.Properties[p]
+		typ, tag := g.goType(ref), p
+		if !required[p] {
+			tag += ",omitempty"
+			if g.isNamedStruct(ref) {
+				typ = "*" + typ
+			}
+		}
+		if ref.Value != nil && ref.Value.Description != "" {
+			g.comment(ref.Value.Description)
+		}
+		g.printf("%s %s `json:%q`\n", goName(p, true), typ, tag)
+	}
+	g.printf("}\n")
+}
+
+// isStruct reports whether s is an object with a fixed set of properties
+func isStruct(s *openapi3.Schema) bool {
+	return s != nil && (s.Type.Is("object") || s.Type == nil) && len(s.Properties) > 0
+}
+
+// isNamedStruct reports whether ref refers to a component schema generated as a struct
+func (g *generator) isNamedStruct(ref *openapi3.SchemaRef) bool {
+	if ref == nil || g.doc.Components == nil || !strings.HasPrefix(ref.Ref, schemaPrefix) {
+		return false
+	}
+	target, ok := g.doc.Components.Schemas[strings.TrimPrefix(ref.Ref, schemaPrefix)]
+	return ok && target.Ref == "" && isStruct(target.Value)
+}
+
+// goType returns the go type for a schema. Objects that aren't component schemas
+// are decoded into maps
+func (g *generator) goType(ref *openapi3.SchemaRef) string {
+	if ref == nil {
+		return "interface{}"
+	}
+	if strings.HasPrefix(ref.Ref, schemaPrefix) {
+		return goName(strings.TrimPrefix(ref.Ref, schemaPrefix), true)
+	}
+	s := ref.Value
+	if s == nil {
+		return "interface{}"
+	}
+	switch {
+	case s.Type.Is("string"):
+		return "string"
+	case s.Type.Is("integer"):
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case s.Type.Is("number"):
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case s.Type.Is("boolean"):
+		return "bool"
+	case s.Type.Is("array"):
+		return "[]" + g.goType(s.Items)
+	case s.Type.Is("object"):
+		if s.AdditionalProperties.Schema != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties.Schema)
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+// operations returns every operation in the document, ordered by path and method
+func (g *generator) operations() ([]operation, error) {
+	if g.doc.Paths == nil {
+		return nil, nil
+	}
+	paths := g.doc.Paths.InMatchingOrder()
+	sort.Strings(paths)
+	seen := map[string]string{}
+	var ops []operation
+	for _, path := range paths {
+		item := g.doc.Paths.Value(path)
+		for _, method := range methods {
+			op := item.GetOperation(method)
+			if op == nil {
+				continue
+			}
+			name := op.OperationID
+			if name == "" {
+				name = strings.ToLower(method) + " " + path
+			}
+			name = goName(name, true)
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("%s %s and %s both generate %s", method, path, other, name)
+			}
+			seen[name] = method + " " + path
+			ops = append(ops, operation{name: name, method: method, path: path, op: op, params: mergeParams(item.Parameters, op.Parameters)})
+		}
+	}
+	return ops, nil
+}
+
+// mergeParams returns the path item's parameters overridden by the operation's
+func mergeParams(item, op openapi3.Parameters) []*openapi3.Parameter {
+	var params []*openapi3.Parameter
+	index := map[string]int{}
+	for _, list := range []openapi3.Parameters{item, op} {
+		for _, ref := range list {
+			p := ref.Value
+			if p == nil {
+				continue
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// jsonSchema returns the json schema of content
+func jsonSchema(content openapi3.Content) *openapi3.SchemaRef {
+	if mt := content.Get("application/json"); mt != nil {
+		return mt.Schema
+	}
+	for ct, mt := range content {
+		if strings.HasSuffix(ct, "+json") {
+			return mt.Schema
+		}
+	}
+	return nil
+}
+
+// response returns the schema of the first successful json response
+func (g *generator) response(op *openapi3.Operation) *openapi3.SchemaRef {
+	if op.Responses == nil {
+		return nil
+	}
+	var codes []string
+	for code := range op.Responses.Map() {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if res := op.Responses.Value(code); res != nil && res.Value != nil {
+			if s := jsonSchema(res.Value.Content); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// operation writes the method for op and an option for each query and header parameter
+func (g *generator) operation(op operation) {
+	args := []string{"ctx context.Context"}
+	pathParams := []string{}
+	for _, p := range op.params {
+		if p.In != openapi3.ParameterInPath {
+			continue
+		}
+		arg := argName(p.Name)
+		typ := g.goType(p.Schema)
+		args = append(args, arg+" "+typ)
+		pathParams = append(pathParams, fmt.Sprintf("%q: %s", p.Name, g.toString(arg, typ)))
+	}
+	body := "nil"
+	if op.op.RequestBody != nil && op.op.RequestBody.Value != nil {
+		if s := jsonSchema(op.op.RequestBody.Value.Content); s != nil {
+			args = append(args, "body "+g.goType(s))
+			body = "body"
+		}
+	}
+	args = append(args, "opts ...httpclient.RequestOption")
+	params := "nil"
+	if len(pathParams) > 0 {
+		params = "map[string]string{" + strings.Join(pathParams, ", ") + "}"
+	}
+
+	g.printf("\n// %s calls %s %s\n", op.name, op.method, op.path)
+	if doc := op.op.Summary + "\n\n" + op.op.Description; strings.TrimSpace(doc) != "" {
+		g.printf("//\n")
+		g.comment(doc)
+	}
+	call := fmt.Sprintf("c.do(ctx, %q, %q, %s, %s", op.method, op.path, params, body)
+	res := g.response(op.op)
+	switch {
+	case res == nil:
+		g.printf("func (c *Client) %s(%s) error {\n", op.name, strings.Join(args, ", "))
+		g.printf("return %s, nil, opts)\n}\n", call)
+	case g.isNamedStruct(res):
+		typ := g.goType(res)
+		g.printf("func (c *Client) %s(%s) (*%s, error) {\n", op.name, strings.Join(args, ", "), typ)
+		g.printf("var out %s\nif err := %s, &out, opts); err != nil {\nreturn nil, err\n}\nreturn &out, nil\n}\n", typ, call)
+	default:
+		typ := g.goType(res)
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", op.name, strings.Join(args, ", "), typ)
+		g.printf("var out %s\nerr := %s, &out, opts)\nreturn out, err\n}\n", typ, call)
+	}
+
+	for _, p := range op.params {
+		switch p.In {
+		case openapi3.ParameterInQuery, openapi3.ParameterInHeader:
+			g.paramOption(op.name, p)
+		}
+	}
+}
+
+// paramOption writes an option that sets a query or header parameter of an operation
+func (g *generator) paramOption(opName string, p *openapi3.Parameter) {
+	name := opName + goName(p.Name, true)
+	typ := g.goType(p.Schema)
+	g.printf("\n// %s sets the `%s` %s parameter of `%s`\n", name, p.Name, p.In, opName)
+	if p.Description != "" {
+		g.printf("//\n")
+		g.comment(p.Description)
+	}
+	var values string
+	if elem := strings.TrimPrefix(typ, "[]"); elem != typ {
+		g.printf("func %s(v ...%s) httpclient.RequestOption {\n", name, elem)
+		if elem == "string" {
+			values = "v"
+		} else {
+			g.imports["fmt"] = true
+			g.printf("values := make([]string, len(v))\nfor i := range v {\nvalues[i] = fmt.Sprint(v[i])\n}\n")
+			values = "values"
+		}
+		if p.In == openapi3.ParameterInQuery {
+			g.printf("return httpclient.QueryParam(%q, %s...)\n}\n", p.Name, values)
+			return
+		}
+		g.imports["strings"] = true
+		g.printf("return httpclient.AddHeaders(map[string]string{%q: strings.Join(%s, \",\")})\n}\n", p.Name, values)
+		return
+	}
+	g.printf("func %s(v %s) httpclient.RequestOption {\n", name, typ)
+	if p.In == openapi3.ParameterInQuery {
+		g.printf("return httpclient.QueryParam(%q, %s)\n}\n", p.Name, g.toString("v", typ))
+		return
+	}
+	g.printf("return httpclient.AddHeaders(map[string]string{%q: %s})\n}\n", p.Name, g.toString("v", typ))
+}
+
+// toString returns an expression formatting the variable v of type typ as a string
+func (g *generator) toString(v, typ string) string {
+	if typ == "string" {
+		return v
+	}
+	g.imports["fmt"] = true
+	return "fmt.Sprint(" + v + ")"
+}
+
+// argName returns the name of the method argument for a parameter
+func argName(name string) string {
+	arg := goName(name, false)
+	if token.IsKeyword(arg) || reserved[arg] {
+		arg += "Param"
+	}
+	return arg
+}
+
+// goName turns an identifier from the document, like `show_pet-by id`, into a go
+// identifier like `ShowPetByID`. The first letter is lower case when exported is false
+func goName(s string, exported bool) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	var b strings.Builder
+	for i, w := range words {
+		upper := strings.ToUpper(w)
+		switch {
+		case i == 0 && !exported:
+			b.WriteString(strings.ToLower(w))
+		case initialisms[upper]:
+			b.WriteString(upper)
+		default:
+			r := []rune(w)
+			b.WriteString(string(unicode.ToUpper(r[0])) + strings.ToLower(string(r[1:])))
+		}
+	}
+	name := b.String()
+	if name == "" {
+		return "X"
+	}
+	if unicode.IsDigit(rune(name[0])) {
+		name = "N" + name
+	}
+	return name
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMatchesPetstore(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromFile("testdata/petstore.yaml")
+	require.NoError(t, err)
+	src, err := Generate(doc, "petstore")
+	require.NoError(t, err)
+	want, err := ioutil.ReadFile("internal/petstore/petstore.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(src), "run go generate ./internal/petstore")
+}
+
+func TestGenerateDuplicateOperation(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info: {title: dupes, version: "1"}
+paths:
+  /a:
+    get: {operationId: fetch, responses: {"200": {description: ok}}}
+  /b:
+    get: {operationId: fetch, responses: {"200": {description: ok}}}
+`))
+	require.NoError(t, err)
+	_, err = Generate(doc, "dupes")
+	assert.Error(t, err)
+}
+
+func TestGenerateWithoutOperationID(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info: {title: plain, version: "1"}
+paths:
+  /users/{user_id}/keys:
+    get:
+      parameters:
+        - {name: user_id, in: path, required: true, schema: {type: string}}
+        - {name: type, in: query, schema: {type: array, items: {type: integer}}}
+      responses: {"200": {description: ok, content: {application/json: {schema: {type: array, items: {type: string}}}}}}
+`))
+	require.NoError(t, err)
+	src, err := Generate(doc, "plain")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "func (c *Client) GetUsersUserIDKeys(ctx context.Context, userID string, opts ...httpclient.RequestOption) ([]string, error)")
+	assert.Contains(t, string(src), `map[string]string{"user_id": userID}`)
+	assert.Contains(t, string(src), "func GetUsersUserIDKeysType(v ...int64) httpclient.RequestOption")
+}
+
+func TestGoName(t *testing.T) {
+	cases := map[string]string{
+		"showPetById":      "ShowPetByID",
+		"list_pets":        "ListPets",
+		"X-Request-Source": "XRequestSource",
+		"HTTPServer":       "HTTPServer",
+		"2fa":              "N2fa",
+		"get /pets/{id}":   "GetPetsID",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, goName(in, true), in)
+	}
+	assert.Equal(t, "petID", goName("petId", false))
+	assert.Equal(t, "typeParam", argName("type"))
+	assert.Equal(t, "bodyParam", argName("body"))
+}
@@ -0,0 +1,125 @@
+// Code generated by httpclient-gen. DO NOT EDIT.
+
+// Package petstore is a client for Petstore 1.0.0
+package petstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// Client calls the api with an *httpclient.Client
+type Client struct {
+	client *httpclient.Client
+}
+
+// New returns a Client for the api at baseURL. opts are applied to every request
+func New(baseURL string, opts ...httpclient.RequestOption) *Client {
+	return &Client{client: httpclient.NewClient(append([]httpclient.RequestOption{httpclient.BaseURL(baseURL)}, opts...)...)}
+}
+
+// do sends a request and decodes a json response into out
+func (c *Client) do(ctx context.Context, method, path string, params map[string]string, body, out interface{}, opts []httpclient.RequestOption) error {
+	reqOpts := []httpclient.RequestOption{
+		httpclient.Method(method),
+		httpclient.Path(path),
+		httpclient.PathParams(params),
+		httpclient.Accept(httpclient.ContentTypeJSON),
+		httpclient.ExpectSuccess(),
+	}
+	if body != nil {
+		reqOpts = append(reqOpts, httpclient.WithTypedBody(body))
+	}
+	req, _, err := c.client.New(append(reqOpts, opts...)...)
+	if err != nil {
+		return err
+	}
+	res, err := req.Send(ctx)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(res.Body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Body, out)
+}
+
+// NewPet is generated from the NewPet schema
+type NewPet struct {
+	Name string `json:"name"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// Pet is generated from the Pet schema
+//
+// A pet in the store
+type Pet struct {
+	ID         int64                  `json:"id"`
+	Labels     map[string]string      `json:"labels,omitempty"`
+	Name       string                 `json:"name"`
+	Owner      map[string]interface{} `json:"owner,omitempty"`
+	Tag        string                 `json:"tag,omitempty"`
+	Vaccinated bool                   `json:"vaccinated,omitempty"`
+	Weight     float64                `json:"weight,omitempty"`
+}
+
+// ListPets calls GET /pets
+//
+// List all pets
+func (c *Client) ListPets(ctx context.Context, opts ...httpclient.RequestOption) ([]Pet, error) {
+	var out []Pet
+	err := c.do(ctx, "GET", "/pets", nil, nil, &out, opts)
+	return out, err
+}
+
+// ListPetsLimit sets the `limit` query parameter of `ListPets`
+//
+// How many items to return at one time
+func ListPetsLimit(v int32) httpclient.RequestOption {
+	return httpclient.QueryParam("limit", fmt.Sprint(v))
+}
+
+// ListPetsTag sets the `tag` query parameter of `ListPets`
+func ListPetsTag(v ...string) httpclient.RequestOption {
+	return httpclient.QueryParam("tag", v...)
+}
+
+// CreatePet calls POST /pets
+//
+// Create a pet
+func (c *Client) CreatePet(ctx context.Context, body NewPet, opts ...httpclient.RequestOption) (*Pet, error) {
+	var out Pet
+	if err := c.do(ctx, "POST", "/pets", nil, body, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePetXRequestSource sets the `X-Request-Source` header parameter of `CreatePet`
+func CreatePetXRequestSource(v string) httpclient.RequestOption {
+	return httpclient.AddHeaders(map[string]string{"X-Request-Source": v})
+}
+
+// ShowPetByID calls GET /pets/{petId}
+//
+// Info for a specific pet
+func (c *Client) ShowPetByID(ctx context.Context, petID int64, opts ...httpclient.RequestOption) (*Pet, error) {
+	var out Pet
+	if err := c.do(ctx, "GET", "/pets/{petId}", map[string]string{"petId": fmt.Sprint(petID)}, nil, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ShowPetByIDVerbose sets the `verbose` query parameter of `ShowPetByID`
+func ShowPetByIDVerbose(v bool) httpclient.RequestOption {
+	return httpclient.QueryParam("verbose", fmt.Sprint(v))
+}
+
+// DeletePet calls DELETE /pets/{petId}
+func (c *Client) DeletePet(ctx context.Context, petID int64, opts ...httpclient.RequestOption) error {
+	return c.do(ctx, "DELETE", "/pets/{petId}", map[string]string{"petId": fmt.Sprint(petID)}, nil, nil, opts)
+}
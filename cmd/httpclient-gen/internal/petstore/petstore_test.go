@@ -0,0 +1,59 @@
+package petstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/pets":
+			assert.Equal(t, "2", r.URL.Query().Get("limit"))
+			assert.Equal(t, []string{"a", "b"}, r.URL.Query()["tag"])
+			w.Write([]byte(`[{"id":1,"name":"rex"},{"id":2,"name":"tom","tag":"a"}]`))
+		case "POST /v1/pets":
+			assert.Equal(t, "tests", r.Header.Get("X-Request-Source"))
+			var p NewPet
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Pet{ID: 3, Name: p.Name})
+		case "GET /v1/pets/3":
+			assert.Equal(t, "true", r.URL.Query().Get("verbose"))
+			w.Write([]byte(`{"id":3,"name":"kit","labels":{"color":"black"}}`))
+		case "DELETE /v1/pets/3":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	c := New(ts.URL + "/v1")
+	ctx := context.Background()
+
+	pets, err := c.ListPets(ctx, ListPetsLimit(2), ListPetsTag("a", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, []Pet{{ID: 1, Name: "rex"}, {ID: 2, Name: "tom", Tag: "a"}}, pets)
+
+	created, err := c.CreatePet(ctx, NewPet{Name: "kit"}, CreatePetXRequestSource("tests"))
+	require.NoError(t, err)
+	assert.Equal(t, &Pet{ID: 3, Name: "kit"}, created)
+
+	pet, err := c.ShowPetByID(ctx, 3, ShowPetByIDVerbose(true))
+	require.NoError(t, err)
+	assert.Equal(t, "black", pet.Labels["color"])
+
+	assert.NoError(t, c.DeletePet(ctx, 3))
+
+	_, err = c.ShowPetByID(ctx, 4)
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode))
+}
@@ -0,0 +1,53 @@
+// Command httpclient-gen reads an OpenAPI 3 document and writes a typed go client built on
+// httpclient. Each operation becomes a method on the generated Client, path parameters
+// and json request bodies become arguments and query and header parameters become options
+//
+//	httpclient-gen -spec petstore.yaml -package petstore -o petstore.go
+//
+//	c := petstore.New("https://petstore.example.com/v1", httpclient.BearerToken(token))
+//	pets, err := c.ListPets(ctx, petstore.ListPetsLimit(10))
+//
+// Component schemas become go types. Inline objects are decoded into maps
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func main() {
+	spec := flag.String("spec", "", "path to the OpenAPI document")
+	pkg := flag.String("package", "client", "name of the generated package")
+	out := flag.String("o", "", "file to write, defaults to stdout")
+	flag.Parse()
+	if *spec == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*spec, *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "httpclient-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(spec, pkg, out string) error {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(spec)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", spec, err)
+	}
+	src, err := Generate(doc, pkg)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
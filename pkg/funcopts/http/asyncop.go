@@ -0,0 +1,220 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// DefaultAsyncPollInterval is how long `FollowAsyncOperation` waits between polls
+// when the server doesn't send `Retry-After`
+const DefaultAsyncPollInterval = 5 * time.Second
+
+// Headers pointing at the status of an async operation, in the order they are used
+var asyncOperationHeaders = []string{"Azure-AsyncOperation", "Operation-Location"}
+
+// AsyncOperationError is the error returned when an operation followed by
+// `FollowAsyncOperation` ends as failed or canceled
+type AsyncOperationError struct {
+	// Status is the status reported by the operation, e.g. Failed
+	Status  string
+	Code    string
+	Message string
+	// Response is the last response of the status resource
+	Response *Response
+}
+
+func (e *AsyncOperationError) Error() string {
+	msg := fmt.Sprintf("%v: %s", ErrAsyncOperationFailed, e.Status)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns `ErrAsyncOperationFailed`
+func (e *AsyncOperationError) Unwrap() error {
+	return ErrAsyncOperationFailed
+}
+
+// asyncStatus is the body of an operation status resource
+type asyncStatus struct {
+	Status           string `json:"status"`
+	ResourceLocation string `json:"resourceLocation"`
+	Error            struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// FollowAsyncOperation waits for a long running operation started by the request.
+// When the server answers 202 Accepted with an `Azure-AsyncOperation` or
+// `Operation-Location` header, that status resource is polled until its status is
+// Succeeded, Failed or Canceled and the resource is then fetched from `Location`, or
+// from the request url for PUT and PATCH. With only a `Location` header it is polled
+// until it stops answering 202. Polls wait for `Retry-After`, or
+// `DefaultAsyncPollInterval`, with the clock set by `WithClock`, and are sent with the
+// options of the request other than its body and query params. Status checks like
+// `ExpectStatus` and expectations only apply to the terminal resource, and a `Client`
+// closed meanwhile waits for the whole operation. Polls to another host
+// than the request's are sent without its credentials, i.e. redacted headers like
+// `Authorization`, cookies and the `Idempotency-Key`. The terminal resource is
+// returned, or an `AsyncOperationError` when the operation failed
+func FollowAsyncOperation() RequestOption {
+	return func(r *Request) error {
+		r.followAsync = true
+		return nil
+	}
+}
+
+// followAsyncOperation polls the operation started by req until it ends
+func (cr *Request) followAsyncOperation(req *http.Request, res *Response) (*Response, error) {
+	if res.Status != http.StatusAccepted {
+		return res, nil
+	}
+	var operation string
+	for _, name := range asyncOperationHeaders {
+		if operation = res.Headers.Get(name); operation != "" {
+			break
+		}
+	}
+	location := res.Headers.Get("Location")
+	if operation == "" && location == "" {
+		return res, nil
+	}
+	if operation == "" {
+		return cr.pollLocation(req, res, location)
+	}
+	last := res
+	for {
+		if err := cr.waitForAsync(req, last); err != nil {
+			return last, err
+		}
+		polled, err := cr.pollAsync(req, last.URL, operation)
+		if err != nil {
+			return polled, err
+		}
+		last = polled
+		var status asyncStatus
+		if err := json.Unmarshal(polled.Body, &status); err != nil {
+			return polled, fmt.Errorf("decoding operation status: %w", err)
+		}
+		switch strings.ToLower(status.Status) {
+		case "succeeded":
+			target := location
+			if req.Method == "PUT" || req.Method == "PATCH" {
+				target = req.URL.String()
+			} else if target == "" {
+				target = status.ResourceLocation
+			}
+			if target == "" {
+				return polled, nil
+			}
+			return cr.pollAsync(req, res.URL, target)
+		case "failed", "canceled", "cancelled":
+			return polled, &AsyncOperationError{
+				Status:   status.Status,
+				Code:     status.Error.Code,
+				Message:  status.Error.Message,
+				Response: polled,
+			}
+		}
+	}
+}
+
+// pollLocation polls location until it no longer answers 202 Accepted
+func (cr *Request) pollLocation(req *http.Request, res *Response, location string) (*Response, error) {
+	last := res
+	for last.Status == http.StatusAccepted {
+		if err := cr.waitForAsync(req, last); err != nil {
+			return last, err
+		}
+		polled, err := cr.pollAsync(req, last.URL, location)
+		if err != nil {
+			return polled, err
+		}
+		if next := polled.Headers.Get("Location"); next != "" {
+			location = next
+		}
+		last = polled
+	}
+	return last, nil
+}
+
+// waitForAsync waits for the `Retry-After` of res or `DefaultAsyncPollInterval`
+func (cr *Request) waitForAsync(req *http.Request, res *Response) error {
+	clk := clock.FromContext(req.Context())
+	wait, ok := transport.RetryAfter(res.Headers, clk.Now())
+	if !ok {
+		wait = DefaultAsyncPollInterval
+	}
+	return clk.Sleep(req.Context(), wait)
+}
+
+// pollAsync sends a GET to location, resolved against base, with the options of the request
+func (cr *Request) pollAsync(req *http.Request, base, location string) (*Response, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	target := u.ResolveReference(ref)
+	ctx := req.Context()
+	opts := []RequestOption{asyncPoll(target.String())}
+	if host := originalHost(req); target.Host != host {
+		ctx = context.WithValue(ctx, originalHostKey{}, host)
+		opts = append(opts, withoutCredentials())
+	}
+	poll, err := cr.Clone(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return poll.Send(ctx)
+}
+
+// withoutCredentials keeps a clone of the request from sending the headers it redacts,
+// its cookies and its `Idempotency-Key`, e.g. to another host
+func withoutCredentials() RequestOption {
+	return func(r *Request) error {
+		names := append(r.redactedHeaders(), HeaderIdempotencyKey)
+		r.rawRequest = append(r.rawRequest, func(req *http.Request) {
+			for _, name := range names {
+				req.Header.Del(name)
+			}
+		})
+		return nil
+	}
+}
+
+// asyncPoll turns a clone of the request into a GET of target
+func asyncPoll(target string) RequestOption {
+	return func(r *Request) error {
+		r.method = "GET"
+		r.url = target
+		r.body = nil
+		r.bodyFunc = nil
+		r.streamBody = false
+		r.typedBody = nil
+		r.hasTypedBody = false
+		r.queryParams = nil
+		r.queryValues = nil
+		r.followAsync = false
+		// polls are part of the operation, which is counted and validated as a whole
+		r.lifecycle = nil
+		r.skipValidation = true
+		return nil
+	}
+}
@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowAsyncOperation(t *testing.T) {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vms/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			w.Header().Set("Azure-AsyncOperation", "/operations/1")
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Write([]byte(`{"name":"vm1"}`))
+	})
+	mux.HandleFunc("/operations/1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "", r.URL.RawQuery)
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		if atomic.AddInt32(&polls, 1) < 3 {
+			w.Write([]byte(`{"status":"InProgress"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"Succeeded"}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	res, err := Put(ts.URL+"/vms/1", WithClock(clk), FollowAsyncOperation(), JSON(),
+		WithBody(strings.NewReader(`{"size":"large"}`)), QueryParams(map[string]string{"api-version": "2024"}),
+		AddHeaders(map[string]string{"Authorization": "secret"}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"vm1"}`, string(res.Body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+	assert.Equal(t, []time.Duration{3 * time.Second, DefaultAsyncPollInterval, DefaultAsyncPollInterval}, clk.Sleeps())
+}
+
+func TestFollowAsyncOperationFailed(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Operation-Location", "/operations/2")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/operations/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"Failed","error":{"code":"QuotaExceeded","message":"no capacity"}}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	_, err := Post(ts.URL+"/jobs", WithClock(clock.NewFake(time.Now())), FollowAsyncOperation())
+	assert.True(t, errors.Is(err, ErrAsyncOperationFailed))
+	var opErr *AsyncOperationError
+	require.True(t, errors.As(err, &opErr))
+	assert.Equal(t, "Failed", opErr.Status)
+	assert.Equal(t, "QuotaExceeded", opErr.Code)
+	assert.Equal(t, "no capacity", opErr.Message)
+}
+
+func TestFollowAsyncOperationLocation(t *testing.T) {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exports", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/exports/status")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/exports/status", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) < 2 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Write([]byte("export"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	res, err := Post(ts.URL+"/exports", WithClock(clk), FollowAsyncOperation())
+	require.NoError(t, err)
+	assert.Equal(t, "export", string(res.Body))
+	assert.Equal(t, []time.Duration{DefaultAsyncPollInterval, time.Second}, clk.Sleeps())
+}
+
+func TestFollowAsyncOperationOtherHost(t *testing.T) {
+	var got http.Header
+	status := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("done"))
+	}))
+	defer status.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", status.URL+"/status")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	res, err := Post(ts.URL, WithClock(clock.NewFake(time.Now())), FollowAsyncOperation(),
+		BearerTokenFunc(func(context.Context) (string, error) { return "token", nil }, nil),
+		APIKey("key", InHeader("X-API-Key")), APIKey("cookie", InCookie("session")),
+		IdempotencyKey("once"), AddHeaders(map[string]string{"X-Trace": "kept"}))
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(res.Body))
+	for _, name := range []string{"Authorization", "X-Api-Key", "Cookie", HeaderIdempotencyKey} {
+		assert.Empty(t, got.Get(name), name)
+	}
+	assert.Equal(t, "kept", got.Get("X-Trace"))
+}
+
+func TestFollowAsyncOperationContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := Get(ts.URL, WithContext(ctx), FollowAsyncOperation())
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func TestFollowAsyncOperationExpectStatus(t *testing.T) {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/jobs/1")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1)%2 == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Write([]byte("done"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	res, err := Post(ts.URL+"/jobs", WithClock(clock.NewFake(time.Now())), FollowAsyncOperation(), ExpectStatus(200))
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(res.Body))
+
+	_, err = Post(ts.URL+"/jobs", WithClock(clock.NewFake(time.Now())), FollowAsyncOperation(), ExpectStatus(202))
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr), "got %v", err)
+	assert.Equal(t, 200, statusErr.Status)
+}
+
+func TestFollowAsyncOperationClientClose(t *testing.T) {
+	var polls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" || atomic.AddInt32(&polls, 1) < 2 {
+			w.Header().Set("Location", "/")
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusAccepted)
+			time.Sleep(20 * time.Millisecond)
+			return
+		}
+		w.Write([]byte("done"))
+	}))
+	defer ts.Close()
+	c := NewClient(FollowAsyncOperation())
+	result := make(chan error)
+	go func() {
+		res, err := c.Post(ts.URL)
+		if err == nil {
+			assert.Equal(t, "done", string(res.Body))
+		}
+		result <- err
+	}()
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, c.Close(context.Background()))
+	assert.NoError(t, <-result)
+	_, err := c.Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrClientClosed))
+}
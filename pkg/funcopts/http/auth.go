@@ -0,0 +1,185 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// BearerToken sets the `Authorization` header to a bearer token
+func BearerToken(token string) RequestOption {
+	return AddHeaders(map[string]string{"Authorization": "Bearer " + token})
+}
+
+// BasicAuth sets the `Authorization` header to the basic credentials user and password
+func BasicAuth(user, password string) RequestOption {
+	creds := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+	return AddHeaders(map[string]string{"Authorization": "Basic " + creds})
+}
+
+// Placement is where `APIKey` sends a key
+type Placement struct {
+	in   string
+	name string
+}
+
+// InHeader sends the key in the header name
+func InHeader(name string) Placement {
+	return Placement{in: "header", name: name}
+}
+
+// InQuery sends the key as the query param name
+func InQuery(name string) Placement {
+	return Placement{in: "query", name: name}
+}
+
+// InCookie sends the key as the cookie name
+func InCookie(name string) Placement {
+	return Placement{in: "cookie", name: name}
+}
+
+// APIKey sends key in the header, query param or cookie given by placement and redacts
+// it from logs, dumps and `Describe`. Like `HeaderFunc`, a key in a header is only sent to
+// the host of the original request
+//
+//	c := NewClient(APIKey(os.Getenv("API_KEY"), InHeader("X-API-Key")))
+func APIKey(key string, placement Placement) RequestOption {
+	return func(r *Request) error {
+		switch placement.in {
+		case "header":
+			r.redactHeaders = append(r.redactHeaders, placement.name)
+			r.middleware = append(r.middleware, originOnly(placement.name))
+			return AddHeaders(map[string]string{placement.name: key})(r)
+		case "query":
+			r.redactQuery = append(r.redactQuery, placement.name)
+			return QueryParam(placement.name, key)(r)
+		case "cookie":
+			r.rawRequest = append(r.rawRequest, func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: placement.name, Value: key})
+			})
+			return nil
+		}
+		return errors.New("api key placement must be InHeader, InQuery or InCookie")
+	}
+}
+
+// originOnly drops the headers names from requests to another host than the original,
+// which net/http only does for `Authorization` and cookies when following redirects
+func originOnly(names ...string) transport.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Host == originalHost(req) {
+				return next.RoundTrip(req)
+			}
+			req = req.Clone(req.Context())
+			for _, name := range names {
+				req.Header.Del(name)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// challenged reports whether resp asks for the auth scheme
+func challenged(resp *http.Response, scheme string) bool {
+	for _, value := range resp.Header.Values("WWW-Authenticate") {
+		name, _, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if strings.EqualFold(name, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// resendable returns a copy of req with a fresh body to answer the auth challenge in resp,
+// draining and closing resp. It returns false and leaves resp alone when the body can't be
+// sent again
+func resendable(req *http.Request, resp *http.Response) (*http.Request, bool) {
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, false
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		retry.Body = body
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return retry, true
+}
+
+// HeaderFunc sets the header name to the value fn returns when each attempt is sent,
+// retries included. Like net/http does for `Authorization`, the header is only sent to
+// the host of the original request, never to another host a redirect leads to. It is
+// redacted from logs and dumps. reject, when not nil, is called with the value after a
+// 401 or 403 response, e.g. to drop a cached credential
+func HeaderFunc(name string, fn func(ctx context.Context) (string, error), reject func(value string)) RequestOption {
+	return func(r *Request) error {
+		r.redactHeaders = append(r.redactHeaders, name)
+		r.middleware = append(r.middleware, func(next http.RoundTripper) http.RoundTripper {
+			return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Host != originalHost(req) {
+					return next.RoundTrip(req)
+				}
+				value, err := fn(req.Context())
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(req.Context())
+				req.Header.Set(name, value)
+				resp, err := next.RoundTrip(req)
+				if reject != nil && err == nil &&
+					(resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+					reject(value)
+				}
+				return resp, err
+			})
+		})
+		return nil
+	}
+}
+
+// BearerTokenFunc sends the token fn returns as a bearer token like `HeaderFunc`.
+// reject is called with the token
+func BearerTokenFunc(fn func(ctx context.Context) (string, error), reject func(token string)) RequestOption {
+	header := func(ctx context.Context) (string, error) {
+		token, err := fn(ctx)
+		return "Bearer " + token, err
+	}
+	var rejectHeader func(string)
+	if reject != nil {
+		rejectHeader = func(value string) { reject(strings.TrimPrefix(value, "Bearer ")) }
+	}
+	return HeaderFunc("Authorization", header, rejectHeader)
+}
+
+// originalHostKey is the context key of the host a request was made for when it is a
+// follow-up sent on its behalf, like the polls of `FollowAsyncOperation`
+type originalHostKey struct{}
+
+// originalHost returns the host of the first request of the redirect chain req is part
+// of, or of the request it follows up on
+func originalHost(req *http.Request) string {
+	if host, ok := req.Context().Value(originalHostKey{}).(string); ok {
+		return host
+	}
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req.URL.Host
+}
+
+// origin returns the scheme and host of u, the server credentials are remembered for
+func origin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
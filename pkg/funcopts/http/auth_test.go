@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasicAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "lusis", user)
+		assert.Equal(t, "s3:cret", pass)
+	}))
+	defer ts.Close()
+	_, err := Get(ts.URL, BasicAuth("lusis", "s3:cret"))
+	assert.NoError(t, err)
+}
+
+func TestAPIKey(t *testing.T) {
+	tests := map[string]struct {
+		placement Placement
+		key       func(r *http.Request) string
+	}{
+		"header": {InHeader("X-API-Key"), func(r *http.Request) string { return r.Header.Get("X-API-Key") }},
+		"query":  {InQuery("api_key"), func(r *http.Request) string { return r.URL.Query().Get("api_key") }},
+		"cookie": {InCookie("session_key"), func(r *http.Request) string {
+			c, err := r.Cookie("session_key")
+			if err != nil {
+				return ""
+			}
+			return c.Value
+		}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "k3ysecret", tc.key(r))
+			}))
+			defer ts.Close()
+			buf := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			_, err := Get(ts.URL, APIKey("k3ysecret", tc.placement), WithLogger(logger), Debug())
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "http request dump")
+			assert.NotContains(t, buf.String(), "k3ysecret")
+		})
+	}
+}
+
+func TestAPIKeyRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/other" {
+			http.Redirect(w, r, "http://"+strings.Replace(r.Host, "127.0.0.1", "localhost", 1)+"/echo", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-API-Key")))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL+"/same", APIKey("k3ysecret", InHeader("X-API-Key")))
+	require.NoError(t, err)
+	assert.Equal(t, "k3ysecret", string(res.Body))
+	res, err = Get(ts.URL+"/other", APIKey("k3ysecret", InHeader("X-API-Key")))
+	require.NoError(t, err)
+	assert.Equal(t, "", string(res.Body))
+}
+
+func TestAPIKeyDescribe(t *testing.T) {
+	r, _, err := New(Method("GET"), URL("https://api.example.com/v1"), APIKey("k3ysecret", InQuery("api_key")))
+	require.NoError(t, err)
+	d, err := r.Describe()
+	require.NoError(t, err)
+	assert.NotContains(t, d.URL, "k3ysecret")
+}
+
+func TestAPIKeyPlacementRequired(t *testing.T) {
+	_, _, err := New(Method("GET"), URL("https://api.example.com"), APIKey("k3ysecret", Placement{}))
+	assert.Error(t, err)
+}
+
+func TestHeaderFuncRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/other":
+			// localhost and 127.0.0.1 reach the same server under different hosts
+			http.Redirect(w, r, "http://"+strings.Replace(r.Host, "127.0.0.1", "localhost", 1)+"/echo", http.StatusFound)
+		default:
+			w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer ts.Close()
+	var rejected []string
+	token := BearerTokenFunc(func(ctx context.Context) (string, error) {
+		return "s3cret", nil
+	}, func(token string) {
+		rejected = append(rejected, token)
+	})
+	res, err := Get(ts.URL+"/same", token)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer s3cret", string(res.Body))
+	res, err = Get(ts.URL+"/other", token)
+	require.NoError(t, err)
+	assert.Equal(t, "", string(res.Body))
+	assert.Empty(t, rejected)
+}
+
+func TestHeaderFuncReject(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	var rejected []string
+	_, err := Get(ts.URL, BearerTokenFunc(func(ctx context.Context) (string, error) {
+		return "expired", nil
+	}, func(token string) {
+		rejected = append(rejected, token)
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expired"}, rejected)
+}
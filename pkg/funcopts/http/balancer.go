@@ -0,0 +1,312 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// DefaultEjectTime is how long a failed endpoint is skipped by a `Balancer`
+const DefaultEjectTime = 30 * time.Second
+
+// ErrNoEndpoints is the error returned by a `Balancer` without any endpoints
+var ErrNoEndpoints = errors.New("no endpoints available")
+
+// Strategy chooses how a `Balancer` picks an endpoint
+type Strategy int
+
+const (
+	// RoundRobin cycles through endpoints in order
+	RoundRobin Strategy = iota
+	// LeastPending picks the endpoint with the fewest requests in flight
+	LeastPending
+)
+
+// BalancerOption configures a `Balancer`
+type BalancerOption func(*Balancer)
+
+// WithStrategy sets the strategy used to pick endpoints. The default is `RoundRobin`
+func WithStrategy(s Strategy) BalancerOption {
+	return func(b *Balancer) {
+		b.strategy = s
+	}
+}
+
+// EjectFor sets how long an endpoint is skipped after a connection error or a 502, 503 or 504 response
+func EjectFor(d time.Duration) BalancerOption {
+	return func(b *Balancer) {
+		b.ejectFor = d
+	}
+}
+
+// HealthCheck polls path on every endpoint each interval and skips endpoints
+// that fail or respond with a non-2xx status until they recover. Probes are sent
+// with the http.Transport of the last request balanced, so they get its TLS, proxy
+// and host settings, but not its middleware, hooks or credentials
+func HealthCheck(path string, interval time.Duration) BalancerOption {
+	return func(b *Balancer) {
+		b.healthPath = path
+		b.healthInterval = interval
+	}
+}
+
+// Balancer spreads requests over a set of replicas of the same service.
+// Requests are made against the virtual base url given to `NewBalancer` and
+// rewritten to the endpoint picked for each attempt: its scheme and host, and its
+// path followed by the request's path below the base url. An endpoint counts as
+// pending until the body of its response is closed
+type Balancer struct {
+	base           *url.URL
+	strategy       Strategy
+	ejectFor       time.Duration
+	healthPath     string
+	healthInterval time.Duration
+	endpoints      []*endpoint
+	// transport sends health probes
+	transport http.RoundTripper
+	next      uint64
+	done      chan struct{}
+	closeOnce sync.Once
+	sync.RWMutex
+}
+
+type endpoint struct {
+	url          *url.URL
+	pending      int64
+	healthy      bool
+	ejectedUntil time.Time
+}
+
+// NewBalancer returns a `Balancer` over urls. Requests to base, or relative requests when
+// base is used as the `BaseURL`, are balanced. Base may be one of urls.
+// Close the balancer to stop health checks
+func NewBalancer(base string, urls []string, opts ...BalancerOption) (*Balancer, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	b := &Balancer{base: u, ejectFor: DefaultEjectTime, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if err := b.SetEndpoints(urls...); err != nil {
+		return nil, err
+	}
+	if b.healthPath != "" && b.healthInterval > 0 {
+		go b.healthLoop()
+	}
+	return b, nil
+}
+
+// Endpoints balances requests round-robin over urls, using the first as the `BaseURL`
+// unless one is set
+func Endpoints(urls ...string) RequestOption {
+	var b *Balancer
+	var err error
+	if len(urls) == 0 {
+		err = ErrNoEndpoints
+	} else {
+		b, err = NewBalancer(urls[0], urls)
+	}
+	return func(r *Request) error {
+		if err != nil {
+			return err
+		}
+		return LoadBalance(b)(r)
+	}
+}
+
+// LoadBalance sends requests through b
+func LoadBalance(b *Balancer) RequestOption {
+	return func(r *Request) error {
+		r.balancer = b
+		if r.baseURL == "" {
+			r.baseURL = b.base.String()
+		}
+		return nil
+	}
+}
+
+// SetEndpoints replaces the endpoints of the balancer. State is kept for urls that were already present
+func (b *Balancer) SetEndpoints(urls ...string) error {
+	b.Lock()
+	defer b.Unlock()
+	existing := make(map[string]*endpoint, len(b.endpoints))
+	for _, e := range b.endpoints {
+		existing[e.url.String()] = e
+	}
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		if e, ok := existing[u.String()]; ok {
+			endpoints = append(endpoints, e)
+			continue
+		}
+		endpoints = append(endpoints, &endpoint{url: u, healthy: true})
+	}
+	b.endpoints = endpoints
+	return nil
+}
+
+// Close stops health checking
+func (b *Balancer) Close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+// pick returns an available endpoint. When every endpoint is unavailable
+// all of them are considered so requests keep flowing
+func (b *Balancer) pick(now time.Time) (*endpoint, error) {
+	b.RLock()
+	defer b.RUnlock()
+	if len(b.endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+	candidates := make([]*endpoint, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		if e.healthy && !now.Before(e.ejectedUntil) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+	if b.strategy == LeastPending {
+		best := candidates[0]
+		for _, e := range candidates[1:] {
+			if atomic.LoadInt64(&e.pending) < atomic.LoadInt64(&best.pending) {
+				best = e
+			}
+		}
+		return best, nil
+	}
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return candidates[n%uint64(len(candidates))], nil
+}
+
+func (b *Balancer) eject(e *endpoint, now time.Time) {
+	b.Lock()
+	defer b.Unlock()
+	e.ejectedUntil = now.Add(b.ejectFor)
+}
+
+func (b *Balancer) healthLoop() {
+	ticker := time.NewTicker(b.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.checkHealth()
+		}
+	}
+}
+
+func (b *Balancer) checkHealth() {
+	b.RLock()
+	endpoints := append([]*endpoint{}, b.endpoints...)
+	b.RUnlock()
+	for _, e := range endpoints {
+		healthy := b.probe(e)
+		b.Lock()
+		e.healthy = healthy
+		b.Unlock()
+	}
+}
+
+func (b *Balancer) probe(e *endpoint) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), b.healthInterval)
+	defer cancel()
+	u, err := joinPath(e.url, b.healthPath)
+	if err != nil {
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return false
+	}
+	b.RLock()
+	rt := b.transport
+	b.RUnlock()
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// balancerTransport rewrites requests for the balancer's base to a picked endpoint
+type balancerTransport struct {
+	balancer *Balancer
+	// probe is the http.Transport under the request's layers, used for health probes
+	probe http.RoundTripper
+	next  http.RoundTripper
+}
+
+func (t *balancerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := t.balancer
+	if req.URL.Host != b.base.Host {
+		return t.next.RoundTrip(req)
+	}
+	if t.probe != nil {
+		b.Lock()
+		b.transport = t.probe
+		b.Unlock()
+	}
+	clk := clock.FromContext(req.Context())
+	e, err := b.pick(clk.Now())
+	if err != nil {
+		return nil, err
+	}
+	rel := strings.TrimPrefix(req.URL.EscapedPath(), strings.TrimRight(b.base.EscapedPath(), "/"))
+	u, err := joinPath(e.url, rel)
+	if err != nil {
+		return nil, err
+	}
+	out := req.Clone(req.Context())
+	out.URL.Scheme = u.Scheme
+	out.URL.Host = u.Host
+	out.URL.Path = u.Path
+	out.URL.RawPath = u.RawPath
+	out.Host = ""
+	atomic.AddInt64(&e.pending, 1)
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		atomic.AddInt64(&e.pending, -1)
+		b.eject(e, clk.Now())
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		b.eject(e, clk.Now())
+	}
+	resp.Body = &pendingBody{ReadCloser: resp.Body, endpoint: e}
+	return resp, nil
+}
+
+// pendingBody keeps its endpoint pending until it is closed
+type pendingBody struct {
+	io.ReadCloser
+	endpoint *endpoint
+	once     sync.Once
+}
+
+func (b *pendingBody) Close() error {
+	b.once.Do(func() { atomic.AddInt64(&b.endpoint.pending, -1) })
+	return b.ReadCloser.Close()
+}
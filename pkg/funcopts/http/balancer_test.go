@@ -0,0 +1,205 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func replica(name string, status *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != nil && *status != 0 {
+			w.WriteHeader(*status)
+		}
+		w.Write([]byte(name + r.URL.Path))
+	}))
+}
+
+func TestEndpointsRoundRobin(t *testing.T) {
+	a, b := replica("a", nil), replica("b", nil)
+	defer a.Close()
+	defer b.Close()
+	c := NewClient(Endpoints(a.URL, b.URL))
+	var bodies []string
+	for i := 0; i < 4; i++ {
+		res, err := c.Get("/users")
+		assert.NoError(t, err)
+		bodies = append(bodies, string(res.Body))
+	}
+	assert.Equal(t, []string{"a/users", "b/users", "a/users", "b/users"}, bodies)
+}
+
+func TestEndpointsEject(t *testing.T) {
+	unavailable := http.StatusServiceUnavailable
+	a, b := replica("a", &unavailable), replica("b", nil)
+	defer a.Close()
+	defer b.Close()
+	c := NewClient(Endpoints(a.URL, b.URL))
+	res, err := c.Get("/")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Status)
+	for i := 0; i < 3; i++ {
+		res, err = c.Get("/")
+		assert.NoError(t, err)
+		assert.Equal(t, "b/", string(res.Body))
+	}
+}
+
+func TestEndpointsConnectionError(t *testing.T) {
+	a, b := replica("a", nil), replica("b", nil)
+	defer b.Close()
+	a.Close()
+	bal, err := NewBalancer("http://service", []string{a.URL, b.URL})
+	assert.NoError(t, err)
+	_, err = Get("http://service/", LoadBalance(bal))
+	assert.Error(t, err)
+	res, err := Get("http://service/", LoadBalance(bal))
+	assert.NoError(t, err)
+	assert.Equal(t, "b/", string(res.Body))
+}
+
+func TestLeastPending(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("slow"))
+	}))
+	defer slow.Close()
+	fast := replica("fast", nil)
+	defer fast.Close()
+	bal, err := NewBalancer(slow.URL, []string{slow.URL, fast.URL}, WithStrategy(LeastPending))
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res, err := Get("/", LoadBalance(bal))
+		assert.NoError(t, err)
+		assert.Equal(t, "slow", string(res.Body))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		res, err := Get("/", LoadBalance(bal))
+		assert.NoError(t, err)
+		assert.Equal(t, "fast/", string(res.Body))
+	}
+	close(release)
+	wg.Wait()
+}
+
+func TestHealthCheck(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/health" && down {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("a"))
+	}))
+	defer a.Close()
+	b := replica("b", nil)
+	defer b.Close()
+	bal, err := NewBalancer(a.URL, []string{a.URL, b.URL}, HealthCheck("/health", 10*time.Millisecond))
+	assert.NoError(t, err)
+	defer bal.Close()
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		res, err := Get("/", LoadBalance(bal))
+		assert.NoError(t, err)
+		assert.Equal(t, "b/", string(res.Body))
+	}
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		res, err := Get("/", LoadBalance(bal))
+		assert.NoError(t, err)
+		seen[string(res.Body)] = true
+	}
+	assert.True(t, seen["a"])
+}
+
+func TestHealthCheckTransport(t *testing.T) {
+	var probes, leaked int32
+	a := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			atomic.AddInt32(&probes, 1)
+			if r.Header.Get("X-Request-Auth") != "" {
+				atomic.AddInt32(&leaked, 1)
+			}
+		}
+	}))
+	defer a.Close()
+	bal, err := NewBalancer(a.URL, []string{a.URL}, HealthCheck("/health", 10*time.Millisecond))
+	assert.NoError(t, err)
+	defer bal.Close()
+	auth := Use(func(next http.RoundTripper) http.RoundTripper {
+		return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Request-Auth", "yes")
+			return next.RoundTrip(req)
+		})
+	})
+	// the test server's certificate is only trusted by its client's transport
+	_, err = Get("/", LoadBalance(bal), SetClient(a.Client()), auth)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&probes) > 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&leaked))
+}
+
+func TestBalancerEndpointPath(t *testing.T) {
+	a := replica("a", nil)
+	defer a.Close()
+	bal, err := NewBalancer("http://service/api", []string{a.URL + "/v2"})
+	assert.NoError(t, err)
+	res, err := Get("http://service/api/users", LoadBalance(bal))
+	assert.NoError(t, err)
+	assert.Equal(t, "a/v2/users", string(res.Body))
+}
+
+func TestBalancerPendingUntilClosed(t *testing.T) {
+	a := replica("a", nil)
+	defer a.Close()
+	bal, err := NewBalancer(a.URL, []string{a.URL})
+	assert.NoError(t, err)
+	res, err := Get("/", LoadBalance(bal), IncludeRawResponse())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&bal.endpoints[0].pending))
+	res.Raw.Body.Close()
+	assert.Equal(t, int64(0), atomic.LoadInt64(&bal.endpoints[0].pending))
+}
+
+func TestBalancerEjectClock(t *testing.T) {
+	unavailable := http.StatusServiceUnavailable
+	a, b := replica("a", &unavailable), replica("b", nil)
+	defer a.Close()
+	defer b.Close()
+	clk := clock.NewFake(time.Now())
+	c := NewClient(Endpoints(a.URL, b.URL), WithClock(clk))
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		res, err := c.Get("/")
+		assert.NoError(t, err)
+		bodies = append(bodies, string(res.Body))
+		if i == 1 {
+			clk.Advance(DefaultEjectTime)
+		}
+	}
+	assert.Equal(t, []string{"a/", "b/", "a/"}, bodies)
+}
+
+func TestEndpointsEmpty(t *testing.T) {
+	_, err := Get("/", Endpoints())
+	assert.Equal(t, ErrNoEndpoints, err)
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// Budget caps the total time of a request at total: every attempt, the backoff sleeps
+// between retries, redirects and reading the body. A request that runs out fails with a
+// `BudgetError`. Unlike `Timeout`, which applies to each send by the http.Client, the
+// budget is shared by everything the request does and is measured with its clock
+func Budget(total time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.budget = total
+		return nil
+	}
+}
+
+// BudgetAttempt is an attempt made within a `Budget`
+type BudgetAttempt struct {
+	Start    time.Time
+	Duration time.Duration
+	// Status is zero when the attempt failed with Err
+	Status int
+	Err    error
+}
+
+// BudgetError is the error for requests that ran out of their `Budget`. It matches
+// `ErrBudgetExceeded` and context.DeadlineExceeded with errors.Is
+type BudgetError struct {
+	Budget   time.Duration
+	Elapsed  time.Duration
+	Attempts []BudgetAttempt
+	// Err is the error the request failed with when the budget ran out
+	Err error
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v: %s spent of %s in %d attempts: %v", ErrBudgetExceeded, e.Elapsed, e.Budget, len(e.Attempts), e.Err)
+}
+
+// Unwrap returns `ErrBudgetExceeded`, context.DeadlineExceeded and the error of the request
+func (e *BudgetError) Unwrap() []error {
+	return []error{ErrBudgetExceeded, context.DeadlineExceeded, e.Err}
+}
+
+// errBudgetSpent stops attempts that would start after the budget ran out
+var errBudgetSpent = errors.New("no budget left for another attempt")
+
+type budgetKey struct{}
+
+// budgetTracker records the attempts of a request with a `Budget`
+type budgetTracker struct {
+	clock    clock.Clock
+	start    time.Time
+	deadline time.Time
+	sync.Mutex
+	attempts []BudgetAttempt
+}
+
+// withBudget returns req with the budget deadline and tracker in its context and the
+// function releasing the deadline
+func (cr *Request) withBudget(req *http.Request) (*http.Request, *budgetTracker, context.CancelFunc) {
+	if cr.budget <= 0 {
+		return req, nil, func() {}
+	}
+	clk := clock.FromContext(req.Context())
+	now := clk.Now()
+	tracker := &budgetTracker{clock: clk, start: now, deadline: now.Add(cr.budget)}
+	ctx, cancel := context.WithTimeout(context.WithValue(req.Context(), budgetKey{}, tracker), cr.budget)
+	return req.WithContext(ctx), tracker, cancel
+}
+
+// result turns err into a `BudgetError` when the budget has run out
+func (t *budgetTracker) result(err error) error {
+	if t == nil || err == nil {
+		return err
+	}
+	now := t.clock.Now()
+	if now.Before(t.deadline) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	t.Lock()
+	defer t.Unlock()
+	return &BudgetError{
+		Budget:   t.deadline.Sub(t.start),
+		Elapsed:  now.Sub(t.start),
+		Attempts: append([]BudgetAttempt{}, t.attempts...),
+		Err:      err,
+	}
+}
+
+// budgetTransport records every attempt and refuses to start one once the budget is spent
+type budgetTransport struct {
+	next http.RoundTripper
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracker, ok := req.Context().Value(budgetKey{}).(*budgetTracker)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	start := tracker.clock.Now()
+	if !start.Before(tracker.deadline) {
+		return nil, errBudgetSpent
+	}
+	resp, err := t.next.RoundTrip(req)
+	attempt := BudgetAttempt{Start: start, Duration: tracker.clock.Now().Sub(start), Err: err}
+	if resp != nil {
+		attempt.Status = resp.StatusCode
+	}
+	tracker.Lock()
+	tracker.attempts = append(tracker.attempts, attempt)
+	tracker.Unlock()
+	return resp, err
+}
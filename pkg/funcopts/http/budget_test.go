@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudget(t *testing.T) {
+	ts, calls := flakyServer(1, http.StatusServiceUnavailable)
+	defer ts.Close()
+	res, err := Get(ts.URL, Retry(3, time.Millisecond), Budget(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestBudgetStopsRetries(t *testing.T) {
+	ts, calls := flakyServer(10, http.StatusServiceUnavailable)
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	_, err := Get(ts.URL, WithClock(clk), Retry(10, time.Second), Budget(2500*time.Millisecond))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	var budgetErr *BudgetError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, 2500*time.Millisecond, budgetErr.Budget)
+	assert.True(t, budgetErr.Elapsed >= budgetErr.Budget)
+	assert.Equal(t, int(atomic.LoadInt32(calls)), len(budgetErr.Attempts))
+	assert.True(t, len(budgetErr.Attempts) < 10)
+	for _, attempt := range budgetErr.Attempts {
+		assert.Equal(t, http.StatusServiceUnavailable, attempt.Status)
+	}
+}
+
+func TestBudgetCancelsAttempt(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+	start := time.Now()
+	_, err := Get(ts.URL, Budget(50*time.Millisecond))
+	assert.True(t, time.Since(start) < time.Second)
+	var budgetErr *BudgetError
+	require.True(t, errors.As(err, &budgetErr))
+	require.Len(t, budgetErr.Attempts, 1)
+	assert.Equal(t, 0, budgetErr.Attempts[0].Status)
+	assert.Error(t, budgetErr.Attempts[0].Err)
+}
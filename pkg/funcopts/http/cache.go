@@ -0,0 +1,82 @@
+package httpclient
+
+import "github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+
+// CacheStore is the interface for storage backends used by `Cache`
+type CacheStore = transport.CacheStore
+
+// CacheEntry is a cached response along with the metadata needed to revalidate it
+type CacheEntry = transport.CacheEntry
+
+// CacheStats tracks cache hits and misses.
+// Embed it in a `CacheStore` to have requests record stats against the store
+type CacheStats = transport.CacheStats
+
+// MemoryCache is an in-memory `CacheStore`
+type MemoryCache = transport.MemoryCache
+
+// DiskCache is a `CacheStore` that persists entries as json files in a directory
+type DiskCache = transport.DiskCache
+
+// NewMemoryCache returns an empty `MemoryCache`
+func NewMemoryCache() *MemoryCache {
+	return transport.NewMemoryCache()
+}
+
+// NewDiskCache returns a `DiskCache` storing entries in dir, creating it if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	return transport.NewDiskCache(dir)
+}
+
+// Cache caches GET responses in store following RFC 7234.
+// Fresh responses are served without contacting the origin and stale responses
+// are revalidated with `If-None-Match`/`If-Modified-Since`. Requests sending different
+// values for the headers redacted by `RedactHeaders` don't share entries
+func Cache(store CacheStore) RequestOption {
+	return func(r *Request) error {
+		r.cacheStore = store
+		return nil
+	}
+}
+
+// OfflineOnly serves responses from the cache regardless of freshness without contacting the origin.
+// Requests without a cached response fail with `ErrNotCached`. It has no effect without `Cache`
+func OfflineOnly() RequestOption {
+	return func(r *Request) error {
+		r.cacheOffline = true
+		return nil
+	}
+}
+
+// StaleIfError serves a stale cached response when the origin can't be reached
+// or responds with a 5xx status. It has no effect without `Cache`
+func StaleIfError() RequestOption {
+	return func(r *Request) error {
+		r.cacheStaleIfError = true
+		return nil
+	}
+}
+
+// SharedCache marks the `Cache` store as shared between users, so responses with
+// `Cache-Control: private` aren't stored, nor are responses to requests with `Authorization`
+// unless they are marked `public`. It has no effect without `Cache`
+func SharedCache() RequestOption {
+	return func(r *Request) error {
+		r.cacheShared = true
+		return nil
+	}
+}
+
+func (cr *Request) cacheOptions() []transport.CacheOption {
+	opts := []transport.CacheOption{transport.CacheKeyHeaders(cr.redactHeaders...)}
+	if cr.cacheShared {
+		opts = append(opts, transport.SharedCache())
+	}
+	if cr.cacheOffline {
+		opts = append(opts, transport.OfflineOnly())
+	}
+	if cr.cacheStaleIfError {
+		opts = append(opts, transport.StaleIfError())
+	}
+	return opts
+}
@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "disk body")
+	}))
+	defer ts.Close()
+	dir := t.TempDir()
+	store, err := NewDiskCache(dir)
+	assert.NoError(t, err)
+	_, err = Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+
+	reopened, err := NewDiskCache(dir)
+	assert.NoError(t, err)
+	res, err := Get(ts.URL, Cache(reopened))
+	assert.NoError(t, err)
+	assert.Equal(t, "disk body", string(res.Body))
+	assert.Equal(t, uint64(1), reopened.Hits())
+
+	reopened.Delete(ts.URL)
+	_, ok := reopened.Get(ts.URL)
+	assert.False(t, ok)
+}
+
+func TestCacheOfflineOnly(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "offline body")
+	}))
+	store := NewMemoryCache()
+	_, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	ts.Close()
+
+	res, err := Get(ts.URL, Cache(store), OfflineOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, "offline body", string(res.Body))
+
+	_, err = Get(ts.URL+"/missing", Cache(store), OfflineOnly())
+	assert.Error(t, err)
+}
+
+func TestCacheStaleIfError(t *testing.T) {
+	failing := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "stale body")
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	_, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	failing = true
+
+	res, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.Status)
+
+	res, err = Get(ts.URL, Cache(store), StaleIfError())
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.Status)
+	assert.Equal(t, "stale body", string(res.Body))
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheFresh(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	first, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	second, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	assert.Equal(t, "response 1", string(first.Body))
+	assert.Equal(t, "response 1", string(second.Body))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(1), store.Hits())
+	assert.Equal(t, uint64(1), store.Misses())
+}
+
+func TestCacheRevalidateETag(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "etag body")
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	_, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	res, err := Get(ts.URL, Cache(store), ExpectStatus(200))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.Status)
+	assert.Equal(t, "etag body", string(res.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(1), store.Hits())
+	assert.Equal(t, uint64(1), store.Revalidations())
+}
+
+func TestCacheRevalidateLastModified(t *testing.T) {
+	lastModified := "Mon, 02 Jan 2006 15:04:05 GMT"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set("Cache-Control", "max-age=0")
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "lm body")
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	_, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	res, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	assert.Equal(t, "lm body", string(res.Body))
+	assert.Equal(t, uint64(1), store.Revalidations())
+}
+
+func TestCacheNoStore(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-store")
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	for i := 0; i < 2; i++ {
+		_, err := Get(ts.URL, Cache(store))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(0), store.Hits())
+}
+
+func TestCacheVary(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept")
+		fmt.Fprint(w, r.Header.Get("Accept"))
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	_, err := Get(ts.URL, Cache(store), JSON())
+	assert.NoError(t, err)
+	res, err := Get(ts.URL, Cache(store), RequestXML())
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeXML, string(res.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheInvalidatedByUnsafeMethod(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	_, err := Get(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	_, err = Post(ts.URL, Cache(store))
+	assert.NoError(t, err)
+	_, ok := store.Get(ts.URL)
+	assert.False(t, ok)
+}
+
+func TestCacheAPIKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, r.Header.Get("X-Api-Key"))
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	for _, key := range []string{"alice", "bob"} {
+		res, err := Get(ts.URL, Cache(store), APIKey(key, InHeader("X-Api-Key")))
+		assert.NoError(t, err)
+		assert.Equal(t, key, string(res.Body))
+	}
+}
@@ -0,0 +1,14 @@
+package httpclient
+
+import "github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+
+// Chaos injects faults into requests to test resilience logic, see `transport.Chaos`.
+// Faults are injected into each attempt, so `Retry` and `Fallback` see them, and
+// a schedule is shared by every request made with the option
+func Chaos(opts ...transport.ChaosOption) RequestOption {
+	chaos := transport.Chaos(opts...)
+	return func(r *Request) error {
+		r.chaos = chaos
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChaos(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	chaos := Chaos(transport.Schedule(transport.DropConnection(), transport.ErrorStatus(http.StatusServiceUnavailable)))
+	res, err := Get(ts.URL, chaos, Retry(2, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+
+	res, err = Get(ts.URL, chaos)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+}
@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DownloadVerifier checks a file fetched with `Download` or `ParallelDownload` before it
+// is moved into place. content reads the downloaded bytes and header holds the headers
+// of the response. An error discards the file
+type DownloadVerifier func(ctx context.Context, content io.Reader, header http.Header) error
+
+// VerifyDownload checks downloads with v. It can be given more than once
+func VerifyDownload(v DownloadVerifier) RequestOption {
+	return func(r *Request) error {
+		r.downloadVerifiers = append(r.downloadVerifiers, v)
+		return nil
+	}
+}
+
+// VerifySHA256 checks that a download has the hex encoded sha-256 checksum hexsum
+func VerifySHA256(hexsum string) RequestOption {
+	return func(r *Request) error {
+		want, err := hex.DecodeString(strings.TrimSpace(hexsum))
+		if err != nil || len(want) != sha256.Size {
+			return fmt.Errorf("invalid sha-256 checksum %q", hexsum)
+		}
+		return VerifyDownload(func(_ context.Context, content io.Reader, _ http.Header) error {
+			return checkSum("sha-256", sha256.New(), content, want, hex.EncodeToString)
+		})(r)
+	}
+}
+
+// digestAlgorithms are the digest algorithms `VerifyDigestHeader` checks
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// VerifyDigestHeader checks a download against the digest the server sent in a
+// `Repr-Digest` or `Content-Digest` header (RFC 9530) or a `Digest` header (RFC 3230).
+// Every sha-256 and sha-512 digest of the first header present must match, and a
+// download without one fails with `ErrNoDigest`
+func VerifyDigestHeader() RequestOption {
+	return VerifyDownload(func(_ context.Context, content io.Reader, header http.Header) error {
+		digests := responseDigests(header)
+		if len(digests) == 0 {
+			return ErrNoDigest
+		}
+		hashes := make(map[string]hash.Hash, len(digests))
+		writers := make([]io.Writer, 0, len(digests))
+		for alg := range digests {
+			hashes[alg] = digestAlgorithms[alg]()
+			writers = append(writers, hashes[alg])
+		}
+		if _, err := io.Copy(io.MultiWriter(writers...), content); err != nil {
+			return err
+		}
+		for alg, want := range digests {
+			if got := hashes[alg].Sum(nil); !bytes.Equal(got, want) {
+				return fmt.Errorf("%s digest %s, want %s: %w", alg, base64.StdEncoding.EncodeToString(got), base64.StdEncoding.EncodeToString(want), ErrChecksumMismatch)
+			}
+		}
+		return nil
+	})
+}
+
+// responseDigests returns the supported digests of the first digest header present,
+// keyed by lower cased algorithm
+func responseDigests(header http.Header) map[string][]byte {
+	for _, name := range []string{"Repr-Digest", "Content-Digest", "Digest"} {
+		digests := map[string][]byte{}
+		for _, value := range header.Values(name) {
+			for _, member := range strings.Split(value, ",") {
+				alg, encoded, ok := strings.Cut(strings.TrimSpace(member), "=")
+				alg = strings.ToLower(strings.TrimSpace(alg))
+				if _, supported := digestAlgorithms[alg]; !ok || !supported {
+					continue
+				}
+				// RFC 9530 wraps the base64 value in colons as a structured field byte sequence
+				encoded, _, _ = strings.Cut(encoded, ";")
+				encoded = strings.Trim(strings.TrimSpace(encoded), ":")
+				if sum, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+					digests[alg] = sum
+				}
+			}
+		}
+		if len(digests) > 0 {
+			return digests
+		}
+	}
+	return nil
+}
+
+// checkSum hashes content with h and compares the sum with want
+func checkSum(name string, h hash.Hash, content io.Reader, want []byte, encode func([]byte) string) error {
+	if _, err := io.Copy(h, content); err != nil {
+		return err
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		return fmt.Errorf("%s checksum %s, want %s: %w", name, encode(got), encode(want), ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// verifyDownload runs the download verifiers against the file written so far
+func (cr *Request) verifyDownload(f *os.File, header http.Header) error {
+	if len(cr.downloadVerifiers) == 0 {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	for _, verify := range cr.downloadVerifiers {
+		if err := verify(cr.context(), io.NewSectionReader(f, 0, info.Size()), header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
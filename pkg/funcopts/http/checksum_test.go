@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySHA256(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(downloadContent)
+	}))
+	defer ts.Close()
+	sum := sha256.Sum256(downloadContent)
+	path := filepath.Join(t.TempDir(), "file.bin")
+
+	require.NoError(t, Download(ts.URL, path, SetClient(ts.Client()), VerifySHA256(hex.EncodeToString(sum[:]))))
+	os.Remove(path)
+
+	wrong := sha256.Sum256([]byte("something else"))
+	err := Download(ts.URL, path, SetClient(ts.Client()), VerifySHA256(hex.EncodeToString(wrong[:])))
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	err = Download(ts.URL, path, VerifySHA256("not hex"))
+	assert.Error(t, err)
+}
+
+func TestVerifyDigestHeader(t *testing.T) {
+	sha256sum := sha256.Sum256(downloadContent)
+	sha512sum := sha512.Sum512(downloadContent)
+	good256 := base64.StdEncoding.EncodeToString(sha256sum[:])
+	good512 := base64.StdEncoding.EncodeToString(sha512sum[:])
+	bad := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	tests := map[string]struct {
+		header string
+		value  string
+		err    error
+	}{
+		"repr digest":            {header: "Repr-Digest", value: "sha-256=:" + good256 + ":, sha-512=:" + good512 + ":"},
+		"content digest":         {header: "Content-Digest", value: "sha-512=:" + good512 + ":"},
+		"rfc 3230 digest":        {header: "Digest", value: "SHA-256=" + good256},
+		"unsupported algorithms": {header: "Digest", value: "MD5=HUXZLQLMuI/KZ5KDcJPcOA==", err: ErrNoDigest},
+		"no digest":              {err: ErrNoDigest},
+		"mismatch":               {header: "Repr-Digest", value: "sha-256=:" + bad + ":", err: ErrChecksumMismatch},
+		"one of two mismatches":  {header: "Repr-Digest", value: "sha-256=:" + bad + ":, sha-512=:" + good512 + ":", err: ErrChecksumMismatch},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.header != "" {
+					w.Header().Set(tc.header, tc.value)
+				}
+				w.Write(downloadContent)
+			}))
+			defer ts.Close()
+			err := Download(ts.URL, filepath.Join(t.TempDir(), "file.bin"), SetClient(ts.Client()), VerifyDigestHeader())
+			if tc.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.err), "got %v", err)
+		})
+	}
+}
+
+func TestParallelDownloadVerify(t *testing.T) {
+	sum := sha256.Sum256(downloadContent)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "file.bin")
+	require.NoError(t, ParallelDownload(ts.URL, path, 3, SetClient(ts.Client()), VerifyDigestHeader(), VerifySHA256(hex.EncodeToString(sum[:]))))
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, downloadContent, got)
+}
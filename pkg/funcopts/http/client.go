@@ -1,14 +1,23 @@
 package httpclient
 
 import (
+	"context"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/http/httptrace"
 	"net/url"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
 )
 
 // Response represents an http response
@@ -17,6 +26,22 @@ type Response struct {
 	Headers http.Header
 	Cookies []*http.Cookie
 	Status  int
+	URL     string
+	Proto   string
+	Timings *ResponseTimings
+	// Connection describes the connection the response came on when `Timings` is set
+	Connection *ConnectionInfo
+	// Raw is the unread response when `IncludeRawResponse` is set
+	Raw *http.Response
+	// RequestID is the `X-Request-ID` sent with the request
+	RequestID string
+	// NotModified is true when the server responded with 304 Not Modified
+	NotModified bool
+	// Trailers are the trailers sent after the body. With `IncludeRawResponse` they are
+	// filled in once `Raw.Body` has been read to the end
+	Trailers http.Header
+	// Continued is true when the server sent 100 Continue to a request with `Expect100Continue`
+	Continued bool
 }
 
 // Request represents an http request
@@ -28,9 +53,90 @@ type Request struct {
 	contentType        string
 	accept             string
 	queryParams        map[string]string
+	queryValues        url.Values
 	body               io.Reader
+	bodyFunc           func() (io.ReadCloser, error)
+	streamBody         bool
+	expectContinue     bool
+	trailers           []trailer
 	headers            map[string]string
 	allowedStatusCodes []int
+	allowedStatusRange [][2]int
+	maxPages           int
+	cursorParam        string
+	cursorFunc         CursorFunc
+	cacheStore         CacheStore
+	cacheOffline       bool
+	cacheStaleIfError  bool
+	cacheShared        bool
+	tracer             trace.Tracer
+	logger             Logger
+	debug              bool
+	redactHeaders      []string
+	redactQuery        []string
+	timings            bool
+	har                *HARRecorder
+	baseURL            string
+	path               string
+	pathParams         map[string]string
+	maxResponseBytes   int64
+	expectations       []expectation
+	downloadVerifiers  []DownloadVerifier
+	transportTuning    []func(*http.Transport)
+	dialControls       []dialControl
+	dialer             *net.Dialer
+	resolver           *net.Resolver
+	staticHosts        map[string]string
+	tunedTransport     interface{ CloseIdleConnections() }
+	h2c                bool
+	hostPolicy         *hostPolicy
+	gzipBody           bool
+	hostConfigs        []*hostConfig
+	hostHeaders        []hostHeaders
+	limiter            *rate.Limiter
+	bandwidth          *Bandwidth
+	digest             *digestAuth
+	negotiate          *negotiator
+	ntlm               *ntlmCredentials
+	dpop               *dpop
+	balancer           *Balancer
+	discovery          *ServiceDiscovery
+	fallback           *fallback
+	retry              *retryPolicy
+	retryBudget        *RetryBudget
+	retryIf            RetryPredicate
+	retryMethods       map[string]RetryPredicate
+	scheduler          *Scheduler
+	priority           Priority
+	concurrency        []*ConcurrencyLimit
+	lifecycle          *lifecycle
+	skipValidation     bool
+	watch              watchConfig
+	maxPollInterval    time.Duration
+	followAsync        bool
+	metrics            []func(Observation)
+	stats              *clientStats
+	dump               *dumpConfig
+	chaos              transport.Middleware
+	middleware         []transport.Middleware
+	clock              clock.Clock
+	timeout            time.Duration
+	budget             time.Duration
+	dedupe             *flightGroup
+	memo               *memo
+	hooks              hooks
+	hookTransport      *hookTransport
+	rawRequest         []func(*http.Request)
+	includeRaw         bool
+	autoIdempotencyKey bool
+	requestID          bool
+	userAgent          string
+	typedBody          interface{}
+	hasTypedBody       bool
+	claims             map[string][2]string
+	conflicts          []error
+	ctx                context.Context
+	opts               []RequestOption
 	sync.RWMutex
 }
 
@@ -45,10 +151,139 @@ func (cr *Request) getAllowedStatusCodes() []int {
 	return cr.allowedStatusCodes
 }
 
+func (cr *Request) setAllowedStatusRange(min, max int) {
+	cr.allowedStatusRange = append(cr.allowedStatusRange, [2]int{min, max})
+}
+
+// statusAllowed reports whether code satisfies the expected codes and ranges.
+// Any code is allowed when no expectations were set
+func (cr *Request) statusAllowed(code int) bool {
+	if len(cr.getAllowedStatusCodes()) == 0 && len(cr.allowedStatusRange) == 0 {
+		return true
+	}
+	for _, allowed := range cr.getAllowedStatusCodes() {
+		if code == allowed {
+			return true
+		}
+	}
+	for _, r := range cr.allowedStatusRange {
+		if code >= r[0] && code <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
 func (cr *Request) setHTTPClient(c *http.Client) {
 	cr.httpClient = c
 }
 
+// client returns a copy of the configured http.Client with the cookie jar
+// and any transport layers from options applied
+func (cr *Request) client() *http.Client {
+	c := *cr.httpClient
+	c.Jar = cr.cookieJar
+	if cr.timeout > 0 {
+		c.Timeout = cr.timeout
+	}
+	c.Transport = cr.transport(c.Transport)
+	return &c
+}
+
+func (cr *Request) transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	rt = cr.tuneTransport(rt)
+	base := rt
+	if len(cr.hostHeaders) > 0 {
+		rt = &hostHeadersTransport{scoped: cr.hostHeaders, next: rt}
+	}
+	if len(cr.trailers) > 0 {
+		rt = &trailerTransport{next: rt}
+	}
+	if cr.stats != nil {
+		rt = &connStatsTransport{stats: cr.stats, next: rt}
+	}
+	if cr.budget > 0 {
+		rt = &budgetTransport{next: rt}
+	}
+	if cr.ntlm != nil {
+		rt = &ntlmTransport{creds: cr.ntlm, base: rt}
+	}
+	// inside the middleware so proofs are bound to tokens set by `HeaderFunc`
+	if cr.dpop != nil {
+		rt = &dpopTransport{dpop: cr.dpop, next: rt}
+	}
+	if len(cr.middleware) > 0 {
+		rt = cr.withRedaction(transport.Chain(cr.middleware...)(rt))
+	}
+	if cr.chaos != nil {
+		rt = cr.chaos(rt)
+	}
+	if cr.limiter != nil {
+		rt = &rateLimitTransport{limiter: cr.limiter, next: rt}
+	}
+	if cr.bandwidth != nil {
+		rt = &throttleTransport{limiter: cr.bandwidth.limiter, next: rt}
+	}
+	if cr.digest != nil {
+		rt = &digestTransport{auth: cr.digest, next: rt}
+	}
+	if cr.negotiate != nil {
+		rt = cr.negotiate.transport(rt)
+	}
+	for _, observe := range cr.metrics {
+		rt = transport.Metrics(observe)(rt)
+	}
+	if !cr.hooks.empty() {
+		cr.hookTransport = &hookTransport{hooks: cr.hooks, next: rt}
+		rt = cr.hookTransport
+	}
+	if cr.hostPolicy != nil {
+		rt = &hostPolicyTransport{policy: cr.hostPolicy, next: rt}
+	}
+	if cr.har != nil {
+		rt = &harTransport{recorder: cr.har, redact: cr.redactedHeaders(), redactQuery: cr.redactQuery, next: rt}
+	}
+	if cr.dump != nil {
+		rt = &dumpTransport{cfg: cr.dump, redact: cr.redactedHeaders(), redactQuery: cr.redactQuery,
+			skipReqBody: cr.expectContinue || cr.streamBody, next: rt}
+	}
+	if cr.logger != nil || cr.debug {
+		rt = cr.loggingTransport(rt)
+	}
+	if cr.tracer != nil {
+		rt = &tracingTransport{tracer: cr.tracer, next: rt}
+	}
+	if cr.balancer != nil {
+		rt = &balancerTransport{balancer: cr.balancer, probe: base, next: rt}
+	}
+	if cr.discovery != nil {
+		rt = &discoveryTransport{discovery: cr.discovery, probe: base, next: rt}
+	}
+	if cr.fallback != nil {
+		rt = &fallbackTransport{fallback: cr.fallback, next: rt}
+	}
+	if cr.retry != nil {
+		opts := []transport.RetryOption{transport.RetryIf(cr.retryable)}
+		if cr.retryBudget != nil {
+			opts = append(opts, transport.WithBudget(cr.retryBudget))
+		}
+		rt = cr.stats.countSends(transport.Retry(cr.retry.max, cr.retry.backoff, opts...), rt, cr.stats.recordRetries)
+	}
+	if cr.cacheStore != nil {
+		rt = cr.stats.countSends(transport.Cache(cr.cacheStore, cr.cacheOptions()...), rt, cr.stats.recordCache)
+	}
+	if cr.dedupe != nil {
+		rt = &dedupeTransport{group: cr.dedupe, vary: cr.redactedHeaders(), readBody: cr.readBody, next: rt}
+	}
+	if cr.memo != nil {
+		rt = &memoTransport{memo: cr.memo, credentials: cr.redactedHeaders(), next: rt}
+	}
+	return rt
+}
+
 // AddHeaders adds custom headers to the request
 func AddHeaders(h ...map[string]string) RequestOption {
 	return func(r *Request) error {
@@ -69,6 +304,15 @@ func SetClient(client *http.Client) RequestOption {
 	}
 }
 
+// Timeout limits the time for the whole request, including reading the response body.
+// It takes precedence over the timeout of a client set with `SetClient`
+func Timeout(d time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.timeout = d
+		return nil
+	}
+}
+
 // QueryParams sets the query params for a request
 func QueryParams(m map[string]string) RequestOption {
 	return func(r *Request) error {
@@ -88,6 +332,7 @@ func SetCookieJar(jar *cookiejar.Jar) RequestOption {
 // JSON sets a request to accept and respond with json
 func JSON() RequestOption {
 	return func(r *Request) error {
+		r.claim("content type", "JSON", ContentTypeJSON)
 		r.accept = ContentTypeJSON
 		r.contentType = ContentTypeJSON
 		return nil
@@ -97,6 +342,7 @@ func JSON() RequestOption {
 // ContentType allows setting the content-type for the request
 func ContentType(ct string) RequestOption {
 	return func(r *Request) error {
+		r.claim("content type", "ContentType", ct)
 		r.contentType = ct
 		return nil
 	}
@@ -113,6 +359,7 @@ func Accept(ct string) RequestOption {
 // RequestXML sets a request to accept and respond with json
 func RequestXML() RequestOption {
 	return func(r *Request) error {
+		r.claim("content type", "RequestXML", ContentTypeXML)
 		r.accept = ContentTypeXML
 		r.contentType = ContentTypeXML
 		return nil
@@ -129,10 +376,73 @@ func ExpectStatus(codes ...int) RequestOption {
 	}
 }
 
-// WithBody provides the body to be used with the http request
+// ExpectRange sets an inclusive range of expected status codes from a response
+func ExpectRange(min, max int) RequestOption {
+	return func(r *Request) error {
+		r.setAllowedStatusRange(min, max)
+		return nil
+	}
+}
+
+// ExpectSuccess expects a 2xx status code from a response
+func ExpectSuccess() RequestOption {
+	return ExpectRange(200, 299)
+}
+
+// Expect2xx3xx expects a 2xx or 3xx status code from a response
+func Expect2xx3xx() RequestOption {
+	return ExpectRange(200, 399)
+}
+
+// MaxResponseBytes limits the size of response bodies to n bytes.
+// Larger responses fail with `ErrResponseTooLarge` instead of being read into memory
+func MaxResponseBytes(n int64) RequestOption {
+	return func(r *Request) error {
+		r.maxResponseBytes = n
+		return nil
+	}
+}
+
+// WithBody provides the body to be used with the http request.
+// Readers other than *bytes.Buffer, *bytes.Reader and *strings.Reader can only be sent once,
+// so redirects that resend the body fail. Use `WithBodyFunc` for those
 func WithBody(reader io.Reader) RequestOption {
 	return func(r *Request) error {
+		r.claim("body", "WithBody", "")
 		r.body = reader
+		r.bodyFunc = nil
+		r.streamBody = false
+		return nil
+	}
+}
+
+// WithBodyFunc sets the body of the request to the reader returned by fn.
+// fn is called again whenever the body needs to be resent, e.g. on redirects and retries
+func WithBodyFunc(fn func() (io.ReadCloser, error)) RequestOption {
+	return func(r *Request) error {
+		r.claim("body", "WithBodyFunc", "")
+		r.bodyFunc = fn
+		r.body = nil
+		r.streamBody = false
+		return nil
+	}
+}
+
+// RawRequest calls fn with the *http.Request just before it is sent, for changes
+// the other options don't cover
+func RawRequest(fn func(*http.Request)) RequestOption {
+	return func(r *Request) error {
+		r.rawRequest = append(r.rawRequest, fn)
+		return nil
+	}
+}
+
+// IncludeRawResponse sets `Response.Raw` to the *http.Response without reading its body,
+// so it can be streamed. `Response.Body` is left empty and the caller must close `Raw.Body`,
+// including when an error is returned along with the response
+func IncludeRawResponse() RequestOption {
+	return func(r *Request) error {
+		r.includeRaw = true
 		return nil
 	}
 }
@@ -142,7 +452,7 @@ func New(opts ...RequestOption) (*Request, *http.Request, error) {
 	return newHTTPRequest(opts...)
 }
 
-func delete() RequestOption {
+func del() RequestOption {
 	return func(r *Request) error {
 		r.method = "DELETE"
 		return nil
@@ -176,6 +486,20 @@ func head() RequestOption {
 		return nil
 	}
 }
+
+// Method sets the http method of a request made with `New`
+func Method(m string) RequestOption {
+	return func(r *Request) error {
+		r.method = m
+		return nil
+	}
+}
+
+// URL sets the url of a request made with `New`
+func URL(u string) RequestOption {
+	return setURL(u)
+}
+
 func setURL(u string) RequestOption {
 	return func(r *Request) error {
 		r.url = u
@@ -185,6 +509,11 @@ func setURL(u string) RequestOption {
 
 // newHTTPRequest returns a new `Request` configured with various options
 func newHTTPRequest(opts ...RequestOption) (*Request, *http.Request, error) {
+	return newRequest(false, opts...)
+}
+
+// newRequest applies opts and validates them, requiring a url and method when send is set
+func newRequest(send bool, opts ...RequestOption) (*Request, *http.Request, error) {
 	r := &Request{}
 	if r.httpClient == nil {
 		r.setHTTPClient(&http.Client{})
@@ -208,6 +537,16 @@ func newHTTPRequest(opts ...RequestOption) (*Request, *http.Request, error) {
 		}
 		r.Unlock()
 	}
+	r.opts = opts
+	if err := r.validate(send); err != nil {
+		return nil, nil, err
+	}
+	if err := r.applyHostConfigs(); err != nil {
+		return nil, nil, err
+	}
+	if err := r.validateDialControls(); err != nil {
+		return nil, nil, err
+	}
 
 	req, err := r.httpRequest()
 	return r, req, err
@@ -219,29 +558,81 @@ func (cr *Request) httpRequest() (*http.Request, error) {
 		cr.accept = DefaultAccept
 	}
 
-	u, uErr := url.Parse(cr.url)
+	if err := cr.encodeTypedBody(); err != nil {
+		return nil, err
+	}
+
+	u, uErr := cr.resolveURL()
 	if uErr != nil {
 		return nil, uErr
 	}
 
-	req, reqErr := http.NewRequest(cr.method, u.String(), cr.body)
+	ctx := cr.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if cr.clock != nil {
+		ctx = clock.NewContext(ctx, cr.clock)
+	}
+	body := cr.body
+	if cr.bodyFunc != nil {
+		rc, err := cr.bodyFunc()
+		if err != nil {
+			return nil, err
+		}
+		body = rc
+	}
+	req, reqErr := http.NewRequestWithContext(ctx, cr.method, u.String(), body)
 
 	if reqErr != nil {
 		return nil, reqErr
 	}
+	if cr.bodyFunc != nil {
+		req.GetBody = cr.bodyFunc
+	}
+	if cr.streamBody {
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+	}
+	if cr.gzipBody {
+		gzipRequestBody(req)
+	}
+	if len(cr.trailers) > 0 {
+		req = addTrailers(req, cr.trailers)
+	}
 
 	for k, v := range cr.headers {
 		req.Header.Add(k, v)
 	}
-	qs := url.Values{}
+	qs := req.URL.Query()
 	for q, p := range cr.queryParams {
 		qs.Add(q, p)
 	}
+	for q, ps := range cr.queryValues {
+		for _, p := range ps {
+			qs.Add(q, p)
+		}
+	}
 	req.URL.RawQuery = qs.Encode()
 	if cr.contentType != "" {
 		req.Header.Add("Content-Type", cr.contentType)
 	}
 	req.Header.Add("Accept", cr.accept)
+	if req.Header.Get("User-Agent") == "" {
+		ua := cr.userAgent
+		if ua == "" {
+			ua = DefaultUserAgent()
+		}
+		if ua != "" {
+			req.Header.Set("User-Agent", ua)
+		}
+	}
+	if err := cr.setIdempotencyKey(req); err != nil {
+		return nil, err
+	}
+	if err := cr.setRequestID(req); err != nil {
+		return nil, err
+	}
 
 	return req, nil
 }
@@ -255,7 +646,7 @@ func Get(url string, opts ...RequestOption) (*Response, error) {
 
 // Delete performs an http DELETE
 func Delete(url string, opts ...RequestOption) (*Response, error) {
-	opts = append(opts, delete())
+	opts = append(opts, del())
 	opts = append(opts, setURL(url))
 	return doRequest(opts...)
 }
@@ -281,38 +672,225 @@ func Head(url string, opts ...RequestOption) (*Response, error) {
 	return doRequest(opts...)
 }
 
+// Client applies a set of default options to every request it makes
+type Client struct {
+	opts      []RequestOption
+	transport sharedTransport
+	presets   presets
+	stats     *clientStats
+	lifecycle *lifecycle
+}
+
+// NewClient returns a `Client` that applies opts to every request.
+// Options passed to individual requests are applied after the client's.
+// Transport options set on the client share one transport so connections are reused
+func NewClient(opts ...RequestOption) *Client {
+	c := &Client{opts: opts, stats: newClientStats()}
+	c.lifecycle = newLifecycle(&c.transport)
+	return c
+}
+
+func (c *Client) options(opts []RequestOption) []RequestOption {
+	all := append([]RequestOption{}, c.opts...)
+	all = append(all, c.shareTransport(), c.trackStats(), c.trackLifecycle())
+	return append(all, opts...)
+}
+
+// shareTransport replaces the transport options applied by the client with
+// a transport built once from them
+func (c *Client) shareTransport() RequestOption {
+	return func(r *Request) error {
+		c.transport.apply(r)
+		r.resetClaims()
+		return nil
+	}
+}
+
+// New creates a ClientRequest with the client's options
+func (c *Client) New(opts ...RequestOption) (*Request, *http.Request, error) {
+	return New(c.options(opts)...)
+}
+
+// Get performs an http GET with the client's options
+func (c *Client) Get(url string, opts ...RequestOption) (*Response, error) {
+	return Get(url, c.options(opts)...)
+}
+
+// Delete performs an http DELETE with the client's options
+func (c *Client) Delete(url string, opts ...RequestOption) (*Response, error) {
+	return Delete(url, c.options(opts)...)
+}
+
+// Post performs an http POST with the client's options
+func (c *Client) Post(url string, opts ...RequestOption) (*Response, error) {
+	return Post(url, c.options(opts)...)
+}
+
+// Put performs an http PUT with the client's options
+func (c *Client) Put(url string, opts ...RequestOption) (*Response, error) {
+	return Put(url, c.options(opts)...)
+}
+
+// Head performs an http HEAD with the client's options
+func (c *Client) Head(url string, opts ...RequestOption) (*Response, error) {
+	return Head(url, c.options(opts)...)
+}
+
 func doRequest(opts ...RequestOption) (*Response, error) {
-	response := &Response{}
-	cr, req, reqErr := newHTTPRequest(opts...)
+	_, response, err := do(opts...)
+	return response, err
+}
+
+// do performs the request and also returns the `Request` built from the options
+func do(opts ...RequestOption) (*Request, *Response, error) {
+	cr, req, reqErr := newRequest(true, opts...)
 	if reqErr != nil {
-		return nil, reqErr
+		return nil, nil, reqErr
+	}
+	response, err := cr.execute(req)
+	return cr, response, err
+}
+
+// Send performs a request prepared with `New`, checking the response like `Get` and
+// friends do. ctx, when not nil, replaces the context set with `WithContext`.
+// A `Request` can be sent more than once but not concurrently; use `Clone` for that
+func (cr *Request) Send(ctx context.Context) (*Response, error) {
+	cr.Lock()
+	err := cr.validate(true)
+	var req *http.Request
+	if err == nil {
+		req, err = cr.httpRequest()
+	}
+	cr.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	return cr.execute(req)
+}
+
+// execute sends req within a span and runs the error hooks
+func (cr *Request) execute(req *http.Request) (*Response, error) {
+	// a `Client` closed while an async operation is followed waits for it to end
+	if err := cr.lifecycle.enter(); err != nil {
+		return nil, err
+	}
+	defer cr.lifecycle.leave()
+	response, err := cr.executeOnce(req)
+	if err != nil || !cr.followAsync || response.Status != http.StatusAccepted {
+		return response, err
+	}
+	if response, err = cr.followAsyncOperation(req, response); err != nil {
+		return response, err
+	}
+	return response, cr.checkResponse(req, response)
+}
+
+// executeOnce sends req, without following an async operation
+func (cr *Request) executeOnce(req *http.Request) (*Response, error) {
+	defer cr.stats.track()()
+	req, budget, release := cr.withBudget(req)
+	if !cr.includeRaw {
+		// a raw body is read after execute returns, its deadline ends with the budget
+		defer release()
+	}
+	done, err := cr.admit(req.Context())
+	if err = budget.result(err); err != nil {
+		cr.stats.recordResult(err)
+		cr.runErrorHooks(req, err)
+		return nil, err
+	}
+	defer done()
+	req, endSpan := cr.startSpan(req)
+	response, err := cr.send(req)
+	err = budget.result(err)
+	cr.stats.recordResult(err)
+	cr.runErrorHooks(req, err)
+	endSpan(response, err)
+	return response, err
+}
+
+// readBody reads the response body enforcing `MaxResponseBytes`
+func (cr *Request) readBody(resp *http.Response) ([]byte, error) {
+	if cr.maxResponseBytes <= 0 {
+		return ioutil.ReadAll(resp.Body)
+	}
+	if resp.ContentLength > cr.maxResponseBytes {
+		return nil, ErrResponseTooLarge
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, cr.maxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > cr.maxResponseBytes {
+		return nil, ErrResponseTooLarge
+	}
+	return body, nil
+}
+
+// send executes req with the configured client and validates the response
+func (cr *Request) send(req *http.Request) (*Response, error) {
+	response := &Response{}
+	var tt *timingTrace
+	if cr.timings {
+		tt = newTimingTrace()
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), tt.clientTrace()))
+	}
+	var continued atomic.Bool
+	if cr.expectContinue {
+		req = traceContinue(req, &continued)
+	}
+	client := cr.client()
+	if cr.tunedTransport != nil {
+		defer cr.tunedTransport.CloseIdleConnections()
 	}
-	cr.httpClient.Jar = cr.cookieJar
-	resp, respErr := cr.httpClient.Do(req)
+	for _, fn := range cr.rawRequest {
+		fn(req)
+	}
+	resp, respErr := client.Do(req)
 	if respErr != nil {
 		return nil, respErr
 	}
-	readBody, readErr := ioutil.ReadAll(resp.Body)
-	if readErr != nil {
-		return nil, readErr
+	if cr.includeRaw {
+		// trailers are merged into a non-nil map as the body is read
+		if resp.Trailer == nil {
+			resp.Trailer = http.Header{}
+		}
+		response.Raw = resp
+	} else {
+		defer resp.Body.Close()
+		readBody, readErr := cr.readBody(resp)
+		if readErr != nil {
+			return nil, readErr
+		}
+		response.Body = readBody
+	}
+	if tt != nil {
+		response.Timings = tt.finish()
+		response.Connection = tt.connection(resp.TLS)
 	}
-	response.Body = readBody
 	response.Headers = resp.Header
+	response.Trailers = resp.Trailer
 	response.Status = resp.StatusCode
+	response.Proto = resp.Proto
+	response.RequestID = req.Header.Get(HeaderRequestID)
+	response.NotModified = resp.StatusCode == http.StatusNotModified
+	response.Continued = continued.Load()
+	response.URL = resp.Request.URL.String()
 	response.Cookies = append(response.Cookies, resp.Cookies()...)
-	if len(cr.getAllowedStatusCodes()) != 0 {
-		passed := false
-		for _, code := range cr.getAllowedStatusCodes() {
-			if resp.StatusCode == code {
-				passed = true
-				break
-			}
-		}
-		if !passed {
-			return response, ErrInvalidStatusCode
-		}
-
+	if cr.skipValidation || (cr.followAsync && resp.StatusCode == http.StatusAccepted) {
+		// the resource an async operation ends with is validated by execute
+		return response, nil
 	}
+	return response, cr.checkResponse(req, response)
+}
 
-	return response, nil
+// checkResponse validates the status and expectations of response
+func (cr *Request) checkResponse(req *http.Request, response *Response) error {
+	if !cr.statusAllowed(response.Status) {
+		return newStatusError(req, response)
+	}
+	return cr.checkExpectations(req, response)
 }
@@ -1,8 +1,11 @@
 package httpclient
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
@@ -11,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lusis/go-experiments/pkg/funcopts/http/testsupport"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/publicsuffix"
 )
@@ -96,11 +100,12 @@ func TestErrOpt(t *testing.T) {
 	assert.Error(t, err)
 }
 func TestAddHeaders(t *testing.T) {
+	bin := testsupport.HTTPBin(t)
 	headers := map[string]string{
 		"fooheader": "foovalue",
 		"barheader": "barvalue",
 	}
-	response, err := Get("https://httpbin.org/anything", AddHeaders(headers))
+	response, err := Get(bin.URL+"/anything", AddHeaders(headers))
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
@@ -109,7 +114,8 @@ func TestAddHeaders(t *testing.T) {
 	assert.Equal(t, "barvalue", res.Headers["Barheader"])
 }
 func TestAccept(t *testing.T) {
-	response, err := Get("https://httpbin.org/anything", Accept("application/octet"))
+	bin := testsupport.HTTPBin(t)
+	response, err := Get(bin.URL+"/anything", Accept("application/octet"))
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
@@ -118,7 +124,8 @@ func TestAccept(t *testing.T) {
 }
 
 func TestRequestXML(t *testing.T) {
-	response, err := Get("https://httpbin.org/anything", RequestXML())
+	bin := testsupport.HTTPBin(t)
+	response, err := Get(bin.URL+"/anything", RequestXML())
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
@@ -126,62 +133,70 @@ func TestRequestXML(t *testing.T) {
 	assert.Equal(t, "application/xml", res.Headers["Accept"])
 }
 func TestGetAllowedStatusCodesInvalid(t *testing.T) {
-	response, err := Get("https://httpbin.org/anything", ExpectStatus(302))
+	bin := testsupport.HTTPBin(t)
+	response, err := Get(bin.URL+"/anything", ExpectStatus(302))
 	assert.Error(t, err)
-	assert.EqualError(t, err, ErrInvalidStatusCode.Error())
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+	assert.Equal(t, 200, err.(*StatusError).Status)
 	assert.Equal(t, 200, response.Status)
 }
 
 func TestGetAllowedStatusCodesValid(t *testing.T) {
-	response, err := Get("https://httpbin.org/anything", ExpectStatus(200, 302))
+	bin := testsupport.HTTPBin(t)
+	response, err := Get(bin.URL+"/anything", ExpectStatus(200, 302))
 	assert.NoError(t, err)
 	assert.Equal(t, 200, response.Status)
 }
 
 func TestGet(t *testing.T) {
+	bin := testsupport.HTTPBin(t)
 	qp := make(map[string]string)
 	qp["foo"] = "bar"
-	response, err := Get("https://httpbin.org/get")
+	response, err := Get(bin.URL + "/get")
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
 	assert.NoError(t, jErr)
-	assert.Equal(t, "https://httpbin.org/get", res.URL)
+	assert.Equal(t, bin.URL+"/get", res.URL)
 }
 
 func TestGetWithOption(t *testing.T) {
+	bin := testsupport.HTTPBin(t)
 	qp := make(map[string]string)
 	qp["foo"] = "bar"
-	response, err := Get("https://httpbin.org/get", QueryParams(qp))
+	response, err := Get(bin.URL+"/get", QueryParams(qp))
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
 	assert.NoError(t, jErr)
 	assert.Equal(t, "bar", res.Args["foo"])
-	assert.Equal(t, "https://httpbin.org/get?foo=bar", res.URL)
+	assert.Equal(t, bin.URL+"/get?foo=bar", res.URL)
 }
 
 func TestGetWithMultipleOptions(t *testing.T) {
+	bin := testsupport.HTTPBin(t)
 	qp := make(map[string]string)
 	qp["foo"] = "bar"
-	response, err := Get("https://httpbin.org/get", QueryParams(qp), JSON())
+	response, err := Get(bin.URL+"/get", QueryParams(qp), JSON())
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
 	assert.NoError(t, jErr)
 	assert.Equal(t, "bar", res.Args["foo"])
-	assert.Equal(t, "https://httpbin.org/get?foo=bar", res.URL)
+	assert.Equal(t, bin.URL+"/get?foo=bar", res.URL)
 	assert.Equal(t, "application/json", res.Headers["Accept"])
 }
 
 func TestHead(t *testing.T) {
-	response, err := Head("https://httpbin.org/ip")
+	bin := testsupport.HTTPBin(t)
+	response, err := Head(bin.URL + "/ip")
 	assert.NoError(t, err)
 	assert.Equal(t, "application/json", response.Headers.Get("Content-Type"))
 }
 
 func TestDelete(t *testing.T) {
-	response, err := Delete("https://httpbin.org/delete")
+	bin := testsupport.HTTPBin(t)
+	response, err := Delete(bin.URL + "/delete")
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
@@ -190,7 +205,8 @@ func TestDelete(t *testing.T) {
 }
 
 func TestPost(t *testing.T) {
-	response, err := Post("https://httpbin.org/post", WithBody(strings.NewReader("this is my body")), ContentType("text/plain"))
+	bin := testsupport.HTTPBin(t)
+	response, err := Post(bin.URL+"/post", WithBody(strings.NewReader("this is my body")), ContentType("text/plain"))
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
@@ -199,10 +215,134 @@ func TestPost(t *testing.T) {
 }
 
 func TestPut(t *testing.T) {
-	response, err := Put("https://httpbin.org/put", WithBody(strings.NewReader("this is my body")), ContentType("text/plain"))
+	bin := testsupport.HTTPBin(t)
+	response, err := Put(bin.URL+"/put", WithBody(strings.NewReader("this is my body")), ContentType("text/plain"))
 	assert.NoError(t, err)
 	res := &testHTPPBinResponse{}
 	jErr := json.Unmarshal(response.Body, &res)
 	assert.NoError(t, jErr)
 	assert.Equal(t, "this is my body", res.Data)
 }
+
+func TestMaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "" {
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+	response, err := Get(ts.URL, MaxResponseBytes(10))
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(response.Body))
+
+	_, err = Get(ts.URL, MaxResponseBytes(5))
+	assert.EqualError(t, err, ErrResponseTooLarge.Error())
+
+	_, err = Get(ts.URL, MaxResponseBytes(5), QueryParams(map[string]string{"chunked": "1"}))
+	assert.EqualError(t, err, ErrResponseTooLarge.Error())
+}
+
+func redirectEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusTemporaryRedirect)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+}
+
+func TestWithBodyFunc(t *testing.T) {
+	ts := redirectEchoServer()
+	defer ts.Close()
+	calls := 0
+	response, err := Post(ts.URL+"/start", WithBodyFunc(func() (io.ReadCloser, error) {
+		calls++
+		return ioutil.NopCloser(strings.NewReader("replayed")), nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.Status)
+	assert.Equal(t, "replayed", string(response.Body))
+	assert.Equal(t, 2, calls)
+}
+
+func TestWithBodyOneShot(t *testing.T) {
+	ts := redirectEchoServer()
+	defer ts.Close()
+	response, err := Post(ts.URL+"/start", WithBody(io.MultiReader(strings.NewReader("once"))))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, response.Status)
+}
+
+func TestWithBodyFuncError(t *testing.T) {
+	_, err := Post("http://example.com", WithBodyFunc(func() (io.ReadCloser, error) {
+		return nil, errors.New("no body")
+	}))
+	assert.EqualError(t, err, "no body")
+}
+
+func TestRawRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+	response, err := Get(ts.URL, RawRequest(func(req *http.Request) {
+		req.Host = "virtual.example.com"
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "virtual.example.com", string(response.Body))
+}
+
+func TestIncludeRawResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("streamed"))
+	}))
+	defer ts.Close()
+	response, err := Get(ts.URL, IncludeRawResponse())
+	assert.NoError(t, err)
+	assert.Empty(t, response.Body)
+	if assert.NotNil(t, response.Raw) {
+		defer response.Raw.Body.Close()
+		body, err := ioutil.ReadAll(response.Raw.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "streamed", string(body))
+	}
+}
+
+func TestSend(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+	r, _, err := New(Method(http.MethodPost), URL(ts.URL), ExpectSuccess(), WithBodyFunc(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("prepared")), nil
+	}))
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		response, err := r.Send(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "prepared", string(response.Body))
+	}
+
+	r, _, err = New(Method(http.MethodGet), URL(ts.URL), ExpectSuccess())
+	assert.NoError(t, err)
+	_, err = r.Send(nil)
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.Send(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	r, _, err = New(URL(ts.URL))
+	assert.NoError(t, err)
+	_, err = r.Send(context.Background())
+	assert.True(t, errors.Is(err, ErrMissingField))
+}
@@ -0,0 +1,185 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// Stats is a snapshot of the activity of a `Client`
+type Stats struct {
+	// InFlight is the number of requests being sent
+	InFlight int64 `json:"in_flight"`
+	// Requests is the number of requests completed and Errors how many of them failed
+	Requests uint64 `json:"requests"`
+	Errors   uint64 `json:"errors"`
+	// Retries is the number of attempts repeated by `Retry`
+	Retries uint64 `json:"retries"`
+	// CacheHits is the number of requests answered by `Cache` without reaching the
+	// server and CacheMisses the number that did, revalidations included
+	CacheHits    uint64  `json:"cache_hits"`
+	CacheMisses  uint64  `json:"cache_misses"`
+	CacheHitRate float64 `json:"cache_hit_rate"`
+	// Hosts are the connection stats per host and port
+	Hosts map[string]HostStats `json:"hosts"`
+}
+
+// HostStats are the connections used for the requests to a host
+type HostStats struct {
+	NewConnections    uint64 `json:"new_connections"`
+	ReusedConnections uint64 `json:"reused_connections"`
+}
+
+// clientStats are the live counters behind `Stats`. Its methods do nothing on a nil
+// receiver so requests made outside a `Client` don't need checks
+type clientStats struct {
+	inFlight    atomic.Int64
+	requests    atomic.Uint64
+	errors      atomic.Uint64
+	retries     atomic.Uint64
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
+	sync.Mutex
+	hosts map[string]*HostStats
+}
+
+func newClientStats() *clientStats {
+	return &clientStats{hosts: map[string]*HostStats{}}
+}
+
+// Stats returns the current counters of the client and of clients derived with `With`
+func (c *Client) Stats() Stats {
+	s := c.stats
+	if s == nil {
+		return Stats{Hosts: map[string]HostStats{}}
+	}
+	out := Stats{
+		InFlight:    s.inFlight.Load(),
+		Requests:    s.requests.Load(),
+		Errors:      s.errors.Load(),
+		Retries:     s.retries.Load(),
+		CacheHits:   s.cacheHits.Load(),
+		CacheMisses: s.cacheMisses.Load(),
+		Hosts:       map[string]HostStats{},
+	}
+	if lookups := out.CacheHits + out.CacheMisses; lookups > 0 {
+		out.CacheHitRate = float64(out.CacheHits) / float64(lookups)
+	}
+	s.Lock()
+	defer s.Unlock()
+	for host, h := range s.hosts {
+		out.Hosts[host] = *h
+	}
+	return out
+}
+
+// StatsHandler serves `Stats` as json, e.g. on a debug port
+//
+//	http.Handle("/debug/httpclient", c.StatsHandler())
+func (c *Client) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		json.NewEncoder(w).Encode(c.Stats())
+	})
+}
+
+// trackStats records the requests of the client in its stats
+func (c *Client) trackStats() RequestOption {
+	return func(r *Request) error {
+		if c.stats != nil {
+			r.stats = c.stats
+		}
+		return nil
+	}
+}
+
+// track counts a request in flight until the returned function is called
+func (s *clientStats) track() func() {
+	if s == nil {
+		return func() {}
+	}
+	s.inFlight.Add(1)
+	return func() { s.inFlight.Add(-1) }
+}
+
+func (s *clientStats) recordResult(err error) {
+	if s == nil {
+		return
+	}
+	s.requests.Add(1)
+	if err != nil {
+		s.errors.Add(1)
+	}
+}
+
+func (s *clientStats) recordRetries(sends int32) {
+	if sends > 1 {
+		s.retries.Add(uint64(sends - 1))
+	}
+}
+
+func (s *clientStats) recordCache(sends int32) {
+	if sends == 0 {
+		s.cacheHits.Add(1)
+	} else {
+		s.cacheMisses.Add(1)
+	}
+}
+
+func (s *clientStats) recordConn(host string, reused bool) {
+	s.Lock()
+	defer s.Unlock()
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &HostStats{}
+		s.hosts[host] = h
+	}
+	if reused {
+		h.ReusedConnections++
+	} else {
+		h.NewConnections++
+	}
+}
+
+// sendsKey keys the send counter of a `countSends` layer in the request context
+type sendsKey struct{ _ byte }
+
+// countSends wraps next in layer and calls record with the number of times layer
+// passed each request on to next. Without stats it's just layer
+func (s *clientStats) countSends(layer transport.Middleware, next http.RoundTripper, record func(int32)) http.RoundTripper {
+	if s == nil {
+		return layer(next)
+	}
+	key := &sendsKey{}
+	wrapped := layer(transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if n, ok := req.Context().Value(key).(*atomic.Int32); ok {
+			n.Add(1)
+		}
+		return next.RoundTrip(req)
+	}))
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		n := new(atomic.Int32)
+		resp, err := wrapped.RoundTrip(req.WithContext(context.WithValue(req.Context(), key, n)))
+		record(n.Load())
+		return resp, err
+	})
+}
+
+// connStatsTransport records whether each attempt got a new or reused connection
+type connStatsTransport struct {
+	stats *clientStats
+	next  http.RoundTripper
+}
+
+func (t *connStatsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) {
+		t.stats.recordConn(host, info.Reused)
+	}}
+	return t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
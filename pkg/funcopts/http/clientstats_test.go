@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientStats(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/cached":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	c := NewClient(Retry(2, time.Millisecond), Cache(NewMemoryCache()))
+	for _, path := range []string{"/flaky", "/cached", "/cached", "/cached"} {
+		_, err := c.Get(ts.URL + path)
+		require.NoError(t, err)
+	}
+	_, err := c.Get(ts.URL+"/missing", ExpectSuccess())
+	assert.Error(t, err)
+
+	stats := c.Stats()
+	assert.Equal(t, int64(0), stats.InFlight)
+	assert.Equal(t, uint64(5), stats.Requests)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, uint64(1), stats.Retries)
+	assert.Equal(t, uint64(2), stats.CacheHits)
+	assert.Equal(t, uint64(3), stats.CacheMisses)
+	assert.InDelta(t, 0.4, stats.CacheHitRate, 0.001)
+	host := stats.Hosts[ts.Listener.Addr().String()]
+	assert.Equal(t, uint64(4), host.NewConnections+host.ReusedConnections, "one connection per attempt sent")
+	assert.NotZero(t, host.ReusedConnections)
+}
+
+func TestClientStatsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	defer ts.Close()
+	c := NewClient()
+	derived := c.With(UserAgent("derived"))
+	done := make(chan struct{})
+	go func() {
+		derived.Get(ts.URL)
+		close(done)
+	}()
+	<-started
+	assert.Equal(t, int64(1), c.Stats().InFlight, "derived clients share stats")
+
+	rec := httptest.NewRecorder()
+	c.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var got Stats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, int64(1), got.InFlight)
+	assert.Equal(t, ContentTypeJSON, rec.Header().Get("Content-Type"))
+
+	close(release)
+	<-done
+	assert.Equal(t, int64(0), c.Stats().InFlight)
+	assert.Equal(t, uint64(1), c.Stats().Requests)
+}
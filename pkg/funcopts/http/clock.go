@@ -0,0 +1,12 @@
+package httpclient
+
+import "github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+
+// WithClock uses c instead of the time package for retry backoff, rate limiting,
+// caching and memoization. With a `clock.Fake` those run without waiting
+func WithClock(c clock.Clock) RequestOption {
+	return func(r *Request) error {
+		r.clock = c
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+// Package clock abstracts time so retries, backoff, rate limiting and caching
+// can be tested without waiting
+//
+//	c := clock.NewFake(time.Now())
+//	res, err := httpclient.Get(url, httpclient.WithClock(c), httpclient.Retry(3, time.Second))
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d, returning the context error if ctx is done first
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// Real is the Clock backed by the time package
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying c
+func NewContext(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the Clock carried by ctx, or `Real`
+func FromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(contextKey{}).(Clock); ok {
+		return c
+	}
+	return Real
+}
+
+// Fake is a Clock that only moves when it is advanced. Sleeping advances it by
+// the duration slept and returns immediately
+type Fake struct {
+	now    time.Time
+	sleeps []time.Duration
+	sync.Mutex
+}
+
+// NewFake returns a `Fake` set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the time of the clock
+func (f *Fake) Now() time.Time {
+	f.Lock()
+	defer f.Unlock()
+	return f.now
+}
+
+// Sleep records d and advances the clock by it
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.sleeps = append(f.sleeps, d)
+	if d > 0 {
+		f.now = f.now.Add(d)
+	}
+	return nil
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.Lock()
+	defer f.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Sleeps returns the durations passed to Sleep, in order
+func (f *Fake) Sleeps() []time.Duration {
+	f.Lock()
+	defer f.Unlock()
+	return append([]time.Duration{}, f.sleeps...)
+}
@@ -0,0 +1,37 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+	assert.NoError(t, f.Sleep(context.Background(), time.Minute))
+	f.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Minute+time.Second), f.Now())
+	assert.Equal(t, []time.Duration{time.Minute}, f.Sleeps())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(f.Sleep(ctx, time.Hour), context.Canceled))
+	assert.Equal(t, start.Add(time.Minute+time.Second), f.Now())
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Real, FromContext(context.Background()))
+	f := NewFake(time.Now())
+	assert.Equal(t, Clock(f), FromContext(NewContext(context.Background(), f)))
+}
+
+func TestRealSleep(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(Real.Sleep(ctx, time.Hour), context.DeadlineExceeded))
+	assert.NoError(t, Real.Sleep(context.Background(), time.Millisecond))
+}
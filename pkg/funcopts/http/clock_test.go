@@ -0,0 +1,56 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithClockRetry(t *testing.T) {
+	ts, calls := flakyServer(2, http.StatusServiceUnavailable)
+	defer ts.Close()
+	c := clock.NewFake(time.Now())
+	start := time.Now()
+	res, err := Get(ts.URL, WithClock(c), Retry(2, time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	assert.Len(t, c.Sleeps(), 2)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestWithClockRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	c := clock.NewFake(time.Now())
+	client := NewClient(WithClock(c), RateLimit(1.0/60, 1))
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(ts.URL)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []time.Duration{0, time.Minute, time.Minute}, c.Sleeps())
+}
+
+func TestWithClockCache(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "cached")
+	}))
+	defer ts.Close()
+	c := clock.NewFake(time.Now())
+	client := NewClient(WithClock(c), Cache(NewMemoryCache()), Memoize(time.Hour))
+	_, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	c.Advance(2 * time.Hour)
+	_, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
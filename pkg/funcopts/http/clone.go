@@ -0,0 +1,37 @@
+package httpclient
+
+// Clone returns an independent copy of the request with overrides applied after
+// its options. The options are applied again, so a reader passed to `WithBody`
+// is shared with the copy; use `WithBodyFunc` for a body sent by several requests
+func (cr *Request) Clone(overrides ...RequestOption) (*Request, error) {
+	cr.RLock()
+	opts := append([]RequestOption{}, cr.opts...)
+	cr.RUnlock()
+	opts = append(opts, overrideClaims())
+	r, _, err := newHTTPRequest(append(opts, overrides...)...)
+	return r, err
+}
+
+// With returns a new `Client` that applies opts after the options of c.
+// It shares the transport, presets and `Stats` of c when it is created, and is closed
+// with c
+func (c *Client) With(opts ...RequestOption) *Client {
+	derived := &Client{opts: c.options(opts), stats: c.stats, lifecycle: c.lifecycle}
+	c.presets.RLock()
+	defer c.presets.RUnlock()
+	if len(c.presets.named) > 0 {
+		derived.presets.named = make(map[string]RequestOption, len(c.presets.named))
+		for name, preset := range c.presets.named {
+			derived.presets.named[name] = preset
+		}
+	}
+	return derived
+}
+
+// overrideClaims lets the options that follow replace earlier ones without a conflict
+func overrideClaims() RequestOption {
+	return func(r *Request) error {
+		r.resetClaims()
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	template, _, err := New(get(), BaseURL("http://example.com/api/"), JSON(), BearerToken("token"))
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := template.Clone(Path(fmt.Sprintf("items/%d", i)), AddHeaders(map[string]string{"X-Item": fmt.Sprint(i)}))
+			assert.NoError(t, err)
+			req, err := r.httpRequest()
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("http://example.com/api/items/%d", i), req.URL.String())
+			assert.Equal(t, fmt.Sprint(i), req.Header.Get("X-Item"))
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		}(i)
+	}
+	wg.Wait()
+	req, err := template.httpRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/api/", req.URL.String())
+	assert.Empty(t, req.Header.Get("X-Item"))
+
+	r, err := template.Clone(RequestXML())
+	assert.NoError(t, err)
+	req, err = r.httpRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeXML, req.Header.Get("Content-Type"))
+}
+
+func TestClientWith(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + " " + r.Header.Get("X-Team")))
+	}))
+	conns := countConns(ts)
+	ts.Start()
+	defer ts.Close()
+	c := NewClient(MaxIdleConnsPerHost(2), AddHeaders(map[string]string{"X-Team": "ops"}))
+	c.DefinePreset("json", JSON())
+	alice := c.With(BearerToken("alice"))
+	bob := c.With(BearerToken("bob"))
+	c.DefinePreset("later", JSON())
+
+	res, err := alice.Get(ts.URL, alice.UsePreset("json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer alice ops", string(res.Body))
+	res, err = bob.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer bob ops", string(res.Body))
+	res, err = c.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, " ops", string(res.Body))
+	assert.Equal(t, int32(1), atomic.LoadInt32(conns))
+
+	_, err = bob.Get(ts.URL, bob.UsePreset("later"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+)
+
+// lifecycle tracks the requests in flight on a `Client` and whether it was closed.
+// Its methods do nothing on a nil receiver so requests made outside a `Client`
+// don't need checks
+type lifecycle struct {
+	transport *sharedTransport
+	sync.Mutex
+	closed   bool
+	inFlight int
+	drained  chan struct{}
+}
+
+func newLifecycle(transport *sharedTransport) *lifecycle {
+	return &lifecycle{transport: transport, drained: make(chan struct{})}
+}
+
+// Close stops the client from sending new requests, which fail with `ErrClientClosed`,
+// waits for the requests in flight, including those sent by a `Fetcher`, and then
+// closes the idle connections of the client. It returns the context error when ctx is
+// done before the requests finish. Clients derived with `With` are closed too
+func (c *Client) Close(ctx context.Context) error {
+	l := c.lifecycle
+	if l == nil {
+		return nil
+	}
+	l.Lock()
+	if !l.closed {
+		l.closed = true
+		if l.inFlight == 0 {
+			close(l.drained)
+		}
+	}
+	l.Unlock()
+	var err error
+	select {
+	case <-l.drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	l.closeIdleConnections()
+	return err
+}
+
+// trackLifecycle has the requests of the client checked in and out of its lifecycle
+func (c *Client) trackLifecycle() RequestOption {
+	return func(r *Request) error {
+		if c.lifecycle != nil {
+			r.lifecycle = c.lifecycle
+		}
+		return nil
+	}
+}
+
+// enter counts a request in flight unless the client is closed
+func (l *lifecycle) enter() error {
+	if l == nil {
+		return nil
+	}
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return ErrClientClosed
+	}
+	l.inFlight++
+	return nil
+}
+
+// leave counts a request out, letting `Close` return after the last one
+func (l *lifecycle) leave() {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.inFlight--
+	if l.closed && l.inFlight == 0 {
+		close(l.drained)
+	}
+}
+
+func (l *lifecycle) closeIdleConnections() {
+	// waits for a request still building the shared transport
+	l.transport.once.Do(func() {})
+	if t, ok := l.transport.rt.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
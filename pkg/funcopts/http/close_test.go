@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientClose(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	c := NewClient()
+	derived := c.With(AddHeaders(map[string]string{"X-Test": "1"}))
+	done := make(chan *Response)
+	go func() {
+		res, err := derived.Get(ts.URL)
+		assert.NoError(t, err)
+		done <- res
+	}()
+	assert.Eventually(t, func() bool { return c.Stats().InFlight == 1 }, time.Second, time.Millisecond)
+
+	closed := make(chan error)
+	go func() {
+		closed <- c.Close(context.Background())
+	}()
+	assert.Eventually(t, func() bool {
+		c.lifecycle.Lock()
+		defer c.lifecycle.Unlock()
+		return c.lifecycle.closed
+	}, time.Second, time.Millisecond)
+	_, err := c.Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrClientClosed))
+	_, err = derived.Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrClientClosed))
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned with a request in flight")
+	default:
+	}
+	close(unblock)
+	assert.Equal(t, "ok", string((<-done).Body))
+	assert.NoError(t, <-closed)
+	assert.NoError(t, c.Close(context.Background()))
+}
+
+func TestClientCloseTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	defer close(unblock)
+	c := NewClient()
+	go c.Get(ts.URL)
+	assert.Eventually(t, func() bool { return c.Stats().InFlight == 1 }, time.Second, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(c.Close(ctx), context.DeadlineExceeded))
+}
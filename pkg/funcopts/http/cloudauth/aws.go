@@ -0,0 +1,44 @@
+package cloudauth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+const (
+	// AWSMetadata is the address of the AWS instance metadata service
+	AWSMetadata = "http://169.254.169.254"
+	// AWSIdentityRefresh is how often the instance identity signature is fetched again.
+	// It doesn't expire but is refreshed in case the instance's metadata changes
+	AWSIdentityRefresh = time.Hour
+	// awsSessionTTL is the lifetime requested for IMDSv2 session tokens
+	awsSessionTTL = 5 * time.Minute
+)
+
+// AWS sends the PKCS7 signature of the instance identity document, which services
+// verify with the AWS public certificate of the region to learn the account, region
+// and instance id of the caller. Metadata is read with IMDSv2 sessions
+func AWS(opts ...Option) httpclient.RequestOption {
+	cfg := newConfig(AWSMetadata, opts)
+	return bearer(cfg, func(ctx context.Context, now time.Time) (string, time.Time, error) {
+		res, err := httpclient.Put(cfg.endpoint+"/latest/api/token", cfg.metadataOptions(ctx,
+			httpclient.AddHeaders(map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": strconv.Itoa(int(awsSessionTTL.Seconds()))}))...)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("aws imdsv2 session: %w", err)
+		}
+		session := strings.TrimSpace(string(res.Body))
+		res, err = httpclient.Get(cfg.endpoint+"/latest/dynamic/instance-identity/pkcs7", cfg.metadataOptions(ctx,
+			httpclient.AddHeaders(map[string]string{"X-aws-ec2-metadata-token": session}))...)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("aws instance identity: %w", err)
+		}
+		// the signature is base64 wrapped over several lines
+		signature := strings.Join(strings.Fields(string(res.Body)), "")
+		return signature, now.Add(AWSIdentityRefresh), nil
+	})
+}
@@ -0,0 +1,33 @@
+package cloudauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWS(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			assert.Equal(t, "300", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+			w.Write([]byte("session"))
+		case r.URL.Path == "/latest/dynamic/instance-identity/pkcs7":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "session" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("MIAGCSqG\nSIb3DQEH\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer metadata.Close()
+	res, err := httpclient.Get(echo(t).URL, AWS(Endpoint(metadata.URL)))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer MIAGCSqGSIb3DQEH", string(res.Body))
+}
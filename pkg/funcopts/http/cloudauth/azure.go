@@ -0,0 +1,47 @@
+package cloudauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// AzureMetadata is the address of the Azure instance metadata service
+const AzureMetadata = "http://169.254.169.254"
+
+// Azure sends access tokens of the managed identity of the instance for resource, the
+// application id uri of the receiving service. The system assigned identity is used
+// unless one is picked with `ClientID`
+func Azure(resource string, opts ...Option) httpclient.RequestOption {
+	cfg := newConfig(AzureMetadata, opts)
+	return bearer(cfg, func(ctx context.Context, now time.Time) (string, time.Time, error) {
+		q := url.Values{"api-version": {"2018-02-01"}, "resource": {resource}}
+		if cfg.clientID != "" {
+			q.Set("client_id", cfg.clientID)
+		}
+		res, err := httpclient.Get(cfg.endpoint+"/metadata/identity/oauth2/token?"+q.Encode(),
+			cfg.metadataOptions(ctx, httpclient.AddHeaders(map[string]string{"Metadata": "true"}))...)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("azure managed identity token: %w", err)
+		}
+		var token struct {
+			AccessToken string `json:"access_token"`
+			// ExpiresOn is sent as a string of unix seconds
+			ExpiresOn json.RawMessage `json:"expires_on"`
+		}
+		if err := json.Unmarshal(res.Body, &token); err != nil {
+			return "", time.Time{}, fmt.Errorf("azure managed identity token: %w", err)
+		}
+		expires, err := strconv.ParseInt(strings.Trim(string(token.ExpiresOn), `"`), 10, 64)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("azure managed identity token expires_on: %w", err)
+		}
+		return token.AccessToken, time.Unix(expires, 0), nil
+	})
+}
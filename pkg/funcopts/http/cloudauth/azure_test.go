@@ -0,0 +1,39 @@
+package cloudauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzure(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	requests := 0
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, "/metadata/identity/oauth2/token", r.URL.Path)
+		assert.Equal(t, "api://orders", r.URL.Query().Get("resource"))
+		assert.Equal(t, "identity-1", r.URL.Query().Get("client_id"))
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_on":"%d","token_type":"Bearer"}`, requests, clk.Now().Add(time.Hour).Unix())
+	}))
+	defer metadata.Close()
+	c := httpclient.NewClient(Azure("api://orders", Endpoint(metadata.URL), ClientID("identity-1")), httpclient.WithClock(clk))
+	get := func() string {
+		res, err := c.Get(echo(t).URL)
+		require.NoError(t, err)
+		return string(res.Body)
+	}
+	assert.Equal(t, "Bearer token-1", get())
+	clk.Advance(50 * time.Minute)
+	assert.Equal(t, "Bearer token-1", get())
+	clk.Advance(6 * time.Minute)
+	assert.Equal(t, "Bearer token-2", get())
+}
@@ -0,0 +1,107 @@
+// Package cloudauth sends identity tokens from the instance metadata services of GCP,
+// Azure and AWS, so workloads can call services protected by cloud IAM without keys
+//
+//	c := httpclient.NewClient(cloudauth.GCP("https://orders-abc123-uc.a.run.app"))
+//	c := httpclient.NewClient(cloudauth.Azure("api://orders", cloudauth.ClientID(identity)))
+//	c := httpclient.NewClient(cloudauth.AWS())
+//
+// Tokens are cached and fetched again shortly before they expire
+package cloudauth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// DefaultRenewBefore is how long before expiry a token is fetched again
+const DefaultRenewBefore = 5 * time.Minute
+
+type config struct {
+	endpoint    string
+	clientID    string
+	renewBefore time.Duration
+	opts        []httpclient.RequestOption
+}
+
+// Option configures a metadata token source
+type Option func(*config)
+
+// Endpoint sends metadata requests to u instead of the well known address of the provider
+func Endpoint(u string) Option {
+	return func(cfg *config) {
+		cfg.endpoint = u
+	}
+}
+
+// ClientID selects the user assigned managed identity with the client id on Azure
+func ClientID(id string) Option {
+	return func(cfg *config) {
+		cfg.clientID = id
+	}
+}
+
+// RenewBefore fetches tokens d before they expire instead of `DefaultRenewBefore`
+func RenewBefore(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.renewBefore = d
+	}
+}
+
+// RequestOptions applies opts to metadata requests
+func RequestOptions(opts ...httpclient.RequestOption) Option {
+	return func(cfg *config) {
+		cfg.opts = append(cfg.opts, opts...)
+	}
+}
+
+func newConfig(endpoint string, opts []Option) *config {
+	cfg := &config{endpoint: endpoint, renewBefore: DefaultRenewBefore}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// fetchFunc gets a token and when it expires from a metadata service
+type fetchFunc func(ctx context.Context, now time.Time) (string, time.Time, error)
+
+// cachedToken keeps the token of fetch until it's about to expire
+type cachedToken struct {
+	fetch       fetchFunc
+	renewBefore time.Duration
+	sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func (c *cachedToken) get(ctx context.Context) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+	now := clock.FromContext(ctx).Now()
+	if c.token != "" && now.Before(c.expiry.Add(-c.renewBefore)) {
+		return c.token, nil
+	}
+	token, expiry, err := c.fetch(ctx, now)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expiry = token, expiry
+	return token, nil
+}
+
+// bearer sends the token of fetch as a bearer token with every request
+func bearer(cfg *config, fetch fetchFunc) httpclient.RequestOption {
+	cache := &cachedToken{fetch: fetch, renewBefore: cfg.renewBefore}
+	return httpclient.BearerTokenFunc(cache.get, nil)
+}
+
+// metadataOptions returns the options of a metadata request sent with ctx
+func (cfg *config) metadataOptions(ctx context.Context, opts ...httpclient.RequestOption) []httpclient.RequestOption {
+	all := append([]httpclient.RequestOption{httpclient.WithContext(ctx), httpclient.ExpectStatus(http.StatusOK)}, cfg.opts...)
+	return append(all, opts...)
+}
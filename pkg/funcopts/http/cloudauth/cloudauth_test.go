@@ -0,0 +1,50 @@
+package cloudauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echo returns the Authorization header of each request as the body
+func echo(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestCachedToken(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	ctx := clock.NewContext(context.Background(), clk)
+	fetches := 0
+	c := &cachedToken{renewBefore: time.Minute, fetch: func(ctx context.Context, now time.Time) (string, time.Time, error) {
+		fetches++
+		return "token", now.Add(10 * time.Minute), nil
+	}}
+	for _, step := range []time.Duration{0, 8 * time.Minute, time.Minute} {
+		clk.Advance(step)
+		token, err := c.get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	}
+	assert.Equal(t, 2, fetches, "fetched again within a minute of expiry")
+}
+
+func TestFetchError(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer metadata.Close()
+	_, err := httpclient.Get(echo(t).URL, GCP("aud", Endpoint(metadata.URL)))
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode))
+}
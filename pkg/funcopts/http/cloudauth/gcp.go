@@ -0,0 +1,59 @@
+package cloudauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// GCPMetadata is the address of the GCP metadata server
+const GCPMetadata = "http://metadata.google.internal"
+
+// GCP sends Google signed ID tokens of the instance's default service account for
+// audience, the url or client id of the receiving service
+func GCP(audience string, opts ...Option) httpclient.RequestOption {
+	cfg := newConfig(GCPMetadata, opts)
+	return bearer(cfg, func(ctx context.Context, now time.Time) (string, time.Time, error) {
+		q := url.Values{"audience": {audience}, "format": {"full"}}
+		res, err := httpclient.Get(cfg.endpoint+"/computeMetadata/v1/instance/service-accounts/default/identity?"+q.Encode(),
+			cfg.metadataOptions(ctx, httpclient.AddHeaders(map[string]string{"Metadata-Flavor": "Google"}))...)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("gcp identity token: %w", err)
+		}
+		token := strings.TrimSpace(string(res.Body))
+		expiry, err := jwtExpiry(token)
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("gcp identity token: %w", err)
+		}
+		return token, expiry, nil
+	})
+}
+
+// jwtExpiry returns the exp claim of a JWT without checking its signature
+func jwtExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("malformed jwt with %d parts", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, errors.New("jwt has no exp claim")
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
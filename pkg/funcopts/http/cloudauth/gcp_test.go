@@ -0,0 +1,39 @@
+package cloudauth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCP(t *testing.T) {
+	token := "e30." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":4102444800}`)) + ".sig"
+	requests := 0
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		assert.Equal(t, "/computeMetadata/v1/instance/service-accounts/default/identity", r.URL.Path)
+		assert.Equal(t, "https://svc.example.com", r.URL.Query().Get("audience"))
+		w.Write([]byte(token))
+	}))
+	defer metadata.Close()
+	c := httpclient.NewClient(GCP("https://svc.example.com", Endpoint(metadata.URL)))
+	for i := 0; i < 2; i++ {
+		res, err := c.Get(echo(t).URL)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer "+token, string(res.Body))
+	}
+	assert.Equal(t, 1, requests)
+}
+
+func TestJWTExpiry(t *testing.T) {
+	_, err := jwtExpiry("not-a-jwt")
+	assert.Error(t, err)
+	_, err = jwtExpiry("e30.e30.sig")
+	assert.Error(t, err)
+}
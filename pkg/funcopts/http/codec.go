@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// Codec encodes and decodes bodies of a media type
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes bodies as `application/json`
+type JSONCodec struct{}
+
+// ContentType returns `application/json`
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+// Marshal encodes v as json
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes json data into v
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// XMLCodec encodes bodies as `application/xml`
+type XMLCodec struct{}
+
+// ContentType returns `application/xml`
+func (XMLCodec) ContentType() string { return ContentTypeXML }
+
+// Marshal encodes v as xml
+func (XMLCodec) Marshal(v interface{}) ([]byte, error) { return xml.Marshal(v) }
+
+// Unmarshal decodes xml data into v
+func (XMLCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+
+var codecs = struct {
+	byType map[string]Codec
+	sync.RWMutex
+}{byType: map[string]Codec{
+	ContentTypeJSON: JSONCodec{},
+	"text/json":     JSONCodec{},
+	ContentTypeXML:  XMLCodec{},
+	"text/xml":      XMLCodec{},
+}}
+
+// RegisterCodec makes c available to `WithTypedBody` and `Response.Decode` for its
+// content type and any additional mediaTypes, replacing existing codecs
+func RegisterCodec(c Codec, mediaTypes ...string) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	for _, t := range append([]string{c.ContentType()}, mediaTypes...) {
+		codecs.byType[strings.ToLower(t)] = c
+	}
+}
+
+// CodecFor returns the codec registered for the media type of contentType.
+// Structured syntax suffixes are understood, so `application/problem+json` uses the json codec
+func CodecFor(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	codecs.RLock()
+	defer codecs.RUnlock()
+	if c, ok := codecs.byType[mediaType]; ok {
+		return c, true
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		c, ok := codecs.byType["application/"+mediaType[i+1:]]
+		return c, ok
+	}
+	return nil, false
+}
+
+// WithTypedBody encodes v as the body of the request with the codec for the request's
+// content type, which defaults to json
+func WithTypedBody(v interface{}) RequestOption {
+	return func(r *Request) error {
+		r.claim("body", "WithTypedBody", "")
+		r.typedBody = v
+		r.hasTypedBody = true
+		return nil
+	}
+}
+
+// encodeTypedBody sets the body from `WithTypedBody`
+func (cr *Request) encodeTypedBody() error {
+	if !cr.hasTypedBody {
+		return nil
+	}
+	if cr.contentType == "" {
+		cr.contentType = ContentTypeJSON
+	}
+	c, ok := CodecFor(cr.contentType)
+	if !ok {
+		return fmt.Errorf("%s: %w", cr.contentType, ErrNoCodec)
+	}
+	data, err := c.Marshal(cr.typedBody)
+	if err != nil {
+		return err
+	}
+	cr.body = bytes.NewReader(data)
+	cr.bodyFunc = nil
+	return nil
+}
+
+// Decode decodes the body into v with the codec for the response's `Content-Type`
+func (r *Response) Decode(v interface{}) error {
+	ct := r.Headers.Get("Content-Type")
+	c, ok := CodecFor(ct)
+	if !ok {
+		return fmt.Errorf("%s: %w", ct, ErrNoCodec)
+	}
+	return c.Unmarshal(r.Body, v)
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codecPayload struct {
+	XMLName xml.Name `json:"-" xml:"payload"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func echoContentServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Write(body)
+	}))
+}
+
+func TestTypedBodyJSON(t *testing.T) {
+	ts := echoContentServer()
+	defer ts.Close()
+	res, err := Post(ts.URL, WithTypedBody(codecPayload{Name: "json"}))
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, res.Headers.Get("Content-Type"))
+	assert.Equal(t, `{"name":"json"}`, string(res.Body))
+	var out codecPayload
+	assert.NoError(t, res.Decode(&out))
+	assert.Equal(t, "json", out.Name)
+}
+
+func TestTypedBodyXML(t *testing.T) {
+	ts := echoContentServer()
+	defer ts.Close()
+	res, err := Post(ts.URL, ContentType("application/atom+xml"), WithTypedBody(codecPayload{Name: "xml"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "<payload><name>xml</name></payload>", string(res.Body))
+	var out codecPayload
+	assert.NoError(t, res.Decode(&out))
+	assert.Equal(t, "xml", out.Name)
+}
+
+func TestTypedBodyNoCodec(t *testing.T) {
+	_, err := Post("http://example.com", ContentType("application/x-unknown"), WithTypedBody(1))
+	assert.True(t, errors.Is(err, ErrNoCodec))
+	err = (&Response{Headers: http.Header{"Content-Type": {"image/png"}}}).Decode(new(int))
+	assert.True(t, errors.Is(err, ErrNoCodec))
+}
+
+type upperCodec struct{}
+
+func (upperCodec) ContentType() string { return "text/x-upper" }
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*string)) = strings.ToLower(string(data))
+	return nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec(upperCodec{}, "text/x-shout")
+	c, ok := CodecFor("text/x-shout; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, "text/x-upper", c.ContentType())
+	_, req, err := New(post(), setURL("http://example.com"), ContentType("text/x-upper"), WithTypedBody("hi"))
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, "HI", string(body))
+}
+
+func TestCodecForSuffix(t *testing.T) {
+	c, ok := CodecFor(ContentTypeProblemJSON)
+	assert.True(t, ok)
+	assert.Equal(t, ContentTypeJSON, c.ContentType())
+	_, ok = CodecFor("application/vnd.thing+unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,26 @@
+// Package cbor registers a CBOR `Codec` with httpclient when imported
+package cbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// ContentType is the mimetype for CBOR
+const ContentType = "application/cbor"
+
+func init() {
+	httpclient.RegisterCodec(Codec{})
+}
+
+// Codec encodes bodies as `application/cbor`
+type Codec struct{}
+
+// ContentType returns `application/cbor`
+func (Codec) ContentType() string { return ContentType }
+
+// Marshal encodes v as CBOR
+func (Codec) Marshal(v interface{}) ([]byte, error) { return cbor.Marshal(v) }
+
+// Unmarshal decodes CBOR data into v
+func (Codec) Unmarshal(data []byte, v interface{}) error { return cbor.Unmarshal(data, v) }
@@ -0,0 +1,42 @@
+package cbor
+
+import (
+	"net/http"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeIntegerKeys(t *testing.T) {
+	// CBOR maps aren't limited to string keys like json
+	sent := map[int][]byte{1: {0x00, 0xff}, 2: {0x80}}
+	data, err := Codec{}.Marshal(sent)
+	require.NoError(t, err)
+	res := &httpclient.Response{Headers: http.Header{"Content-Type": {ContentType}}, Body: data}
+	var out map[int][]byte
+	require.NoError(t, res.Decode(&out))
+	assert.Equal(t, sent, out)
+}
+
+func TestDecodeSuffix(t *testing.T) {
+	data, err := Codec{}.Marshal([]map[string]interface{}{{"n": "temp", "v": 21.5}})
+	require.NoError(t, err)
+	res := &httpclient.Response{Headers: http.Header{"Content-Type": {"application/senml+cbor"}}, Body: data}
+	var out []struct {
+		N string  `cbor:"n"`
+		V float64 `cbor:"v"`
+	}
+	require.NoError(t, res.Decode(&out))
+	assert.Equal(t, "temp", out[0].N)
+	assert.Equal(t, 21.5, out[0].V)
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data, err := Codec{}.Marshal(map[string]string{"name": "cbor"})
+	require.NoError(t, err)
+	res := &httpclient.Response{Headers: http.Header{"Content-Type": {ContentType}}, Body: data[:len(data)-2]}
+	var out map[string]string
+	assert.Error(t, res.Decode(&out))
+}
@@ -0,0 +1,26 @@
+// Package msgpack registers a MessagePack `Codec` with httpclient when imported
+package msgpack
+
+import (
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType is the mimetype for MessagePack
+const ContentType = "application/msgpack"
+
+func init() {
+	httpclient.RegisterCodec(Codec{}, "application/x-msgpack", "application/vnd.msgpack")
+}
+
+// Codec encodes bodies as `application/msgpack`
+type Codec struct{}
+
+// ContentType returns `application/msgpack`
+func (Codec) ContentType() string { return ContentType }
+
+// Marshal encodes v as MessagePack
+func (Codec) Marshal(v interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+
+// Unmarshal decodes MessagePack data into v
+func (Codec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
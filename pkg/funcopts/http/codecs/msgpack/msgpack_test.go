@@ -0,0 +1,42 @@
+package msgpack
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaTypes(t *testing.T) {
+	for _, ct := range []string{ContentType, "application/x-msgpack", "application/vnd.msgpack"} {
+		c, ok := httpclient.CodecFor(ct)
+		assert.True(t, ok, ct)
+		assert.Equal(t, Codec{}, c, ct)
+	}
+}
+
+func TestTypedBodyBinary(t *testing.T) {
+	type blob struct {
+		Name string `msgpack:"name"`
+		Data []byte `msgpack:"data"`
+	}
+	sent := blob{Name: "blob", Data: []byte{0x00, 0xff, 0xfe, 0x80, '\n'}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		var in blob
+		assert.NoError(t, Codec{}.Unmarshal(data, &in))
+		assert.Equal(t, sent, in)
+		w.Header().Set("Content-Type", "application/x-msgpack")
+		w.Write(data)
+	}))
+	defer ts.Close()
+	res, err := httpclient.Post(ts.URL, httpclient.ContentType(ContentType), httpclient.WithTypedBody(sent))
+	require.NoError(t, err)
+	var out blob
+	require.NoError(t, res.Decode(&out))
+	assert.Equal(t, sent, out)
+}
@@ -0,0 +1,26 @@
+// Package yaml registers a YAML `Codec` with httpclient when imported
+package yaml
+
+import (
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"gopkg.in/yaml.v2"
+)
+
+// ContentType is the mimetype for yaml
+const ContentType = "application/yaml"
+
+func init() {
+	httpclient.RegisterCodec(Codec{}, "application/x-yaml", "text/yaml", "text/x-yaml")
+}
+
+// Codec encodes bodies as `application/yaml`
+type Codec struct{}
+
+// ContentType returns `application/yaml`
+func (Codec) ContentType() string { return ContentType }
+
+// Marshal encodes v as yaml
+func (Codec) Marshal(v interface{}) ([]byte, error) { return yaml.Marshal(v) }
+
+// Unmarshal decodes yaml data into v
+func (Codec) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
@@ -0,0 +1,51 @@
+package yaml
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type payload struct {
+	Name  string `yaml:"name"`
+	Count int    `yaml:"count"`
+}
+
+func TestDecodeAnchors(t *testing.T) {
+	body := "defaults: &defaults\n  count: 2\nitem:\n  <<: *defaults\n  name: yaml\n"
+	res := &httpclient.Response{Headers: http.Header{"Content-Type": {"text/yaml; charset=utf-8"}}, Body: []byte(body)}
+	var out struct {
+		Item payload `yaml:"item"`
+	}
+	require.NoError(t, res.Decode(&out))
+	assert.Equal(t, payload{Name: "yaml", Count: 2}, out.Item)
+}
+
+func TestDecodeFirstDocument(t *testing.T) {
+	body := "name: first\n---\nname: second\n"
+	res := &httpclient.Response{Headers: http.Header{"Content-Type": {ContentType}}, Body: []byte(body)}
+	var out payload
+	require.NoError(t, res.Decode(&out))
+	assert.Equal(t, "first", out.Name)
+}
+
+func TestTypedBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ContentType, r.Header.Get("Content-Type"))
+		data, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "name: yaml\ncount: 2\n", string(data))
+		w.Header().Set("Content-Type", "application/x-yaml")
+		w.Write([]byte("name: yaml\ncount: 3\n"))
+	}))
+	defer ts.Close()
+	res, err := httpclient.Post(ts.URL, httpclient.ContentType(ContentType), httpclient.WithTypedBody(payload{Name: "yaml", Count: 2}))
+	require.NoError(t, err)
+	var out payload
+	require.NoError(t, res.Decode(&out))
+	assert.Equal(t, payload{Name: "yaml", Count: 3}, out)
+}
@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// GzipBody compresses the request body while it is sent and sets `Content-Encoding: gzip`.
+// Bodies that can be replayed, like a *bytes.Reader or *strings.Reader, stay replayable for redirects
+func GzipBody() RequestOption {
+	return func(r *Request) error {
+		r.gzipBody = true
+		return nil
+	}
+}
+
+// DisableDecompression stops the transport from requesting and transparently decompressing
+// gzip responses so bodies can be passed through untouched. It has no effect on custom transports
+// that are not an *http.Transport
+func DisableDecompression() RequestOption {
+	return func(r *Request) error {
+		r.transportTuning = append(r.transportTuning, func(t *http.Transport) {
+			t.DisableCompression = true
+		})
+		return nil
+	}
+}
+
+func gzipRequestBody(req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	req.Body = gzipReader(req.Body)
+	req.ContentLength = -1
+	req.Header.Set("Content-Encoding", "gzip")
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return gzipReader(body), nil
+		}
+	}
+}
+
+// gzipReader streams a gzip compressed copy of rc
+func gzipReader(rc io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, rc)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+		rc.Close()
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testGzipEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/echo", http.StatusTemporaryRedirect)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := ioutil.ReadAll(zr)
+		w.Write([]byte(r.Header.Get("Content-Encoding") + " " + string(body)))
+	}))
+}
+
+func TestGzipBody(t *testing.T) {
+	ts := testGzipEchoServer()
+	defer ts.Close()
+	res, err := Post(ts.URL+"/echo", GzipBody(), WithBody(strings.NewReader("compress me")), ExpectStatus(200))
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip compress me", string(res.Body))
+}
+
+func TestGzipBodyReplay(t *testing.T) {
+	ts := testGzipEchoServer()
+	defer ts.Close()
+	res, err := Post(ts.URL+"/redirect", GzipBody(), WithBody(strings.NewReader("replay me")), ExpectStatus(200))
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip replay me", string(res.Body))
+}
+
+func TestDisableDecompression(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte("compressed response"))
+		zw.Close()
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "compressed response", string(res.Body))
+
+	res, err = Get(ts.URL, DisableDecompression(), AddHeaders(map[string]string{"Accept-Encoding": "gzip"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", res.Headers.Get("Content-Encoding"))
+	zr, err := gzip.NewReader(strings.NewReader(string(res.Body)))
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(zr)
+	assert.Equal(t, "compressed response", string(body))
+}
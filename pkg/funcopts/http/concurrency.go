@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ConcurrencyOption configures a `ConcurrencyLimit`
+type ConcurrencyOption func(*ConcurrencyLimit)
+
+// QueueFor lets up to size requests wait for a slot for at most timeout, or as long as
+// their context allows when timeout is zero. Without it requests over the limit fail
+// straight away
+func QueueFor(size int, timeout time.Duration) ConcurrencyOption {
+	return func(l *ConcurrencyLimit) {
+		l.queue = int64(size)
+		l.timeout = timeout
+	}
+}
+
+// ConcurrencyLimit caps the number of requests in flight at once. Requests over the
+// limit fail with `ErrConcurrencyLimit`
+type ConcurrencyLimit struct {
+	slots   chan struct{}
+	queue   int64
+	timeout time.Duration
+	queued  atomic.Int64
+}
+
+// NewConcurrencyLimit returns a `ConcurrencyLimit` of n requests. Share one between
+// clients with `LimitConcurrency` to cap the requests of the whole process
+func NewConcurrencyLimit(n int, opts ...ConcurrencyOption) *ConcurrencyLimit {
+	l := &ConcurrencyLimit{slots: make(chan struct{}, n)}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// InFlight returns the number of requests holding a slot
+func (l *ConcurrencyLimit) InFlight() int {
+	return len(l.slots)
+}
+
+// Queued returns the number of requests waiting for a slot
+func (l *ConcurrencyLimit) Queued() int {
+	return int(l.queued.Load())
+}
+
+// MaxConcurrent caps the requests in flight at once to n. The limit is shared by every
+// request made with the option, so set it on a `Client` to cap the client
+func MaxConcurrent(n int, opts ...ConcurrencyOption) RequestOption {
+	return LimitConcurrency(NewConcurrencyLimit(n, opts...))
+}
+
+// LimitConcurrency counts the request against l, in addition to any other limit set
+// on it. Use one limit for every client to protect the file descriptors of the
+// process, and `MaxConcurrent` on each client to protect the services it calls
+func LimitConcurrency(l *ConcurrencyLimit) RequestOption {
+	return func(r *Request) error {
+		r.concurrency = append(r.concurrency, l)
+		return nil
+	}
+}
+
+// acquire takes a slot, waiting in the queue when there is one, and returns the
+// function giving it back
+func (l *ConcurrencyLimit) acquire(ctx context.Context) (func(), error) {
+	release := func() { <-l.slots }
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if l.queued.Add(1) > l.queue {
+		l.queued.Add(-1)
+		return nil, fmt.Errorf("%w: %d requests in flight", ErrConcurrencyLimit, cap(l.slots))
+	}
+	defer l.queued.Add(-1)
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, fmt.Errorf("%w: no slot after waiting %s", ErrConcurrencyLimit, l.timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// admit waits for the `Scheduler` and every `ConcurrencyLimit` of the request to let
+// it start and returns the function letting the next one in
+func (cr *Request) admit(ctx context.Context) (func(), error) {
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	if cr.scheduler != nil {
+		done, err := cr.scheduler.acquire(ctx, cr.priority)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, done)
+	}
+	for _, l := range cr.concurrency {
+		done, err := l.acquire(ctx)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, done)
+	}
+	return release, nil
+}
@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxConcurrent(t *testing.T) {
+	var inFlight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer ts.Close()
+	c := NewClient(MaxConcurrent(2, QueueFor(10, 0)))
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(ts.URL)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&peak) <= 2)
+}
+
+func TestMaxConcurrentFailsFast(t *testing.T) {
+	l := NewConcurrencyLimit(1)
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+	_, err = l.acquire(context.Background())
+	assert.True(t, errors.Is(err, ErrConcurrencyLimit))
+	release()
+	assert.Equal(t, 0, l.InFlight())
+}
+
+func TestMaxConcurrentQueueTimeout(t *testing.T) {
+	l := NewConcurrencyLimit(1, QueueFor(1, 10*time.Millisecond))
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+	_, err = l.acquire(context.Background())
+	assert.True(t, errors.Is(err, ErrConcurrencyLimit))
+	assert.Equal(t, 0, l.Queued())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = NewConcurrencyLimit(0, QueueFor(1, 0))
+	_, err = l.acquire(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestLimitConcurrencyShared(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	global := NewConcurrencyLimit(1)
+	a := NewClient(LimitConcurrency(global), MaxConcurrent(5))
+	b := NewClient(LimitConcurrency(global))
+	done := make(chan error)
+	go func() {
+		_, err := a.Get(ts.URL)
+		done <- err
+	}()
+	assert.Eventually(t, func() bool { return global.InFlight() == 1 }, time.Second, time.Millisecond)
+	_, err := b.Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrConcurrencyLimit))
+	close(unblock)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, global.InFlight())
+}
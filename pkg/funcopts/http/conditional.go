@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IfNoneMatch sends `If-None-Match` so the server responds with 304 Not Modified when
+// the resource still has etag. Unquoted etags are quoted
+func IfNoneMatch(etag string) RequestOption {
+	return func(r *Request) error {
+		r.headers["If-None-Match"] = quoteETag(etag)
+		return nil
+	}
+}
+
+// IfMatch sends `If-Match` so the server only applies the request when the resource
+// still has etag, responding with 412 Precondition Failed otherwise. Unquoted etags are quoted
+func IfMatch(etag string) RequestOption {
+	return func(r *Request) error {
+		r.headers["If-Match"] = quoteETag(etag)
+		return nil
+	}
+}
+
+// IfModifiedSince sends `If-Modified-Since` so the server responds with 304 Not Modified
+// when the resource hasn't changed since t
+func IfModifiedSince(t time.Time) RequestOption {
+	return func(r *Request) error {
+		r.headers["If-Modified-Since"] = t.UTC().Format(http.TimeFormat)
+		return nil
+	}
+}
+
+func quoteETag(etag string) string {
+	if etag == "*" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
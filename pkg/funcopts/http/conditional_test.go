@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfNoneMatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		if r.Header.Get("If-None-Match") == `"v2"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("fresh"))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, IfNoneMatch("v1"))
+	assert.NoError(t, err)
+	assert.False(t, res.NotModified)
+	assert.Equal(t, "fresh", string(res.Body))
+
+	res, err = Get(ts.URL, IfNoneMatch(res.Headers.Get("ETag")))
+	assert.NoError(t, err)
+	assert.True(t, res.NotModified)
+}
+
+func TestIfModifiedSince(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.txt", modified, strings.NewReader("content"))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, IfModifiedSince(modified.In(time.FixedZone("EST", -5*3600))))
+	assert.NoError(t, err)
+	assert.True(t, res.NotModified)
+
+	res, err = Get(ts.URL, IfModifiedSince(modified.Add(-time.Hour)))
+	assert.NoError(t, err)
+	assert.False(t, res.NotModified)
+}
+
+func TestIfMatch(t *testing.T) {
+	_, req, err := New(put(), setURL("http://example.com"), IfMatch("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, `"abc"`, req.Header.Get("If-Match"))
+	_, req, err = New(put(), setURL("http://example.com"), IfMatch(`W/"abc"`))
+	assert.NoError(t, err)
+	assert.Equal(t, `W/"abc"`, req.Header.Get("If-Match"))
+	_, req, err = New(put(), setURL("http://example.com"), IfMatch("*"))
+	assert.NoError(t, err)
+	assert.Equal(t, "*", req.Header.Get("If-Match"))
+}
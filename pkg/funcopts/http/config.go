@@ -0,0 +1,199 @@
+package httpclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/net/http/httpproxy"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultProfile is the profile `LoadConfig` uses when `HTTPCLIENT_PROFILE` is not set
+	DefaultProfile = "default"
+	// EnvPrefix is the prefix of the environment variables read by `FromEnv`
+	EnvPrefix = "HTTPCLIENT_"
+)
+
+// Profile is a client configuration read from a config file or the environment.
+// Durations use the `time.ParseDuration` format, e.g. `10s`
+type Profile struct {
+	BaseURL             string            `yaml:"base_url" toml:"base_url"`
+	Headers             map[string]string `yaml:"headers" toml:"headers"`
+	UserAgent           string            `yaml:"user_agent" toml:"user_agent"`
+	Proxy               string            `yaml:"proxy" toml:"proxy"`
+	NoProxy             string            `yaml:"no_proxy" toml:"no_proxy"`
+	Timeout             string            `yaml:"timeout" toml:"timeout"`
+	IdleConnTimeout     string            `yaml:"idle_conn_timeout" toml:"idle_conn_timeout"`
+	TLSHandshakeTimeout string            `yaml:"tls_handshake_timeout" toml:"tls_handshake_timeout"`
+	MaxIdleConnsPerHost int               `yaml:"max_idle_conns_per_host" toml:"max_idle_conns_per_host"`
+	Retries             int               `yaml:"retries" toml:"retries"`
+	RetryBackoff        string            `yaml:"retry_backoff" toml:"retry_backoff"`
+}
+
+// LoadConfig builds a `Client` from the profile named by `HTTPCLIENT_PROFILE`,
+// or `DefaultProfile`, in the config file at path. See `LoadProfile`
+func LoadConfig(path string) (*Client, error) {
+	name := os.Getenv(EnvPrefix + "PROFILE")
+	if name == "" {
+		name = DefaultProfile
+	}
+	return LoadProfile(path, name)
+}
+
+// LoadProfile builds a `Client` from a profile in the config file at path.
+// The file maps profile names to a `Profile` and is read as TOML when its
+// extension is `.toml`, and YAML otherwise
+func LoadProfile(path, name string) (*Client, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]Profile)
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &profiles)
+	} else {
+		err = yaml.Unmarshal(data, &profiles)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s in %s", ErrUnknownProfile, name, path)
+	}
+	opts, err := p.Options()
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	return NewClient(opts...), nil
+}
+
+// FromEnv builds a `Client` from `HTTPCLIENT_` environment variables named after
+// the fields of `Profile`, e.g. `HTTPCLIENT_BASE_URL` or `HTTPCLIENT_RETRIES`.
+// Headers are a comma separated list of `name=value` pairs in `HTTPCLIENT_HEADERS`
+func FromEnv() (*Client, error) {
+	p := Profile{
+		BaseURL:             os.Getenv(EnvPrefix + "BASE_URL"),
+		UserAgent:           os.Getenv(EnvPrefix + "USER_AGENT"),
+		Proxy:               os.Getenv(EnvPrefix + "PROXY"),
+		NoProxy:             os.Getenv(EnvPrefix + "NO_PROXY"),
+		Timeout:             os.Getenv(EnvPrefix + "TIMEOUT"),
+		IdleConnTimeout:     os.Getenv(EnvPrefix + "IDLE_CONN_TIMEOUT"),
+		TLSHandshakeTimeout: os.Getenv(EnvPrefix + "TLS_HANDSHAKE_TIMEOUT"),
+		RetryBackoff:        os.Getenv(EnvPrefix + "RETRY_BACKOFF"),
+	}
+	for _, pair := range strings.Split(os.Getenv(EnvPrefix+"HEADERS"), ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			if p.Headers == nil {
+				p.Headers = make(map[string]string)
+			}
+			p.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	for name, field := range map[string]*int{"RETRIES": &p.Retries, "MAX_IDLE_CONNS_PER_HOST": &p.MaxIdleConnsPerHost} {
+		raw := os.Getenv(EnvPrefix + name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+		}
+		*field = n
+	}
+	opts, err := p.Options()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(opts...), nil
+}
+
+// Options returns the request options for the profile. Without a `Proxy` the
+// standard `HTTP_PROXY`, `HTTPS_PROXY` and `NO_PROXY` variables are used
+func (p Profile) Options() ([]RequestOption, error) {
+	var opts []RequestOption
+	if p.BaseURL != "" {
+		opts = append(opts, BaseURL(p.BaseURL))
+	}
+	if len(p.Headers) > 0 {
+		opts = append(opts, AddHeaders(p.Headers))
+	}
+	if p.UserAgent != "" {
+		opts = append(opts, UserAgent(p.UserAgent))
+	}
+	if p.Proxy != "" {
+		noProxy := p.NoProxy
+		if noProxy == "" {
+			noProxy = httpproxy.FromEnvironment().NoProxy
+		}
+		opts = append(opts, Proxy(p.Proxy, noProxy))
+	} else {
+		opts = append(opts, ProxyFromEnvironment())
+	}
+	durations := []struct {
+		value string
+		opt   func(time.Duration) RequestOption
+	}{
+		{p.Timeout, Timeout},
+		{p.IdleConnTimeout, IdleConnTimeout},
+		{p.TLSHandshakeTimeout, TLSHandshakeTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, d.opt(parsed))
+	}
+	if p.MaxIdleConnsPerHost > 0 {
+		opts = append(opts, MaxIdleConnsPerHost(p.MaxIdleConnsPerHost))
+	}
+	if p.Retries > 0 {
+		backoff := 100 * time.Millisecond
+		if p.RetryBackoff != "" {
+			parsed, err := time.ParseDuration(p.RetryBackoff)
+			if err != nil {
+				return nil, err
+			}
+			backoff = parsed
+		}
+		opts = append(opts, Retry(p.Retries, backoff))
+	}
+	return opts, nil
+}
+
+// Proxy sends requests through the proxy at proxyURL, except for hosts matching
+// noProxy, a comma separated list in the format of `NO_PROXY`
+func Proxy(proxyURL, noProxy string) RequestOption {
+	return proxyOption(&httpproxy.Config{HTTPProxy: proxyURL, HTTPSProxy: proxyURL, NoProxy: noProxy})
+}
+
+// ProxyFromEnvironment uses the proxies set in `HTTP_PROXY`, `HTTPS_PROXY` and
+// `NO_PROXY` when the option is applied. Unlike http.ProxyFromEnvironment the
+// variables are not cached for the life of the process
+func ProxyFromEnvironment() RequestOption {
+	return func(r *Request) error {
+		return proxyOption(httpproxy.FromEnvironment())(r)
+	}
+}
+
+func proxyOption(cfg *httpproxy.Config) RequestOption {
+	proxy := cfg.ProxyFunc()
+	return transportOption(func(t *http.Transport) {
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func headerServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Team")))
+	}))
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	ts := headerServer()
+	defer ts.Close()
+	path := writeConfig(t, "client.yaml", `
+default:
+  base_url: `+ts.URL+`/api/
+  headers:
+    X-Team: ops
+  timeout: 5s
+  retries: 2
+  retry_backoff: 10ms
+staging:
+  base_url: http://staging.invalid
+`)
+	c, err := LoadConfig(path)
+	assert.NoError(t, err)
+	res, err := c.Get("items")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/items ops", string(res.Body))
+
+	r, _, err := c.New(get(), Path("items"))
+	assert.NoError(t, err)
+	d, err := r.Describe()
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, d.Timeout)
+	assert.Equal(t, 2, d.Retries)
+	assert.Equal(t, 10*time.Millisecond, d.RetryBackoff)
+
+	t.Setenv("HTTPCLIENT_PROFILE", "missing")
+	_, err = LoadConfig(path)
+	assert.True(t, errors.Is(err, ErrUnknownProfile))
+}
+
+func TestLoadProfileTOML(t *testing.T) {
+	ts := headerServer()
+	defer ts.Close()
+	path := writeConfig(t, "client.toml", `
+[internal]
+base_url = "`+ts.URL+`"
+timeout = "1s"
+
+[internal.headers]
+X-Team = "platform"
+`)
+	c, err := LoadProfile(path, "internal")
+	assert.NoError(t, err)
+	res, err := c.Get("/status")
+	assert.NoError(t, err)
+	assert.Equal(t, "/status platform", string(res.Body))
+
+	bad := writeConfig(t, "bad.yaml", "default:\n  timeout: soon\n")
+	_, err = LoadConfig(bad)
+	assert.Error(t, err)
+}
+
+func TestProfileProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	defer proxy.Close()
+	ts := headerServer()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	hosts := StaticHosts(map[string]string{"upstream.example": "127.0.0.1"})
+
+	c, err := fromEnvWith(t, map[string]string{"HTTPCLIENT_PROXY": proxy.URL})
+	assert.NoError(t, err)
+	res, err := c.Get("http://upstream.example:"+u.Port()+"/a", hosts)
+	assert.NoError(t, err)
+	assert.Equal(t, "proxied http://upstream.example:"+u.Port()+"/a", string(res.Body))
+
+	c, err = fromEnvWith(t, map[string]string{"HTTP_PROXY": proxy.URL, "NO_PROXY": "upstream.example"})
+	assert.NoError(t, err)
+	res, err = c.Get("http://upstream.example:"+u.Port()+"/a", hosts)
+	assert.NoError(t, err)
+	assert.Equal(t, "/a ", string(res.Body))
+}
+
+// fromEnvWith calls FromEnv with env set for the duration of the test
+func fromEnvWith(t *testing.T, env map[string]string) (*Client, error) {
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	return FromEnv()
+}
+
+func TestFromEnv(t *testing.T) {
+	ts := headerServer()
+	defer ts.Close()
+	c, err := fromEnvWith(t, map[string]string{
+		"HTTPCLIENT_BASE_URL": ts.URL,
+		"HTTPCLIENT_HEADERS":  "X-Team=env, X-Other=1",
+		"HTTPCLIENT_RETRIES":  "1",
+	})
+	assert.NoError(t, err)
+	res, err := c.Get("/env")
+	assert.NoError(t, err)
+	assert.Equal(t, "/env env", string(res.Body))
+
+	_, err = fromEnvWith(t, map[string]string{"HTTPCLIENT_RETRIES": "many"})
+	assert.Error(t, err)
+}
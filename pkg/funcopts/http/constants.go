@@ -1,18 +1,84 @@
 package httpclient
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
 
 const (
+	// Version is the version of the package sent in the default `User-Agent`
+	Version = "0.1"
 	// ContentTypeJSON is the mimetype for json
 	ContentTypeJSON = "application/json"
 	// ContentTypeXML is the mimetype for xml
 	ContentTypeXML = "application/xml"
+	// ContentTypeProblemJSON is the mimetype for RFC 7807 problem details
+	ContentTypeProblemJSON = "application/problem+json"
 	// DefaultAccept is the default Accept mimetype for requests
 	DefaultAccept = "*/*"
+	// DefaultMaxPages is the default maximum number of pages `Paginate` will fetch
+	DefaultMaxPages = 100
 )
 
 var (
-	// ErrInvalidStatusCode is the error type returned when the user sets expected
+	// ErrInvalidStatusCode is the error wrapped by `StatusError` when the user sets expected
 	// status code with `ExpectStatus`, but it does not match
 	ErrInvalidStatusCode = errors.New("response had an invalid status code")
+	// ErrMaxPagesExceeded is the error returned by a `Pager` when there are more pages
+	// than allowed by `MaxPages`
+	ErrMaxPagesExceeded = errors.New("pagination exceeded the maximum number of pages")
+	// ErrNotCached is the error returned when `OfflineOnly` is set and there is no cached response
+	ErrNotCached = transport.ErrNotCached
+	// ErrResponseTooLarge is the error returned when a response body is larger than `MaxResponseBytes`
+	ErrResponseTooLarge = errors.New("response body exceeded the maximum size")
+	// ErrHostNotAllowed is the error returned when a request is blocked by `AllowHosts` or `DenyPrivateNetworks`
+	ErrHostNotAllowed = errors.New("host is not allowed")
+	// ErrInvalidCertificate is the error returned by `WithCA` when no certificates could be parsed
+	ErrInvalidCertificate = errors.New("no valid certificates found")
+	// ErrNoCodec is the error returned when no `Codec` is registered for a content type
+	ErrNoCodec = errors.New("no codec registered for content type")
+	// ErrOptionConflict is the error wrapped when options that exclude each other are combined
+	ErrOptionConflict = errors.New("conflicting options")
+	// ErrMissingField is the error wrapped when a request is sent without a url or method
+	ErrMissingField = errors.New("missing required field")
+	// ErrUnknownPreset is the error returned by `UsePreset` when no preset has the name
+	ErrUnknownPreset = errors.New("unknown preset")
+	// ErrExpectationFailed is the error wrapped by `ExpectationError`
+	ErrExpectationFailed = errors.New("response did not meet expectation")
+	// ErrHeaderMismatch is the error wrapped when a response fails `ExpectHeader`
+	ErrHeaderMismatch = errors.New("unexpected header value")
+	// ErrContentTypeMismatch is the error wrapped when a response fails `ExpectContentType`
+	ErrContentTypeMismatch = errors.New("unexpected content type")
+	// ErrBodyMismatch is the error wrapped when a response fails `ExpectBodyContains`
+	ErrBodyMismatch = errors.New("body does not contain expected text")
+	// ErrRangeIgnored is the error returned when a server answers a range request of
+	// `ParallelDownload` with the whole body, e.g. because the file changed since it was probed
+	ErrRangeIgnored = errors.New("server ignored the range request")
+	// ErrChecksumMismatch is the error returned when a download fails `VerifySHA256` or `VerifyDigestHeader`
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrNoDigest is the error returned by `VerifyDigestHeader` when the response has no digest
+	// with a supported algorithm
+	ErrNoDigest = errors.New("response has no supported digest")
+	// ErrKerberosUnavailable is the error returned by `NegotiateAuth` in builds without the `kerberos` tag
+	ErrKerberosUnavailable = errors.New("kerberos support needs a build with -tags kerberos")
+	// ErrUnknownProfile is the error returned by `LoadConfig` when the config file has no such profile
+	ErrUnknownProfile = errors.New("unknown profile")
+	// ErrUnknownSecretProvider is the error returned when a secret reference names a provider
+	// that isn't registered with `RegisterSecretProvider`
+	ErrUnknownSecretProvider = errors.New("unknown secret provider")
+	// ErrSecretNotFound is the error wrapped by a `SecretProvider` that has no secret for a key
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrQueueFull is the error returned when a request finds the queue of its `Scheduler` full
+	ErrQueueFull = errors.New("request queue is full")
+	// ErrConcurrencyLimit is the error returned when a request can't get a slot from its `ConcurrencyLimit`
+	ErrConcurrencyLimit = errors.New("too many concurrent requests")
+	// ErrClientClosed is the error returned for requests sent by a `Client` after `Close`
+	ErrClientClosed = errors.New("client is closed")
+	// ErrAsyncOperationFailed is the error wrapped by `AsyncOperationError`
+	ErrAsyncOperationFailed = errors.New("async operation failed")
+	// ErrLinkNotFound is the error returned by `Response.FollowLink` when the response has no link with the rel
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrBudgetExceeded is the error wrapped by `BudgetError` when a request runs out of its `Budget`
+	ErrBudgetExceeded = errors.New("request budget exceeded")
 )
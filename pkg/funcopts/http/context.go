@@ -0,0 +1,19 @@
+package httpclient
+
+import "context"
+
+// WithContext sets the context used for the request, including cancellation and deadlines
+func WithContext(ctx context.Context) RequestOption {
+	return func(r *Request) error {
+		r.ctx = ctx
+		return nil
+	}
+}
+
+// context returns the context set with `WithContext` or the background context
+func (cr *Request) context() context.Context {
+	if cr.ctx == nil {
+		return context.Background()
+	}
+	return cr.ctx
+}
@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// Expect100Continue sends `Expect: 100-continue` and holds the body back until the server
+// answers with 100 Continue, or timeout passes without an answer. A server that rejects
+// the request, e.g. for failed auth, answers before a large upload is sent for nothing.
+// `Response.Continued` reports whether the 100 was received. The timeout needs an
+// *http.Transport
+func Expect100Continue(timeout time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.expectContinue = true
+		r.transportTuning = append(r.transportTuning, func(t *http.Transport) {
+			t.ExpectContinueTimeout = timeout
+		})
+		return nil
+	}
+}
+
+// traceContinue sets the `Expect` header on req and returns it with a trace recording
+// the 100 Continue into got
+func traceContinue(req *http.Request, got *atomic.Bool) *http.Request {
+	req.Header.Set("Expect", "100-continue")
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		Got100Continue: func() {
+			got.Store(true)
+		},
+	}))
+}
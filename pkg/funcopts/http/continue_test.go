@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readTracker records whether the body of a request was read
+type readTracker struct {
+	io.ReadCloser
+	read *atomic.Bool
+}
+
+func (r *readTracker) Read(p []byte) (int, error) {
+	r.read.Store(true)
+	return r.ReadCloser.Read(p)
+}
+
+func trackBody(read *atomic.Bool) RequestOption {
+	return RawRequest(func(req *http.Request) {
+		req.Body = &readTracker{ReadCloser: req.Body, read: read}
+	})
+}
+
+func TestExpect100Continue(t *testing.T) {
+	body := bytes.Repeat([]byte("upload"), 1000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100-continue", r.Header.Get("Expect"))
+		got, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, got)
+	}))
+	defer ts.Close()
+	res, err := Put(ts.URL, WithBody(bytes.NewReader(body)), Expect100Continue(5*time.Second))
+	require.NoError(t, err)
+	assert.True(t, res.Continued)
+}
+
+func TestExpect100ContinueRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	var read atomic.Bool
+	res, err := Put(ts.URL, WithBody(bytes.NewReader(make([]byte, 1<<20))), Expect100Continue(5*time.Second), trackBody(&read), ExpectSuccess())
+	assert.Error(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, http.StatusUnauthorized, res.Status)
+	assert.False(t, res.Continued)
+	assert.False(t, read.Load(), "body was sent to a server that rejected it")
+}
@@ -0,0 +1,108 @@
+package contracts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func usersAPI(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/users/1":
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "name": name, "tags": []string{"a"}})
+		case r.Method == "POST" && r.URL.Path == "/users":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRecordAndVerify(t *testing.T) {
+	consumerSide := usersAPI("ann")
+	defer consumerSide.Close()
+	rec := NewRecorder("web", "users")
+	ctx := WithProviderStates(WithDescription(context.Background(), "get user 1"), "user 1 exists")
+	_, err := httpclient.Get(consumerSide.URL+"/users/1?fields=all", rec.Option(), httpclient.WithContext(ctx), httpclient.BearerToken("secret"))
+	assert.NoError(t, err)
+	_, err = httpclient.Post(consumerSide.URL+"/users", rec.Option(), httpclient.JSON(), httpclient.WithBody(strings.NewReader(`{"name":"bob"}`)))
+	assert.NoError(t, err)
+
+	path, err := rec.WriteFile(t.TempDir())
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(path, "web-users.json"))
+	p, err := Load(path)
+	assert.NoError(t, err)
+	assert.Len(t, p.Interactions, 2)
+	get := p.Interactions[0]
+	assert.Equal(t, "get user 1", get.Description)
+	assert.Equal(t, []ProviderState{{Name: "user 1 exists"}}, get.ProviderStates)
+	assert.Equal(t, "/users/1", get.Request.Path)
+	assert.Equal(t, map[string][]string{"fields": {"all"}}, get.Request.Query)
+	assert.Empty(t, get.Request.Headers["Authorization"])
+	assert.Equal(t, "ann", get.Response.Body.(map[string]interface{})["name"])
+	assert.Equal(t, "POST /users", p.Interactions[1].Description)
+	assert.Equal(t, map[string]interface{}{"name": "bob"}, p.Interactions[1].Request.Body)
+	assert.Equal(t, SpecificationVersion, p.Metadata.PactSpecification.Version)
+
+	provider := usersAPI("ann")
+	defer provider.Close()
+	var states []string
+	err = Verify(path, provider.URL, StateHandler(func(s ProviderState) error {
+		states = append(states, s.Name)
+		return nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user 1 exists"}, states)
+
+	drifted := usersAPI("bea")
+	defer drifted.Close()
+	err = Verify(path, drifted.URL, StateHandler(func(ProviderState) error { return nil }))
+	assert.True(t, errors.Is(err, ErrVerificationFailed))
+	var verr *VerificationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Len(t, verr.Mismatches, 1)
+	assert.Equal(t, "body $.name", verr.Mismatches[0].Field)
+	assert.Equal(t, "bea", verr.Mismatches[0].Actual)
+
+	err = Verify(path, provider.URL)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no state handler")
+}
+
+func TestMatchJSON(t *testing.T) {
+	var paths []string
+	collect := func(path string, e, a interface{}) { paths = append(paths, path) }
+	matchJSON("$",
+		map[string]interface{}{"a": []interface{}{1.0, 2.0}, "b": map[string]interface{}{"c": "x"}, "d": true},
+		map[string]interface{}{"a": []interface{}{1.0, 3.0}, "b": map[string]interface{}{"c": "x", "extra": 1.0}},
+		collect)
+	assert.ElementsMatch(t, []string{"$.a[1]", "$.d"}, paths)
+}
+
+func TestRecordRedacted(t *testing.T) {
+	consumerSide := usersAPI("ann")
+	defer consumerSide.Close()
+	rec := NewRecorder("web", "users")
+	_, err := httpclient.Get(consumerSide.URL+"/users/1", rec.Option(),
+		httpclient.APIKey("k3ysecret", httpclient.InHeader("X-API-Key")),
+		httpclient.APIKey("q3ysecret", httpclient.InQuery("api_key")),
+		httpclient.HeaderFunc("X-Session", func(context.Context) (string, error) { return "s3ssion", nil }, nil))
+	assert.NoError(t, err)
+	p := rec.Pact()
+	assert.Len(t, p.Interactions, 1)
+	req := p.Interactions[0].Request
+	assert.Empty(t, req.Headers["X-Api-Key"])
+	assert.Empty(t, req.Headers["X-Session"])
+	assert.Equal(t, map[string][]string{"api_key": {"[REDACTED]"}}, req.Query)
+}
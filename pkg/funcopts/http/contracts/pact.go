@@ -0,0 +1,161 @@
+// Package contracts records the interactions a consumer makes through httpclient
+// as Pact v3 files, and verifies a provider by replaying them
+//
+//	rec := contracts.NewRecorder("web", "users-api")
+//	res, err := httpclient.Get(url, rec.Option())
+//	err = rec.WriteFile("pacts")
+//
+//	err = contracts.Verify("pacts/web-users-api.json", "http://localhost:8080")
+package contracts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/vcr"
+)
+
+// SpecificationVersion is the Pact specification version of the files written
+const SpecificationVersion = "3.0.0"
+
+// Pacticipant is the consumer or provider of a pact
+type Pacticipant struct {
+	Name string `json:"name"`
+}
+
+// ProviderState is a state the provider must be in for an interaction
+type ProviderState struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Request is the request of an interaction
+type Request struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query,omitempty"`
+	Headers map[string]string   `json:"headers,omitempty"`
+	Body    interface{}         `json:"body,omitempty"`
+}
+
+// Response is the expected response of an interaction
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// Interaction is a request the consumer makes and the response it expects
+type Interaction struct {
+	Description    string          `json:"description"`
+	ProviderStates []ProviderState `json:"providerStates,omitempty"`
+	Request        Request         `json:"request"`
+	Response       Response        `json:"response"`
+}
+
+// Pact is the contract between a consumer and a provider
+type Pact struct {
+	Consumer     Pacticipant    `json:"consumer"`
+	Provider     Pacticipant    `json:"provider"`
+	Interactions []*Interaction `json:"interactions"`
+	Metadata     Metadata       `json:"metadata"`
+}
+
+// Metadata describes the pact file
+type Metadata struct {
+	PactSpecification struct {
+		Version string `json:"version"`
+	} `json:"pactSpecification"`
+}
+
+// Load reads the pact file at path
+func Load(path string) (*Pact, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pact{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FromInteraction converts an interaction recorded by the vcr package into a pact interaction
+func FromInteraction(description string, i *vcr.Interaction, states ...string) (*Interaction, error) {
+	u, err := url.Parse(i.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	out := &Interaction{
+		Description: description,
+		Request: Request{
+			Method:  i.Request.Method,
+			Path:    path,
+			Headers: flatten(i.Request.Headers),
+			Body:    decodeBody(i.Request.Headers, i.Request.Body),
+		},
+		Response: Response{
+			Status:  i.Response.Status,
+			Headers: flatten(i.Response.Headers),
+			Body:    decodeBody(i.Response.Headers, i.Response.Body),
+		},
+	}
+	if q := u.Query(); len(q) > 0 {
+		out.Request.Query = q
+	}
+	for _, s := range states {
+		out.ProviderStates = append(out.ProviderStates, ProviderState{Name: s})
+	}
+	return out, nil
+}
+
+// flatten joins the values of each header as pact files expect
+func flatten(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// decodeBody returns json bodies as values and others as strings
+func decodeBody(h http.Header, body string) interface{} {
+	if body == "" {
+		return nil
+	}
+	if isJSON(h.Get("Content-Type")) {
+		var v interface{}
+		if json.Unmarshal([]byte(body), &v) == nil {
+			return v
+		}
+	}
+	return body
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// sortedKeys returns the keys of m in order so output is stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
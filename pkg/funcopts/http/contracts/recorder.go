@@ -0,0 +1,154 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/vcr"
+)
+
+// DefaultIgnoredHeaders are request and response headers left out of recorded
+// interactions because they vary between runs or are set by the transport. The headers a
+// request redacts, see `httpclient.RedactionFromContext`, are left out too and the values
+// of its redacted query params are replaced
+var DefaultIgnoredHeaders = []string{
+	"Authorization", "Cookie", "Set-Cookie", "Date", "Content-Length", "User-Agent",
+	"Accept-Encoding", "X-Request-Id", "Idempotency-Key", "Traceparent", "Tracestate",
+}
+
+type descriptionKey struct{}
+
+type statesKey struct{}
+
+// WithDescription returns a copy of ctx that describes the interactions recorded
+// for requests made with it. The default description is the method and path
+func WithDescription(ctx context.Context, description string) context.Context {
+	return context.WithValue(ctx, descriptionKey{}, description)
+}
+
+// WithProviderStates returns a copy of ctx that records states as the provider
+// states of interactions made with it
+func WithProviderStates(ctx context.Context, states ...string) context.Context {
+	return context.WithValue(ctx, statesKey{}, states)
+}
+
+// Recorder records the interactions of a consumer with a provider
+type Recorder struct {
+	pact   Pact
+	ignore []string
+	sync.Mutex
+}
+
+// NewRecorder returns a `Recorder` for the pact between consumer and provider
+func NewRecorder(consumer, provider string) *Recorder {
+	r := &Recorder{ignore: DefaultIgnoredHeaders}
+	r.pact.Consumer.Name = consumer
+	r.pact.Provider.Name = provider
+	r.pact.Metadata.PactSpecification.Version = SpecificationVersion
+	return r
+}
+
+// Option records the requests made with it
+func (r *Recorder) Option() httpclient.RequestOption {
+	return httpclient.Use(r.Middleware)
+}
+
+// Middleware records the interactions made through next
+func (r *Recorder) Middleware(next http.RoundTripper) http.RoundTripper {
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqBody, err := readBody(&req.Body)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		respBody, err := readBody(&resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		redaction, _ := httpclient.RedactionFromContext(req.Context())
+		recorded := &vcr.Interaction{
+			Request: vcr.Request{
+				Method:  req.Method,
+				URL:     transport.RedactURL(req.URL, redaction.Query...),
+				Headers: r.filter(req.Header, redaction.Headers),
+				Body:    string(reqBody),
+			},
+			Response: vcr.Response{
+				Status:  resp.StatusCode,
+				Headers: r.filter(resp.Header, redaction.Headers),
+				Body:    string(respBody),
+			},
+		}
+		description, _ := req.Context().Value(descriptionKey{}).(string)
+		if description == "" {
+			description = req.Method + " " + req.URL.Path
+		}
+		states, _ := req.Context().Value(statesKey{}).([]string)
+		i, err := FromInteraction(description, recorded, states...)
+		if err != nil {
+			return nil, err
+		}
+		r.Lock()
+		r.pact.Interactions = append(r.pact.Interactions, i)
+		r.Unlock()
+		return resp, nil
+	})
+}
+
+// filter removes the ignored headers and the redacted headers from h
+func (r *Recorder) filter(h http.Header, redacted []string) http.Header {
+	h = h.Clone()
+	for _, name := range append(append([]string{}, r.ignore...), redacted...) {
+		h.Del(name)
+	}
+	return h
+}
+
+// Pact returns a copy of the pact recorded so far
+func (r *Recorder) Pact() Pact {
+	r.Lock()
+	defer r.Unlock()
+	p := r.pact
+	p.Interactions = append([]*Interaction{}, r.pact.Interactions...)
+	return p
+}
+
+// WriteFile writes the pact to `consumer-provider.json` in dir and returns its path
+func (r *Recorder) WriteFile(dir string) (string, error) {
+	p := r.Pact()
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, p.Consumer.Name+"-"+p.Provider.Name+".json")
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+// readBody reads and replaces body so it can still be consumed by the caller
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
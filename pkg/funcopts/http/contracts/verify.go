@@ -0,0 +1,225 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// ErrVerificationFailed is the error wrapped by `VerificationError`
+var ErrVerificationFailed = errors.New("provider does not satisfy the pact")
+
+// Mismatch is a difference between an expected and an actual response
+type Mismatch struct {
+	Interaction string
+	// Field is `status`, `header <name>` or `body` followed by a json path like `$.user.name`
+	Field    string
+	Expected interface{}
+	Actual   interface{}
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s: expected %v, got %v", m.Interaction, m.Field, m.Expected, m.Actual)
+}
+
+// VerificationError lists every mismatch found by `Verify`
+type VerificationError struct {
+	Mismatches []Mismatch
+}
+
+func (e *VerificationError) Error() string {
+	lines := make([]string, 0, len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		lines = append(lines, m.String())
+	}
+	return fmt.Sprintf("%s:\n%s", ErrVerificationFailed, strings.Join(lines, "\n"))
+}
+
+// Unwrap returns `ErrVerificationFailed`
+func (e *VerificationError) Unwrap() error {
+	return ErrVerificationFailed
+}
+
+// VerifyOption configures `Verify`
+type VerifyOption func(*verifier)
+
+// StateHandler sets up the provider for an interaction. It is called with each of
+// the provider states of an interaction before it is replayed
+func StateHandler(fn func(state ProviderState) error) VerifyOption {
+	return func(v *verifier) {
+		v.states = fn
+	}
+}
+
+// RequestOptions are applied to every replayed request, e.g. for authentication
+func RequestOptions(opts ...httpclient.RequestOption) VerifyOption {
+	return func(v *verifier) {
+		v.opts = append(v.opts, opts...)
+	}
+}
+
+type verifier struct {
+	states func(ProviderState) error
+	opts   []httpclient.RequestOption
+}
+
+// Verify replays the interactions of the pact file at path against the provider at
+// providerURL and checks the responses. Response bodies may have json fields the
+// pact doesn't mention
+func Verify(path, providerURL string, opts ...VerifyOption) error {
+	p, err := Load(path)
+	if err != nil {
+		return err
+	}
+	return VerifyPact(context.Background(), p, providerURL, opts...)
+}
+
+// VerifyPact is `Verify` for a loaded pact
+func VerifyPact(ctx context.Context, p *Pact, providerURL string, opts ...VerifyOption) error {
+	v := &verifier{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	var mismatches []Mismatch
+	for _, i := range p.Interactions {
+		found, err := v.verify(ctx, providerURL, i)
+		if err != nil {
+			return fmt.Errorf("%s: %w", i.Description, err)
+		}
+		mismatches = append(mismatches, found...)
+	}
+	if len(mismatches) > 0 {
+		return &VerificationError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+func (v *verifier) verify(ctx context.Context, providerURL string, i *Interaction) ([]Mismatch, error) {
+	for _, state := range i.ProviderStates {
+		if v.states == nil {
+			return nil, fmt.Errorf("no state handler for provider state %q", state.Name)
+		}
+		if err := v.states(state); err != nil {
+			return nil, err
+		}
+	}
+	u := strings.TrimSuffix(providerURL, "/") + i.Request.Path
+	if len(i.Request.Query) > 0 {
+		u += "?" + url.Values(i.Request.Query).Encode()
+	}
+	opts := []httpclient.RequestOption{httpclient.Method(i.Request.Method), httpclient.URL(u)}
+	if len(i.Request.Headers) > 0 {
+		opts = append(opts, httpclient.AddHeaders(i.Request.Headers))
+	}
+	if i.Request.Body != nil {
+		body, err := encodeBody(i.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpclient.WithBodyFunc(func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}))
+	}
+	r, _, err := httpclient.New(append(opts, v.opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return compare(i, res), nil
+}
+
+func encodeBody(body interface{}) ([]byte, error) {
+	if s, ok := body.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(body)
+}
+
+func compare(i *Interaction, res *httpclient.Response) []Mismatch {
+	var out []Mismatch
+	mismatch := func(field string, expected, actual interface{}) {
+		out = append(out, Mismatch{Interaction: i.Description, Field: field, Expected: expected, Actual: actual})
+	}
+	if res.Status != i.Response.Status {
+		mismatch("status", i.Response.Status, res.Status)
+	}
+	for _, name := range sortedKeys(i.Response.Headers) {
+		expected, actual := i.Response.Headers[name], strings.Join(res.Headers.Values(name), ", ")
+		if !headerMatches(name, expected, actual) {
+			mismatch("header "+name, expected, actual)
+		}
+	}
+	switch expected := i.Response.Body.(type) {
+	case nil:
+	case string:
+		if string(res.Body) != expected {
+			mismatch("body", expected, string(res.Body))
+		}
+	default:
+		var actual interface{}
+		if err := json.Unmarshal(res.Body, &actual); err != nil {
+			mismatch("body", expected, string(res.Body))
+			break
+		}
+		matchJSON("$", expected, actual, func(path string, e, a interface{}) {
+			mismatch("body "+path, e, a)
+		})
+	}
+	return out
+}
+
+func headerMatches(name, expected, actual string) bool {
+	if http.CanonicalHeaderKey(name) == "Content-Type" {
+		e, _, errE := mime.ParseMediaType(expected)
+		a, _, errA := mime.ParseMediaType(actual)
+		return errE == nil && errA == nil && e == a
+	}
+	return expected == actual
+}
+
+// matchJSON reports the differences between expected and actual. Objects may have
+// keys that aren't expected, arrays must have the same length
+func matchJSON(path string, expected, actual interface{}, mismatch func(path string, expected, actual interface{})) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			mismatch(path, expected, actual)
+			return
+		}
+		for k, ev := range e {
+			av, ok := a[k]
+			if !ok {
+				mismatch(path+"."+k, ev, nil)
+				continue
+			}
+			matchJSON(path+"."+k, ev, av, mismatch)
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			mismatch(path, expected, actual)
+			return
+		}
+		for idx := range e {
+			matchJSON(fmt.Sprintf("%s[%d]", path, idx), e[idx], a[idx], mismatch)
+		}
+	default:
+		if expected != actual {
+			mismatch(path, expected, actual)
+		}
+	}
+}
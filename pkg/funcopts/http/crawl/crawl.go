@@ -0,0 +1,395 @@
+// Package crawl walks web sites with an httpclient.Fetcher, following the links found on
+// each page while honoring robots.txt and a per host delay
+//
+//	for page := range crawl.Crawl(ctx, []string{"https://example.com/"}, crawl.MaxDepth(2), crawl.HostDelay(time.Second)) {
+//		if page.Err != nil { ... }
+//		fmt.Println(page.URL, page.Response.Status, len(page.Links))
+//	}
+package crawl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"golang.org/x/net/html"
+)
+
+// ErrUnsupportedScheme is the error for seeds that aren't http or https urls
+var ErrUnsupportedScheme = errors.New("only http and https urls can be crawled")
+
+// ErrDisallowed is the error for seeds the robots.txt of their host doesn't allow
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// Page is a fetched page
+type Page struct {
+	URL string
+	// Depth is the number of links followed from a seed url
+	Depth    int
+	Response *httpclient.Response
+	Err      error
+	// Links are the absolute urls found on the page, without fragments
+	Links []string
+}
+
+// LinkExtractor returns the links in a response. Relative links are resolved against
+// the url of the response
+type LinkExtractor func(res *httpclient.Response) []string
+
+type config struct {
+	client    *httpclient.Client
+	workers   int
+	delay     time.Duration
+	maxDepth  int
+	maxPages  int
+	extract   LinkExtractor
+	robots    bool
+	userAgent string
+	hosts     map[string]bool
+	filter    func(*url.URL) bool
+}
+
+// Option configures a crawl
+type Option func(*config)
+
+// Client fetches pages with c
+func Client(c *httpclient.Client) Option {
+	return func(cfg *config) {
+		cfg.client = c
+	}
+}
+
+// Workers sets the number of pages fetched at once. The default is 4
+func Workers(n int) Option {
+	return func(cfg *config) {
+		cfg.workers = n
+	}
+}
+
+// HostDelay sets the minimum time between requests to the same host
+func HostDelay(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.delay = d
+	}
+}
+
+// MaxDepth limits how many links are followed from the seeds. Only the seeds are
+// fetched with 0. The default is no limit
+func MaxDepth(n int) Option {
+	return func(cfg *config) {
+		cfg.maxDepth = n
+	}
+}
+
+// MaxPages stops the crawl after n pages have been fetched
+func MaxPages(n int) Option {
+	return func(cfg *config) {
+		cfg.maxPages = n
+	}
+}
+
+// Extract finds links with fn instead of `HTMLLinks`
+func Extract(fn LinkExtractor) Option {
+	return func(cfg *config) {
+		cfg.extract = fn
+	}
+}
+
+// IgnoreRobots fetches pages disallowed by robots.txt
+func IgnoreRobots() Option {
+	return func(cfg *config) {
+		cfg.robots = false
+	}
+}
+
+// UserAgent sends ua and matches robots.txt groups against its product token
+func UserAgent(ua string) Option {
+	return func(cfg *config) {
+		cfg.userAgent = ua
+	}
+}
+
+// Hosts allows links to hosts other than those of the seeds to be followed
+func Hosts(hosts ...string) Option {
+	return func(cfg *config) {
+		for _, h := range hosts {
+			cfg.hosts[h] = true
+		}
+	}
+}
+
+// Filter only follows links fn returns true for, in addition to the host check
+func Filter(fn func(*url.URL) bool) Option {
+	return func(cfg *config) {
+		cfg.filter = fn
+	}
+}
+
+// Crawl fetches the seeds and the pages they link to and sends each page on the returned
+// channel. Only links to the hosts of the seeds are followed unless more are added
+// with `Hosts`. Seeds that are invalid or disallowed by robots.txt are sent as pages
+// with an error. The channel is closed when there are no more pages to fetch, a limit
+// is reached or ctx is done
+func Crawl(ctx context.Context, seeds []string, opts ...Option) <-chan Page {
+	cfg := &config{workers: 4, maxDepth: -1, robots: true, extract: HTMLLinks, hosts: map[string]bool{}}
+	c := &crawler{robotsFor: map[string]*robots{}, waiting: map[string][]string{}, seen: map[string]int{}}
+	for _, s := range seeds {
+		u, err := normalize(nil, s)
+		if err != nil {
+			c.pages = append(c.pages, Page{URL: s, Err: err})
+			continue
+		}
+		cfg.hosts[u.Host] = true
+		if _, ok := c.seen[u.String()]; !ok {
+			c.seen[u.String()] = 0
+			c.queue = append(c.queue, u.String())
+		}
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.client == nil {
+		cfg.client = httpclient.NewClient()
+	}
+	if cfg.userAgent == "" {
+		cfg.userAgent = httpclient.DefaultUserAgent()
+	}
+	c.config = cfg
+	c.jobOpts = []httpclient.RequestOption{httpclient.UserAgent(cfg.userAgent)}
+	out := make(chan Page)
+	go c.run(ctx, out)
+	return out
+}
+
+type crawler struct {
+	*config
+	jobOpts []httpclient.RequestOption
+	// robotsFor holds the rules of every host seen, nil while its robots.txt is fetched
+	robotsFor map[string]*robots
+	// waiting holds the urls of hosts whose robots.txt is being fetched
+	waiting map[string][]string
+	// seen maps every url queued to its depth
+	seen  map[string]int
+	queue []string
+	// pages are the pages ready to be sent
+	pages []Page
+}
+
+func (c *crawler) run(ctx context.Context, out chan<- Page) {
+	defer close(out)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan httpclient.Job)
+	defer close(jobs)
+	// robots.txt is fetched by the fetcher too so it waits for the host delay like pages
+	fetcher := httpclient.NewFetcher(c.client, httpclient.Workers(c.workers), httpclient.PolitenessDelay(c.delay))
+	results := fetcher.Run(ctx, jobs)
+	var staged *httpclient.Job
+	pending, scheduled := 0, 0
+	for {
+		if staged == nil && (c.maxPages <= 0 || scheduled < c.maxPages) {
+			staged = c.stage()
+		}
+		var send chan<- httpclient.Job
+		var emit chan<- Page
+		var page Page
+		if len(c.pages) > 0 {
+			emit, page = out, c.pages[0]
+		} else if staged != nil {
+			// new jobs wait for the pages already fetched to be taken
+			send = jobs
+		}
+		if send == nil && emit == nil && pending == 0 {
+			return
+		}
+		var next httpclient.Job
+		if staged != nil {
+			next = *staged
+		}
+		select {
+		case send <- next:
+			if !isRobots(next) {
+				scheduled++
+			}
+			staged = nil
+			pending++
+		case emit <- page:
+			c.pages = c.pages[1:]
+		case res, ok := <-results:
+			if !ok {
+				return
+			}
+			pending--
+			if isRobots(res.Job) {
+				c.robotsFetched(res)
+			} else {
+				c.pages = append(c.pages, c.page(res))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stage takes the job to send next from the queue: the url at its head, or the robots.txt
+// of the url's host when its rules aren't known yet. Urls of hosts whose robots.txt is
+// being fetched wait for it, and seeds it disallows are reported with `ErrDisallowed`
+func (c *crawler) stage() *httpclient.Job {
+	for len(c.queue) > 0 {
+		raw := c.queue[0]
+		c.queue = c.queue[1:]
+		if !c.robots {
+			return &httpclient.Job{URL: raw, Options: c.jobOpts}
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		rules, known := c.robotsFor[u.Host]
+		switch {
+		case !known:
+			c.robotsFor[u.Host] = nil
+			c.waiting[u.Host] = append(c.waiting[u.Host], raw)
+			if job := c.robotsJob(u); job != nil {
+				return job
+			}
+		case rules == nil:
+			c.waiting[u.Host] = append(c.waiting[u.Host], raw)
+		case c.allowed(rules, u):
+			return &httpclient.Job{URL: raw, Options: c.jobOpts}
+		case c.seen[raw] == 0:
+			c.pages = append(c.pages, Page{URL: raw, Err: ErrDisallowed})
+		}
+	}
+	return nil
+}
+
+// robotsJob returns the job fetching the robots.txt of the url's host. It's sent as a
+// built request so its result can be told apart from pages. When the request can't be
+// built the host is disallowed and nil is returned
+func (c *crawler) robotsJob(u *url.URL) *httpclient.Job {
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	req, _, err := c.client.New(httpclient.Method("GET"), httpclient.URL(robotsURL), httpclient.UserAgent(c.userAgent))
+	if err != nil {
+		c.robotsFetched(httpclient.FetchResult{Job: httpclient.Job{URL: robotsURL}, Err: err})
+		return nil
+	}
+	return &httpclient.Job{URL: robotsURL, Request: req}
+}
+
+func isRobots(job httpclient.Job) bool {
+	return job.Request != nil
+}
+
+// robotsFetched records the rules of the host of a robots.txt result and queues the
+// urls waiting for them again. A missing robots.txt allows everything and one that
+// can't be fetched disallows everything
+func (c *crawler) robotsFetched(res httpclient.FetchResult) {
+	u, err := url.Parse(res.Job.URL)
+	if err != nil {
+		return
+	}
+	rules := disallowAll
+	switch {
+	case res.Response == nil:
+	case res.Response.Status >= 200 && res.Response.Status < 300 && res.Err == nil:
+		rules = parseRobots(res.Response.Body)
+	case res.Response.Status >= 400 && res.Response.Status < 500:
+		rules = allowAll
+	}
+	c.robotsFor[u.Host] = rules
+	c.queue = append(c.waiting[u.Host], c.queue...)
+	delete(c.waiting, u.Host)
+}
+
+// page builds the page for a result and queues the links to follow
+func (c *crawler) page(res httpclient.FetchResult) Page {
+	depth := c.seen[res.Job.URL]
+	page := Page{URL: res.Job.URL, Depth: depth, Response: res.Response, Err: res.Err}
+	if res.Err != nil || res.Response == nil {
+		return page
+	}
+	base, err := url.Parse(res.Response.URL)
+	if err != nil {
+		return page
+	}
+	found := map[string]bool{}
+	for _, link := range c.extract(res.Response) {
+		u, err := normalize(base, link)
+		if err != nil || found[u.String()] {
+			continue
+		}
+		found[u.String()] = true
+		page.Links = append(page.Links, u.String())
+		if c.maxDepth >= 0 && depth >= c.maxDepth {
+			continue
+		}
+		if _, ok := c.seen[u.String()]; ok || !c.follow(u) {
+			continue
+		}
+		c.seen[u.String()] = depth + 1
+		c.queue = append(c.queue, u.String())
+	}
+	return page
+}
+
+func (c *crawler) follow(u *url.URL) bool {
+	return c.hosts[u.Host] && (c.filter == nil || c.filter(u))
+}
+
+// allowed checks the url against the robots.txt rules of its host
+func (c *crawler) allowed(rules *robots, u *url.URL) bool {
+	agent, _, _ := strings.Cut(c.userAgent, "/")
+	return rules.allowed(agent, u.RequestURI())
+}
+
+// normalize resolves link against base and drops its fragment. Only http and https urls are kept
+func normalize(base *url.URL, link string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, &url.Error{Op: "crawl", URL: link, Err: ErrUnsupportedScheme}
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u, nil
+}
+
+// HTMLLinks returns the href of every `a` and `area` element of an html response
+func HTMLLinks(res *httpclient.Response) []string {
+	if mt, _, _ := mime.ParseMediaType(res.Headers.Get("Content-Type")); mt != "text/html" && mt != "application/xhtml+xml" {
+		return nil
+	}
+	var links []string
+	z := html.NewTokenizer(bytes.NewReader(res.Body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !hasAttr || (string(name) != "a" && string(name) != "area") {
+				continue
+			}
+			for {
+				key, val, more := z.TagAttr()
+				if string(key) == "href" {
+					links = append(links, string(val))
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var site = map[string]string{
+	"/":          `<a href="/a">a</a> <a href="b#top">b</a> <a href="/private/x">x</a> <a href="http://other.example/">other</a> <a href="mailto:me@example.com">mail</a>`,
+	"/a":         `<a href="/c">c</a><a href="/">home</a>`,
+	"/b":         `<area href="/a">`,
+	"/c":         `plain`,
+	"/private/x": `secret`,
+}
+
+func siteServer(t *testing.T, robotsFetches *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if robotsFetches != nil {
+				atomic.AddInt32(robotsFetches, 1)
+			}
+			assert.True(t, strings.HasPrefix(r.UserAgent(), "go-experiments-httpclient/"))
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+			return
+		}
+		body, ok := site[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/c" {
+			w.Header().Set("Content-Type", "text/plain")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func collect(ch <-chan Page, base string) map[string]Page {
+	pages := map[string]Page{}
+	for p := range ch {
+		pages[strings.TrimPrefix(p.URL, base)] = p
+	}
+	return pages
+}
+
+func keys(pages map[string]Page) []string {
+	var out []string
+	for k := range pages {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestCrawl(t *testing.T) {
+	var robotsFetches int32
+	ts := siteServer(t, &robotsFetches)
+	defer ts.Close()
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/"}), ts.URL)
+	assert.Equal(t, []string{"/", "/a", "/b", "/c"}, keys(pages))
+	assert.Equal(t, 0, pages["/"].Depth)
+	assert.Equal(t, 1, pages["/a"].Depth)
+	assert.Equal(t, 1, pages["/b"].Depth)
+	assert.Equal(t, 2, pages["/c"].Depth)
+	assert.Equal(t, []string{ts.URL + "/a", ts.URL + "/b", ts.URL + "/private/x", "http://other.example/"}, pages["/"].Links)
+	assert.Empty(t, pages["/c"].Links)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&robotsFetches))
+}
+
+func TestCrawlLimits(t *testing.T) {
+	ts := siteServer(t, nil)
+	defer ts.Close()
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/"}, MaxDepth(1)), ts.URL)
+	assert.Equal(t, []string{"/", "/a", "/b"}, keys(pages))
+
+	pages = collect(Crawl(context.Background(), []string{ts.URL + "/"}, MaxPages(2), Workers(1)), ts.URL)
+	assert.Len(t, pages, 2)
+
+	pages = collect(Crawl(context.Background(), []string{ts.URL + "/"}, MaxDepth(0)), ts.URL)
+	assert.Equal(t, []string{"/"}, keys(pages))
+}
+
+func TestCrawlIgnoreRobotsAndFilter(t *testing.T) {
+	ts := siteServer(t, nil)
+	defer ts.Close()
+	noB := Filter(func(u *url.URL) bool { return u.Path != "/b" })
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/"}, IgnoreRobots(), noB), ts.URL)
+	assert.Equal(t, []string{"/", "/a", "/c", "/private/x"}, keys(pages))
+}
+
+func TestCrawlRobotsUnavailable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		t.Errorf("fetched %s", r.URL.Path)
+	}))
+	defer ts.Close()
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/"}), ts.URL)
+	assert.Equal(t, []string{"/"}, keys(pages))
+	assert.True(t, errors.Is(pages["/"].Err, ErrDisallowed))
+}
+
+func TestCrawlDisallowedSeed(t *testing.T) {
+	ts := siteServer(t, nil)
+	defer ts.Close()
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/private/x", ts.URL + "/c"}), ts.URL)
+	assert.Equal(t, []string{"/c", "/private/x"}, keys(pages))
+	assert.True(t, errors.Is(pages["/private/x"].Err, ErrDisallowed))
+	assert.Nil(t, pages["/private/x"].Response)
+	assert.NoError(t, pages["/c"].Err)
+}
+
+func TestCrawlRobotsHostDelay(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, time.Now())
+		mu.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/"}, HostDelay(50*time.Millisecond)), ts.URL)
+	assert.Equal(t, []string{"/"}, keys(pages))
+	require.Len(t, fetched, 2)
+	assert.True(t, fetched[1].Sub(fetched[0]) >= 50*time.Millisecond, "robots.txt and the page were %s apart", fetched[1].Sub(fetched[0]))
+}
+
+func TestCrawlCustomExtractorAndClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(w, r)
+		case "/feed":
+			assert.Equal(t, "test", r.Header.Get("X-Crawler"))
+			w.Write([]byte("/one\n/two\n"))
+		}
+	}))
+	defer ts.Close()
+	lines := Extract(func(res *httpclient.Response) []string {
+		return strings.Fields(string(res.Body))
+	})
+	c := httpclient.NewClient(httpclient.AddHeaders(map[string]string{"X-Crawler": "test"}))
+	pages := collect(Crawl(context.Background(), []string{ts.URL + "/feed"}, lines, Client(c)), ts.URL)
+	assert.Equal(t, []string{"/feed", "/one", "/two"}, keys(pages))
+}
+
+func TestCrawlInvalidSeed(t *testing.T) {
+	var pages []Page
+	for p := range Crawl(context.Background(), []string{"ftp://example.com/"}) {
+		pages = append(pages, p)
+	}
+	require.Len(t, pages, 1)
+	assert.True(t, errors.Is(pages[0].Err, ErrUnsupportedScheme))
+}
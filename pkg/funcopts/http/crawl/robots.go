@@ -0,0 +1,125 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// robots is a parsed robots.txt file, see RFC 9309
+type robots struct {
+	groups []robotsGroup
+}
+
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allowAll is used for hosts without a robots.txt
+var allowAll = &robots{}
+
+// disallowAll is used for hosts whose robots.txt couldn't be fetched
+var disallowAll = &robots{groups: []robotsGroup{{agents: []string{"*"}, rules: []robotsRule{{pattern: "/"}}}}}
+
+func parseRobots(data []byte) *robots {
+	r := &robots{}
+	var current *robotsGroup
+	inAgents := false
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				r.groups = append(r.groups, robotsGroup{})
+				current = &r.groups[len(r.groups)-1]
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		}
+	}
+	return r
+}
+
+// allowed reports whether the crawler with the product token agent may fetch path,
+// which includes the query. The longest matching rule wins and allow wins ties
+func (r *robots) allowed(agent, path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	rules := r.rules(strings.ToLower(agent))
+	best, allow := -1, true
+	for _, rule := range rules {
+		if !matchPattern(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// rules returns the rules of the groups for agent, or of the `*` groups if none name it
+func (r *robots) rules(agent string) []robotsRule {
+	var named, fallback []robotsRule
+	for _, g := range r.groups {
+		for _, a := range g.agents {
+			switch a {
+			case agent:
+				named = append(named, g.rules...)
+			case "*":
+				fallback = append(fallback, g.rules...)
+			}
+		}
+	}
+	if named != nil {
+		return named
+	}
+	return fallback
+}
+
+// matchPattern matches a robots.txt path pattern, where `*` matches any characters
+// and a trailing `$` anchors the end of the path
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	return rest == "" || (len(parts) > 1 && strings.HasSuffix(path, parts[len(parts)-1]))
+}
@@ -0,0 +1,68 @@
+package crawl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const robotsTxt = `# comments are ignored
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow:
+
+User-agent: examplebot
+User-agent: otherbot
+Disallow: /
+Allow: /docs/
+`
+
+func TestRobotsAllowed(t *testing.T) {
+	r := parseRobots([]byte(robotsTxt))
+	cases := []struct {
+		agent string
+		path  string
+		want  bool
+	}{
+		{"go-experiments-httpclient", "/", true},
+		{"go-experiments-httpclient", "/private", false},
+		{"go-experiments-httpclient", "/private/secret", false},
+		{"go-experiments-httpclient", "/private/public/page", true},
+		{"go-experiments-httpclient", "/files/report.pdf", false},
+		{"go-experiments-httpclient", "/files/report.pdf?download=1", true},
+		{"ExampleBot", "/", false},
+		{"examplebot", "/docs/intro", true},
+		{"otherbot", "/private/public", false},
+		{"otherbot", "/robots.txt", true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, r.allowed(c.agent, c.path), "%s %s", c.agent, c.path)
+	}
+	assert.True(t, allowAll.allowed("any", "/private"))
+	assert.False(t, disallowAll.allowed("any", "/"))
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/", "/anything", true},
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish", false},
+		{"/fish$", "/fish", true},
+		{"/fish$", "/fish/", false},
+		{"/*.php", "/index.php", true},
+		{"/*.php", "/dir/index.php?x=1", true},
+		{"/*.php$", "/index.php?x=1", false},
+		{"/a*b*c", "/axxbyyc", true},
+		{"/a*b*c", "/axxcyyb", false},
+		{"*$", "/x", true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, matchPattern(c.pattern, c.path), "%s %s", c.pattern, c.path)
+	}
+}
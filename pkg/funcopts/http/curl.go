@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// ToCurl renders the request as an equivalent curl command line.
+// The body is buffered so the request can still be sent afterwards, and a `WithBodyFunc`
+// body is only opened once. Redacted headers and query params are rendered as `transport.Redacted`
+func (cr *Request) ToCurl() (string, error) {
+	cr.Lock()
+	defer cr.Unlock()
+	var body []byte
+	if cr.bodyFunc != nil {
+		rc, err := cr.bodyFunc()
+		if err != nil {
+			return "", err
+		}
+		body, err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+		// the request below is only rendered, so it reads the body already opened
+		bodyFunc := cr.bodyFunc
+		cr.bodyFunc = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		defer func() { cr.bodyFunc = bodyFunc }()
+	} else if cr.body != nil {
+		data, err := ioutil.ReadAll(cr.body)
+		if err != nil {
+			return "", err
+		}
+		body = data
+		cr.body = bytes.NewReader(body)
+	}
+	req, err := cr.httpRequest()
+	if err != nil {
+		return "", err
+	}
+	cmd := []string{"curl", "-X", req.Method, shellQuote(transport.RedactURL(req.URL, cr.redactQuery...))}
+	header := transport.RedactHeader(req.Header, cr.redactedHeaders()...)
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range header[name] {
+			cmd = append(cmd, "-H", shellQuote(name+": "+v))
+		}
+	}
+	if body != nil {
+		cmd = append(cmd, "--data-binary", shellQuote(string(body)))
+	}
+	return strings.Join(cmd, " "), nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCurl(t *testing.T) {
+	c, _, err := New(post(), setURL("https://httpbin.org/post"),
+		QueryParams(map[string]string{"foo": "bar"}),
+		JSON(),
+		AddHeaders(map[string]string{"X-Quote": "it's"}),
+		WithBody(strings.NewReader(`{"a":1}`)))
+	assert.NoError(t, err)
+	cmd, err := c.ToCurl()
+	assert.NoError(t, err)
+	assert.Equal(t, `curl -X POST 'https://httpbin.org/post?foo=bar' -H 'Accept: application/json' -H 'Content-Type: application/json' -H 'User-Agent: go-experiments-httpclient/`+Version+`' -H 'X-Quote: it'\''s' --data-binary '{"a":1}'`, cmd)
+
+	body, err := ioutil.ReadAll(c.body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(body))
+}
+
+func TestToCurlBodyFunc(t *testing.T) {
+	opened := 0
+	r, _, err := New(post(), setURL("http://example.com"), WithBodyFunc(func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(strings.NewReader("abc")), nil
+	}))
+	assert.NoError(t, err)
+	opened = 0
+	cmd, err := r.ToCurl()
+	assert.NoError(t, err)
+	assert.Contains(t, cmd, "--data-binary 'abc'")
+	assert.Equal(t, 1, opened)
+}
+
+func TestToCurlRedacts(t *testing.T) {
+	r, _, err := New(setURL("http://example.com"), BearerToken("secret"), APIKey("key", InQuery("api_key")))
+	assert.NoError(t, err)
+	cmd, err := r.ToCurl()
+	assert.NoError(t, err)
+	assert.NotContains(t, cmd, "secret")
+	assert.NotContains(t, cmd, "key=key")
+	assert.Contains(t, cmd, "-H 'Authorization: [REDACTED]'")
+}
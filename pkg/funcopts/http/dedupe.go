@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultDedupeVary are the headers that must match for `Dedupe` to share a request
+var DefaultDedupeVary = []string{"Accept", "Accept-Encoding", "Accept-Language", "Authorization", "Cookie", "Range"}
+
+// Dedupe makes concurrent identical GET and HEAD requests share a single request to the origin.
+// Requests are identical when their method, url, the headers in `DefaultDedupeVary` and vary,
+// and the headers the request redacts, like `APIKey` headers, match; headers that differ per
+// request, like request ids, idempotency keys and trace context, are ignored. Every caller
+// receives its own copy of the response. The shared request is only canceled once every
+// caller waiting for it is. Set it on a `Client` so requests share in-flight state
+func Dedupe(vary ...string) RequestOption {
+	g := &flightGroup{calls: make(map[string]*flight), vary: append(append([]string{}, DefaultDedupeVary...), vary...)}
+	return func(r *Request) error {
+		r.dedupe = g
+		return nil
+	}
+}
+
+type flightGroup struct {
+	calls map[string]*flight
+	vary  []string
+	sync.Mutex
+}
+
+type flight struct {
+	done    chan struct{}
+	resp    *http.Response
+	body    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+type dedupeTransport struct {
+	group *flightGroup
+	// vary are the headers redacted by the request, added to the group's
+	vary     []string
+	readBody func(*http.Response) ([]byte, error)
+	next     http.RoundTripper
+}
+
+func (t *dedupeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return t.next.RoundTrip(req)
+	}
+	g := t.group
+	key := dedupeKey(req, append(append([]string{}, g.vary...), t.vary...))
+	g.Lock()
+	f, ok := g.calls[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = f
+		go t.do(req.WithContext(ctx), key, f)
+	}
+	f.waiters++
+	g.Unlock()
+	select {
+	case <-f.done:
+		return f.response(req)
+	case <-req.Context().Done():
+		g.leave(key, f)
+		return nil, req.Context().Err()
+	}
+}
+
+// do sends the shared request, detached from the context of the caller that started it
+func (t *dedupeTransport) do(req *http.Request, key string, f *flight) {
+	defer f.cancel()
+	f.resp, f.err = t.next.RoundTrip(req)
+	if f.err == nil {
+		f.body, f.err = t.readBody(f.resp)
+		f.resp.Body.Close()
+	}
+	t.group.Lock()
+	if t.group.calls[key] == f {
+		delete(t.group.calls, key)
+	}
+	t.group.Unlock()
+	close(f.done)
+}
+
+// leave stops waiting for f, canceling it when no caller is left
+func (g *flightGroup) leave(key string, f *flight) {
+	g.Lock()
+	defer g.Unlock()
+	f.waiters--
+	if f.waiters == 0 {
+		f.cancel()
+		if g.calls[key] == f {
+			delete(g.calls, key)
+		}
+	}
+}
+
+// response returns a copy of the shared response for req
+func (f *flight) response(req *http.Request) (*http.Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(f.body))
+	resp.Request = req
+	return &resp, nil
+}
+
+func dedupeKey(req *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(req.URL.String())
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteString(": ")
+		b.WriteString(strings.Join(req.Header.Values(name), ", "))
+	}
+	return b.String()
+}
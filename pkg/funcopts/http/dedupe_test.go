@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupe(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("shared"))
+	}))
+	defer ts.Close()
+	c := NewClient(Dedupe())
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := c.Get(ts.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, "shared", string(res.Body))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestDedupeDistinctRequests(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+	c := NewClient(Dedupe())
+	var wg sync.WaitGroup
+	for _, accept := range []string{ContentTypeJSON, ContentTypeXML} {
+		wg.Add(2)
+		go func(accept string) {
+			defer wg.Done()
+			_, err := c.Get(ts.URL, Accept(accept))
+			assert.NoError(t, err)
+		}(accept)
+		go func() {
+			defer wg.Done()
+			_, err := c.Post(ts.URL)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestDedupeVary(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+	c := NewClient(Dedupe("X-Tenant"), RequestID())
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"a", "a", "b", "b"} {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			_, err := c.Get(ts.URL, AddHeaders(map[string]string{"X-Tenant": tenant}))
+			assert.NoError(t, err)
+		}(tenant)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits), "request ids are ignored, tenants aren't")
+}
+
+func TestDedupeAPIKey(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(r.Header.Get("X-Api-Key")))
+	}))
+	defer ts.Close()
+	c := NewClient(Dedupe())
+	var wg sync.WaitGroup
+	for _, key := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			res, err := c.Get(ts.URL, APIKey(key, InHeader("X-Api-Key")))
+			assert.NoError(t, err)
+			assert.Equal(t, key, string(res.Body))
+		}(key)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestDedupeMaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		w.Write([]byte("too large"))
+	}))
+	defer ts.Close()
+	_, err := Get(ts.URL, Dedupe(), MaxResponseBytes(4))
+	assert.True(t, errors.Is(err, ErrResponseTooLarge), "got %v", err)
+}
+
+func TestDedupeLeaderCanceled(t *testing.T) {
+	started := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("shared"))
+	}))
+	defer ts.Close()
+	c := NewClient(Dedupe())
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, err := c.Get(ts.URL, WithContext(ctx))
+		leader <- err
+	}()
+	<-started
+	follower := make(chan *Response)
+	go func() {
+		res, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+		follower <- res
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.True(t, errors.Is(<-leader, context.Canceled))
+	res := <-follower
+	if assert.NotNil(t, res) {
+		assert.Equal(t, "shared", string(res.Body))
+	}
+}
@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// Description is the effective configuration of a `Request` after its options were applied
+type Description struct {
+	Method string
+	URL    string
+	// Headers are the headers that will be sent, with sensitive values redacted
+	Headers http.Header
+	// Timeout is the timeout for the whole request, zero for none
+	Timeout time.Duration
+	// Deadline is the deadline of the request context, zero for none
+	Deadline         time.Time
+	ExpectedStatus   []int
+	ExpectedRanges   [][2]int
+	MaxResponseBytes int64
+	// Retries is the maximum number of retries and RetryBackoff the initial delay between them
+	Retries      int
+	RetryBackoff time.Duration
+	// Features are the behaviors enabled by options, e.g. `cache` or `fallback`
+	Features []string
+}
+
+// Describe returns the effective configuration of the request, to see which options took effect
+func (cr *Request) Describe() (*Description, error) {
+	cr.Lock()
+	defer cr.Unlock()
+	req, err := cr.httpRequest()
+	if err != nil {
+		return nil, err
+	}
+	if cr.bodyFunc != nil && req.Body != nil {
+		req.Body.Close()
+	}
+	d := &Description{
+		Method:           req.Method,
+		URL:              transport.RedactURL(req.URL, cr.redactQuery...),
+		Headers:          transport.RedactHeader(req.Header, cr.redactedHeaders()...),
+		ExpectedStatus:   cr.allowedStatusCodes,
+		ExpectedRanges:   cr.allowedStatusRange,
+		MaxResponseBytes: cr.maxResponseBytes,
+		Features:         cr.features(),
+	}
+	if cr.retry != nil {
+		d.Retries, d.RetryBackoff = cr.retry.max, cr.retry.backoff
+	}
+	if cr.httpClient != nil {
+		d.Timeout = cr.httpClient.Timeout
+	}
+	if cr.timeout > 0 {
+		d.Timeout = cr.timeout
+	}
+	if deadline, ok := req.Context().Deadline(); ok {
+		d.Deadline = deadline
+	}
+	return d, nil
+}
+
+func (cr *Request) features() []string {
+	enabled := map[string]bool{
+		"cache":             cr.cacheStore != nil,
+		"tracing":           cr.tracer != nil,
+		"logging":           cr.logger != nil || cr.debug,
+		"har":               cr.har != nil,
+		"timings":           cr.timings,
+		"rate limit":        cr.limiter != nil,
+		"host policy":       cr.hostPolicy != nil,
+		"load balancing":    cr.balancer != nil,
+		"discovery":         cr.discovery != nil,
+		"fallback":          cr.fallback != nil,
+		"retry":             cr.retry != nil,
+		"dedupe":            cr.dedupe != nil,
+		"memoize":           cr.memo != nil,
+		"hooks":             !cr.hooks.empty(),
+		"gzip body":         cr.gzipBody,
+		"metrics":           len(cr.metrics) > 0,
+		"chaos":             cr.chaos != nil,
+		"transport":         len(cr.transportTuning) > 0 || cr.customDial(),
+		"throttle":          cr.bandwidth != nil,
+		"digest":            cr.digest != nil,
+		"negotiate":         cr.negotiate != nil,
+		"ntlm":              cr.ntlm != nil,
+		"dpop":              cr.dpop != nil,
+		"dump":              cr.dump != nil,
+		"budget":            cr.budget > 0,
+		"scheduler":         cr.scheduler != nil,
+		"concurrency limit": len(cr.concurrency) > 0,
+	}
+	var features []string
+	for name, on := range enabled {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// String renders the description one setting per line
+func (d *Description) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", d.Method, d.URL)
+	names := make([]string, 0, len(d.Headers))
+	for name := range d.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %s: %s\n", name, strings.Join(d.Headers[name], ", "))
+	}
+	if d.Timeout > 0 {
+		fmt.Fprintf(&b, "timeout: %s\n", d.Timeout)
+	}
+	if !d.Deadline.IsZero() {
+		fmt.Fprintf(&b, "deadline: %s\n", d.Deadline.Format(time.RFC3339))
+	}
+	if len(d.ExpectedStatus) > 0 || len(d.ExpectedRanges) > 0 {
+		expected := make([]string, 0, len(d.ExpectedStatus)+len(d.ExpectedRanges))
+		for _, code := range d.ExpectedStatus {
+			expected = append(expected, fmt.Sprint(code))
+		}
+		for _, r := range d.ExpectedRanges {
+			expected = append(expected, fmt.Sprintf("%d-%d", r[0], r[1]))
+		}
+		fmt.Fprintf(&b, "expect: %s\n", strings.Join(expected, ", "))
+	}
+	if d.Retries > 0 {
+		fmt.Fprintf(&b, "retries: %d, backoff %s\n", d.Retries, d.RetryBackoff)
+	}
+	if d.MaxResponseBytes > 0 {
+		fmt.Fprintf(&b, "max response bytes: %d\n", d.MaxResponseBytes)
+	}
+	if len(d.Features) > 0 {
+		fmt.Fprintf(&b, "features: %s\n", strings.Join(d.Features, ", "))
+	}
+	return b.String()
+}
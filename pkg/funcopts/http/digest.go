@@ -0,0 +1,218 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DigestAuth answers RFC 7616 digest challenges with user and password. A request that
+// gets a 401 with a `WWW-Authenticate: Digest` challenge is sent once more with the
+// response. The challenge is remembered for its host so later requests made with the option
+// to that host authenticate up front. Challenges from another host than the original one,
+// e.g. after a redirect, aren't answered. MD5 and SHA-256, with or without `-sess`, and qop=auth are supported.
+// Bodies are resent with `GetBody`, so a body set with `StreamBody` can't be
+func DigestAuth(user, password string) RequestOption {
+	d := &digestAuth{user: user, password: password}
+	return func(r *Request) error {
+		r.digest = d
+		return nil
+	}
+}
+
+// digestChallenge is a parsed `WWW-Authenticate: Digest` challenge
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	nc        int
+}
+
+type digestAuth struct {
+	user     string
+	password string
+	sync.Mutex
+	// challenges holds the last challenge of each origin, which carries its realm
+	challenges map[string]*digestChallenge
+}
+
+// authorization returns the `Authorization` header for req with the last challenge of its origin
+func (d *digestAuth) authorization(req *http.Request) string {
+	d.Lock()
+	defer d.Unlock()
+	c := d.challenges[origin(req.URL)]
+	if c == nil {
+		return ""
+	}
+	c.nc++
+	return c.authorize(d.user, d.password, req.Method, req.URL.RequestURI(), c.nc, newCnonce())
+}
+
+// remember keeps the first supported challenge of resp to a request for u and reports whether there was one
+func (d *digestAuth) remember(u *url.URL, resp *http.Response) bool {
+	var best *digestChallenge
+	for _, value := range resp.Header.Values("WWW-Authenticate") {
+		c := parseDigestChallenge(value)
+		if c != nil && (best == nil || strings.HasPrefix(c.algorithm, "SHA-256")) {
+			best = c
+		}
+	}
+	if best == nil {
+		return false
+	}
+	d.Lock()
+	defer d.Unlock()
+	if d.challenges == nil {
+		d.challenges = make(map[string]*digestChallenge)
+	}
+	d.challenges[origin(u)] = best
+	return true
+}
+
+type digestTransport struct {
+	auth *digestAuth
+	next http.RoundTripper
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != originalHost(req) {
+		return t.next.RoundTrip(req)
+	}
+	first := req
+	if authz := t.auth.authorization(req); authz != "" {
+		first = req.Clone(req.Context())
+		first.Header.Set("Authorization", authz)
+	}
+	resp, err := t.next.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if !t.auth.remember(req.URL, resp) {
+		return resp, nil
+	}
+	retry, ok := resendable(req, resp)
+	if !ok {
+		return resp, nil
+	}
+	retry.Header.Set("Authorization", t.auth.authorization(retry))
+	return t.next.RoundTrip(retry)
+}
+
+// parseDigestChallenge parses a digest challenge, returning nil for other schemes and
+// unsupported algorithms or qop values
+func parseDigestChallenge(value string) *digestChallenge {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(value), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil
+	}
+	params := parseAuthParams(rest)
+	c := &digestChallenge{realm: params["realm"], nonce: params["nonce"], opaque: params["opaque"], algorithm: strings.ToUpper(params["algorithm"])}
+	if c.algorithm == "" {
+		c.algorithm = "MD5"
+	}
+	if digestHash(c.algorithm) == nil || c.nonce == "" {
+		return nil
+	}
+	if qop, ok := params["qop"]; ok {
+		for _, q := range strings.Split(qop, ",") {
+			if strings.TrimSpace(q) == "auth" {
+				c.qop = "auth"
+			}
+		}
+		if c.qop == "" {
+			return nil
+		}
+	}
+	return c
+}
+
+// parseAuthParams parses the comma separated `key=value` and `key="quoted value"` params of a challenge
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, ", \t")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " \t")
+		var value strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			s = rest[min(i+1, len(rest)):]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value.WriteString(strings.TrimSpace(rest[:end]))
+			s = rest[end:]
+		}
+		params[key] = value.String()
+	}
+	return params
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+// authorize computes the `Authorization` header for a request to uri
+func (c *digestChallenge) authorize(user, password, method, uri string, nc int, cnonce string) string {
+	newHash := digestHash(c.algorithm)
+	h := func(parts ...string) string {
+		sum := newHash()
+		io.WriteString(sum, strings.Join(parts, ":"))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	ha1 := h(user, c.realm, password)
+	if strings.HasSuffix(c.algorithm, "-SESS") {
+		ha1 = h(ha1, c.nonce, cnonce)
+	}
+	ha2 := h(method, uri)
+	count := fmt.Sprintf("%08x", nc)
+	var response string
+	if c.qop == "" {
+		response = h(ha1, c.nonce, ha2)
+	} else {
+		response = h(ha1, c.nonce, count, cnonce, c.qop, ha2)
+	}
+	authz := fmt.Sprintf(`Digest username=%q, realm=%q, uri=%q, algorithm=%s, nonce=%q, response=%q`, user, c.realm, uri, c.algorithm, c.nonce, response)
+	if c.qop != "" {
+		authz += fmt.Sprintf(`, qop=%s, nc=%s, cnonce=%q`, c.qop, count, cnonce)
+	}
+	if c.opaque != "" {
+		authz += fmt.Sprintf(`, opaque=%q`, c.opaque)
+	}
+	return authz
+}
+
+// newCnonce returns a random client nonce
+func newCnonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
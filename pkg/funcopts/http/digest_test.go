@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 7616 section 3.9.1
+func TestDigestAuthorize(t *testing.T) {
+	for alg, response := range map[string]string{
+		"MD5":     "8ca523f5e9506fed4657c9700eebdbec",
+		"SHA-256": "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+	} {
+		c := parseDigestChallenge(`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=` + alg +
+			`, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`)
+		require.NotNil(t, c, alg)
+		authz := c.authorize("Mufasa", "Circle of Life", "GET", "/dir/index.html", 1, "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ")
+		params := parseAuthParams(strings.TrimPrefix(authz, "Digest "))
+		assert.Equal(t, response, params["response"], alg)
+		assert.Equal(t, "00000001", params["nc"])
+		assert.Equal(t, "auth", params["qop"])
+		assert.Equal(t, "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS", params["opaque"])
+	}
+}
+
+func TestParseDigestChallengeUnsupported(t *testing.T) {
+	assert.Nil(t, parseDigestChallenge(`Basic realm="x"`))
+	assert.Nil(t, parseDigestChallenge(`Digest realm="x", nonce="n", algorithm=SHA-512-256`))
+	assert.Nil(t, parseDigestChallenge(`Digest realm="x", nonce="n", qop="auth-int"`))
+}
+
+// digestServer challenges requests without valid digest credentials for user:password
+func digestServer(t *testing.T, algorithm string, challenges *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		challenge := `Digest realm="cameras", qop="auth", nonce="abc123", opaque="xyz", algorithm=` + algorithm
+		authz := r.Header.Get("Authorization")
+		if authz != "" {
+			params := parseAuthParams(strings.TrimPrefix(authz, "Digest "))
+			c := parseDigestChallenge(challenge)
+			var nc int
+			_, err := fmt.Sscanf(params["nc"], "%x", &nc)
+			assert.NoError(t, err)
+			want := parseAuthParams(strings.TrimPrefix(c.authorize("admin", "secret", r.Method, r.URL.RequestURI(), nc, params["cnonce"]), "Digest "))
+			if params["response"] == want["response"] {
+				body, _ := ioutil.ReadAll(r.Body)
+				w.Write(body)
+				return
+			}
+		}
+		atomic.AddInt32(challenges, 1)
+		w.Header().Add("WWW-Authenticate", `Basic realm="cameras"`)
+		w.Header().Add("WWW-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+}
+
+func TestDigestAuth(t *testing.T) {
+	for _, alg := range []string{"MD5", "SHA-256", "SHA-256-sess"} {
+		t.Run(alg, func(t *testing.T) {
+			var challenges int32
+			ts := digestServer(t, alg, &challenges)
+			defer ts.Close()
+			c := NewClient(DigestAuth("admin", "secret"), ExpectSuccess())
+			res, err := c.Post(ts.URL+"/snapshot?size=large", WithBody(strings.NewReader("payload")))
+			require.NoError(t, err)
+			assert.Equal(t, "payload", string(res.Body))
+			_, err = c.Get(ts.URL + "/status")
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&challenges), "the challenge is reused")
+		})
+	}
+}
+
+func TestDigestAuthWrongPassword(t *testing.T) {
+	var challenges int32
+	ts := digestServer(t, "MD5", &challenges)
+	defer ts.Close()
+	res, err := Get(ts.URL, DigestAuth("admin", "wrong"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&challenges), "retried once")
+}
+
+func TestDigestAuthOtherHost(t *testing.T) {
+	var challenges int32
+	ts := digestServer(t, "MD5", &challenges)
+	defer ts.Close()
+	var other http.Header
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		other = r.Header.Clone()
+		if r.URL.Path == "/challenge" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="elsewhere", nonce="n"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer elsewhere.Close()
+	c := NewClient(DigestAuth("admin", "secret"))
+	_, err := c.Get(ts.URL + "/status")
+	require.NoError(t, err)
+
+	_, err = c.Get(elsewhere.URL)
+	require.NoError(t, err)
+	assert.Empty(t, other.Get("Authorization"), "the challenge of another host isn't answered up front")
+
+	redirect := httptest.NewServer(http.RedirectHandler(elsewhere.URL+"/challenge", http.StatusFound))
+	defer redirect.Close()
+	res, err := c.Get(redirect.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.Status)
+	assert.Empty(t, other.Get("Authorization"), "challenges after a redirect aren't answered")
+}
@@ -0,0 +1,282 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval is how often `Discover` resolves services again
+const DefaultRefreshInterval = 30 * time.Second
+
+// Endpoint is the address of a single instance of a service
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+// String returns the endpoint as host:port
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// Discovery finds the instances of a service
+type Discovery interface {
+	Resolve(ctx context.Context, service string) ([]Endpoint, error)
+}
+
+// SRV discovers services with DNS SRV records named `_service._proto.domain`
+type SRV struct {
+	// Domain is the domain services are registered under, e.g. `service.consul`
+	Domain string
+	// Proto is the protocol of the records, `tcp` when empty
+	Proto string
+	// Resolver is used for lookups, net.DefaultResolver when nil
+	Resolver *net.Resolver
+}
+
+// Resolve looks up the SRV records of service, ordered by priority and weight
+func (s *SRV) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	proto := s.Proto
+	if proto == "" {
+		proto = "tcp"
+	}
+	resolver := s.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, service, proto, s.Domain)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]Endpoint, 0, len(records))
+	for _, srv := range records {
+		endpoints = append(endpoints, Endpoint{Host: strings.TrimSuffix(srv.Target, "."), Port: int(srv.Port)})
+	}
+	return endpoints, nil
+}
+
+// Consul discovers passing instances of services from the Consul health api
+type Consul struct {
+	// Address is the url of the Consul agent, e.g. `http://127.0.0.1:8500`
+	Address string
+	// Token is sent as the ACL token when set
+	Token string
+	// Datacenter queries a datacenter other than the agent's when set
+	Datacenter string
+	// Options are applied to the requests to the agent, e.g. `WithCA` or `Timeout`
+	Options []RequestOption
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string
+	}
+	Service struct {
+		Address string
+		Port    int
+	}
+}
+
+// Resolve returns the instances of service passing their health checks
+func (c *Consul) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	opts := []RequestOption{
+		WithContext(ctx),
+		BaseURL(c.Address),
+		PathParams(map[string]string{"service": service}),
+		QueryParam("passing", "true"),
+		ExpectSuccess(),
+	}
+	if c.Token != "" {
+		opts = append(opts, AddHeaders(map[string]string{"X-Consul-Token": c.Token}))
+	}
+	if c.Datacenter != "" {
+		opts = append(opts, QueryParam("dc", c.Datacenter))
+	}
+	opts = append(opts, c.Options...)
+	res, err := Get("/v1/health/service/{service}", opts...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []consulServiceEntry
+	if err := json.Unmarshal(res.Body, &entries); err != nil {
+		return nil, err
+	}
+	endpoints := make([]Endpoint, 0, len(entries))
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		endpoints = append(endpoints, Endpoint{Host: host, Port: entry.Service.Port})
+	}
+	return endpoints, nil
+}
+
+// Discover balances requests to `service://name` urls over the instances of name found with d.
+// Use `service+https://name` to connect to instances over TLS. It is `NewServiceDiscovery`
+// for discovery that lasts as long as the program
+func Discover(d Discovery, refresh time.Duration, opts ...BalancerOption) RequestOption {
+	return NewServiceDiscovery(d, refresh, opts...).Option()
+}
+
+// ServiceDiscovery balances requests to services over the instances found with a
+// `Discovery`. A service is resolved when it's first requested and again in the
+// background every refresh interval; if a lookup fails the previous instances are kept
+type ServiceDiscovery struct {
+	discovery Discovery
+	refresh   time.Duration
+	opts      []BalancerOption
+	services  map[string]*service
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopped   chan struct{}
+	sync.Mutex
+}
+
+type service struct {
+	url      *url.URL
+	balancer *Balancer
+	sync.Mutex
+}
+
+// NewServiceDiscovery returns a `ServiceDiscovery` resolving services with d every refresh,
+// or `DefaultRefreshInterval` when refresh isn't positive. opts configure the balancer of
+// each service. Close it to stop the refreshes
+func NewServiceDiscovery(d Discovery, refresh time.Duration, opts ...BalancerOption) *ServiceDiscovery {
+	if refresh <= 0 {
+		refresh = DefaultRefreshInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sd := &ServiceDiscovery{discovery: d, refresh: refresh, opts: opts, services: make(map[string]*service),
+		ctx: ctx, cancel: cancel, stopped: make(chan struct{})}
+	go sd.refreshLoop()
+	return sd
+}
+
+// Option sends requests to `service://name` and `service+https://name` urls through sd
+func (sd *ServiceDiscovery) Option() RequestOption {
+	return func(r *Request) error {
+		r.discovery = sd
+		return nil
+	}
+}
+
+// Close stops refreshing services and closes their balancers
+func (sd *ServiceDiscovery) Close() {
+	sd.cancel()
+	<-sd.stopped
+	sd.Lock()
+	defer sd.Unlock()
+	for _, svc := range sd.services {
+		svc.Lock()
+		if svc.balancer != nil {
+			svc.balancer.Close()
+		}
+		svc.Unlock()
+	}
+}
+
+func isServiceURL(u *url.URL) bool {
+	return u.Scheme == "service" || u.Scheme == "service+https"
+}
+
+func (sd *ServiceDiscovery) refreshLoop() {
+	defer close(sd.stopped)
+	ticker := time.NewTicker(sd.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sd.ctx.Done():
+			return
+		case <-ticker.C:
+			sd.Lock()
+			services := make([]*service, 0, len(sd.services))
+			for _, svc := range sd.services {
+				services = append(services, svc)
+			}
+			sd.Unlock()
+			for _, svc := range services {
+				if sd.ctx.Err() != nil {
+					return
+				}
+				ctx, cancel := context.WithTimeout(sd.ctx, sd.refresh)
+				svc.Lock()
+				sd.resolve(ctx, svc)
+				svc.Unlock()
+				cancel()
+			}
+		}
+	}
+}
+
+// balancer returns the balancer for the service addressed by u, resolving it with ctx
+// when it's first requested
+func (sd *ServiceDiscovery) balancer(ctx context.Context, u *url.URL) (*Balancer, error) {
+	key := u.Scheme + "://" + u.Host
+	sd.Lock()
+	svc, ok := sd.services[key]
+	if !ok {
+		svc = &service{url: u}
+		sd.services[key] = svc
+	}
+	sd.Unlock()
+
+	svc.Lock()
+	defer svc.Unlock()
+	if svc.balancer != nil {
+		return svc.balancer, nil
+	}
+	if err := sd.resolve(ctx, svc); err != nil {
+		return nil, err
+	}
+	return svc.balancer, nil
+}
+
+// resolve looks up the instances of svc and updates its balancer, keeping the previous
+// instances when the lookup fails. svc must be locked
+func (sd *ServiceDiscovery) resolve(ctx context.Context, svc *service) error {
+	u := svc.url
+	endpoints, err := sd.discovery.Resolve(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("discovering %s: %w", u.Hostname(), err)
+	}
+	scheme := "http"
+	if u.Scheme == "service+https" {
+		scheme = "https"
+	}
+	urls := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		urls = append(urls, scheme+"://"+e.String())
+	}
+	if svc.balancer == nil {
+		svc.balancer, err = NewBalancer(u.Scheme+"://"+u.Host, urls, sd.opts...)
+		return err
+	}
+	return svc.balancer.SetEndpoints(urls...)
+}
+
+// discoveryTransport routes requests for service urls through the service's balancer
+type discoveryTransport struct {
+	discovery *ServiceDiscovery
+	probe     http.RoundTripper
+	next      http.RoundTripper
+}
+
+func (t *discoveryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isServiceURL(req.URL) {
+		return t.next.RoundTrip(req)
+	}
+	b, err := t.discovery.balancer(req.Context(), req.URL)
+	if err != nil {
+		return nil, err
+	}
+	return (&balancerTransport{balancer: b, probe: t.probe, next: t.next}).RoundTrip(req)
+}
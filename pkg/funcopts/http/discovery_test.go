@@ -0,0 +1,166 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type staticDiscovery struct {
+	sync.Mutex
+	endpoints []Endpoint
+	err       error
+	calls     int32
+}
+
+func (d *staticDiscovery) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	atomic.AddInt32(&d.calls, 1)
+	d.Lock()
+	defer d.Unlock()
+	return d.endpoints, d.err
+}
+
+func (d *staticDiscovery) set(endpoints []Endpoint, err error) {
+	d.Lock()
+	defer d.Unlock()
+	d.endpoints, d.err = endpoints, err
+}
+
+func serverEndpoint(ts *httptest.Server) Endpoint {
+	u, _ := url.Parse(ts.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return Endpoint{Host: u.Hostname(), Port: port}
+}
+
+func TestDiscover(t *testing.T) {
+	a, b := replica("a", nil), replica("b", nil)
+	defer a.Close()
+	defer b.Close()
+	d := &staticDiscovery{endpoints: []Endpoint{serverEndpoint(a), serverEndpoint(b)}}
+	c := NewClient(Discover(d, time.Minute))
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		res, err := c.Get("service://payments/charges")
+		assert.NoError(t, err)
+		bodies = append(bodies, string(res.Body))
+	}
+	assert.ElementsMatch(t, []string{"a/charges", "b/charges"}, bodies)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&d.calls))
+}
+
+func TestDiscoverRefresh(t *testing.T) {
+	a, b := replica("a", nil), replica("b", nil)
+	defer a.Close()
+	defer b.Close()
+	d := &staticDiscovery{endpoints: []Endpoint{serverEndpoint(a)}}
+	sd := NewServiceDiscovery(d, 5*time.Millisecond)
+	defer sd.Close()
+	c := NewClient(sd.Option())
+	_, err := c.Get("service://payments/")
+	assert.NoError(t, err)
+
+	d.set([]Endpoint{serverEndpoint(b)}, nil)
+	assert.Eventually(t, func() bool {
+		res, err := c.Get("service://payments/")
+		return err == nil && string(res.Body) == "b/"
+	}, time.Second, 5*time.Millisecond, "refreshed in the background")
+
+	d.set(nil, errors.New("registry down"))
+	calls := atomic.LoadInt32(&d.calls)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&d.calls) > calls }, time.Second, 5*time.Millisecond)
+	res, err := c.Get("service://payments/")
+	assert.NoError(t, err, "previous instances should be kept")
+	assert.Equal(t, "b/", string(res.Body))
+
+	sd.Close()
+	calls = atomic.LoadInt32(&d.calls)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, calls, atomic.LoadInt32(&d.calls), "no refreshes after Close")
+}
+
+func TestDiscoverError(t *testing.T) {
+	d := &staticDiscovery{err: errors.New("registry down")}
+	_, err := Get("service://payments/", Discover(d, time.Minute))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "registry down")
+}
+
+func TestConsul(t *testing.T) {
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/health/service/payments", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("passing"))
+		assert.Equal(t, "dc2", r.URL.Query().Get("dc"))
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+		assert.Equal(t, "yes", r.Header.Get("X-Option"))
+		w.Write([]byte(`[
+			{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": "", "Port": 8080}},
+			{"Node": {"Address": "10.0.0.2"}, "Service": {"Address": "10.1.0.2", "Port": 9090}}
+		]`))
+	}))
+	defer consul.Close()
+	c := &Consul{Address: consul.URL, Token: "secret", Datacenter: "dc2",
+		Options: []RequestOption{AddHeaders(map[string]string{"X-Option": "yes"})}}
+	endpoints, err := c.Resolve(context.Background(), "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, []Endpoint{{Host: "10.0.0.1", Port: 8080}, {Host: "10.1.0.2", Port: 9090}}, endpoints)
+}
+
+func testSRVServer(t *testing.T) net.PacketConn {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, _ := p.Question()
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			if q.Type == dnsmessage.TypeSRV && q.Name.String() == "_payments._tcp.service.test." {
+				b.SRVResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60},
+					dnsmessage.SRVResource{Priority: 1, Weight: 10, Port: 8443, Target: dnsmessage.MustNewName("node1.service.test.")})
+			}
+			msg, _ := b.Finish()
+			pc.WriteTo(msg, addr)
+		}
+	}()
+	return pc
+}
+
+func TestSRV(t *testing.T) {
+	pc := testSRVServer(t)
+	defer pc.Close()
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return net.Dial("udp", pc.LocalAddr().String())
+		},
+	}
+	s := &SRV{Domain: "service.test", Resolver: resolver}
+	endpoints, err := s.Resolve(context.Background(), "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, []Endpoint{{Host: "node1.service.test", Port: 8443}}, endpoints)
+	assert.Equal(t, "node1.service.test:8443", endpoints[0].String())
+}
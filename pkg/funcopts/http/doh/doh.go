@@ -0,0 +1,208 @@
+// Package doh provides a DNS-over-HTTPS (RFC 8484) resolver that plugs into httpclient.WithResolver
+//
+//	r := doh.New(doh.Cloudflare)
+//	res, err := httpclient.Get("https://example.com", httpclient.WithResolver(r.NetResolver()))
+package doh
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// Cloudflare is the Cloudflare DoH endpoint
+	Cloudflare = "https://cloudflare-dns.com/dns-query"
+	// Google is the Google Public DNS DoH endpoint
+	Google = "https://dns.google/dns-query"
+	// ContentTypeDNSMessage is the mimetype for DNS wire format messages
+	ContentTypeDNSMessage = "application/dns-message"
+)
+
+// ErrInvalidMessage is the error returned for malformed DNS messages
+var ErrInvalidMessage = errors.New("invalid dns message")
+
+// Resolver sends DNS queries to a DoH endpoint and caches answers for their TTL
+type Resolver struct {
+	endpoint string
+	opts     []httpclient.RequestOption
+	cache    map[string]cacheEntry
+	sync.Mutex
+}
+
+type cacheEntry struct {
+	msg     []byte
+	expires time.Time
+}
+
+// New returns a `Resolver` for the DoH endpoint. opts are applied to every query,
+// for example to set a custom http.Client with `httpclient.SetClient`
+func New(endpoint string, opts ...httpclient.RequestOption) *Resolver {
+	return &Resolver{
+		endpoint: endpoint,
+		opts:     opts,
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+// NetResolver returns a *net.Resolver that sends all queries through the DoH endpoint
+func (r *Resolver) NetResolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return &conn{ctx: ctx, resolver: r}, nil
+		},
+	}
+}
+
+// Exchange sends a DNS wire format query and returns the response.
+// Responses are cached until the lowest TTL in their answer and authority sections expires
+func (r *Resolver) Exchange(ctx context.Context, query []byte) ([]byte, error) {
+	if len(query) < 12 {
+		return nil, ErrInvalidMessage
+	}
+	id := query[:2]
+	key := string(query[2:])
+	if msg, ok := r.cached(key); ok {
+		return withID(msg, id), nil
+	}
+	// RFC 8484 recommends an id of 0 so responses are cache friendly
+	wire := withID(query, []byte{0, 0})
+	opts := append([]httpclient.RequestOption{
+		httpclient.ContentType(ContentTypeDNSMessage),
+		httpclient.Accept(ContentTypeDNSMessage),
+		httpclient.WithBody(bytes.NewReader(wire)),
+		httpclient.ExpectStatus(200),
+		httpclient.WithContext(ctx),
+	}, r.opts...)
+	res, err := httpclient.Post(r.endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if ttl, ok := minTTL(res.Body); ok && ttl > 0 {
+		r.Lock()
+		r.cache[key] = cacheEntry{msg: res.Body, expires: time.Now().Add(ttl)}
+		r.Unlock()
+	}
+	return withID(res.Body, id), nil
+}
+
+func (r *Resolver) cached(key string) ([]byte, bool) {
+	r.Lock()
+	defer r.Unlock()
+	entry, ok := r.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(r.cache, key)
+		return nil, false
+	}
+	return entry.msg, true
+}
+
+func withID(msg []byte, id []byte) []byte {
+	out := append([]byte{}, msg...)
+	if len(out) >= 2 {
+		copy(out, id)
+	}
+	return out
+}
+
+// minTTL returns the lowest TTL of the answer and authority records in msg
+func minTTL(msg []byte) (time.Duration, bool) {
+	var p dnsmessage.Parser
+	if _, err := p.Start(msg); err != nil {
+		return 0, false
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return 0, false
+	}
+	var ttl uint32
+	found := false
+	record := func(h dnsmessage.ResourceHeader) {
+		if !found || h.TTL < ttl {
+			ttl = h.TTL
+		}
+		found = true
+	}
+	for {
+		h, err := p.AnswerHeader()
+		if err != nil {
+			break
+		}
+		record(h)
+		if err := p.SkipAnswer(); err != nil {
+			return 0, false
+		}
+	}
+	for {
+		h, err := p.AuthorityHeader()
+		if err != nil {
+			break
+		}
+		record(h)
+		if err := p.SkipAuthority(); err != nil {
+			return 0, false
+		}
+	}
+	return time.Duration(ttl) * time.Second, found
+}
+
+// conn is a net.Conn speaking the DNS stream protocol (length prefixed messages)
+// that forwards each query to the DoH endpoint
+type conn struct {
+	ctx      context.Context
+	resolver *Resolver
+	in       bytes.Buffer
+	out      bytes.Buffer
+	sync.Mutex
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.in.Write(b)
+	for c.in.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.in.Bytes()[:2]))
+		if c.in.Len() < size+2 {
+			break
+		}
+		c.in.Next(2)
+		query := append([]byte{}, c.in.Next(size)...)
+		resp, err := c.resolver.Exchange(c.ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		var size16 [2]byte
+		binary.BigEndian.PutUint16(size16[:], uint16(len(resp)))
+		c.out.Write(size16[:])
+		c.out.Write(resp)
+	}
+	return len(b), nil
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.Lock()
+	defer c.Unlock()
+	return c.out.Read(b)
+}
+
+func (c *conn) Close() error                       { return nil }
+func (c *conn) LocalAddr() net.Addr                { return dohAddr{} }
+func (c *conn) RemoteAddr() net.Addr               { return dohAddr{} }
+func (c *conn) SetDeadline(t time.Time) error      { return nil }
+func (c *conn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *conn) SetWriteDeadline(t time.Time) error { return nil }
+
+type dohAddr struct{}
+
+func (dohAddr) Network() string { return "doh" }
+func (dohAddr) String() string  { return "doh" }
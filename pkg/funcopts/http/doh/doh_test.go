@@ -0,0 +1,79 @@
+package doh
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testDoHServer(t *testing.T, queries *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var p dnsmessage.Parser
+		h, err := p.Start(body)
+		if err != nil || r.Header.Get("Content-Type") != ContentTypeDNSMessage {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		q, _ := p.Question()
+		atomic.AddInt32(queries, 1)
+		b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true})
+		b.StartQuestions()
+		b.Question(q)
+		b.StartAnswers()
+		if q.Type == dnsmessage.TypeA {
+			b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60},
+				dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}})
+		} else {
+			b.StartAuthorities()
+			b.SOAResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 30},
+				dnsmessage.SOAResource{NS: q.Name, MBox: q.Name, MinTTL: 30})
+		}
+		msg, _ := b.Finish()
+		w.Header().Set("Content-Type", ContentTypeDNSMessage)
+		w.Write(msg)
+	}))
+}
+
+func TestNetResolver(t *testing.T) {
+	var queries int32
+	dns := testDoHServer(t, &queries)
+	defer dns.Close()
+	r := New(dns.URL)
+	addrs, err := r.NetResolver().LookupIPAddr(context.Background(), "doh.example.test")
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 1)
+	assert.Equal(t, "127.0.0.1", addrs[0].IP.String())
+
+	first := atomic.LoadInt32(&queries)
+	_, err = r.NetResolver().LookupIPAddr(context.Background(), "doh.example.test")
+	assert.NoError(t, err)
+	assert.Equal(t, first, atomic.LoadInt32(&queries), "answers should be served from cache")
+}
+
+func TestWithResolver(t *testing.T) {
+	var queries int32
+	dns := testDoHServer(t, &queries)
+	defer dns.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("resolved over doh"))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	res, err := httpclient.Get("http://doh.example.test:"+u.Port(), httpclient.WithResolver(New(dns.URL).NetResolver()))
+	assert.NoError(t, err)
+	assert.Equal(t, "resolved over doh", string(res.Body))
+}
+
+func TestExchangeInvalid(t *testing.T) {
+	_, err := New("http://127.0.0.1:0").Exchange(context.Background(), []byte{1, 2})
+	assert.Equal(t, ErrInvalidMessage, err)
+}
@@ -0,0 +1,180 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Download streams the body of a GET of url into a file at path. The file is written
+// next to path and renamed when complete and verified with any `VerifyDownload`
+// options, so path never holds a partial or rejected download. A file replaced keeps
+// its mode and a new one is created with 0644
+func Download(url, path string, opts ...RequestOption) error {
+	r, _, err := New(append(opts, get(), setURL(url))...)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, func(f *os.File) error {
+		header, err := r.downloadTo(f)
+		if err != nil {
+			return err
+		}
+		return r.verifyDownload(f, header)
+	})
+}
+
+// ParallelDownload downloads url into a file at path with up to segments concurrent
+// range requests. A HEAD request checks that the server sends `Accept-Ranges: bytes`
+// and a `Content-Length`; without them, or when a range is answered with the whole
+// body, the file is downloaded with a single request like `Download`. Verifiers get
+// the headers of the HEAD response
+func ParallelDownload(url, path string, segments int, opts ...RequestOption) error {
+	probe, _, err := New(append(opts, head(), setURL(url))...)
+	if err != nil {
+		return err
+	}
+	res, err := probe.Send(probe.context())
+	size := int64(-1)
+	if err == nil && strings.EqualFold(res.Headers.Get("Accept-Ranges"), "bytes") {
+		size, _ = strconv.ParseInt(res.Headers.Get("Content-Length"), 10, 64)
+	}
+	if segments < 2 || size < int64(segments) {
+		return Download(url, path, opts...)
+	}
+	validator := res.Headers.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = res.Headers.Get("Last-Modified")
+	}
+	err = writeFile(path, func(f *os.File) error {
+		if err := probe.downloadRanges(f, size, segments, validator); err != nil {
+			return err
+		}
+		return probe.verifyDownload(f, res.Headers)
+	})
+	if errors.Is(err, ErrRangeIgnored) {
+		return Download(url, path, opts...)
+	}
+	return err
+}
+
+// writeFile calls write with a temporary file next to path and moves it to path when
+// write succeeds. The file gets the mode of the file it replaces, or 0644 since
+// temporary files are only readable by their owner
+func writeFile(path string, write func(*os.File) error) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".part-*")
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// downloadTo sends the request, copies the body into w and returns the response headers
+func (cr *Request) downloadTo(w io.Writer) (http.Header, error) {
+	r, err := cr.Clone(IncludeRawResponse(), ExpectSuccess())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Send(cr.context())
+	if resp != nil && resp.Raw != nil {
+		defer resp.Raw.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(w, resp.Raw.Body)
+	return resp.Headers, err
+}
+
+// downloadRanges fetches size bytes in segments concurrent range requests and writes
+// each at its offset in f. Every range is conditional on validator so a file that
+// changes mid download isn't stitched together from two versions
+func (cr *Request) downloadRanges(f *os.File, size int64, segments int, validator string) error {
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(cr.context())
+	defer cancel()
+	chunk := size / int64(segments)
+	var wg sync.WaitGroup
+	errs := make([]error, segments)
+	for i := 0; i < segments; i++ {
+		start, end := int64(i)*chunk, int64(i+1)*chunk-1
+		if i == segments-1 {
+			end = size - 1
+		}
+		wg.Add(1)
+		go func(i int, start, end int64) {
+			defer wg.Done()
+			if errs[i] = cr.downloadRange(ctx, f, start, end, validator); errs[i] != nil {
+				cancel()
+			}
+		}(i, start, end)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if errors.Is(err, ErrRangeIgnored) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (cr *Request) downloadRange(ctx context.Context, f *os.File, start, end int64, validator string) error {
+	headers := map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", start, end)}
+	if validator != "" {
+		headers["If-Range"] = validator
+	}
+	r, err := cr.Clone(get(), AddHeaders(headers), IncludeRawResponse(), ExpectSuccess())
+	if err != nil {
+		return err
+	}
+	res, err := r.Send(ctx)
+	if res != nil && res.Raw != nil {
+		defer res.Raw.Body.Close()
+	}
+	if err != nil {
+		return err
+	}
+	if res.Status != http.StatusPartialContent || !strings.HasPrefix(res.Headers.Get("Content-Range"), fmt.Sprintf("bytes %d-%d/", start, end)) {
+		return ErrRangeIgnored
+	}
+	n, err := io.Copy(io.NewOffsetWriter(f, start), io.LimitReader(res.Raw.Body, end-start+1))
+	if err != nil {
+		return err
+	}
+	if n != end-start+1 {
+		return fmt.Errorf("range %d-%d: got %d bytes: %w", start, end, n, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
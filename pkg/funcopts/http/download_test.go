@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var downloadContent = bytes.Repeat([]byte("0123456789abcdef"), 1000)
+
+func TestDownload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(downloadContent)
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "file.bin")
+	require.NoError(t, Download(ts.URL, path, SetClient(ts.Client())))
+	got, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, downloadContent, got)
+}
+
+func TestDownloadMode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(downloadContent)
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "file.bin")
+	require.NoError(t, Download(ts.URL, path, SetClient(ts.Client())))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	require.NoError(t, os.Chmod(path, 0640))
+	require.NoError(t, Download(ts.URL, path, SetClient(ts.Client())))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+}
+
+func TestDownloadErrorLeavesNoFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	dir := t.TempDir()
+	err := Download(ts.URL, filepath.Join(dir, "file.bin"), SetClient(ts.Client()))
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestParallelDownload(t *testing.T) {
+	var ranges int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			atomic.AddInt32(&ranges, 1)
+			assert.Equal(t, `"v1"`, r.Header.Get("If-Range"))
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "file.bin")
+	require.NoError(t, ParallelDownload(ts.URL, path, 4, SetClient(ts.Client())))
+	got, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, downloadContent, got)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&ranges))
+}
+
+func TestParallelDownloadFallback(t *testing.T) {
+	tests := map[string]http.HandlerFunc{
+		"no accept ranges": func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Range"))
+			w.Write(downloadContent)
+		},
+		"range ignored": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", "16000")
+			if r.Method == http.MethodHead {
+				return
+			}
+			w.Write(downloadContent)
+		},
+	}
+	for name, handler := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gets int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && !strings.HasPrefix(r.Header.Get("Range"), "bytes=") {
+					atomic.AddInt32(&gets, 1)
+				}
+				handler(w, r)
+			}))
+			defer ts.Close()
+			path := filepath.Join(t.TempDir(), "file.bin")
+			require.NoError(t, ParallelDownload(ts.URL, path, 4, SetClient(ts.Client())))
+			got, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, downloadContent, got)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&gets))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// DPoP sends an RFC 9449 proof of possession of key in a `DPoP` header with every
+// attempt, bound to its method and url. A request with an `Authorization: DPoP` token,
+// including one set by `HeaderFunc`, also binds the proof to the token. A nonce the server hands out in `DPoP-Nonce` is
+// used in later proofs, and a request rejected with a new nonce is sent once more with
+// it. Like `RateLimit` the nonces are shared by every request made with the option,
+// each kept for the origin that handed it out
+func DPoP(key crypto.Signer) RequestOption {
+	jwk, err := publicJWK(key.Public())
+	d := &dpop{key: key, jwk: jwk, nonces: make(map[string]string)}
+	return func(r *Request) error {
+		if err != nil {
+			return err
+		}
+		r.dpop = d
+		return nil
+	}
+}
+
+type dpop struct {
+	key crypto.Signer
+	jwk map[string]interface{}
+	sync.Mutex
+	// nonces are keyed by origin since servers only accept their own
+	nonces map[string]string
+}
+
+// proof returns the DPoP proof for req
+func (d *dpop) proof(req *http.Request) (string, error) {
+	jti := make([]byte, 16)
+	rand.Read(jti)
+	htu := *req.URL
+	htu.RawQuery, htu.Fragment, htu.RawFragment = "", "", ""
+	claims := map[string]interface{}{
+		"jti": hex.EncodeToString(jti),
+		"htm": req.Method,
+		"htu": htu.String(),
+		"iat": clock.FromContext(req.Context()).Now().Unix(),
+	}
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "DPoP "); ok {
+		sum := sha256.Sum256([]byte(token))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	d.Lock()
+	if nonce := d.nonces[origin(req.URL)]; nonce != "" {
+		claims["nonce"] = nonce
+	}
+	d.Unlock()
+	return signJWT(map[string]interface{}{"typ": "dpop+jwt", "jwk": d.jwk}, claims, d.key)
+}
+
+// remember keeps the nonce of resp for the origin of u and reports whether resp rejected
+// the request for lack of it. Resource servers reject with 401 and authorization servers with 400
+func (d *dpop) remember(u *url.URL, resp *http.Response) bool {
+	nonce := resp.Header.Get("DPoP-Nonce")
+	if nonce == "" {
+		return false
+	}
+	d.Lock()
+	defer d.Unlock()
+	key := origin(u)
+	changed := nonce != d.nonces[key]
+	d.nonces[key] = nonce
+	return changed && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusBadRequest)
+}
+
+type dpopTransport struct {
+	dpop *dpop
+	next http.RoundTripper
+}
+
+func (t *dpopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.send(req)
+	if err != nil || !t.dpop.remember(req.URL, resp) {
+		return resp, err
+	}
+	retry, ok := resendable(req, resp)
+	if !ok {
+		return resp, nil
+	}
+	return t.send(retry)
+}
+
+func (t *dpopTransport) send(req *http.Request) (*http.Response, error) {
+	proof, err := t.dpop.proof(req)
+	if err != nil {
+		return nil, fmt.Errorf("dpop proof: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("DPoP", proof)
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDPoP(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var jtis []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header, claims := verifyJWT(t, r.Header.Get("DPoP"), &key.PublicKey)
+		assert.Equal(t, "dpop+jwt", header["typ"])
+		assert.Equal(t, "ES256", header["alg"])
+		assert.Equal(t, "EC", header["jwk"].(map[string]interface{})["kty"])
+		assert.Equal(t, "POST", claims["htm"])
+		assert.Equal(t, "http://"+r.Host+"/resource", claims["htu"])
+		assert.Equal(t, float64(1700000000), claims["iat"])
+		sum := sha256.Sum256([]byte("at123"))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), claims["ath"])
+		jtis = append(jtis, claims["jti"].(string))
+	}))
+	defer ts.Close()
+	c := NewClient(DPoP(key), AddHeaders(map[string]string{"Authorization": "DPoP at123"}), WithClock(clock.NewFake(time.Unix(1700000000, 0))))
+	for i := 0; i < 2; i++ {
+		_, err := c.Post(ts.URL + "/resource?q=1")
+		require.NoError(t, err)
+	}
+	require.Len(t, jtis, 2)
+	assert.NotEqual(t, jtis[0], jtis[1])
+}
+
+func TestDPoPNonce(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, claims := verifyJWT(t, r.Header.Get("DPoP"), &key.PublicKey)
+		w.Header().Set("DPoP-Nonce", "n1")
+		if claims["nonce"] != "n1" {
+			w.Header().Set("WWW-Authenticate", `DPoP error="use_dpop_nonce"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(DPoP(key), ExpectSuccess())
+	_, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	_, err = c.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests, "the nonce is reused")
+}
+
+func TestDPoPHeaderFunc(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims := verifyJWT(t, r.Header.Get("DPoP"), &key.PublicKey)
+		sum := sha256.Sum256([]byte("at456"))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), claims["ath"])
+	}))
+	defer ts.Close()
+	_, err := Get(ts.URL, DPoP(key), HeaderFunc("Authorization", func(ctx context.Context) (string, error) {
+		return "DPoP at456", nil
+	}, nil))
+	require.NoError(t, err)
+}
+
+func TestDPoPNoncePerOrigin(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	nonces := map[string]interface{}{}
+	handler := func(nonce string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, claims := verifyJWT(t, r.Header.Get("DPoP"), &key.PublicKey)
+			nonces[nonce] = claims["nonce"]
+			w.Header().Set("DPoP-Nonce", nonce)
+		}
+	}
+	first := httptest.NewServer(handler("n1"))
+	defer first.Close()
+	second := httptest.NewServer(handler("n2"))
+	defer second.Close()
+	c := NewClient(DPoP(key))
+	_, err := c.Get(first.URL)
+	require.NoError(t, err)
+	_, err = c.Get(second.URL)
+	require.NoError(t, err)
+	assert.Nil(t, nonces["n2"], "the nonce of another server isn't sent")
+	_, err = c.Get(first.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "n1", nonces["n1"])
+}
@@ -0,0 +1,208 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// DefaultDumpLimit is the number of body bytes `Dump` writes of each request and response
+const DefaultDumpLimit = 64 << 10
+
+// dumpConfig is where and how `Dump` writes
+type dumpConfig struct {
+	w     io.Writer
+	scrub []func([]byte) []byte
+	limit int64
+	gate  func(*http.Request) bool
+	// mu keeps the dumps of concurrent attempts from interleaving
+	mu *sync.Mutex
+}
+
+// DumpOption configures `Dump`
+type DumpOption func(*dumpConfig)
+
+// ScrubDump passes every dump through fn before it's written, e.g. to mask account
+// numbers in bodies. Scrubbers run in order after headers have been redacted
+func ScrubDump(fn func([]byte) []byte) DumpOption {
+	return func(cfg *dumpConfig) {
+		cfg.scrub = append(cfg.scrub, fn)
+	}
+}
+
+// DumpLimit writes at most n bytes of each body instead of `DefaultDumpLimit`.
+// A negative n writes whole bodies
+func DumpLimit(n int64) DumpOption {
+	return func(cfg *dumpConfig) {
+		cfg.limit = n
+	}
+}
+
+// DumpIf only dumps the attempts fn returns true for
+func DumpIf(fn func(*http.Request) bool) DumpOption {
+	return func(cfg *dumpConfig) {
+		cfg.gate = fn
+	}
+}
+
+// Dump writes the wire format of every attempt and its response to w, including retries
+// and redirects. Headers and query params redacted from logs are redacted from dumps too.
+// Bodies are read only up to the dump limit and still sent and received in full; request
+// bodies sent with `Expect100Continue` or `StreamBody` aren't read at all. Set on
+// a `Client` to dump all its requests and turn off for one with `NoDump`
+//
+//	c := NewClient(Dump(os.Stderr, DumpLimit(1024), ScrubDump(maskCards)))
+func Dump(w io.Writer, opts ...DumpOption) RequestOption {
+	cfg := &dumpConfig{w: w, limit: DefaultDumpLimit, mu: &sync.Mutex{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(r *Request) error {
+		r.dump = cfg
+		return nil
+	}
+}
+
+// NoDump turns off a `Dump` set earlier, e.g. by the client
+func NoDump() RequestOption {
+	return func(r *Request) error {
+		r.dump = nil
+		return nil
+	}
+}
+
+type dumpTransport struct {
+	cfg         *dumpConfig
+	redact      []string
+	redactQuery []string
+	// skipReqBody leaves request bodies unread, for `Expect100Continue` and `StreamBody`
+	skipReqBody bool
+	next        http.RoundTripper
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cfg.gate != nil && !t.cfg.gate(req) {
+		return t.next.RoundTrip(req)
+	}
+	var reqDump bytes.Buffer
+	req, err := t.dumpRequest(&reqDump, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	var respDump bytes.Buffer
+	if err != nil {
+		fmt.Fprintf(&respDump, "error: %v\n", err)
+	} else if err := t.dumpResponse(&respDump, resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	t.write(reqDump.Bytes(), respDump.Bytes())
+	return resp, err
+}
+
+// dumpRequest dumps req to buf and returns the request to send in its place, a clone
+// whose body still reads from the start
+func (t *dumpTransport) dumpRequest(buf *bytes.Buffer, req *http.Request) (*http.Request, error) {
+	req = req.Clone(req.Context())
+	var shown []byte
+	var truncated bool
+	if !t.skipReqBody {
+		var body io.ReadCloser
+		var err error
+		if shown, body, truncated, err = peekBody(req.Body, t.cfg.limit); err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	out := req.Clone(req.Context())
+	out.Header = transport.RedactHeader(req.Header, t.redact...)
+	if len(t.redactQuery) > 0 {
+		out.URL, _ = url.Parse(transport.RedactURL(req.URL, t.redactQuery...))
+	}
+	head, err := httputil.DumpRequestOut(out, false)
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(head)
+	if t.skipReqBody && req.Body != nil && req.Body != http.NoBody {
+		buf.WriteString("[body not dumped]\n")
+	}
+	writeDumpBody(buf, shown, truncated, t.cfg.limit)
+	return req, nil
+}
+
+func (t *dumpTransport) dumpResponse(buf *bytes.Buffer, resp *http.Response) error {
+	shown, body, truncated, err := peekBody(resp.Body, t.cfg.limit)
+	if err != nil {
+		return err
+	}
+	resp.Body = body
+	out := *resp
+	out.Header = transport.RedactHeader(resp.Header, t.redact...)
+	out.Body = nil
+	head, err := httputil.DumpResponse(&out, false)
+	if err != nil {
+		return err
+	}
+	buf.Write(head)
+	writeDumpBody(buf, shown, truncated, t.cfg.limit)
+	return nil
+}
+
+// write scrubs and writes the dumps of one attempt together
+func (t *dumpTransport) write(dumps ...[]byte) {
+	var all []byte
+	for _, d := range dumps {
+		all = append(all, d...)
+		all = append(all, '\n')
+	}
+	for _, scrub := range t.cfg.scrub {
+		all = scrub(all)
+	}
+	t.cfg.mu.Lock()
+	defer t.cfg.mu.Unlock()
+	t.cfg.w.Write(all)
+}
+
+func writeDumpBody(buf *bytes.Buffer, shown []byte, truncated bool, limit int64) {
+	buf.Write(shown)
+	if truncated {
+		fmt.Fprintf(buf, "\n[body truncated after %d bytes]", limit)
+	}
+	if len(shown) > 0 {
+		buf.WriteByte('\n')
+	}
+}
+
+// peekBody reads the first limit bytes of body, or all of it for a negative limit, and
+// returns them with a body that still reads from the start
+func peekBody(body io.ReadCloser, limit int64) ([]byte, io.ReadCloser, bool, error) {
+	if body == nil || body == http.NoBody {
+		return nil, body, false, nil
+	}
+	var r io.Reader = body
+	if limit >= 0 {
+		r = io.LimitReader(body, limit+1)
+	}
+	prefix, err := ioutil.ReadAll(r)
+	if err != nil {
+		body.Close()
+		return nil, nil, false, err
+	}
+	restored := struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+	if limit >= 0 && int64(len(prefix)) > limit {
+		return prefix[:limit], restored, true, nil
+	}
+	return prefix, restored, false, nil
+}
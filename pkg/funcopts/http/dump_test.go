@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write(bytes.ToUpper(body))
+	}))
+	defer ts.Close()
+	var out bytes.Buffer
+	mask := func(b []byte) []byte { return bytes.ReplaceAll(b, []byte("4111"), []byte("****")) }
+	payload := "card=4111&" + strings.Repeat("x", 100)
+	res, err := Post(ts.URL+"/pay?key=s3cret", WithBody(strings.NewReader(payload)), BearerToken("tok"),
+		APIKey("s3cret", InQuery("key")), Dump(&out, DumpLimit(20), ScrubDump(mask)))
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToUpper(payload), string(res.Body), "bodies are sent and read in full")
+
+	dump := out.String()
+	assert.Contains(t, dump, "POST /pay?key=%5BREDACTED%5D HTTP/1.1")
+	assert.Contains(t, dump, "Authorization: [REDACTED]")
+	assert.Contains(t, dump, "Set-Cookie: [REDACTED]")
+	assert.Contains(t, dump, "card=****&xxxxxxxxxx\n[body truncated after 20 bytes]")
+	assert.Contains(t, dump, "HTTP/1.1 200 OK")
+	assert.Contains(t, dump, "CARD=****&XXXXXXXXXX\n[body truncated after 20 bytes]")
+	assert.NotContains(t, dump, "tok")
+}
+
+func TestDumpLeavesRequestAlone(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = append(got, string(body))
+	}))
+	defer ts.Close()
+	var out bytes.Buffer
+	req, err := http.NewRequest("POST", ts.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	body := req.Body
+	rt := &dumpTransport{cfg: &dumpConfig{w: &out, limit: DefaultDumpLimit, mu: &sync.Mutex{}}, next: http.DefaultTransport}
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, body, req.Body, "the body of the caller's request isn't replaced")
+
+	out.Reset()
+	_, err = Post(ts.URL, StreamBody(strings.NewReader("streamed")), Dump(&out))
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "[body not dumped]")
+	assert.NotContains(t, out.String(), "streamed")
+	assert.Equal(t, []string{"payload", "streamed"}, got)
+}
+
+func TestDumpGates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	var out bytes.Buffer
+	c := NewClient(Dump(&out, DumpIf(func(r *http.Request) bool { return r.URL.Path != "/health" })))
+	for _, path := range []string{"/health", "/quiet", "/users"} {
+		var opts []RequestOption
+		if path == "/quiet" {
+			opts = append(opts, NoDump())
+		}
+		_, err := c.Get(ts.URL+path, opts...)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "GET "))
+	assert.Contains(t, out.String(), "GET /users HTTP/1.1")
+}
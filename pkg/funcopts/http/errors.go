@@ -0,0 +1,58 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// maxStatusErrorBody is the number of body bytes kept on a `StatusError`
+const maxStatusErrorBody = 512
+
+// StatusError is the error returned when a response status doesn't match `ExpectStatus`.
+// It wraps `ErrInvalidStatusCode` so it can be checked with errors.Is
+type StatusError struct {
+	Status int
+	Method string
+	URL    string
+	// Body is the start of the response body, truncated to 512 bytes
+	Body []byte
+	// Problem is the decoded body of an `application/problem+json` error response
+	Problem *ProblemDetails
+	// RequestID is the `X-Request-ID` sent with the request
+	RequestID string
+}
+
+func newStatusError(req *http.Request, res *Response) *StatusError {
+	body := res.Body
+	if len(body) > maxStatusErrorBody {
+		body = body[:maxStatusErrorBody]
+	}
+	problem, _ := res.Problem()
+	return &StatusError{
+		Status:    res.Status,
+		Method:    req.Method,
+		URL:       req.URL.Redacted(),
+		Body:      body,
+		Problem:   problem,
+		RequestID: res.RequestID,
+	}
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s: %d", e.Method, e.URL, ErrInvalidStatusCode, e.Status)
+	if e.Problem != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Problem)
+	}
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request id %s)", msg, e.RequestID)
+	}
+	return msg
+}
+
+// Unwrap returns `ErrInvalidStatusCode` and the `ProblemDetails` if there are any
+func (e *StatusError) Unwrap() []error {
+	if e.Problem != nil {
+		return []error{ErrInvalidStatusCode, e.Problem}
+	}
+	return []error{ErrInvalidStatusCode}
+}
@@ -0,0 +1,55 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	}))
+	defer ts.Close()
+	_, err := Put(ts.URL+"/things/1", ExpectStatus(200))
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusConflict, statusErr.Status)
+	assert.Equal(t, "PUT", statusErr.Method)
+	assert.Equal(t, ts.URL+"/things/1", statusErr.URL)
+	assert.Len(t, statusErr.Body, maxStatusErrorBody)
+	assert.EqualError(t, err, "PUT "+ts.URL+"/things/1: response had an invalid status code: 409")
+}
+
+func TestExpectRanges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+	}))
+	defer ts.Close()
+	tests := []struct {
+		code int
+		opt  RequestOption
+		ok   bool
+	}{
+		{204, ExpectSuccess(), true},
+		{304, ExpectSuccess(), false},
+		{304, Expect2xx3xx(), true},
+		{404, Expect2xx3xx(), false},
+		{418, ExpectRange(400, 499), true},
+		{500, ExpectRange(400, 499), false},
+	}
+	for _, tt := range tests {
+		_, err := Get(ts.URL, QueryInt("code", tt.code), tt.opt)
+		assert.Equal(t, tt.ok, err == nil, "%d", tt.code)
+	}
+	_, err := Get(ts.URL, QueryInt("code", 500), ExpectSuccess(), ExpectStatus(500))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// expectation checks a response that passed the status check
+type expectation func(*Response) error
+
+// ExpectationError is returned when a response doesn't meet an `ExpectHeader`,
+// `ExpectContentType` or `ExpectBodyContains` option. It wraps `ErrExpectationFailed`
+// and one of `ErrHeaderMismatch`, `ErrContentTypeMismatch` or `ErrBodyMismatch`
+type ExpectationError struct {
+	Method string
+	URL    string
+	// Expectation names what was checked, like `header X-Cache`
+	Expectation string
+	Want        string
+	Got         string
+	Err         error
+}
+
+func (e *ExpectationError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s: want %q, got %q", e.Method, e.URL, e.Err, e.Expectation, e.Want, e.Got)
+}
+
+// Unwrap returns `ErrExpectationFailed` and the specific mismatch error
+func (e *ExpectationError) Unwrap() []error {
+	return []error{ErrExpectationFailed, e.Err}
+}
+
+// ExpectHeader expects the response header key to have value. An empty value only
+// requires the header to be present
+func ExpectHeader(key, value string) RequestOption {
+	return expect(func(res *Response) error {
+		values := res.Headers.Values(key)
+		for _, v := range values {
+			if value == "" || v == value {
+				return nil
+			}
+		}
+		return &ExpectationError{Expectation: "header " + http.CanonicalHeaderKey(key), Want: value, Got: strings.Join(values, ", "), Err: ErrHeaderMismatch}
+	})
+}
+
+// ExpectContentType expects the media type of the response to be ct. Parameters like
+// `charset` are ignored on both sides
+func ExpectContentType(ct string) RequestOption {
+	want := mediaType(ct)
+	return expect(func(res *Response) error {
+		got := res.Headers.Get("Content-Type")
+		if mediaType(got) == want {
+			return nil
+		}
+		return &ExpectationError{Expectation: "content type", Want: want, Got: got, Err: ErrContentTypeMismatch}
+	})
+}
+
+// ExpectBodyContains expects the response body to contain substr. It isn't checked
+// with `IncludeRawResponse` since the body isn't read
+func ExpectBodyContains(substr string) RequestOption {
+	return expect(func(res *Response) error {
+		if res.Raw != nil || bytes.Contains(res.Body, []byte(substr)) {
+			return nil
+		}
+		body := res.Body
+		if len(body) > maxStatusErrorBody {
+			body = body[:maxStatusErrorBody]
+		}
+		return &ExpectationError{Expectation: "body", Want: substr, Got: string(body), Err: ErrBodyMismatch}
+	})
+}
+
+func expect(fn expectation) RequestOption {
+	return func(r *Request) error {
+		r.expectations = append(r.expectations, fn)
+		return nil
+	}
+}
+
+// mediaType returns the lower cased media type of a content type without parameters
+func mediaType(ct string) string {
+	if mt, _, err := mime.ParseMediaType(ct); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(ct))
+}
+
+// checkExpectations runs every expectation against res and joins the failures
+func (cr *Request) checkExpectations(req *http.Request, res *Response) error {
+	var errs []error
+	for _, check := range cr.expectations {
+		err := check(res)
+		var eerr *ExpectationError
+		if errors.As(err, &eerr) {
+			eerr.Method, eerr.URL = req.Method, req.URL.Redacted()
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Add("X-Cache", "HIT")
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+}
+
+func TestExpectationsPass(t *testing.T) {
+	ts := expectServer()
+	defer ts.Close()
+	res, err := Get(ts.URL,
+		ExpectHeader("x-cache", "HIT"),
+		ExpectHeader("X-Cache", ""),
+		ExpectContentType("application/JSON"),
+		ExpectBodyContains(`"ok"`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+}
+
+func TestExpectHeaderMismatch(t *testing.T) {
+	ts := expectServer()
+	defer ts.Close()
+	res, err := Get(ts.URL, ExpectHeader("X-Cache", "MISS"))
+	assert.NotNil(t, res)
+	assert.True(t, errors.Is(err, ErrExpectationFailed))
+	assert.True(t, errors.Is(err, ErrHeaderMismatch))
+	var eerr *ExpectationError
+	require.True(t, errors.As(err, &eerr))
+	assert.Equal(t, "GET", eerr.Method)
+	assert.Equal(t, "header X-Cache", eerr.Expectation)
+	assert.Equal(t, "MISS", eerr.Want)
+	assert.Equal(t, "HIT", eerr.Got)
+
+	_, err = Get(ts.URL, ExpectHeader("X-Missing", ""))
+	assert.True(t, errors.Is(err, ErrHeaderMismatch))
+}
+
+func TestExpectContentTypeMismatch(t *testing.T) {
+	ts := expectServer()
+	defer ts.Close()
+	_, err := Get(ts.URL, ExpectContentType(ContentTypeXML))
+	assert.True(t, errors.Is(err, ErrContentTypeMismatch))
+	assert.Contains(t, err.Error(), `want "application/xml", got "application/json; charset=utf-8"`)
+}
+
+func TestExpectBodyContainsMismatch(t *testing.T) {
+	ts := expectServer()
+	defer ts.Close()
+	_, err := Get(ts.URL, ExpectBodyContains("healthy"), ExpectContentType(ContentTypeXML))
+	assert.True(t, errors.Is(err, ErrBodyMismatch))
+	assert.True(t, errors.Is(err, ErrContentTypeMismatch))
+}
+
+func TestExpectationsAfterStatus(t *testing.T) {
+	ts := expectServer()
+	defer ts.Close()
+	_, err := Get(ts.URL, ExpectStatus(http.StatusCreated), ExpectBodyContains("healthy"))
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+	assert.False(t, errors.Is(err, ErrExpectationFailed))
+}
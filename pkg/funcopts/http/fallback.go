@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// circuitThreshold is the number of consecutive failures that opens a circuit
+	circuitThreshold = 3
+	// circuitCooldown is how long an open circuit skips its url before trying it again
+	circuitCooldown = 30 * time.Second
+)
+
+// Fallback retries a request against each of urls in order when it fails with a
+// connection error or a 5xx response. Only the scheme and host of urls are used.
+// Urls that keep failing are skipped for a while; the state is shared by every
+// request made with the option
+func Fallback(urls ...string) RequestOption {
+	f := &fallback{circuits: make(map[string]*circuit)}
+	var parseErr error
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			parseErr = err
+			break
+		}
+		f.urls = append(f.urls, u)
+	}
+	return func(r *Request) error {
+		if parseErr != nil {
+			return parseErr
+		}
+		r.fallback = f
+		return nil
+	}
+}
+
+type fallback struct {
+	urls     []*url.URL
+	circuits map[string]*circuit
+	sync.Mutex
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// available reports whether requests to host should be attempted
+func (f *fallback) available(host string) bool {
+	f.Lock()
+	defer f.Unlock()
+	c, ok := f.circuits[host]
+	return !ok || c.failures < circuitThreshold || !time.Now().Before(c.openUntil)
+}
+
+func (f *fallback) record(host string, failed bool) {
+	f.Lock()
+	defer f.Unlock()
+	c, ok := f.circuits[host]
+	if !ok {
+		c = &circuit{}
+		f.circuits[host] = c
+	}
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= circuitThreshold {
+		c.openUntil = time.Now().Add(circuitCooldown)
+	}
+}
+
+type fallbackTransport struct {
+	fallback *fallback
+	next     http.RoundTripper
+}
+
+func (t *fallbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := []*http.Request{req}
+	for _, u := range t.fallback.urls {
+		out := req.Clone(req.Context())
+		out.URL.Scheme = u.Scheme
+		out.URL.Host = u.Host
+		out.Host = ""
+		attempts = append(attempts, out)
+	}
+	var available []*http.Request
+	for _, attempt := range attempts {
+		if t.fallback.available(attempt.URL.Host) {
+			available = append(available, attempt)
+		}
+	}
+	if len(available) == 0 {
+		available = attempts
+	}
+
+	var resp *http.Response
+	var err error
+	for i, attempt := range available {
+		if i > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				break
+			}
+			if attempt.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = t.next.RoundTrip(attempt)
+		failed := err != nil || resp.StatusCode >= 500
+		t.fallback.record(attempt.URL.Host, failed)
+		if !failed {
+			return resp, nil
+		}
+	}
+	return resp, err
+}
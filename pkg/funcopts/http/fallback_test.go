@@ -0,0 +1,74 @@
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallback(t *testing.T) {
+	failing := http.StatusInternalServerError
+	primary, secondary := replica("primary", &failing), replica("secondary", nil)
+	defer primary.Close()
+	defer secondary.Close()
+	res, err := Get(primary.URL+"/users", Fallback(secondary.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, "secondary/users", string(res.Body))
+}
+
+func TestFallbackConnectionError(t *testing.T) {
+	primary, secondary := replica("primary", nil), replica("secondary", nil)
+	defer secondary.Close()
+	primary.Close()
+	res, err := Get(primary.URL, Fallback(secondary.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, "secondary/", string(res.Body))
+}
+
+func TestFallbackBody(t *testing.T) {
+	failing := http.StatusBadGateway
+	primary := replica("primary", &failing)
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer secondary.Close()
+	res, err := Post(primary.URL, WithBody(bytes.NewBufferString("payload")), Fallback(secondary.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(res.Body))
+}
+
+func TestFallbackAllFail(t *testing.T) {
+	failing := http.StatusServiceUnavailable
+	primary, secondary := replica("primary", &failing), replica("secondary", &failing)
+	defer primary.Close()
+	defer secondary.Close()
+	res, err := Get(primary.URL, Fallback(secondary.URL))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Status)
+	assert.Equal(t, "secondary/", string(res.Body))
+}
+
+func TestFallbackCircuit(t *testing.T) {
+	var hits int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+	secondary := replica("secondary", nil)
+	defer secondary.Close()
+	c := NewClient(Fallback(secondary.URL))
+	for i := 0; i < circuitThreshold+2; i++ {
+		res, err := c.Get(primary.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, "secondary/", string(res.Body))
+	}
+	assert.Equal(t, int32(circuitThreshold), atomic.LoadInt32(&hits))
+}
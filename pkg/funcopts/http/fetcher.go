@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// Job is a request for a `Fetcher` to send. Either URL is fetched with Options or
+// Request, a request prepared with `New`, is sent
+type Job struct {
+	URL     string
+	Options []RequestOption
+	Request *Request
+}
+
+// FetchResult is the outcome of a `Job`
+type FetchResult struct {
+	Job      Job
+	Response *Response
+	Err      error
+}
+
+// Fetcher sends jobs with a bounded number of workers, waiting between requests to
+// the same host so crawls and batch syncs don't overload a server
+//
+//	f := NewFetcher(NewClient(), Workers(8), PolitenessDelay(time.Second))
+//	for res := range f.Run(ctx, jobs) {
+//		fmt.Println(res.Job.URL, res.Err)
+//	}
+type Fetcher struct {
+	client  *Client
+	workers int
+	delay   time.Duration
+	gates   sync.Map
+}
+
+// FetcherOption configures a `Fetcher`
+type FetcherOption func(*Fetcher)
+
+// Workers sets the number of jobs sent at once. The default is 4
+func Workers(n int) FetcherOption {
+	return func(f *Fetcher) {
+		f.workers = n
+	}
+}
+
+// PolitenessDelay sets the minimum time between the start of requests to the same host
+func PolitenessDelay(d time.Duration) FetcherOption {
+	return func(f *Fetcher) {
+		f.delay = d
+	}
+}
+
+// NewFetcher returns a `Fetcher` that sends requests with c, or a new client when c is nil
+func NewFetcher(c *Client, opts ...FetcherOption) *Fetcher {
+	if c == nil {
+		c = NewClient()
+	}
+	f := &Fetcher{client: c, workers: 4}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.workers < 1 {
+		f.workers = 1
+	}
+	return f
+}
+
+// hostGate spaces the requests to one host
+type hostGate struct {
+	next time.Time
+	sync.Mutex
+}
+
+// reserve returns how long to wait before a request can start at now
+func (g *hostGate) reserve(now time.Time, delay time.Duration) time.Duration {
+	g.Lock()
+	defer g.Unlock()
+	if g.next.Before(now) {
+		g.next = now
+	}
+	wait := g.next.Sub(now)
+	g.next = g.next.Add(delay)
+	return wait
+}
+
+// Run sends every job received on jobs and returns their results in the order they finish.
+// The results channel is closed once jobs is closed and drained, or ctx is done. Requests
+// are sent with ctx and wait with its clock
+func (f *Fetcher) Run(ctx context.Context, jobs <-chan Job) <-chan FetchResult {
+	results := make(chan FetchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < f.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job, ok := <-jobs:
+					if !ok {
+						return
+					}
+					res := f.fetch(ctx, job)
+					select {
+					case results <- res:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// FetchAll sends a GET for every url and collects the results in the order of urls.
+// Urls not fetched before ctx is done have its error
+func (f *Fetcher) FetchAll(ctx context.Context, urls ...string) []FetchResult {
+	jobs := make(chan Job)
+	go func() {
+		defer close(jobs)
+		for _, u := range urls {
+			select {
+			case jobs <- Job{URL: u}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	index := make(map[string][]int, len(urls))
+	for i, u := range urls {
+		index[u] = append(index[u], i)
+	}
+	out := make([]FetchResult, len(urls))
+	for res := range f.Run(ctx, jobs) {
+		i := index[res.Job.URL][0]
+		index[res.Job.URL] = index[res.Job.URL][1:]
+		out[i] = res
+	}
+	for i := range out {
+		if out[i].Response == nil && out[i].Err == nil {
+			out[i] = FetchResult{Job: Job{URL: urls[i]}, Err: ctx.Err()}
+		}
+	}
+	return out
+}
+
+func (f *Fetcher) fetch(ctx context.Context, job Job) FetchResult {
+	result := FetchResult{Job: job}
+	req := job.Request
+	if req == nil {
+		var err error
+		opts := append([]RequestOption{get(), setURL(job.URL)}, job.Options...)
+		if req, _, err = f.client.New(opts...); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	if f.delay > 0 {
+		if err := f.wait(ctx, req); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	result.Response, result.Err = req.Send(ctx)
+	return result
+}
+
+// wait sleeps until the politeness delay for the request's host has passed
+func (f *Fetcher) wait(ctx context.Context, req *Request) error {
+	u, err := req.resolveURL()
+	if err != nil {
+		return err
+	}
+	gate, _ := f.gates.LoadOrStore(u.Host, &hostGate{})
+	clk := clock.FromContext(ctx)
+	return clk.Sleep(ctx, gate.(*hostGate).reserve(clk.Now(), f.delay))
+}
@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcherRun(t *testing.T) {
+	var active, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+	defer ts.Close()
+	prepared, _, err := New(Method("DELETE"), URL(ts.URL+"/prepared"))
+	require.NoError(t, err)
+	jobs := make(chan Job)
+	go func() {
+		defer close(jobs)
+		for _, p := range []string{"/a", "/b", "/c", "/d", "/e"} {
+			jobs <- Job{URL: ts.URL + p}
+		}
+		jobs <- Job{URL: ts.URL + "/posted", Options: []RequestOption{post()}}
+		jobs <- Job{Request: prepared}
+	}()
+	f := NewFetcher(nil, Workers(2))
+	got := map[string]string{}
+	for res := range f.Run(context.Background(), jobs) {
+		require.NoError(t, res.Err)
+		key := res.Job.URL
+		if res.Job.Request != nil {
+			key = "prepared"
+		}
+		got[key] = string(res.Response.Body)
+	}
+	assert.Len(t, got, 7)
+	assert.Equal(t, "GET /c", got[ts.URL+"/c"])
+	assert.Equal(t, "POST /posted", got[ts.URL+"/posted"])
+	assert.Equal(t, "DELETE /prepared", got["prepared"])
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+}
+
+func TestFetcherFetchAll(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer ts.Close()
+	urls := []string{ts.URL + "/1", ts.URL + "/2", ts.URL + "/1", "http://127.0.0.1:0/"}
+	results := NewFetcher(nil, Workers(3)).FetchAll(context.Background(), urls...)
+	require.Len(t, results, 4)
+	assert.Equal(t, "/1", string(results[0].Response.Body))
+	assert.Equal(t, "/2", string(results[1].Response.Body))
+	assert.Equal(t, "/1", string(results[2].Response.Body))
+	assert.Equal(t, urls[3], results[3].Job.URL)
+	assert.Error(t, results[3].Err)
+}
+
+func TestFetcherPolitenessDelay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx := clock.NewContext(context.Background(), fake)
+	f := NewFetcher(nil, Workers(1), PolitenessDelay(time.Second))
+	results := f.FetchAll(ctx, ts.URL+"/1", ts.URL+"/2", ts.URL+"/3")
+	for _, res := range results {
+		assert.NoError(t, res.Err)
+	}
+	assert.Equal(t, []time.Duration{0, time.Second, time.Second}, fake.Sleeps())
+}
+
+func TestHostGateReserve(t *testing.T) {
+	g := &hostGate{}
+	now := time.Unix(100, 0)
+	assert.Equal(t, time.Duration(0), g.reserve(now, time.Second))
+	assert.Equal(t, time.Second, g.reserve(now, time.Second))
+	assert.Equal(t, 2*time.Second, g.reserve(now, time.Second))
+	assert.Equal(t, time.Duration(0), g.reserve(now.Add(time.Minute), time.Second))
+}
+
+func TestFetcherCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := NewFetcher(nil).FetchAll(ctx, "http://example.com/a", "http://example.com/b")
+	for _, res := range results {
+		assert.True(t, errors.Is(res.Err, context.Canceled), "%v", res.Err)
+	}
+}
@@ -0,0 +1,316 @@
+// Package forms fills in and submits html forms, for automating web interfaces
+// that have no api. Hidden fields like CSRF tokens are kept from the page
+//
+//	jar, _ := cookiejar.New(nil)
+//	c := httpclient.NewClient(httpclient.SetCookieJar(jar))
+//	form, err := forms.Get(ctx, c, "https://legacy.example.com/login", "login")
+//	form.Set("username", "lusis").Set("password", secret)
+//	res, err := form.Submit(ctx)
+package forms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"golang.org/x/net/html"
+)
+
+// ErrFormNotFound is the error returned when a page has no form with the name or id
+var ErrFormNotFound = errors.New("form not found")
+
+const (
+	// EnctypeURLEncoded is the default encoding of form bodies
+	EnctypeURLEncoded = "application/x-www-form-urlencoded"
+	// EnctypeMultipart is the encoding of forms that upload files
+	EnctypeMultipart = "multipart/form-data"
+)
+
+// File is a file to upload with a multipart form
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Form is a parsed html form
+type Form struct {
+	// Action is the absolute url the form submits to
+	Action string
+	// Method is GET or POST
+	Method  string
+	Enctype string
+	// Values are the fields that would be submitted, starting with their values on the page
+	Values url.Values
+	Files  map[string]File
+	// Page is the url of the page the form was on, sent as the `Referer`
+	Page   string
+	client *httpclient.Client
+}
+
+// Get fetches pageURL with c and parses the form with the name or id. An empty name
+// selects the first form on the page. c should carry a cookie jar set with
+// `httpclient.SetCookieJar` so the session cookies set by the page are sent with the
+// submission; a nil c uses a new client with its own jar
+func Get(ctx context.Context, c *httpclient.Client, pageURL, name string) (*Form, error) {
+	if c == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		c = httpclient.NewClient(httpclient.SetCookieJar(jar))
+	}
+	res, err := c.Get(pageURL, httpclient.WithContext(ctx), httpclient.ExpectSuccess())
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(res.URL)
+	if err != nil {
+		return nil, err
+	}
+	form, err := Parse(base, res.Body, name)
+	if err != nil {
+		return nil, err
+	}
+	form.client = c
+	return form, nil
+}
+
+// Parse finds the form with the name or id in an html page at base
+func Parse(base *url.URL, page []byte, name string) (*Form, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	node := findForm(doc, name)
+	if node == nil {
+		return nil, fmt.Errorf("%q: %w", name, ErrFormNotFound)
+	}
+	action, err := base.Parse(attr(node, "action"))
+	if err != nil {
+		return nil, err
+	}
+	action.Fragment = ""
+	form := &Form{
+		Action:  action.String(),
+		Method:  strings.ToUpper(attr(node, "method")),
+		Enctype: strings.ToLower(attr(node, "enctype")),
+		Values:  url.Values{},
+		Files:   map[string]File{},
+		Page:    base.String(),
+	}
+	if form.Method != "POST" {
+		form.Method = "GET"
+	}
+	if form.Enctype != EnctypeMultipart {
+		form.Enctype = EnctypeURLEncoded
+	}
+	form.collect(node)
+	return form, nil
+}
+
+// Set replaces the values of the field name
+func (f *Form) Set(name, value string) *Form {
+	f.Values.Set(name, value)
+	return f
+}
+
+// Add adds a value to the field name, like a second checked checkbox
+func (f *Form) Add(name, value string) *Form {
+	f.Values.Add(name, value)
+	return f
+}
+
+// Remove drops the field name, like an unchecked checkbox
+func (f *Form) Remove(name string) *Form {
+	f.Values.Del(name)
+	return f
+}
+
+// SetFile uploads content as filename in the field name. The form is sent as multipart
+func (f *Form) SetFile(name, filename string, content []byte) *Form {
+	f.Files[name] = File{Name: filename, Content: content}
+	f.Enctype = EnctypeMultipart
+	return f
+}
+
+// Submit sends the form with the method and encoding from the page, using the client
+// it was fetched with. opts are applied after the form's options
+func (f *Form) Submit(ctx context.Context, opts ...httpclient.RequestOption) (*httpclient.Response, error) {
+	reqOpts, err := f.Options()
+	if err != nil {
+		return nil, err
+	}
+	c := f.client
+	if c == nil {
+		c = httpclient.NewClient()
+	}
+	req, _, err := c.New(append(reqOpts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return req.Send(ctx)
+}
+
+// Options returns the request options that submit the form
+func (f *Form) Options() ([]httpclient.RequestOption, error) {
+	opts := []httpclient.RequestOption{httpclient.Method(f.Method)}
+	if f.Page != "" {
+		opts = append(opts, httpclient.AddHeaders(map[string]string{"Referer": f.Page}))
+	}
+	if f.Method == "GET" {
+		u, err := url.Parse(f.Action)
+		if err != nil {
+			return nil, err
+		}
+		u.RawQuery = f.Values.Encode()
+		return append(opts, httpclient.URL(u.String())), nil
+	}
+	opts = append(opts, httpclient.URL(f.Action))
+	if f.Enctype != EnctypeMultipart {
+		body := f.Values.Encode()
+		return append(opts, httpclient.ContentType(EnctypeURLEncoded), httpclient.WithBody(strings.NewReader(body))), nil
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, values := range f.Values {
+		for _, v := range values {
+			if err := mw.WriteField(name, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, file := range f.Files {
+		w, err := mw.CreateFormFile(name, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return append(opts, httpclient.ContentType(mw.FormDataContentType()), httpclient.WithBody(bytes.NewReader(buf.Bytes()))), nil
+}
+
+func findForm(n *html.Node, name string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == "form" {
+		if name == "" || attr(n, "name") == name || attr(n, "id") == name {
+			return n
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findForm(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// collect adds the value each field under n would submit, like a browser would
+// before any input
+func (f *Form) collect(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		name := attr(child, "name")
+		if name == "" || hasAttr(child, "disabled") {
+			f.collect(child)
+			continue
+		}
+		switch child.Data {
+		case "input":
+			switch strings.ToLower(attr(child, "type")) {
+			case "submit", "button", "image", "reset", "file":
+			case "checkbox", "radio":
+				if hasAttr(child, "checked") {
+					value := attr(child, "value")
+					if !hasAttr(child, "value") {
+						value = "on"
+					}
+					f.Values.Add(name, value)
+				}
+			default:
+				f.Values.Add(name, attr(child, "value"))
+			}
+		case "textarea":
+			f.Values.Add(name, strings.TrimPrefix(text(child), "\n"))
+		case "select":
+			f.selectValues(child, name)
+		default:
+			f.collect(child)
+		}
+	}
+}
+
+// selectValues adds the selected options, or the first option of a single select
+func (f *Form) selectValues(n *html.Node, name string) {
+	var options []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Data == "option" {
+				options = append(options, child)
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	value := func(o *html.Node) string {
+		if hasAttr(o, "value") {
+			return attr(o, "value")
+		}
+		return strings.TrimSpace(text(o))
+	}
+	selected := false
+	for _, o := range options {
+		if hasAttr(o, "selected") && !hasAttr(o, "disabled") {
+			f.Values.Add(name, value(o))
+			selected = true
+		}
+	}
+	if !selected && !hasAttr(n, "multiple") && len(options) > 0 {
+		f.Values.Add(name, value(options[0]))
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
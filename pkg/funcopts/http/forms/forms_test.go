@@ -0,0 +1,132 @@
+package forms
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const loginPage = `<html><body>
+<form id="search" action="/search"><input name="q" value="default"><input type="submit" name="go" value="Go"></form>
+<form name="login" method="post" action="/session#done">
+  <input type="hidden" name="csrf" value="token-123">
+  <input name="username">
+  <input type="password" name="password">
+  <input type="checkbox" name="remember">
+  <input type="checkbox" name="terms" value="yes" checked>
+  <input name="ignored" disabled value="x">
+  <select name="lang"><option value="en">English</option><option value="fr" selected>French</option></select>
+  <select name="theme"><option>light</option><option>dark</option></select>
+  <textarea name="note">
+hello</textarea>
+  <button type="submit" name="action" value="login">Log in</button>
+</form>
+<form name="upload" method="POST" action="upload" enctype="multipart/form-data">
+  <input type="hidden" name="csrf" value="token-456">
+  <input type="file" name="doc">
+</form>
+</body></html>`
+
+func formServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(loginPage))
+		case "/session":
+			cookie, err := r.Cookie("session")
+			if err != nil || cookie.Value != "abc" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			assert.Equal(t, "POST", r.Method)
+			assert.True(t, len(r.Referer()) > 0)
+			require.NoError(t, r.ParseForm())
+			w.Write([]byte(r.PostForm.Encode()))
+		case "/search":
+			w.Write([]byte(r.Method + " " + r.URL.RawQuery))
+		case "/upload":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			file, header, err := r.FormFile("doc")
+			require.NoError(t, err)
+			data, _ := ioutil.ReadAll(file)
+			w.Write([]byte(r.FormValue("csrf") + " " + header.Filename + " " + string(data)))
+		}
+	}))
+}
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/account/login")
+	form, err := Parse(base, []byte(loginPage), "login")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/session", form.Action)
+	assert.Equal(t, "POST", form.Method)
+	assert.Equal(t, EnctypeURLEncoded, form.Enctype)
+	assert.Equal(t, url.Values{
+		"csrf":     {"token-123"},
+		"username": {""},
+		"password": {""},
+		"terms":    {"yes"},
+		"lang":     {"fr"},
+		"theme":    {"light"},
+		"note":     {"hello"},
+	}, form.Values)
+
+	form, err = Parse(base, []byte(loginPage), "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/search", form.Action)
+	assert.Equal(t, "GET", form.Method)
+
+	form, err = Parse(base, []byte(loginPage), "upload")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/account/upload", form.Action)
+	assert.Equal(t, EnctypeMultipart, form.Enctype)
+
+	_, err = Parse(base, []byte(loginPage), "missing")
+	assert.True(t, errors.Is(err, ErrFormNotFound))
+}
+
+func TestSubmitWithSessionCookie(t *testing.T) {
+	ts := formServer(t)
+	defer ts.Close()
+	ctx := context.Background()
+	form, err := Get(ctx, nil, ts.URL+"/login", "login")
+	require.NoError(t, err)
+	res, err := form.Set("username", "lusis").Set("password", "secret").Add("remember", "on").Remove("note").Submit(ctx)
+	require.NoError(t, err)
+	got, err := url.ParseQuery(string(res.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "token-123", got.Get("csrf"))
+	assert.Equal(t, "lusis", got.Get("username"))
+	assert.Equal(t, "secret", got.Get("password"))
+	assert.Equal(t, "on", got.Get("remember"))
+	assert.Empty(t, got["note"])
+}
+
+func TestSubmitGet(t *testing.T) {
+	ts := formServer(t)
+	defer ts.Close()
+	form, err := Get(context.Background(), nil, ts.URL+"/login", "search")
+	require.NoError(t, err)
+	res, err := form.Set("q", "go experiments").Submit(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "GET q=go+experiments", string(res.Body))
+}
+
+func TestSubmitMultipart(t *testing.T) {
+	ts := formServer(t)
+	defer ts.Close()
+	form, err := Get(context.Background(), nil, ts.URL+"/login", "upload")
+	require.NoError(t, err)
+	res, err := form.SetFile("doc", "notes.txt", []byte("file body")).Submit(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-456 notes.txt file body", string(res.Body))
+}
@@ -0,0 +1,207 @@
+// Package golden snapshots responses to golden files and compares later
+// responses against them
+//
+//	func TestMain(m *testing.M) {
+//		golden.RegisterFlag()
+//		os.Exit(m.Run())
+//	}
+//
+//	func TestUsers(t *testing.T) {
+//		res, err := httpclient.Get(url)
+//		golden.Assert(t, "users", res, golden.Headers("Content-Type"), golden.Ignore("created_at"))
+//	}
+//
+// Run `go test -update` to write the golden files
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// Update writes golden files instead of comparing against them. It is set by
+// `UPDATE_GOLDEN` or the flag added with `RegisterFlag`
+var Update = os.Getenv("UPDATE_GOLDEN") != ""
+
+// ignored replaces the values of ignored json fields
+const ignored = "<ignored>"
+
+// RegisterFlag adds the `-update` flag that sets `Update`. Call it from TestMain
+// or an init function in a test package
+func RegisterFlag() {
+	flag.BoolVar(&Update, "update", Update, "update golden files")
+}
+
+// Option configures a snapshot
+type Option func(*snapshot)
+
+// Headers includes the named response headers in the snapshot
+func Headers(names ...string) Option {
+	return func(s *snapshot) {
+		s.headers = append(s.headers, names...)
+	}
+}
+
+// Ignore replaces the values of json fields, given as dot separated paths like
+// `user.created_at`, so values that change between runs don't fail the comparison.
+// A path element of `*` matches every element of an array or object
+func Ignore(paths ...string) Option {
+	return func(s *snapshot) {
+		s.ignore = append(s.ignore, paths...)
+	}
+}
+
+// Dir stores golden files in dir instead of `testdata`
+func Dir(dir string) Option {
+	return func(s *snapshot) {
+		s.dir = dir
+	}
+}
+
+type snapshot struct {
+	dir     string
+	headers []string
+	ignore  []string
+}
+
+// Assert compares res with the golden file `name.golden`, or writes it when
+// `Update` is set. Json bodies are indented with sorted keys
+func Assert(t testing.TB, name string, res *httpclient.Response, opts ...Option) {
+	t.Helper()
+	if err := check(name, res, opts...); err != nil {
+		t.Errorf("golden %s: %v", name, err)
+	}
+}
+
+func check(name string, res *httpclient.Response, opts ...Option) error {
+	s := &snapshot{dir: "testdata"}
+	for _, opt := range opts {
+		opt(s)
+	}
+	got, err := s.render(res)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, name+".golden")
+	if Update {
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, got, 0644)
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w (run with -update to create it)", err)
+	}
+	if !bytes.Equal(want, got) {
+		return fmt.Errorf("response differs from %s (-want +got):\n%s", path, diff(string(want), string(got)))
+	}
+	return nil
+}
+
+func (s *snapshot) render(res *httpclient.Response) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "status: %d\n", res.Status)
+	names := append([]string{}, s.headers...)
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range res.Headers.Values(name) {
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+	b.WriteString("\n")
+	body, err := s.normalize(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.Write(body)
+	if len(body) > 0 && body[len(body)-1] != '\n' {
+		b.WriteString("\n")
+	}
+	return b.Bytes(), nil
+}
+
+// normalize indents json bodies and replaces ignored fields. Other bodies are returned as is
+func (s *snapshot) normalize(body []byte) ([]byte, error) {
+	var v interface{}
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &v) != nil {
+		return body, nil
+	}
+	for _, path := range s.ignore {
+		v = replace(v, strings.Split(path, "."))
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(v)
+	return b.Bytes(), err
+}
+
+func replace(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return ignored
+	}
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for k, child := range node {
+			if path[0] == "*" || path[0] == k {
+				node[k] = replace(child, path[1:])
+			}
+		}
+	case []interface{}:
+		for i, child := range node {
+			if path[0] == "*" || path[0] == fmt.Sprint(i) {
+				node[i] = replace(child, path[1:])
+			}
+		}
+	}
+	return v
+}
+
+// diff returns a line diff of want and got
+func diff(want, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,46 @@
+package golden
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/testsupport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssert(t *testing.T) {
+	bin := testsupport.HTTPBin(t)
+	res, err := httpclient.Post(bin.URL+"/post", httpclient.JSON(), httpclient.WithBody(strings.NewReader(`{"b":2,"a":1}`)))
+	assert.NoError(t, err)
+	Assert(t, "post", res, Headers("Content-Type"), Ignore("headers", "origin", "url"))
+}
+
+func TestAssertUpdate(t *testing.T) {
+	dir := t.TempDir()
+	res := &httpclient.Response{Status: 200, Headers: http.Header{}, Body: []byte(`{"id":1,"created":"now"}`)}
+	assert.Error(t, check("created", res, Dir(dir)))
+	Update = true
+	assert.NoError(t, check("created", res, Dir(dir), Ignore("created")))
+	Update = false
+	assert.NoError(t, check("created", res, Dir(dir), Ignore("created")))
+
+	res.Body = []byte(`{"id":2,"created":"later"}`)
+	err := check("created", res, Dir(dir), Ignore("created"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `-   "id": 1`)
+	assert.Contains(t, err.Error(), `+   "id": 2`)
+}
+
+func TestDiff(t *testing.T) {
+	assert.Equal(t, "  a\n- b\n+ c\n  d\n", diff("a\nb\nd", "a\nc\nd"))
+}
+
+func TestIgnoreWildcard(t *testing.T) {
+	s := &snapshot{ignore: []string{"items.*.id"}}
+	out, err := s.normalize([]byte(`{"items":[{"id":1,"name":"a"},{"id":2,"name":"b"}]}`))
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"id": "<ignored>"`)
+	assert.Contains(t, string(out), `"name": "b"`)
+}
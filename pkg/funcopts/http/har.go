@@ -0,0 +1,307 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// HARRecorder accumulates requests and responses as HTTP Archive (HAR 1.2) entries
+type HARRecorder struct {
+	entries []HAREntry
+	sync.Mutex
+}
+
+// HAREntry is a single request/response pair in a HAR log
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request part of a `HAREntry`
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	Cookies     []HARNameValue `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+}
+
+// HARResponse is the response part of a `HAREntry`
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, query param or cookie in a HAR log
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is a request body in a HAR log
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding is `base64` when the body isn't valid UTF-8, as for `HARContent`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARContent is a response body in a HAR log
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	// Encoding is `base64` when the body isn't valid UTF-8
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are the timings of a `HAREntry` in milliseconds
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NewHARRecorder returns an empty `HARRecorder`
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+// RecordHAR adds every request and response to rec. Redacted headers, query params and
+// cookie values are recorded as `transport.Redacted`
+func RecordHAR(rec *HARRecorder) RequestOption {
+	return func(r *Request) error {
+		r.har = rec
+		return nil
+	}
+}
+
+// Entries returns the recorded entries
+func (h *HARRecorder) Entries() []HAREntry {
+	h.Lock()
+	defer h.Unlock()
+	return append([]HAREntry{}, h.entries...)
+}
+
+// WriteTo writes the recorded entries to w as a HAR document
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	doc := map[string]interface{}{
+		"log": map[string]interface{}{
+			"version": "1.2",
+			"creator": map[string]string{"name": "go-experiments-httpclient", "version": "1.0"},
+			"entries": h.Entries(),
+		},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile writes the recorded entries to a .har file at path
+func (h *HARRecorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := h.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *HARRecorder) add(e HAREntry) {
+	h.Lock()
+	defer h.Unlock()
+	h.entries = append(h.entries, e)
+}
+
+type harTransport struct {
+	recorder    *HARRecorder
+	redact      []string
+	redactQuery []string
+	next        http.RoundTripper
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the request body is kept as it's sent rather than read up front
+	var reqBody *harBody
+	if req.Body != nil && req.Body != http.NoBody {
+		reqBody = &harBody{ReadCloser: req.Body}
+		req = req.Clone(req.Context())
+		req.Body = reqBody
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+
+	entry := HAREntry{
+		StartedDateTime: start,
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         transport.RedactURL(req.URL, t.redactQuery...),
+			HTTPVersion: req.Proto,
+			Headers:     harHeaders(transport.RedactHeader(req.Header, t.redact...)),
+			QueryString: []HARNameValue{},
+			Cookies:     []HARNameValue{},
+			HeadersSize: -1,
+		},
+		Response: HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     harHeaders(transport.RedactHeader(resp.Header, t.redact...)),
+			Cookies:     []HARNameValue{},
+			Content:     HARContent{MimeType: resp.Header.Get("Content-Type")},
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+		},
+	}
+	query := req.URL.Query()
+	for _, name := range t.redactQuery {
+		if _, ok := query[name]; ok {
+			query.Set(name, transport.Redacted)
+		}
+	}
+	for k, vs := range query {
+		for _, v := range vs {
+			entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: k, Value: v})
+		}
+	}
+	for _, c := range req.Cookies() {
+		entry.Request.Cookies = append(entry.Request.Cookies, t.cookie("Cookie", c))
+	}
+	for _, c := range resp.Cookies() {
+		entry.Response.Cookies = append(entry.Response.Cookies, t.cookie("Set-Cookie", c))
+	}
+	resp.Body = &harBody{ReadCloser: resp.Body, done: func(body []byte, size int) {
+		receive := time.Since(start) - wait
+		entry.Time = milliseconds(wait + receive)
+		entry.Timings = HARTimings{Wait: milliseconds(wait), Receive: milliseconds(receive)}
+		entry.Response.Content.Size = size
+		entry.Response.Content.Text, entry.Response.Content.Encoding = harText(body)
+		entry.Response.BodySize = size
+		if reqBody != nil {
+			sent, size := reqBody.sent()
+			entry.Request.BodySize = size
+			entry.Request.PostData = &HARPostData{MimeType: req.Header.Get("Content-Type")}
+			entry.Request.PostData.Text, entry.Request.PostData.Encoding = harText(sent)
+		}
+		t.recorder.add(entry)
+	}}
+	return resp, nil
+}
+
+// harMaxBodySize is the most of a response body kept in a `HAREntry`
+const harMaxBodySize = 1 << 20
+
+// harBody passes a body through, keeping up to `harMaxBodySize` bytes of it, and calls
+// done, when set, once it's read to the end or closed
+type harBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	size int
+	once sync.Once
+	done func(body []byte, size int)
+	// mu guards buf and size for request bodies, which the transport may still be
+	// writing when the response is recorded
+	mu sync.Mutex
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	b.size += n
+	if keep := harMaxBodySize - b.buf.Len(); keep > 0 {
+		if keep > n {
+			keep = n
+		}
+		b.buf.Write(p[:keep])
+	}
+	b.mu.Unlock()
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *harBody) finish() {
+	if b.done != nil {
+		b.once.Do(func() { b.done(b.buf.Bytes(), b.size) })
+	}
+}
+
+// sent returns the part of the body kept so far and its size
+func (b *harBody) sent() ([]byte, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte{}, b.buf.Bytes()...), b.size
+}
+
+// harText returns body as HAR text, base64 encoded when it isn't valid UTF-8
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// cookie returns c as sent in header, with its value redacted when header is
+func (t *harTransport) cookie(header string, c *http.Cookie) HARNameValue {
+	for _, name := range t.redact {
+		if http.CanonicalHeaderKey(name) == header {
+			return HARNameValue{Name: c.Name, Value: transport.Redacted}
+		}
+	}
+	return HARNameValue{Name: c.Name, Value: c.Value}
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for k, vs := range h {
+		for _, v := range vs {
+			headers = append(headers, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return headers
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordHAR(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "got %s", body)
+	}))
+	defer ts.Close()
+	rec := NewHARRecorder()
+	res, err := Post(ts.URL, RecordHAR(rec), QueryParams(map[string]string{"foo": "bar"}),
+		ContentType("text/plain"), WithBody(strings.NewReader("har body")))
+	assert.NoError(t, err)
+	assert.Equal(t, "got har body", string(res.Body))
+
+	entries := rec.Entries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "POST", entries[0].Request.Method)
+	assert.Equal(t, "har body", entries[0].Request.PostData.Text)
+	assert.Equal(t, []HARNameValue{{Name: "foo", Value: "bar"}}, entries[0].Request.QueryString)
+	assert.Equal(t, 200, entries[0].Response.Status)
+	assert.Equal(t, "got har body", entries[0].Response.Content.Text)
+
+	path := filepath.Join(t.TempDir(), "requests.har")
+	assert.NoError(t, rec.WriteFile(path))
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	doc := struct {
+		Log struct {
+			Version string     `json:"version"`
+			Entries []HAREntry `json:"entries"`
+		} `json:"log"`
+	}{}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "1.2", doc.Log.Version)
+	assert.Len(t, doc.Log.Entries, 1)
+}
+
+func TestRecordHARRedacts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+	}))
+	defer ts.Close()
+	rec := NewHARRecorder()
+	_, err := Get(ts.URL, RecordHAR(rec), BearerToken("secret"), APIKey("key", InQuery("api_key")),
+		APIKey("cookie-secret", InCookie("token")))
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(rec.Entries())
+	assert.NoError(t, err)
+	for _, secret := range []string{"Bearer secret", "api_key=key", "cookie-secret", "server-secret"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	entry := rec.Entries()[0]
+	assert.Equal(t, []HARNameValue{{Name: "api_key", Value: "[REDACTED]"}}, entry.Request.QueryString)
+	assert.Equal(t, []HARNameValue{{Name: "token", Value: "[REDACTED]"}}, entry.Request.Cookies)
+	assert.Equal(t, []HARNameValue{{Name: "session", Value: "[REDACTED]"}}, entry.Response.Cookies)
+}
+
+func TestRecordHARCapsBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("a", harMaxBodySize+10))
+	}))
+	defer ts.Close()
+	rec := NewHARRecorder()
+	res, err := Get(ts.URL, RecordHAR(rec))
+	assert.NoError(t, err)
+	assert.Len(t, res.Body, harMaxBodySize+10)
+	entry := rec.Entries()[0]
+	assert.Equal(t, harMaxBodySize+10, entry.Response.Content.Size)
+	assert.Len(t, entry.Response.Content.Text, harMaxBodySize)
+}
+
+func TestRecordHARBinaryBodies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+	}))
+	defer ts.Close()
+	rec := NewHARRecorder()
+	large := append([]byte{0xff}, bytes.Repeat([]byte("a"), harMaxBodySize+10)...)
+	_, err := Post(ts.URL, RecordHAR(rec), WithBody(bytes.NewReader(large)))
+	assert.NoError(t, err)
+	entry := rec.Entries()[0]
+	assert.Equal(t, "base64", entry.Response.Content.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G', 0xff}), entry.Response.Content.Text)
+	assert.Equal(t, "base64", entry.Request.PostData.Encoding)
+	assert.Equal(t, len(large), entry.Request.BodySize)
+	sent, err := base64.StdEncoding.DecodeString(entry.Request.PostData.Text)
+	assert.NoError(t, err)
+	assert.Equal(t, large[:harMaxBodySize], sent)
+}
@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// HealthResult is the outcome of a `Healthcheck`
+type HealthResult struct {
+	URL string
+	// Reachable is true when the server sent a response, whatever its status
+	Reachable bool
+	// Healthy is true when the response passed the status check and any expectations
+	Healthy bool
+	Status  int
+	Latency time.Duration
+	// TLSExpiry is when the server certificate expires, zero for plain http
+	TLSExpiry time.Time
+	// TLSExpiryDays is the number of whole days left until TLSExpiry
+	TLSExpiryDays int
+	// Err is why the check wasn't healthy
+	Err       error
+	CheckedAt time.Time
+}
+
+// changed reports whether r is in a different state than prev
+func (r HealthResult) changed(prev HealthResult) bool {
+	return r.Reachable != prev.Reachable || r.Healthy != prev.Healthy || r.Status != prev.Status
+}
+
+// Healthcheck sends a GET to url and reports whether it is up. A 2xx status is expected
+// unless more are added with `ExpectStatus`, and `ExpectHeader`, `ExpectBodyContains` and
+// the like can make the check stricter
+//
+//	res := Healthcheck("https://api.example.com/healthz", Timeout(2*time.Second))
+//	if !res.Healthy || res.TLSExpiryDays < 14 { ... }
+func Healthcheck(url string, opts ...RequestOption) HealthResult {
+	result := HealthResult{URL: url, CheckedAt: time.Now()}
+	var mu sync.Mutex
+	var peer *http.Response
+	capture := OnResponse(func(resp *http.Response, attempt int) {
+		mu.Lock()
+		peer = resp
+		mu.Unlock()
+	})
+	checkOpts := append([]RequestOption{ExpectSuccess()}, opts...)
+	res, err := Get(url, append(checkOpts, capture)...)
+	result.Latency = time.Since(result.CheckedAt)
+	result.Err = err
+	result.Healthy = err == nil
+	if res != nil {
+		result.Reachable = true
+		result.Status = res.Status
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peer != nil && peer.TLS != nil && len(peer.TLS.PeerCertificates) > 0 {
+		result.TLSExpiry = peer.TLS.PeerCertificates[0].NotAfter
+		result.TLSExpiryDays = int(result.TLSExpiry.Sub(result.CheckedAt) / (24 * time.Hour))
+	}
+	return result
+}
+
+// WatchHealth runs a `Healthcheck` of url every interval until ctx is done. The first
+// result is sent on the returned channel, then only results where reachability, health
+// or status changed. The channel is closed when ctx is done
+func WatchHealth(ctx context.Context, url string, interval time.Duration, opts ...RequestOption) <-chan HealthResult {
+	ch := make(chan HealthResult)
+	clk := clock.FromContext(ctx)
+	checkOpts := append(append([]RequestOption{}, opts...), WithContext(ctx))
+	go func() {
+		defer close(ch)
+		var last HealthResult
+		for first := true; ; first = false {
+			result := Healthcheck(url, checkOpts...)
+			if ctx.Err() != nil {
+				return
+			}
+			if first || result.changed(last) {
+				select {
+				case ch <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = result
+			if err := clk.Sleep(ctx, interval); err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
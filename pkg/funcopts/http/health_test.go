@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthcheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+	res := Healthcheck(ts.URL, ExpectBodyContains("ok"))
+	assert.True(t, res.Reachable)
+	assert.True(t, res.Healthy)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.NoError(t, res.Err)
+	assert.True(t, res.Latency > 0)
+	assert.True(t, res.TLSExpiry.IsZero())
+}
+
+func TestHealthcheckTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	res := Healthcheck(ts.URL, SetClient(ts.Client()))
+	require.True(t, res.Healthy, "%v", res.Err)
+	cert := ts.Certificate()
+	assert.Equal(t, cert.NotAfter, res.TLSExpiry)
+	assert.Equal(t, int(time.Until(cert.NotAfter)/(24*time.Hour)), res.TLSExpiryDays)
+}
+
+func TestHealthcheckUnhealthy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	res := Healthcheck(ts.URL)
+	assert.True(t, res.Reachable)
+	assert.False(t, res.Healthy)
+	assert.True(t, errors.Is(res.Err, ErrInvalidStatusCode))
+
+	ts.Close()
+	res = Healthcheck(ts.URL)
+	assert.False(t, res.Reachable)
+	assert.False(t, res.Healthy)
+	assert.Error(t, res.Err)
+}
+
+func TestWatchHealth(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&n, 1) {
+		case 3, 4:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	fake := clock.NewFake(time.Unix(0, 0))
+	ctx, cancel := context.WithCancel(clock.NewContext(context.Background(), fake))
+	defer cancel()
+	ch := WatchHealth(ctx, ts.URL, time.Minute)
+	var statuses []int
+	for res := range ch {
+		statuses = append(statuses, res.Status)
+		if len(statuses) == 3 {
+			cancel()
+		}
+	}
+	assert.Equal(t, []int{200, 503, 200}, statuses)
+	assert.True(t, atomic.LoadInt32(&n) >= 5)
+	assert.Equal(t, time.Minute, fake.Sleeps()[0])
+}
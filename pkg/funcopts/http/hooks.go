@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// RequestHook is called before each attempt is sent. Returning an error aborts the request
+type RequestHook func(req *http.Request, attempt int) error
+
+// ResponseHook is called with the response to each attempt
+type ResponseHook func(resp *http.Response, attempt int)
+
+// ErrorHook is called once when a request fails, with the number of attempts made
+type ErrorHook func(req *http.Request, err error, attempts int)
+
+// RetryHook is called before an attempt that follows a failed one. err is the
+// error of the failed attempt, wrapping `ErrInvalidStatusCode` for 5xx responses
+type RetryHook func(req *http.Request, attempt int, err error)
+
+type hooks struct {
+	request  []RequestHook
+	response []ResponseHook
+	errors   []ErrorHook
+	retry    []RetryHook
+}
+
+func (h hooks) empty() bool {
+	return len(h.request) == 0 && len(h.response) == 0 && len(h.errors) == 0 && len(h.retry) == 0
+}
+
+// OnRequest calls fn before every attempt, including redirects and retries.
+// It can modify the request, e.g. to refresh credentials
+func OnRequest(fn RequestHook) RequestOption {
+	return func(r *Request) error {
+		r.hooks.request = append(r.hooks.request, fn)
+		return nil
+	}
+}
+
+// OnResponse calls fn with the response to every attempt
+func OnResponse(fn ResponseHook) RequestOption {
+	return func(r *Request) error {
+		r.hooks.response = append(r.hooks.response, fn)
+		return nil
+	}
+}
+
+// OnError calls fn when a request fails with an error or an unexpected status
+func OnError(fn ErrorHook) RequestOption {
+	return func(r *Request) error {
+		r.hooks.errors = append(r.hooks.errors, fn)
+		return nil
+	}
+}
+
+// OnRetry calls fn before an attempt that retries a failed one
+func OnRetry(fn RetryHook) RequestOption {
+	return func(r *Request) error {
+		r.hooks.retry = append(r.hooks.retry, fn)
+		return nil
+	}
+}
+
+// hookTransport calls the hooks of a single request around each attempt
+type hookTransport struct {
+	hooks   hooks
+	attempt int
+	lastErr error
+	next    http.RoundTripper
+	sync.Mutex
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.Lock()
+	t.attempt++
+	attempt, lastErr := t.attempt, t.lastErr
+	t.Unlock()
+	if lastErr != nil {
+		for _, fn := range t.hooks.retry {
+			fn(req, attempt, lastErr)
+		}
+	}
+	if len(t.hooks.request) > 0 {
+		req = req.Clone(req.Context())
+	}
+	for _, fn := range t.hooks.request {
+		if err := fn(req, attempt); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	failure := err
+	if err == nil {
+		for _, fn := range t.hooks.response {
+			fn(resp, attempt)
+		}
+		if resp.StatusCode >= 500 {
+			failure = fmt.Errorf("%s: %w", resp.Status, ErrInvalidStatusCode)
+		}
+	}
+	t.Lock()
+	t.lastErr = failure
+	t.Unlock()
+	return resp, err
+}
+
+func (t *hookTransport) attempts() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.attempt
+}
+
+// runErrorHooks calls the `OnError` hooks when a request failed
+func (cr *Request) runErrorHooks(req *http.Request, err error) {
+	if err == nil || len(cr.hooks.errors) == 0 {
+		return
+	}
+	attempts := 0
+	if cr.hookTransport != nil {
+		attempts = cr.hookTransport.attempts()
+	}
+	for _, fn := range cr.hooks.errors {
+		fn(req, err, attempts)
+	}
+}
@@ -0,0 +1,85 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/end", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	var requests, responses []int
+	res, err := Get(ts.URL+"/start",
+		OnRequest(func(req *http.Request, attempt int) error {
+			requests = append(requests, attempt)
+			req.Header.Set("Authorization", "Bearer refreshed")
+			return nil
+		}),
+		OnResponse(func(resp *http.Response, attempt int) {
+			responses = append(responses, resp.StatusCode)
+		}),
+		OnError(func(req *http.Request, err error, attempts int) {
+			t.Error("unexpected error hook")
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer refreshed", string(res.Body))
+	assert.Equal(t, []int{1, 2}, requests)
+	assert.Equal(t, []int{http.StatusFound, http.StatusOK}, responses)
+}
+
+func TestOnRequestAbort(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent")
+	}))
+	defer ts.Close()
+	abort := errors.New("abort")
+	var hookErr error
+	_, err := Get(ts.URL,
+		OnRequest(func(req *http.Request, attempt int) error { return abort }),
+		OnError(func(req *http.Request, err error, attempts int) { hookErr = err }),
+	)
+	assert.True(t, errors.Is(err, abort))
+	assert.True(t, errors.Is(hookErr, abort))
+}
+
+func TestOnErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	var attempts int
+	var hookErr error
+	_, err := Get(ts.URL, ExpectSuccess(), OnError(func(req *http.Request, err error, n int) {
+		hookErr, attempts = err, n
+	}))
+	assert.Error(t, err)
+	assert.True(t, errors.Is(hookErr, ErrInvalidStatusCode))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestOnRetry(t *testing.T) {
+	failing := http.StatusServiceUnavailable
+	primary, secondary := replica("primary", &failing), replica("secondary", nil)
+	defer primary.Close()
+	defer secondary.Close()
+	var retries []int
+	var retryErr error
+	_, err := Get(primary.URL, Fallback(secondary.URL), OnRetry(func(req *http.Request, attempt int, err error) {
+		retries = append(retries, attempt)
+		retryErr = err
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, retries)
+	assert.True(t, errors.Is(retryErr, ErrInvalidStatusCode))
+}
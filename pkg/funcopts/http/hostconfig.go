@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// hostConfig is a set of options scoped to hosts matching pattern
+type hostConfig struct {
+	pattern   string
+	opts      []RequestOption
+	transport sharedTransport
+}
+
+// HostConfig applies opts only to requests whose host matches pattern, e.g. `*.internal.corp`.
+// This lets one `Client` carry credentials, TLS settings and rate limits for many backends
+// without leaking them to other hosts. Matching options are applied after the request's own options.
+// The headers they set, including redacted ones, are checked against pattern again on every
+// attempt and dropped when a redirect, balancer, fallback or discovery sends it to another host
+func HostConfig(pattern string, opts ...RequestOption) RequestOption {
+	hc := &hostConfig{pattern: strings.ToLower(pattern), opts: opts}
+	return func(r *Request) error {
+		r.hostConfigs = append(r.hostConfigs, hc)
+		return nil
+	}
+}
+
+func (hc *hostConfig) matches(host string) bool {
+	ok, _ := path.Match(hc.pattern, strings.ToLower(host))
+	return ok
+}
+
+// applyHostConfigs applies the options of every `HostConfig` matching the request url
+func (cr *Request) applyHostConfigs() error {
+	if len(cr.hostConfigs) == 0 {
+		return nil
+	}
+	u, err := cr.resolveURL()
+	if err != nil {
+		return err
+	}
+	configs := cr.hostConfigs
+	cr.hostConfigs = nil
+	// transport options set on the request itself are applied on top of the shared host transport
+	tuning, controls, dialer, resolver, hosts := cr.transportTuning, cr.dialControls, cr.dialer, cr.resolver, cr.staticHosts
+	cr.transportTuning, cr.dialControls, cr.dialer, cr.resolver, cr.staticHosts = nil, nil, nil, nil, nil
+	defer func() {
+		cr.transportTuning = append(cr.transportTuning, tuning...)
+		cr.dialControls = append(cr.dialControls, controls...)
+		if dialer != nil {
+			cr.dialer = dialer
+		}
+		if resolver != nil {
+			cr.resolver = resolver
+		}
+		if hosts != nil {
+			cr.staticHosts = hosts
+		}
+	}()
+	for _, hc := range configs {
+		if !hc.matches(u.Hostname()) {
+			continue
+		}
+		headers := make(map[string]string, len(cr.headers))
+		for k, v := range cr.headers {
+			headers[k] = v
+		}
+		redacted := len(cr.redactHeaders)
+		for _, opt := range hc.opts {
+			if err := opt(cr); err != nil {
+				return err
+			}
+		}
+		scoped := hostHeaders{config: hc, names: append([]string{}, cr.redactHeaders[redacted:]...)}
+		for k, v := range cr.headers {
+			if old, ok := headers[k]; !ok || old != v {
+				scoped.names = append(scoped.names, k)
+			}
+		}
+		if len(scoped.names) > 0 {
+			cr.hostHeaders = append(cr.hostHeaders, scoped)
+		}
+		hc.transport.apply(cr)
+	}
+	return nil
+}
+
+// hostHeaders are the headers set by the options of a `HostConfig`
+type hostHeaders struct {
+	config *hostConfig
+	names  []string
+}
+
+// hostHeadersTransport drops the headers set by a `HostConfig` from attempts sent to
+// hosts it doesn't match. It is one of the innermost layers so it sees the host actually used
+type hostHeadersTransport struct {
+	scoped []hostHeaders
+	next   http.RoundTripper
+}
+
+func (t *hostHeadersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req
+	for _, h := range t.scoped {
+		if h.config.matches(req.URL.Hostname()) {
+			continue
+		}
+		if out == req {
+			out = req.Clone(req.Context())
+		}
+		for _, name := range h.names {
+			out.Header.Del(name)
+		}
+	}
+	return t.next.RoundTrip(out)
+}
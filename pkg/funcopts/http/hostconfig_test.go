@@ -0,0 +1,62 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	c := NewClient(
+		HostConfig("127.0.0.*", AddHeaders(map[string]string{"Authorization": "internal"})),
+		HostConfig("*.example.com", AddHeaders(map[string]string{"Authorization": "external"})),
+	)
+	res, err := c.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "internal", string(res.Body))
+	res, err = c.Get(strings.Replace(ts.URL, "127.0.0.1", "localhost", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, "", string(res.Body))
+}
+
+func TestHostConfigTransport(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	conns := countConns(ts)
+	ts.StartTLS()
+	defer ts.Close()
+	c := NewClient(HostConfig("127.0.0.1", WithCA(testCA(ts))))
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(conns))
+	_, err := c.Get(strings.Replace(ts.URL, "127.0.0.1", "localhost", 1))
+	assert.Error(t, err)
+}
+
+func TestHostConfigRedirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/other" {
+			http.Redirect(w, r, "http://"+strings.Replace(r.Host, "127.0.0.1", "localhost", 1)+"/echo", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-Internal-Token") + r.Header.Get("X-Trace")))
+	}))
+	defer ts.Close()
+	c := NewClient(HostConfig("127.0.0.*", AddHeaders(map[string]string{"X-Internal-Token": "internal"})),
+		AddHeaders(map[string]string{"X-Trace": "kept"}))
+	res, err := c.Get(ts.URL + "/same")
+	assert.NoError(t, err)
+	assert.Equal(t, "internalkept", string(res.Body))
+	res, err = c.Get(ts.URL + "/other")
+	assert.NoError(t, err)
+	assert.Equal(t, "kept", string(res.Body))
+}
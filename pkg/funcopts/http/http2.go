@@ -0,0 +1,78 @@
+package httpclient
+
+import "net/http"
+
+// ForceHTTP2 only allows HTTP/2 over TLS, failing requests to servers that don't negotiate it
+func ForceHTTP2() RequestOption {
+	return protocols(func(p *http.Protocols) {
+		p.SetHTTP2(true)
+	})
+}
+
+// DisableHTTP2 only allows HTTP/1.1
+func DisableHTTP2() RequestOption {
+	return protocols(func(p *http.Protocols) {
+		p.SetHTTP1(true)
+	})
+}
+
+// H2C uses cleartext HTTP/2 with prior knowledge for `http://` urls, as used by gRPC-gateway and
+// internal services. The server must support h2c. `https://` urls keep the protocols of the transport
+func H2C() RequestOption {
+	return func(r *Request) error {
+		r.h2c = true
+		return nil
+	}
+}
+
+// h2cTransport sends `http://` requests over cleartext HTTP/2 and the others with next.
+// net/http only uses h2c when HTTP/1 isn't allowed, so it needs a transport of its own
+type h2cTransport struct {
+	h2c  *http.Transport
+	next *http.Transport
+}
+
+func newH2CTransport(t *http.Transport) *h2cTransport {
+	h2c := t.Clone()
+	h2c.Protocols = &http.Protocols{}
+	h2c.Protocols.SetUnencryptedHTTP2(true)
+	h2c.ForceAttemptHTTP2 = true
+	return &h2cTransport{h2c: h2c, next: t}
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of both transports
+func (t *h2cTransport) CloseIdleConnections() {
+	t.h2c.CloseIdleConnections()
+	t.next.CloseIdleConnections()
+}
+
+// protocols replaces the protocols allowed by the transport with the ones set by fn.
+// It has no effect on custom transports that are not an *http.Transport
+func protocols(fn func(*http.Protocols)) RequestOption {
+	return func(r *Request) error {
+		r.transportTuning = append(r.transportTuning, func(t *http.Transport) {
+			p := &http.Protocols{}
+			fn(p)
+			t.Protocols = p
+			t.ForceAttemptHTTP2 = p.HTTP2() || p.UnencryptedHTTP2()
+			if t.TLSClientConfig != nil && !p.HTTP2() {
+				t.TLSClientConfig = t.TLSClientConfig.Clone()
+				nextProtos := []string{}
+				for _, proto := range t.TLSClientConfig.NextProtos {
+					if proto != "h2" {
+						nextProtos = append(nextProtos, proto)
+					}
+				}
+				t.TLSClientConfig.NextProtos = nextProtos
+			}
+		})
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testProtoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+}
+
+func TestForceAndDisableHTTP2(t *testing.T) {
+	ts := httptest.NewUnstartedServer(testProtoHandler())
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	res, err := Get(ts.URL, SetClient(ts.Client()), ForceHTTP2())
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+	assert.Equal(t, "HTTP/2.0", string(res.Body))
+
+	res, err = Get(ts.URL, SetClient(ts.Client()), DisableHTTP2())
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", res.Proto)
+}
+
+func TestForceHTTP2Unsupported(t *testing.T) {
+	ts := httptest.NewTLSServer(testProtoHandler())
+	defer ts.Close()
+	_, err := Get(ts.URL, SetClient(ts.Client()), ForceHTTP2())
+	assert.Error(t, err)
+}
+
+func TestH2C(t *testing.T) {
+	ts := httptest.NewUnstartedServer(testProtoHandler())
+	ts.Config.Protocols = &http.Protocols{}
+	ts.Config.Protocols.SetHTTP1(true)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+	defer ts.Close()
+
+	res, err := Get(ts.URL, H2C())
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+
+	res, err = Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", res.Proto)
+}
+
+func TestH2CKeepsTLSProtocols(t *testing.T) {
+	h2c := httptest.NewUnstartedServer(testProtoHandler())
+	h2c.Config.Protocols = &http.Protocols{}
+	h2c.Config.Protocols.SetHTTP1(true)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+	tls := httptest.NewUnstartedServer(testProtoHandler())
+	tls.EnableHTTP2 = true
+	tls.StartTLS()
+	defer tls.Close()
+
+	client := NewClient(SetClient(tls.Client()), H2C())
+	res, err := client.Get(h2c.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+
+	res, err = client.Get(tls.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+
+	res, err = Get(tls.URL, SetClient(tls.Client()), H2C(), DisableHTTP2())
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", res.Proto)
+}
@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// HeaderIdempotencyKey is the header used by `IdempotencyKey` and `AutoIdempotencyKey`
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// IdempotencyKey sends key in the `Idempotency-Key` header so the server can
+// safely ignore duplicates of the request
+func IdempotencyKey(key string) RequestOption {
+	return func(r *Request) error {
+		r.headers[HeaderIdempotencyKey] = key
+		return nil
+	}
+}
+
+// AutoIdempotencyKey generates a random `Idempotency-Key` for POST and PATCH requests
+// that don't set one. The key is generated once per request and reused by every attempt
+func AutoIdempotencyKey() RequestOption {
+	return func(r *Request) error {
+		r.autoIdempotencyKey = true
+		return nil
+	}
+}
+
+func (cr *Request) setIdempotencyKey(req *http.Request) error {
+	if !cr.autoIdempotencyKey || (req.Method != "POST" && req.Method != "PATCH") {
+		return nil
+	}
+	if req.Header.Get(HeaderIdempotencyKey) != "" {
+		return nil
+	}
+	key, err := newUUID()
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderIdempotencyKey, key)
+	return nil
+}
+
+// newUUID returns a random RFC 4122 version 4 uuid
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
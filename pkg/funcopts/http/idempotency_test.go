@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	_, req, err := New(post(), setURL("http://example.com"), IdempotencyKey("order-42"))
+	assert.NoError(t, err)
+	assert.Equal(t, "order-42", req.Header.Get(HeaderIdempotencyKey))
+}
+
+func TestAutoIdempotencyKey(t *testing.T) {
+	_, req, err := New(post(), setURL("http://example.com"), AutoIdempotencyKey())
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), req.Header.Get(HeaderIdempotencyKey))
+
+	_, other, err := New(post(), setURL("http://example.com"), AutoIdempotencyKey())
+	assert.NoError(t, err)
+	assert.NotEqual(t, req.Header.Get(HeaderIdempotencyKey), other.Header.Get(HeaderIdempotencyKey))
+
+	_, req, err = New(post(), setURL("http://example.com"), AutoIdempotencyKey(), IdempotencyKey("explicit"))
+	assert.NoError(t, err)
+	assert.Equal(t, "explicit", req.Header.Get(HeaderIdempotencyKey))
+
+	_, req, err = New(get(), setURL("http://example.com"), AutoIdempotencyKey())
+	assert.NoError(t, err)
+	assert.Empty(t, req.Header.Get(HeaderIdempotencyKey))
+}
+
+func TestAutoIdempotencyKeyRetries(t *testing.T) {
+	var keys []string
+	record := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+			w.WriteHeader(status)
+		}))
+	}
+	primary, secondary := record(http.StatusServiceUnavailable), record(http.StatusCreated)
+	defer primary.Close()
+	defer secondary.Close()
+	_, err := Post(primary.URL, AutoIdempotencyKey(), Fallback(secondary.URL))
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2) {
+		assert.NotEmpty(t, keys[0])
+		assert.Equal(t, keys[0], keys[1])
+	}
+}
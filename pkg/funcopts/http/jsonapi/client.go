@@ -0,0 +1,124 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// Client sends JSON:API requests to a server at a base url
+type Client struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+// New returns a `Client` for the server at baseURL. opts are applied to every request
+func New(baseURL string, opts ...httpclient.RequestOption) *Client {
+	all := append([]httpclient.RequestOption{httpclient.Accept(ContentType)}, opts...)
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), client: httpclient.NewClient(all...)}
+}
+
+// Get fetches the document at path
+func (c *Client) Get(ctx context.Context, path string, opts ...httpclient.RequestOption) (*Document, error) {
+	return c.do(ctx, "GET", path, nil, opts)
+}
+
+// Create posts doc to path and returns the document of the created resource. A 204
+// response returns nil
+func (c *Client) Create(ctx context.Context, path string, doc *Document, opts ...httpclient.RequestOption) (*Document, error) {
+	return c.do(ctx, "POST", path, doc, opts)
+}
+
+// Update patches the resource at path with doc and returns its document. A 204
+// response returns nil
+func (c *Client) Update(ctx context.Context, path string, doc *Document, opts ...httpclient.RequestOption) (*Document, error) {
+	return c.do(ctx, "PATCH", path, doc, opts)
+}
+
+// Delete deletes the resource at path
+func (c *Client) Delete(ctx context.Context, path string, opts ...httpclient.RequestOption) error {
+	_, err := c.do(ctx, "DELETE", path, nil, opts)
+	return err
+}
+
+// do sends a request and decodes the document of the response. Error responses fail
+// with the `Errors` of their document, or wrap `httpclient.ErrInvalidStatusCode`
+func (c *Client) do(ctx context.Context, method, path string, doc *Document, opts []httpclient.RequestOption) (*Document, error) {
+	all := []httpclient.RequestOption{httpclient.WithContext(ctx), httpclient.Method(method), httpclient.URL(c.baseURL + path)}
+	if doc != nil {
+		body, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, httpclient.ContentType(ContentType), httpclient.WithBody(bytes.NewReader(body)))
+	}
+	req, _, err := c.client.New(append(all, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := req.Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out *Document
+	if len(bytes.TrimSpace(res.Body)) > 0 {
+		if mt, _, _ := mime.ParseMediaType(res.Headers.Get("Content-Type")); mt == ContentType || mt == httpclient.ContentTypeJSON {
+			out = &Document{}
+			if err := json.Unmarshal(res.Body, out); err != nil {
+				return nil, fmt.Errorf("decoding jsonapi document: %w", err)
+			}
+		}
+	}
+	if res.Status >= 400 {
+		if out != nil && len(out.Errors) > 0 {
+			return out, out.Errors
+		}
+		return out, fmt.Errorf("%w: %s %s returned %d", httpclient.ErrInvalidStatusCode, method, path, res.Status)
+	}
+	if res.Status == http.StatusNoContent {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// Include asks the server to include the resources related by paths, e.g. `comments.author`
+func Include(paths ...string) httpclient.RequestOption {
+	return httpclient.QueryParam("include", strings.Join(paths, ","))
+}
+
+// Fields asks for a sparse fieldset of resources of type typ
+func Fields(typ string, fields ...string) httpclient.RequestOption {
+	return httpclient.QueryParam("fields["+typ+"]", strings.Join(fields, ","))
+}
+
+// Sort orders a collection by fields, descending for fields starting with `-`
+func Sort(fields ...string) httpclient.RequestOption {
+	return httpclient.QueryParam("sort", strings.Join(fields, ","))
+}
+
+// Filter sets the filter param name, whose meaning is up to the server
+func Filter(name, value string) httpclient.RequestOption {
+	return httpclient.QueryParam("filter["+name+"]", value)
+}
+
+// Page sets the page params of a collection, e.g. `Page(map[string]int{"number": 2, "size": 50})`
+func Page(params map[string]int) httpclient.RequestOption {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	opts := make([]httpclient.RequestOption, len(keys))
+	for i, k := range keys {
+		opts[i] = httpclient.QueryParam("page["+k+"]", strconv.Itoa(params[k]))
+	}
+	return httpclient.Preset(opts...)
+}
@@ -0,0 +1,79 @@
+package jsonapi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ContentType, r.Header.Get("Accept"))
+		assert.Equal(t, "/articles", r.URL.Path)
+		q := r.URL.Query()
+		assert.Equal(t, "author,comments.author", q.Get("include"))
+		assert.Equal(t, "title,body", q.Get("fields[articles]"))
+		assert.Equal(t, "-created", q.Get("sort"))
+		assert.Equal(t, "go", q.Get("filter[tag]"))
+		assert.Equal(t, "2", q.Get("page[number]"))
+		assert.Equal(t, "50", q.Get("page[size]"))
+		w.Header().Set("Content-Type", ContentType)
+		w.Write([]byte(articlesDoc))
+	}))
+	defer ts.Close()
+	c := New(ts.URL + "/")
+	doc, err := c.Get(context.Background(), "/articles",
+		Include("author", "comments.author"), Fields("articles", "title", "body"), Sort("-created"),
+		Filter("tag", "go"), Page(map[string]int{"number": 2, "size": 50}))
+	require.NoError(t, err)
+	require.Len(t, doc.Primary(), 1)
+	assert.Len(t, doc.Included, 2)
+}
+
+func TestClientCreate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, ContentType, r.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.JSONEq(t, `{"data":{"type":"articles","attributes":{"title":"hello"}}}`, string(body))
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"type":"articles","id":"7","attributes":{"title":"hello"}}}`))
+	}))
+	defer ts.Close()
+	r, err := NewResource("articles", "", article{Title: "hello"})
+	require.NoError(t, err)
+	doc, err := New(ts.URL).Create(context.Background(), "/articles", &Document{Data: One(r)})
+	require.NoError(t, err)
+	assert.Equal(t, "7", doc.Primary()[0].ID)
+}
+
+func TestClientErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		switch r.Method {
+		case "PATCH":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"errors":[{"status":"422","title":"Invalid Attribute"}]}`))
+		case "DELETE":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	c := New(ts.URL)
+	doc, err := c.Update(context.Background(), "/articles/1", &Document{Data: One(Resource{Type: "articles", ID: "1"})})
+	var errs Errors
+	require.True(t, errors.As(err, &errs))
+	assert.Equal(t, "422", errs[0].Status)
+	assert.NotNil(t, doc)
+
+	err = c.Delete(context.Background(), "/articles/1")
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode))
+}
@@ -0,0 +1,254 @@
+// Package jsonapi reads and writes JSON:API (application/vnd.api+json) documents on top
+// of httpclient: document envelopes, relationship resolution through included resources,
+// and the include, sparse fieldset, sort, filter and page query params
+//
+//	c := jsonapi.New("https://api.example.com")
+//	doc, err := c.Get(ctx, "/articles", jsonapi.Include("author"), jsonapi.Fields("articles", "title"))
+//	for _, article := range doc.Primary() {
+//		title, _ := jsonapi.Decode[Article](article)
+//		authors := doc.Related(article, "author")
+//	}
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContentType is the JSON:API media type
+const ContentType = "application/vnd.api+json"
+
+// Document is a JSON:API top level document
+type Document struct {
+	Data     *Data                  `json:"data,omitempty"`
+	Included []Resource             `json:"included,omitempty"`
+	Errors   Errors                 `json:"errors,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Links    map[string]Link        `json:"links,omitempty"`
+	JSONAPI  *Version               `json:"jsonapi,omitempty"`
+}
+
+// Version is the `jsonapi` object of a document
+type Version struct {
+	Version string `json:"version,omitempty"`
+}
+
+// Data is the primary data of a document or the linkage of a relationship: a single
+// resource, a collection or null
+type Data struct {
+	One  *Resource
+	Many []Resource
+	// IsMany is true for collections, even empty ones
+	IsMany bool
+}
+
+// One returns the data for a single resource
+func One(r Resource) *Data {
+	return &Data{One: &r}
+}
+
+// Many returns the data for a collection of resources
+func Many(rs ...Resource) *Data {
+	return &Data{Many: append([]Resource{}, rs...), IsMany: true}
+}
+
+// Resources returns the resources of the data, none when it is null
+func (d *Data) Resources() []Resource {
+	switch {
+	case d == nil:
+		return nil
+	case d.IsMany:
+		return d.Many
+	case d.One != nil:
+		return []Resource{*d.One}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the data as an object, an array or null
+func (d Data) MarshalJSON() ([]byte, error) {
+	switch {
+	case d.IsMany:
+		if d.Many == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(d.Many)
+	case d.One != nil:
+		return json.Marshal(d.One)
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON decodes an object, an array or null
+func (d *Data) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	switch {
+	case bytes.Equal(b, []byte("null")):
+		*d = Data{}
+		return nil
+	case len(b) > 0 && b[0] == '[':
+		*d = Data{IsMany: true, Many: []Resource{}}
+		return json.Unmarshal(b, &d.Many)
+	}
+	var r Resource
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+	*d = Data{One: &r}
+	return nil
+}
+
+// Resource is a resource object, or a resource identifier when it only has a type and id
+type Resource struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	// LID identifies a resource created in the same request before the server assigns an ID
+	LID           string                  `json:"lid,omitempty"`
+	Attributes    json.RawMessage         `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         map[string]Link         `json:"links,omitempty"`
+	Meta          map[string]interface{}  `json:"meta,omitempty"`
+}
+
+// NewResource returns a resource of type typ with attributes encoded from v, e.g. a struct
+// with json tags. id can be empty for resources the server creates
+func NewResource(typ, id string, attributes interface{}) (Resource, error) {
+	r := Resource{Type: typ, ID: id}
+	if attributes == nil {
+		return r, nil
+	}
+	b, err := json.Marshal(attributes)
+	if err != nil {
+		return r, err
+	}
+	r.Attributes = b
+	return r, nil
+}
+
+// Relate sets the relationship name of r to data, e.g. `One(Resource{Type: "people", ID: "9"})`
+func (r *Resource) Relate(name string, data *Data) {
+	if r.Relationships == nil {
+		r.Relationships = map[string]Relationship{}
+	}
+	r.Relationships[name] = Relationship{Data: data}
+}
+
+// Decode decodes the attributes of r into a T
+func Decode[T any](r Resource) (T, error) {
+	var v T
+	if len(r.Attributes) == 0 {
+		return v, nil
+	}
+	err := json.Unmarshal(r.Attributes, &v)
+	return v, err
+}
+
+// Relationship is a relationship object. Data is nil when the relationship only has links
+type Relationship struct {
+	Data  *Data                  `json:"data,omitempty"`
+	Links map[string]Link        `json:"links,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Link is a link, written either as a url or as a link object
+type Link struct {
+	Href string                 `json:"href"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// UnmarshalJSON decodes a url string or a link object
+func (l *Link) UnmarshalJSON(b []byte) error {
+	var href string
+	if err := json.Unmarshal(b, &href); err == nil {
+		*l = Link{Href: href}
+		return nil
+	}
+	type link Link
+	return json.Unmarshal(b, (*link)(l))
+}
+
+// Error is an error object
+type Error struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *ErrorSource           `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// ErrorSource points at the part of the request an `Error` is about
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+func (e Error) Error() string {
+	msg := e.Title
+	if e.Detail != "" {
+		if msg != "" {
+			msg += ": "
+		}
+		msg += e.Detail
+	}
+	if msg == "" {
+		msg = e.Code
+	}
+	if e.Source != nil && e.Source.Pointer != "" {
+		msg += " (" + e.Source.Pointer + ")"
+	}
+	return msg
+}
+
+// Errors are the errors of a document, returned as the error of failed requests
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("jsonapi: %s", strings.Join(msgs, "; "))
+}
+
+// Primary returns the primary resources of the document
+func (d *Document) Primary() []Resource {
+	return d.Data.Resources()
+}
+
+// Find returns the resource with the type and id of ref from the primary data or the
+// included resources
+func (d *Document) Find(ref Resource) (Resource, bool) {
+	for _, rs := range [][]Resource{d.Primary(), d.Included} {
+		for _, r := range rs {
+			if r.Type == ref.Type && r.ID == ref.ID && (r.ID != "" || r.LID == ref.LID) {
+				return r, true
+			}
+		}
+	}
+	return Resource{}, false
+}
+
+// Related returns the resources r links to through its relationship name, resolved
+// against the document. Resources the document doesn't include are returned as the
+// identifiers of the linkage
+func (d *Document) Related(r Resource, name string) []Resource {
+	rel, ok := r.Relationships[name]
+	if !ok {
+		return nil
+	}
+	refs := rel.Data.Resources()
+	related := make([]Resource, len(refs))
+	for i, ref := range refs {
+		if found, ok := d.Find(ref); ok {
+			related[i] = found
+		} else {
+			related[i] = ref
+		}
+	}
+	return related
+}
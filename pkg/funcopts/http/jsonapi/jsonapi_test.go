@@ -0,0 +1,90 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type article struct {
+	Title string `json:"title"`
+}
+
+const articlesDoc = `{
+	"data": [{
+		"type": "articles", "id": "1",
+		"attributes": {"title": "JSON:API paints my bikeshed!"},
+		"relationships": {
+			"author": {"data": {"type": "people", "id": "9"}},
+			"comments": {"data": [{"type": "comments", "id": "5"}, {"type": "comments", "id": "12"}]},
+			"tags": {"links": {"related": "/articles/1/tags"}}
+		},
+		"links": {"self": "http://example.com/articles/1"}
+	}],
+	"included": [
+		{"type": "people", "id": "9", "attributes": {"firstName": "Dan"}},
+		{"type": "comments", "id": "5", "attributes": {"body": "First!"}}
+	],
+	"meta": {"total": 1},
+	"links": {"next": {"href": "http://example.com/articles?page[number]=2", "meta": {"count": 10}}}
+}`
+
+func TestDocument(t *testing.T) {
+	var doc Document
+	require.NoError(t, json.Unmarshal([]byte(articlesDoc), &doc))
+	primary := doc.Primary()
+	require.Len(t, primary, 1)
+	a, err := Decode[article](primary[0])
+	require.NoError(t, err)
+	assert.Equal(t, "JSON:API paints my bikeshed!", a.Title)
+	assert.Equal(t, "http://example.com/articles/1", primary[0].Links["self"].Href)
+	assert.Equal(t, "http://example.com/articles?page[number]=2", doc.Links["next"].Href)
+	assert.Equal(t, float64(1), doc.Meta["total"])
+
+	author := doc.Related(primary[0], "author")
+	require.Len(t, author, 1)
+	assert.JSONEq(t, `{"firstName":"Dan"}`, string(author[0].Attributes))
+
+	comments := doc.Related(primary[0], "comments")
+	require.Len(t, comments, 2)
+	assert.JSONEq(t, `{"body":"First!"}`, string(comments[0].Attributes))
+	assert.Equal(t, Resource{Type: "comments", ID: "12"}, comments[1])
+
+	assert.Empty(t, doc.Related(primary[0], "tags"))
+	assert.Empty(t, doc.Related(primary[0], "missing"))
+}
+
+func TestDataMarshal(t *testing.T) {
+	r, err := NewResource("articles", "", article{Title: "hello"})
+	require.NoError(t, err)
+	r.Relate("author", One(Resource{Type: "people", ID: "9"}))
+	r.Relate("tags", Many())
+	b, err := json.Marshal(Document{Data: One(r)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"type":"articles","attributes":{"title":"hello"},"relationships":{
+		"author":{"data":{"type":"people","id":"9"}},
+		"tags":{"data":[]}
+	}}}`, string(b))
+
+	b, err = json.Marshal(Relationship{Data: &Data{}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":null}`, string(b))
+
+	var doc Document
+	require.NoError(t, json.Unmarshal([]byte(`{"data":[]}`), &doc))
+	assert.True(t, doc.Data.IsMany)
+	assert.Empty(t, doc.Primary())
+	require.NoError(t, json.Unmarshal([]byte(`{"data":null}`), &doc))
+	assert.Empty(t, doc.Primary())
+}
+
+func TestErrors(t *testing.T) {
+	var doc Document
+	require.NoError(t, json.Unmarshal([]byte(`{"errors":[
+		{"status":"422","title":"Invalid Attribute","detail":"must be at least three characters","source":{"pointer":"/data/attributes/firstName"}},
+		{"code":"rate_limited"}
+	]}`), &doc))
+	assert.Equal(t, "jsonapi: Invalid Attribute: must be at least three characters (/data/attributes/firstName); rate_limited", doc.Errors.Error())
+}
@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// SignJWT returns claims signed as a compact JWT with key. The algorithm follows the key:
+// ES256, ES384 or ES512 for ECDSA keys, RS256 for RSA keys and EdDSA for Ed25519 keys.
+// It covers client assertions like private_key_jwt
+//
+//	assertion, err := SignJWT(map[string]interface{}{
+//		"iss": clientID, "sub": clientID, "aud": tokenURL,
+//		"jti": uuid, "exp": time.Now().Add(time.Minute).Unix(),
+//	}, key)
+func SignJWT(claims map[string]interface{}, key crypto.Signer) (string, error) {
+	return signJWT(map[string]interface{}{"typ": "JWT"}, claims, key)
+}
+
+// signJWT signs claims with the extra header fields in header
+func signJWT(header, claims map[string]interface{}, key crypto.Signer) (string, error) {
+	alg, hash, err := jwtAlgorithm(key.Public())
+	if err != nil {
+		return "", err
+	}
+	header["alg"] = alg
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := []byte(input)
+	if hash != 0 {
+		sum := hash.New()
+		sum.Write(digest)
+		digest = sum.Sum(nil)
+	}
+	sig, err := key.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return "", err
+	}
+	if pub, ok := key.Public().(*ecdsa.PublicKey); ok {
+		if sig, err = rawECDSASignature(sig, pub.Curve); err != nil {
+			return "", err
+		}
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// jwtAlgorithm returns the JWS algorithm and hash for a public key
+func jwtAlgorithm(pub crypto.PublicKey) (string, crypto.Hash, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return "ES256", crypto.SHA256, nil
+		case elliptic.P384():
+			return "ES384", crypto.SHA384, nil
+		case elliptic.P521():
+			return "ES512", crypto.SHA512, nil
+		}
+	case *rsa.PublicKey:
+		return "RS256", crypto.SHA256, nil
+	case ed25519.PublicKey:
+		return "EdDSA", 0, nil
+	}
+	return "", 0, fmt.Errorf("unsupported key type %T", pub)
+}
+
+// rawECDSASignature converts an ASN.1 ECDSA signature to the fixed size r||s form of JWS
+func rawECDSASignature(der []byte, curve elliptic.Curve) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+// publicJWK returns the JSON Web Key of a public key
+func publicJWK(pub crypto.PublicKey) (map[string]interface{}, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		return map[string]interface{}{"kty": "EC", "crv": k.Curve.Params().Name, "x": b64(x), "y": b64(y)}, nil
+	case *rsa.PublicKey:
+		return map[string]interface{}{"kty": "RSA", "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}, nil
+	case ed25519.PublicKey:
+		return map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": b64(k)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", pub)
+}
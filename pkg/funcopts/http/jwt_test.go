@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyJWT checks the signature of token with pub and returns its header and claims
+func verifyJWT(t *testing.T, token string, pub crypto.PublicKey) (map[string]interface{}, map[string]interface{}) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	input := []byte(parts[0] + "." + parts[1])
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		_, hash, err := jwtAlgorithm(k)
+		require.NoError(t, err)
+		h := hash.New()
+		h.Write(input)
+		size := len(sig) / 2
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		require.True(t, ecdsa.Verify(k, h.Sum(nil), r, s), "ecdsa signature")
+	case *rsa.PublicKey:
+		h := crypto.SHA256.New()
+		h.Write(input)
+		require.NoError(t, rsa.VerifyPKCS1v15(k, crypto.SHA256, h.Sum(nil), sig))
+	case ed25519.PublicKey:
+		require.True(t, ed25519.Verify(k, input, sig), "ed25519 signature")
+	}
+	var header, claims map[string]interface{}
+	for i, v := range []*map[string]interface{}{&header, &claims} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, v))
+	}
+	return header, claims
+}
+
+func TestSignJWT(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	for alg, key := range map[string]crypto.Signer{"ES256": p256, "ES384": p384, "ES512": p521, "RS256": rsaKey, "EdDSA": edKey} {
+		t.Run(alg, func(t *testing.T) {
+			token, err := SignJWT(map[string]interface{}{"iss": "client", "exp": 1700000000}, key)
+			require.NoError(t, err)
+			header, claims := verifyJWT(t, token, key.Public())
+			assert.Equal(t, alg, header["alg"])
+			assert.Equal(t, "JWT", header["typ"])
+			assert.Equal(t, "client", claims["iss"])
+			assert.Equal(t, float64(1700000000), claims["exp"])
+		})
+	}
+}
+
+func TestPublicJWK(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwk, err := publicJWK(key.Public())
+	require.NoError(t, err)
+	assert.Equal(t, "EC", jwk["kty"])
+	assert.Equal(t, "P-256", jwk["crv"])
+	x, err := base64.RawURLEncoding.DecodeString(jwk["x"].(string))
+	require.NoError(t, err)
+	assert.Len(t, x, 32)
+}
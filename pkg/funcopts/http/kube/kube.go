@@ -0,0 +1,102 @@
+// Package kube authenticates requests to the Kubernetes API, either from inside a pod
+// with its service account or with a kubeconfig file
+//
+//	c := httpclient.NewClient(kube.InCluster())
+//	res, err := c.Get("/api/v1/namespaces/default/pods")
+//
+//	c := httpclient.NewClient(kube.Kubeconfig("", "staging"))
+package kube
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// TokenRefresh is how long a token read from a file is used before the file is read
+// again, so rotated service account tokens are picked up
+const TokenRefresh = time.Minute
+
+// ErrNotInCluster is the error for `InCluster` outside of a pod
+var ErrNotInCluster = errors.New("not running in a kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+
+// serviceAccountDir is where the service account of a pod is mounted
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// InCluster sends requests to the API server of the cluster the pod runs in, trusting
+// the cluster CA and authenticating with the service account token. Relative urls are
+// joined to the API server url
+func InCluster() httpclient.RequestOption {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return failed(ErrNotInCluster)
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return failed(err)
+	}
+	token := filepath.Join(serviceAccountDir, "token")
+	if _, err := os.Stat(token); err != nil {
+		return failed(err)
+	}
+	return httpclient.Preset(
+		httpclient.BaseURL("https://"+net.JoinHostPort(host, port)),
+		httpclient.WithCA(ca),
+		bearer(&tokenFile{path: token}),
+	)
+}
+
+// Namespace returns the namespace of the pod's service account
+func Namespace() (string, error) {
+	ns, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+	return strings.TrimSpace(string(ns)), err
+}
+
+// failed is an option that fails with err
+func failed(err error) httpclient.RequestOption {
+	return func(*httpclient.Request) error {
+		return err
+	}
+}
+
+// tokenFile reads a token from a file at most once every `TokenRefresh`
+type tokenFile struct {
+	path string
+	sync.Mutex
+	token string
+	read  time.Time
+}
+
+// get returns the token, reading the file again when the token is older than
+// `TokenRefresh`. The last token read is kept when the file can't be read
+func (f *tokenFile) get(ctx context.Context) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	now := clock.FromContext(ctx).Now()
+	if f.token != "" && now.Sub(f.read) < TokenRefresh {
+		return f.token, nil
+	}
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		if f.token != "" {
+			return f.token, nil
+		}
+		return "", err
+	}
+	f.token, f.read = strings.TrimSpace(string(b)), now
+	return f.token, nil
+}
+
+// bearer sends the token in f as a bearer token to the API server
+func bearer(f *tokenFile) httpclient.RequestOption {
+	return httpclient.BearerTokenFunc(f.get, nil)
+}
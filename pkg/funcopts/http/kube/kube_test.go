@@ -0,0 +1,69 @@
+package kube
+
+import (
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiServer echoes the Authorization header of each request
+func apiServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func caPEM(ts *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+}
+
+func TestInCluster(t *testing.T) {
+	ts := apiServer(t)
+	u, _ := url.Parse(ts.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+	dir := t.TempDir()
+	serviceAccountDir = dir
+	t.Cleanup(func() { serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount" })
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crt"), caPEM(ts), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("first\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "namespace"), []byte("apps"), 0600))
+
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	c := httpclient.NewClient(InCluster(), httpclient.WithClock(clk))
+	get := func() string {
+		res, err := c.Get("/api/v1/pods")
+		require.NoError(t, err)
+		return string(res.Body)
+	}
+	assert.Equal(t, "/api/v1/pods Bearer first", get())
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("second\n"), 0600))
+	assert.Equal(t, "/api/v1/pods Bearer first", get())
+	clk.Advance(TokenRefresh)
+	assert.Equal(t, "/api/v1/pods Bearer second", get(), "a rotated token is read again")
+
+	ns, err := Namespace()
+	require.NoError(t, err)
+	assert.Equal(t, "apps", ns)
+}
+
+func TestNotInCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err := httpclient.Get("/api", InCluster())
+	assert.True(t, errors.Is(err, ErrNotInCluster))
+}
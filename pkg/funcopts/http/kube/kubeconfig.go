@@ -0,0 +1,252 @@
+package kube
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrContextNotFound is the error for kubeconfig contexts, clusters or users that aren't defined
+	ErrContextNotFound = errors.New("kubeconfig context not found")
+	// ErrUnsupportedAuth is the error for kubeconfig users that authenticate with exec or auth
+	// provider plugins, or clusters that skip tls verification
+	ErrUnsupportedAuth = errors.New("unsupported kubeconfig auth")
+)
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string  `yaml:"name"`
+		Cluster cluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User user   `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string      `yaml:"name"`
+		Context kubeContext `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+type cluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+}
+
+type user struct {
+	Token                 string      `yaml:"token"`
+	TokenFile             string      `yaml:"tokenFile"`
+	ClientCertificate     string      `yaml:"client-certificate"`
+	ClientCertificateData string      `yaml:"client-certificate-data"`
+	ClientKey             string      `yaml:"client-key"`
+	ClientKeyData         string      `yaml:"client-key-data"`
+	Username              string      `yaml:"username"`
+	Password              string      `yaml:"password"`
+	Exec                  interface{} `yaml:"exec"`
+	AuthProvider          interface{} `yaml:"auth-provider"`
+}
+
+type kubeContext struct {
+	Cluster string `yaml:"cluster"`
+	User    string `yaml:"user"`
+}
+
+// Kubeconfig sends requests to the cluster of context in the kubeconfig at path with
+// the credentials of its user. An empty path reads the files listed in `KUBECONFIG` or
+// `~/.kube/config`, with the first file to define a name winning like kubectl. An empty
+// context uses the current context. Tokens, basic auth and client certificates are
+// supported, exec and auth provider plugins are not
+func Kubeconfig(path, context string) httpclient.RequestOption {
+	cfg, err := loadKubeconfig(path)
+	if err != nil {
+		return failed(err)
+	}
+	opts, err := cfg.options(context)
+	if err != nil {
+		return failed(err)
+	}
+	return httpclient.Preset(opts...)
+}
+
+// loadKubeconfig reads and merges the kubeconfig files
+func loadKubeconfig(path string) (*kubeconfig, error) {
+	paths := []string{path}
+	if path == "" {
+		paths = filepath.SplitList(os.Getenv("KUBECONFIG"))
+		if len(paths) == 0 {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			paths = []string{filepath.Join(home, ".kube", "config")}
+		}
+	}
+	merged := &kubeconfig{}
+	found := false
+	for _, p := range paths {
+		b, err := ioutil.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) && len(paths) > 1 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var cfg kubeconfig
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		cfg.resolve(filepath.Dir(p))
+		merged.merge(&cfg)
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no kubeconfig found in %v: %w", paths, os.ErrNotExist)
+	}
+	return merged, nil
+}
+
+// resolve makes the file paths in cfg relative to dir, the directory of its file
+func (cfg *kubeconfig) resolve(dir string) {
+	abs := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	for i := range cfg.Clusters {
+		abs(&cfg.Clusters[i].Cluster.CertificateAuthority)
+	}
+	for i := range cfg.Users {
+		u := &cfg.Users[i].User
+		abs(&u.TokenFile)
+		abs(&u.ClientCertificate)
+		abs(&u.ClientKey)
+	}
+}
+
+// merge adds the entries of other that aren't defined in cfg yet
+func (cfg *kubeconfig) merge(other *kubeconfig) {
+	if cfg.CurrentContext == "" {
+		cfg.CurrentContext = other.CurrentContext
+	}
+	for _, c := range other.Clusters {
+		if _, ok := cfg.cluster(c.Name); !ok {
+			cfg.Clusters = append(cfg.Clusters, c)
+		}
+	}
+	for _, u := range other.Users {
+		if _, ok := cfg.user(u.Name); !ok {
+			cfg.Users = append(cfg.Users, u)
+		}
+	}
+	for _, c := range other.Contexts {
+		if _, ok := cfg.context(c.Name); !ok {
+			cfg.Contexts = append(cfg.Contexts, c)
+		}
+	}
+}
+
+func (cfg *kubeconfig) cluster(name string) (cluster, bool) {
+	for _, c := range cfg.Clusters {
+		if c.Name == name {
+			return c.Cluster, true
+		}
+	}
+	return cluster{}, false
+}
+
+func (cfg *kubeconfig) user(name string) (user, bool) {
+	for _, u := range cfg.Users {
+		if u.Name == name {
+			return u.User, true
+		}
+	}
+	return user{}, false
+}
+
+func (cfg *kubeconfig) context(name string) (kubeContext, bool) {
+	for _, c := range cfg.Contexts {
+		if c.Name == name {
+			return c.Context, true
+		}
+	}
+	return kubeContext{}, false
+}
+
+// options returns the request options for the cluster and user of the context name
+func (cfg *kubeconfig) options(name string) ([]httpclient.RequestOption, error) {
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	ctx, ok := cfg.context(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrContextNotFound, name)
+	}
+	c, ok := cfg.cluster(ctx.Cluster)
+	if !ok {
+		return nil, fmt.Errorf("%w: cluster %q of context %q", ErrContextNotFound, ctx.Cluster, name)
+	}
+	u, ok := cfg.user(ctx.User)
+	if !ok && ctx.User != "" {
+		return nil, fmt.Errorf("%w: user %q of context %q", ErrContextNotFound, ctx.User, name)
+	}
+	if c.InsecureSkipTLSVerify {
+		return nil, fmt.Errorf("%w: insecure-skip-tls-verify", ErrUnsupportedAuth)
+	}
+	if u.Exec != nil || u.AuthProvider != nil {
+		return nil, fmt.Errorf("%w: user %q uses a credential plugin", ErrUnsupportedAuth, ctx.User)
+	}
+	opts := []httpclient.RequestOption{httpclient.BaseURL(c.Server)}
+	ca, err := dataOrFile(c.CertificateAuthorityData, c.CertificateAuthority)
+	if err != nil {
+		return nil, err
+	}
+	if ca != nil {
+		opts = append(opts, httpclient.WithCA(ca))
+	}
+	cert, err := dataOrFile(u.ClientCertificateData, u.ClientCertificate)
+	if err != nil {
+		return nil, err
+	}
+	key, err := dataOrFile(u.ClientKeyData, u.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil || key != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate of user %q: %w", ctx.User, err)
+		}
+		opts = append(opts, httpclient.WithClientCertificate(pair))
+	}
+	switch {
+	case u.Token != "":
+		opts = append(opts, httpclient.BearerToken(u.Token))
+	case u.TokenFile != "":
+		opts = append(opts, bearer(&tokenFile{path: u.TokenFile}))
+	case u.Username != "":
+		opts = append(opts, httpclient.BasicAuth(u.Username, u.Password))
+	}
+	return opts, nil
+}
+
+// dataOrFile returns the base64 decoded data or else the content of file
+func dataOrFile(data, file string) ([]byte, error) {
+	switch {
+	case data != "":
+		return base64.StdEncoding.DecodeString(data)
+	case file != "":
+		return ioutil.ReadFile(file)
+	}
+	return nil, nil
+}
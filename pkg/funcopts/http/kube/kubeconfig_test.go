@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: local
+  cluster:
+    server: %[1]s
+    certificate-authority: ca.crt
+contexts:
+- name: dev
+  context:
+    cluster: local
+    user: token
+- name: admin
+  context:
+    cluster: local
+    user: cert
+- name: plugin
+  context:
+    cluster: local
+    user: exec
+users:
+- name: token
+  user:
+    token: dev-token
+- name: cert
+  user:
+    client-certificate-data: %[2]s
+    client-key-data: %[3]s
+- name: exec
+  user:
+    exec:
+      command: aws
+`
+
+// writeKubeconfig writes a kubeconfig for ts, whose certificate is also the client
+// certificate of the cert user
+func writeKubeconfig(t *testing.T, ts *httptest.Server) string {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crt"), caPEM(ts), 0600))
+	cert := ts.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+	certData := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}))
+	keyData := base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}))
+	path := filepath.Join(dir, "config")
+	require.NoError(t, ioutil.WriteFile(path, []byte(fmt.Sprintf(testKubeconfig, ts.URL, certData, keyData)), 0600))
+	return path
+}
+
+func TestKubeconfig(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		who := r.Header.Get("Authorization")
+		if len(r.TLS.PeerCertificates) > 0 {
+			who = "cert " + r.TLS.PeerCertificates[0].Subject.Organization[0]
+		}
+		w.Write([]byte(who))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+	path := writeKubeconfig(t, ts)
+
+	res, err := httpclient.Get("/api", Kubeconfig(path, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer dev-token", string(res.Body))
+
+	res, err = httpclient.Get("/api", Kubeconfig(path, "admin"))
+	require.NoError(t, err)
+	assert.Equal(t, "cert Acme Co", string(res.Body))
+
+	_, err = httpclient.Get("/api", Kubeconfig(path, "plugin"))
+	assert.True(t, errors.Is(err, ErrUnsupportedAuth))
+	_, err = httpclient.Get("/api", Kubeconfig(path, "prod"))
+	assert.True(t, errors.Is(err, ErrContextNotFound))
+}
+
+func TestKubeconfigEnv(t *testing.T) {
+	ts := apiServer(t)
+	path := writeKubeconfig(t, ts)
+	override := filepath.Join(t.TempDir(), "override")
+	require.NoError(t, ioutil.WriteFile(override, []byte("current-context: admin\nusers:\n- name: token\n  user:\n    token: override-token\n"), 0600))
+	t.Setenv("KUBECONFIG", override+string(os.PathListSeparator)+filepath.Join(t.TempDir(), "missing")+string(os.PathListSeparator)+path)
+
+	cfg, err := loadKubeconfig("")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", cfg.CurrentContext)
+	u, _ := cfg.user("token")
+	assert.Equal(t, "override-token", u.Token, "the first file to define a name wins")
+
+	res, err := httpclient.Get("/api", Kubeconfig("", "dev"))
+	require.NoError(t, err)
+	assert.Equal(t, "/api Bearer override-token", string(res.Body))
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ContentTypeHALJSON is the mimetype for HAL documents
+const ContentTypeHALJSON = "application/hal+json"
+
+// Link is a hypermedia link from a `Link` header or the `_links` of a HAL document
+type Link struct {
+	Rel string
+	// URL is resolved against the url of the response, unless the link is templated
+	URL   string
+	Title string
+	Type  string
+	Name  string
+	// Templated links are RFC 6570 uri templates, expanded by `PathParams` when followed
+	Templated bool
+}
+
+// halLink is a link object in the `_links` of a HAL document
+type halLink struct {
+	Href      string `json:"href"`
+	Title     string `json:"title"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Templated bool   `json:"templated"`
+}
+
+// Links returns the links of the response from its RFC 8288 `Link` headers and, for
+// json and HAL bodies, the `_links` object, in that order. Rels are lowercased
+func (r *Response) Links() []Link {
+	var links []Link
+	for _, link := range parseLinks(strings.Join(r.Headers["Link"], ",")) {
+		links = append(links, r.resolveLink(link))
+	}
+	for _, link := range r.halLinks() {
+		links = append(links, r.resolveLink(link))
+	}
+	return links
+}
+
+// Link returns the first link of the response with rel
+func (r *Response) Link(rel string) (Link, bool) {
+	rel = strings.ToLower(rel)
+	for _, link := range r.Links() {
+		if link.Rel == rel {
+			return link, true
+		}
+	}
+	return Link{}, false
+}
+
+// FollowLink sends a GET to the link of the response with rel, failing with
+// `ErrLinkNotFound` when there is none. opts are the options of the new request,
+// e.g. `PathParams` for a templated link
+//
+//	order, err := c.Get(orderURL, Accept(ContentTypeHALJSON))
+//	customer, err := order.FollowLink("customer", Accept(ContentTypeHALJSON))
+func (r *Response) FollowLink(rel string, opts ...RequestOption) (*Response, error) {
+	link, ok := r.Link(rel)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrLinkNotFound, rel)
+	}
+	return Get(link.URL, opts...)
+}
+
+// halLinks decodes the `_links` of a json or HAL body
+func (r *Response) halLinks() []Link {
+	mt, _, err := mime.ParseMediaType(r.Headers.Get("Content-Type"))
+	if err != nil || (mt != ContentTypeHALJSON && mt != ContentTypeJSON) {
+		return nil
+	}
+	var doc struct {
+		Links map[string]json.RawMessage `json:"_links"`
+	}
+	if err := json.Unmarshal(r.Body, &doc); err != nil {
+		return nil
+	}
+	var links []Link
+	for rel, raw := range doc.Links {
+		var many []halLink
+		if err := json.Unmarshal(raw, &many); err != nil {
+			var one halLink
+			if err := json.Unmarshal(raw, &one); err != nil {
+				continue
+			}
+			many = []halLink{one}
+		}
+		for _, l := range many {
+			links = append(links, Link{
+				Rel:       strings.ToLower(rel),
+				URL:       l.Href,
+				Title:     l.Title,
+				Type:      l.Type,
+				Name:      l.Name,
+				Templated: l.Templated,
+			})
+		}
+	}
+	// map order is random, links sharing a rel keep the order of the document
+	sort.SliceStable(links, func(i, j int) bool { return links[i].Rel < links[j].Rel })
+	return links
+}
+
+// resolveLink resolves the url of link against the url of the response
+func (r *Response) resolveLink(link Link) Link {
+	base, err := url.Parse(r.URL)
+	if err != nil {
+		return link
+	}
+	if link.Templated {
+		// templates aren't valid urls until expanded, so only root relative ones are resolved
+		if strings.HasPrefix(link.URL, "/") && !strings.HasPrefix(link.URL, "//") {
+			link.URL = base.Scheme + "://" + base.Host + link.URL
+		}
+		return link
+	}
+	if ref, err := url.Parse(link.URL); err == nil {
+		link.URL = base.ResolveReference(ref).String()
+	}
+	return link
+}
+
+// parseLinks parses an RFC 8288 `Link` header. A link with several rels is returned once for each
+func parseLinks(h string) []Link {
+	var links []Link
+	for _, value := range splitLinks(h) {
+		parts := strings.Split(value, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		link := Link{URL: strings.Trim(target, "<>")}
+		var rels []string
+		for _, param := range parts[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			v := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			switch strings.ToLower(strings.TrimSpace(kv[0])) {
+			case "rel":
+				rels = strings.Fields(v)
+			case "title":
+				link.Title = v
+			case "type":
+				link.Type = v
+			}
+		}
+		for _, rel := range rels {
+			link.Rel = strings.ToLower(rel)
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// splitLinks splits a `Link` header on the commas between links, ignoring those
+// inside urls and quoted params
+func splitLinks(h string) []string {
+	var values []string
+	inURL, inQuote, start := false, false, 0
+	for i := 0; i < len(h); i++ {
+		switch c := h[i]; {
+		case c == '<' && !inQuote:
+			inURL = true
+		case c == '>' && !inQuote:
+			inURL = false
+		case c == '"' && !inURL:
+			inQuote = !inQuote
+		case c == ',' && !inURL && !inQuote:
+			values = append(values, h[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, h[start:])
+}
@@ -0,0 +1,88 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseLinks(t *testing.T) {
+	res := &Response{
+		URL: "https://api.example.com/orders/1?x=1",
+		Headers: http.Header{
+			"Link":         {`</orders?page=2>; rel="next last"; title="Next, please", <https://docs.example.com>; rel=help`},
+			"Content-Type": {ContentTypeHALJSON},
+		},
+		Body: []byte(`{"_links":{
+			"self":{"href":"/orders/1"},
+			"customer":{"href":"../customers/7","title":"Customer"},
+			"item":[{"href":"items/1"},{"href":"items/2","name":"second"}],
+			"find":{"href":"/orders{?id}","templated":true}
+		}}`),
+	}
+	assert.Equal(t, []Link{
+		{Rel: "next", URL: "https://api.example.com/orders?page=2", Title: "Next, please"},
+		{Rel: "last", URL: "https://api.example.com/orders?page=2", Title: "Next, please"},
+		{Rel: "help", URL: "https://docs.example.com"},
+		{Rel: "customer", URL: "https://api.example.com/customers/7", Title: "Customer"},
+		{Rel: "find", URL: "https://api.example.com/orders{?id}", Templated: true},
+		{Rel: "item", URL: "https://api.example.com/orders/items/1"},
+		{Rel: "item", URL: "https://api.example.com/orders/items/2", Name: "second"},
+		{Rel: "self", URL: "https://api.example.com/orders/1"},
+	}, res.Links())
+
+	link, ok := res.Link("ITEM")
+	assert.True(t, ok)
+	assert.Equal(t, "https://api.example.com/orders/items/1", link.URL)
+	_, ok = res.Link("missing")
+	assert.False(t, ok)
+}
+
+func TestResponseLinksIgnoresOtherBodies(t *testing.T) {
+	res := &Response{
+		URL:     "https://api.example.com/",
+		Headers: http.Header{"Content-Type": {"text/plain"}},
+		Body:    []byte(`{"_links":{"self":{"href":"/"}}}`),
+	}
+	assert.Empty(t, res.Links())
+}
+
+func TestFollowLink(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeHALJSON)
+		w.Write([]byte(`{"_links":{"customer":{"href":"/customers/7"},"search":{"href":"/customers{?name}","templated":true}}}`))
+	})
+	mux.HandleFunc("/customers/7", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("customer 7"))
+	})
+	mux.HandleFunc("/customers", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("search " + r.URL.Query().Get("name")))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	order, err := Get(ts.URL + "/orders/1")
+	require.NoError(t, err)
+	customer, err := order.FollowLink("customer")
+	require.NoError(t, err)
+	assert.Equal(t, "customer 7", string(customer.Body))
+	search, err := order.FollowLink("search", PathParams(map[string]string{"name": "ada"}))
+	require.NoError(t, err)
+	assert.Equal(t, "search ada", string(search.Body))
+
+	_, err = order.FollowLink("next")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+}
+
+func TestParseLinksQuotedComma(t *testing.T) {
+	links := parseLinks(`<https://a.example/x,y>; rel="alternate"; title="a, b", <https://b.example>; rel=next`)
+	require.Len(t, links, 2)
+	assert.Equal(t, "https://a.example/x,y", links[0].URL)
+	assert.Equal(t, "a, b", links[0].Title)
+	assert.Equal(t, "next", links[1].Rel)
+}
@@ -0,0 +1,262 @@
+// Package loadtest drives load at an api through an httpclient.Client and reports
+// latency percentiles, status counts and errors
+//
+//	c := httpclient.NewClient(httpclient.BaseURL("https://staging.example.com"))
+//	report, err := loadtest.Run(ctx, c, []httpclient.RequestOption{httpclient.Method("GET"), httpclient.Path("/users")},
+//		loadtest.Concurrency(20), loadtest.Duration(time.Minute), loadtest.RPS(200))
+//	fmt.Println(report)
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"golang.org/x/time/rate"
+)
+
+// ErrNoLimit is the error returned by `Run` without a `Duration` or `Requests` limit
+var ErrNoLimit = errors.New("load test needs a duration or a number of requests")
+
+type config struct {
+	concurrency int
+	duration    time.Duration
+	requests    int64
+	rps         float64
+}
+
+// Option configures a load test
+type Option func(*config)
+
+// Concurrency sets the number of requests in flight at once. The default is 1
+func Concurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// Duration stops sending new requests after d. Requests already in flight are finished
+func Duration(d time.Duration) Option {
+	return func(c *config) {
+		c.duration = d
+	}
+}
+
+// Requests stops after n requests have been sent
+func Requests(n int) Option {
+	return func(c *config) {
+		c.requests = int64(n)
+	}
+}
+
+// RPS limits the requests started to rps per second across all workers
+func RPS(rps float64) Option {
+	return func(c *config) {
+		c.rps = rps
+	}
+}
+
+// Percentiles summarizes the latencies of a load test
+type Percentiles struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+	Max  time.Duration
+}
+
+// Report is the result of a load test
+type Report struct {
+	// Requests is the number of requests sent
+	Requests int
+	// Errors is the number of requests that returned an error, including unexpected statuses
+	Errors   int
+	Duration time.Duration
+	// Throughput is the achieved number of requests per second
+	Throughput float64
+	Latency    Percentiles
+	// Statuses counts responses by status code
+	Statuses map[int]int
+	// ErrorKinds counts errors by kind, like `timeout` or `unexpected status`
+	ErrorKinds map[string]int
+}
+
+// String renders the report for a terminal
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests:   %d in %s (%.1f/s)\n", r.Requests, r.Duration.Round(time.Millisecond), r.Throughput)
+	fmt.Fprintf(&b, "errors:     %d\n", r.Errors)
+	l := r.Latency
+	fmt.Fprintf(&b, "latency:    min %s mean %s p50 %s p90 %s p95 %s p99 %s max %s\n", l.Min, l.Mean, l.P50, l.P90, l.P95, l.P99, l.Max)
+	codes := make([]int, 0, len(r.Statuses))
+	for code := range r.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(&b, "status %d: %d\n", code, r.Statuses[code])
+	}
+	kinds := make([]string, 0, len(r.ErrorKinds))
+	for kind := range r.ErrorKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "error %s: %d\n", kind, r.ErrorKinds[kind])
+	}
+	return b.String()
+}
+
+// recorder collects the results of requests from every worker
+type recorder struct {
+	sync.Mutex
+	latencies []time.Duration
+	statuses  map[int]int
+	kinds     map[string]int
+	errors    int
+}
+
+func (r *recorder) add(latency time.Duration, res *httpclient.Response, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.latencies = append(r.latencies, latency)
+	if res != nil {
+		r.statuses[res.Status]++
+	}
+	if err != nil {
+		r.errors++
+		r.kinds[errorKind(err)]++
+	}
+}
+
+// Run sends the request built from template with c until the `Duration` or `Requests`
+// limit is reached or ctx is done. A nil c uses a new client. Every request is built
+// from template again, so bodies should be set with `WithTypedBody` or `WithBodyFunc`
+func Run(ctx context.Context, c *httpclient.Client, template []httpclient.RequestOption, opts ...Option) (*Report, error) {
+	cfg := &config{concurrency: 1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.duration <= 0 && cfg.requests <= 0 {
+		return nil, ErrNoLimit
+	}
+	if c == nil {
+		c = httpclient.NewClient()
+	}
+	if _, _, err := c.New(template...); err != nil {
+		return nil, err
+	}
+	// run stops new requests, in flight requests use ctx so they aren't cut off
+	run, cancel := ctx, context.CancelFunc(func() {})
+	if cfg.duration > 0 {
+		run, cancel = context.WithTimeout(ctx, cfg.duration)
+	}
+	defer cancel()
+	var limiter *rate.Limiter
+	if cfg.rps > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.rps), 1)
+	}
+
+	rec := &recorder{statuses: map[int]int{}, kinds: map[string]int{}}
+	var issued int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < cfg.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run.Err() == nil {
+				if limiter != nil && limiter.Wait(run) != nil {
+					return
+				}
+				if cfg.requests > 0 && atomic.AddInt64(&issued, 1) > cfg.requests {
+					return
+				}
+				sent := time.Now()
+				res, err := send(ctx, c, template)
+				rec.add(time.Since(sent), res, err)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &Report{
+		Requests:   len(rec.latencies),
+		Errors:     rec.errors,
+		Duration:   elapsed,
+		Latency:    percentiles(rec.latencies),
+		Statuses:   rec.statuses,
+		ErrorKinds: rec.kinds,
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	}
+	return report, nil
+}
+
+func send(ctx context.Context, c *httpclient.Client, template []httpclient.RequestOption) (*httpclient.Response, error) {
+	req, _, err := c.New(template...)
+	if err != nil {
+		return nil, err
+	}
+	return req.Send(ctx)
+}
+
+// percentiles returns the nearest rank percentiles of latencies
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	return Percentiles{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  rank(0.50),
+		P90:  rank(0.90),
+		P95:  rank(0.95),
+		P99:  rank(0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// errorKind groups errors so a report doesn't list every url and address
+func errorKind(err error) string {
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, httpclient.ErrInvalidStatusCode):
+		return "unexpected status"
+	case errors.Is(err, httpclient.ErrExpectationFailed):
+		return "failed expectation"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &opErr):
+		return opErr.Op + " error"
+	}
+	return "other"
+}
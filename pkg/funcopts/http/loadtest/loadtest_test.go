@@ -0,0 +1,80 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRequests(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1)%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	c := httpclient.NewClient(httpclient.BaseURL(ts.URL))
+	report, err := Run(context.Background(), c, []httpclient.RequestOption{
+		httpclient.Method("GET"), httpclient.Path("/ping"), httpclient.ExpectSuccess(),
+	}, Concurrency(5), Requests(50))
+	require.NoError(t, err)
+	assert.Equal(t, 50, report.Requests)
+	assert.Equal(t, int32(50), atomic.LoadInt32(&n))
+	assert.Equal(t, map[int]int{200: 40, 503: 10}, report.Statuses)
+	assert.Equal(t, 10, report.Errors)
+	assert.Equal(t, map[string]int{"unexpected status": 10}, report.ErrorKinds)
+	assert.True(t, report.Latency.Min <= report.Latency.P50 && report.Latency.P50 <= report.Latency.Max)
+	assert.True(t, report.Throughput > 0)
+	assert.Contains(t, report.String(), "status 503: 10")
+}
+
+func TestRunDurationAndRPS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	report, err := Run(context.Background(), nil, []httpclient.RequestOption{httpclient.Method("GET"), httpclient.URL(ts.URL)},
+		Concurrency(4), Duration(300*time.Millisecond), RPS(20))
+	require.NoError(t, err)
+	assert.True(t, report.Requests >= 3 && report.Requests <= 8, "sent %d requests", report.Requests)
+	assert.Zero(t, report.Errors)
+}
+
+func TestRunConnectionErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+	report, err := Run(context.Background(), nil, []httpclient.RequestOption{httpclient.Method("GET"), httpclient.URL(ts.URL)}, Requests(3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Errors)
+	assert.Equal(t, map[string]int{"dial error": 3}, report.ErrorKinds)
+}
+
+func TestRunNeedsLimit(t *testing.T) {
+	_, err := Run(context.Background(), nil, []httpclient.RequestOption{httpclient.Method("GET"), httpclient.URL("http://example.com")})
+	assert.True(t, errors.Is(err, ErrNoLimit))
+}
+
+func TestPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	p := percentiles(latencies)
+	assert.Equal(t, Percentiles{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P95:  95 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}, p)
+	assert.Equal(t, Percentiles{}, percentiles(nil))
+}
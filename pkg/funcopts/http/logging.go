@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// DefaultRedactedHeaders is `transport.DefaultRedactedHeaders`, the headers that are
+// always redacted from logs. Add to the transport list to redact more headers by default
+var DefaultRedactedHeaders = transport.DefaultRedactedHeaders
+
+// redacted is the value logged in place of sensitive header values
+const redacted = transport.Redacted
+
+// Logger is the interface used to log requests. *slog.Logger satisfies it
+type Logger = transport.Logger
+
+type slogLogger struct {
+	*slog.Logger
+}
+
+func (l slogLogger) Info(msg string, args ...interface{}) {
+	l.Logger.Info(msg, args...)
+}
+
+func (l slogLogger) Debug(msg string, args ...interface{}) {
+	l.Logger.Debug(msg, args...)
+}
+
+// WithLogger logs a line for every request with the method, url, status, latency and sizes
+func WithLogger(l Logger) RequestOption {
+	return func(r *Request) error {
+		r.logger = l
+		return nil
+	}
+}
+
+// Debug additionally logs full request and response dumps.
+// Without `WithLogger` the default slog logger is used
+func Debug() RequestOption {
+	return func(r *Request) error {
+		r.debug = true
+		return nil
+	}
+}
+
+// RedactHeaders adds headers to be redacted from logs in addition to `DefaultRedactedHeaders`
+func RedactHeaders(names ...string) RequestOption {
+	return func(r *Request) error {
+		r.redactHeaders = append(r.redactHeaders, names...)
+		return nil
+	}
+}
+
+type redactionKey struct{}
+
+// Redaction lists what a request redacts from logs, dumps and recordings
+type Redaction struct {
+	// Headers are the redacted headers, `DefaultRedactedHeaders` included
+	Headers []string
+	// Query are the redacted query params
+	Query []string
+}
+
+// RedactionFromContext returns what the request being sent redacts, for middleware set
+// with `Use` that records or logs requests
+func RedactionFromContext(ctx context.Context) (Redaction, bool) {
+	r, ok := ctx.Value(redactionKey{}).(Redaction)
+	return r, ok
+}
+
+// withRedaction makes the redaction of the request available to the middleware in rt
+func (cr *Request) withRedaction(rt http.RoundTripper) http.RoundTripper {
+	redaction := Redaction{Headers: cr.redactedHeaders(), Query: append([]string{}, cr.redactQuery...)}
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return rt.RoundTrip(req.WithContext(context.WithValue(req.Context(), redactionKey{}, redaction)))
+	})
+}
+
+// redactedHeaders returns the headers redacted for the request
+func (cr *Request) redactedHeaders() []string {
+	return append(append([]string{}, transport.DefaultRedactedHeaders...), cr.redactHeaders...)
+}
+
+func (cr *Request) loggingTransport(rt http.RoundTripper) http.RoundTripper {
+	logger := cr.logger
+	if logger == nil {
+		logger = slogLogger{slog.Default()}
+	}
+	// the transport redacts `DefaultRedactedHeaders` itself
+	opts := []transport.LogOption{transport.Redact(cr.redactHeaders...), transport.RedactQuery(cr.redactQuery...)}
+	if cr.debug {
+		opts = append(opts, transport.Dump())
+	}
+	if cr.expectContinue || cr.streamBody {
+		opts = append(opts, transport.SkipRequestBody())
+	}
+	return transport.Logging(logger, opts...)(rt)
+}
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "logged body")
+	}))
+	defer ts.Close()
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+	_, err := Get(ts.URL+"/path", WithLogger(logger))
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `msg="http request"`)
+	assert.Contains(t, buf.String(), "method=GET")
+	assert.Contains(t, buf.String(), "status=200")
+	assert.Contains(t, buf.String(), "response_bytes=11")
+	assert.NotContains(t, buf.String(), "dump")
+}
+
+func TestDebugRedaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "sessionsecret"})
+		fmt.Fprint(w, r.Header.Get("X-Api-Key"))
+	}))
+	defer ts.Close()
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	res, err := Post(ts.URL, WithLogger(logger), Debug(), RedactHeaders("X-Api-Key"),
+		AddHeaders(map[string]string{"Authorization": "Bearer tokensecret", "X-Api-Key": "keysecret"}),
+		WithBody(strings.NewReader("posted body")))
+	assert.NoError(t, err)
+	assert.Equal(t, "keysecret", string(res.Body))
+	assert.Equal(t, "sessionsecret", res.Cookies[0].Value)
+	assert.Contains(t, buf.String(), "http request dump")
+	assert.Contains(t, buf.String(), "http response dump")
+	assert.Contains(t, buf.String(), "posted body")
+	assert.Contains(t, buf.String(), redacted)
+	assert.NotContains(t, buf.String(), "tokensecret")
+	assert.NotContains(t, buf.String(), "sessionsecret")
+}
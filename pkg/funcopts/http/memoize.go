@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// Memoize caches successful GET responses by url for ttl, ignoring caching headers.
+// Requests with different credentials, i.e. the values of redacted headers like
+// `Authorization` and `Cookie`, don't share responses.
+// It is a lighter alternative to `Cache` for origins that don't send useful headers.
+// Set it on a `Client` so requests share the memoized responses and use `Client.Invalidate`
+// to drop a response early
+func Memoize(ttl time.Duration) RequestOption {
+	m := &memo{ttl: ttl, entries: make(map[string]*memoEntry)}
+	return func(r *Request) error {
+		r.memo = m
+		return nil
+	}
+}
+
+// Invalidate removes the memoized response for url. The url is built with the client's
+// options and opts the same way a GET to url would be
+func (c *Client) Invalidate(url string, opts ...RequestOption) error {
+	r, req, err := c.New(append(opts, get(), setURL(url))...)
+	if err != nil {
+		return err
+	}
+	if r.memo != nil {
+		r.memo.delete(memoKey(req, r.redactedHeaders()))
+	}
+	return nil
+}
+
+type memo struct {
+	ttl     time.Duration
+	entries map[string]*memoEntry
+	sync.RWMutex
+}
+
+type memoEntry struct {
+	resp    *http.Response
+	body    []byte
+	expires time.Time
+}
+
+func (m *memo) get(key string, now time.Time) (*memoEntry, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	e, ok := m.entries[key]
+	if !ok || now.After(e.expires) {
+		return nil, false
+	}
+	return e, true
+}
+
+func (m *memo) set(key string, e *memoEntry, now time.Time) {
+	m.Lock()
+	defer m.Unlock()
+	for k, existing := range m.entries {
+		if now.After(existing.expires) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = e
+}
+
+func (m *memo) delete(key string) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.entries, key)
+}
+
+type memoTransport struct {
+	memo        *memo
+	credentials []string
+	next        http.RoundTripper
+}
+
+func (t *memoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return t.next.RoundTrip(req)
+	}
+	clk := clock.FromContext(req.Context())
+	key := memoKey(req, t.credentials)
+	if e, ok := t.memo.get(key, clk.Now()); ok {
+		return e.response(req), nil
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	now := clk.Now()
+	e := &memoEntry{resp: resp, body: body, expires: now.Add(t.memo.ttl)}
+	t.memo.set(key, e, now)
+	return e.response(req), nil
+}
+
+func (e *memoEntry) response(req *http.Request) *http.Response {
+	resp := *e.resp
+	resp.Header = e.resp.Header.Clone()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(e.body))
+	resp.Request = req
+	return &resp
+}
+
+// memoKey returns the url of req, followed by a hash of the credential headers it sends
+func memoKey(req *http.Request, credentials []string) string {
+	h := sha256.New()
+	sent := false
+	for _, name := range credentials {
+		for _, v := range req.Header.Values(name) {
+			h.Write([]byte(http.CanonicalHeaderKey(name) + ": " + v + "\n"))
+			sent = true
+		}
+	}
+	if !sent {
+		return req.URL.String()
+	}
+	return req.URL.String() + " " + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countingServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(hits, 1)
+		w.Header().Set("Cache-Control", "no-store")
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		fmt.Fprintf(w, "%d", n)
+	}))
+}
+
+func TestMemoize(t *testing.T) {
+	var hits int32
+	ts := countingServer(&hits)
+	defer ts.Close()
+	c := NewClient(Memoize(time.Minute))
+	for i := 0; i < 3; i++ {
+		res, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", string(res.Body))
+	}
+	_, err := c.Post(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestMemoizeExpires(t *testing.T) {
+	var hits int32
+	ts := countingServer(&hits)
+	defer ts.Close()
+	c := NewClient(Memoize(10 * time.Millisecond))
+	_, err := c.Get(ts.URL)
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	res, err := c.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", string(res.Body))
+}
+
+func TestMemoizeSkipsErrors(t *testing.T) {
+	var hits int32
+	ts := countingServer(&hits)
+	defer ts.Close()
+	c := NewClient(Memoize(time.Minute))
+	for i := 0; i < 2; i++ {
+		_, err := c.Get(ts.URL, QueryParam("fail", "true"))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestInvalidate(t *testing.T) {
+	var hits int32
+	ts := countingServer(&hits)
+	defer ts.Close()
+	c := NewClient(Memoize(time.Minute), BaseURL(ts.URL))
+	_, err := c.Get("/items", QueryParam("page", "1"))
+	assert.NoError(t, err)
+	assert.NoError(t, c.Invalidate("/items", QueryParam("page", "1")))
+	res, err := c.Get("/items", QueryParam("page", "1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", string(res.Body))
+}
+
+func TestMemoizeCredentials(t *testing.T) {
+	var hits int32
+	ts := countingServer(&hits)
+	defer ts.Close()
+	c := NewClient(Memoize(time.Minute), BaseURL(ts.URL))
+	alice, bob := BearerToken("alice"), BearerToken("bob")
+	for _, auth := range []RequestOption{alice, bob, alice} {
+		_, err := c.Get("/me", auth)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	assert.NoError(t, c.Invalidate("/me", bob))
+	res, err := c.Get("/me", bob)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", string(res.Body))
+	res, err = c.Get("/me", alice)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(res.Body))
+}
@@ -0,0 +1,14 @@
+package httpclient
+
+import "github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+
+// Observation describes one attempt of a request for `Metrics`
+type Observation = transport.Observation
+
+// Metrics calls observe after every attempt, including retries and redirects
+func Metrics(observe func(Observation)) RequestOption {
+	return func(r *Request) error {
+		r.metrics = append(r.metrics, observe)
+		return nil
+	}
+}
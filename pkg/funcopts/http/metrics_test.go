@@ -0,0 +1,20 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	ts, _ := flakyServer(1, http.StatusServiceUnavailable)
+	defer ts.Close()
+	var statuses []int
+	_, err := Get(ts.URL, Retry(1, time.Millisecond), Metrics(func(o Observation) {
+		statuses = append(statuses, o.Status)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusOK}, statuses)
+}
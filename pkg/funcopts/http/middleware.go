@@ -0,0 +1,12 @@
+package httpclient
+
+import "github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+
+// Use wraps the transport of the request with middleware, the first outermost.
+// The middleware sees each attempt as it is sent, after the layers added by other options
+func Use(middleware ...transport.Middleware) RequestOption {
+	return func(r *Request) error {
+		r.middleware = append(r.middleware, middleware...)
+		return nil
+	}
+}
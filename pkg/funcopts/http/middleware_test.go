@@ -0,0 +1,29 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join(r.Header.Values("X-Order"), ",")))
+	}))
+	defer ts.Close()
+	order := func(name string) transport.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Add("X-Order", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	response, err := Get(ts.URL, Use(order("outer"), order("inner")))
+	assert.NoError(t, err)
+	assert.Equal(t, "outer,inner", string(response.Body))
+}
@@ -0,0 +1,251 @@
+// Package mock provides an http.RoundTripper driven by expectations so code using
+// httpclient can be tested without running a server
+//
+//	m := mock.New()
+//	m.ExpectGET("/users/1").ReturnJSON(200, user)
+//	res, err := httpclient.Get("http://api.example.com/users/1", m.Option())
+//	m.AssertExpectations(t)
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// ErrUnmatchedRequest is the error returned for requests that match no expectation
+var ErrUnmatchedRequest = errors.New("request did not match any expectation")
+
+// TestingT is the subset of *testing.T used to report failures
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Transport is an http.RoundTripper serving responses from expectations
+type Transport struct {
+	expectations []*Expectation
+	unmatched    []string
+	sync.Mutex
+}
+
+// New returns a `Transport` with no expectations
+func New() *Transport {
+	return &Transport{}
+}
+
+// Expect registers an expectation for a request with the given method and path
+func (m *Transport) Expect(method, path string) *Expectation {
+	m.Lock()
+	defer m.Unlock()
+	e := &Expectation{
+		method:  method,
+		path:    path,
+		headers: make(http.Header),
+		times:   1,
+		status:  http.StatusOK,
+		respHdr: make(http.Header),
+	}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// ExpectGET registers an expectation for a GET request to path
+func (m *Transport) ExpectGET(path string) *Expectation {
+	return m.Expect("GET", path)
+}
+
+// ExpectPOST registers an expectation for a POST request to path
+func (m *Transport) ExpectPOST(path string) *Expectation {
+	return m.Expect("POST", path)
+}
+
+// ExpectPUT registers an expectation for a PUT request to path
+func (m *Transport) ExpectPUT(path string) *Expectation {
+	return m.Expect("PUT", path)
+}
+
+// ExpectDELETE registers an expectation for a DELETE request to path
+func (m *Transport) ExpectDELETE(path string) *Expectation {
+	return m.Expect("DELETE", path)
+}
+
+// ExpectHEAD registers an expectation for a HEAD request to path
+func (m *Transport) ExpectHEAD(path string) *Expectation {
+	return m.Expect("HEAD", path)
+}
+
+// Client returns an http.Client using the transport
+func (m *Transport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// Option returns a `RequestOption` that routes requests through the transport
+func (m *Transport) Option() httpclient.RequestOption {
+	return httpclient.SetClient(m.Client())
+}
+
+// RoundTrip serves the response of the first expectation matching the request
+func (m *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+	m.Lock()
+	defer m.Unlock()
+	for _, e := range m.expectations {
+		if !e.exhausted() && e.matches(req, body) {
+			e.calls++
+			return e.response(req)
+		}
+	}
+	m.unmatched = append(m.unmatched, req.Method+" "+req.URL.String())
+	return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrUnmatchedRequest)
+}
+
+// AssertExpectations reports unmet expectations and unmatched requests to t
+func (m *Transport) AssertExpectations(t TestingT) bool {
+	m.Lock()
+	defer m.Unlock()
+	ok := true
+	for _, e := range m.expectations {
+		if !e.anyTimes && e.calls != e.times {
+			t.Errorf("expected %s %s to be called %d time(s), was called %d time(s)", e.method, e.path, e.times, e.calls)
+			ok = false
+		}
+	}
+	for _, req := range m.unmatched {
+		t.Errorf("unexpected request: %s", req)
+		ok = false
+	}
+	return ok
+}
+
+// Expectation describes an expected request and the response to return for it
+type Expectation struct {
+	method   string
+	path     string
+	query    map[string]string
+	headers  http.Header
+	body     []byte
+	times    int
+	anyTimes bool
+	calls    int
+	status   int
+	respHdr  http.Header
+	respBody []byte
+	err      error
+}
+
+// WithQuery requires the request to have the query param key set to value
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	if e.query == nil {
+		e.query = make(map[string]string)
+	}
+	e.query[key] = value
+	return e
+}
+
+// WithHeader requires the request to have the header key set to value
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.headers.Set(key, value)
+	return e
+}
+
+// WithBody requires the request body to equal body
+func (e *Expectation) WithBody(body string) *Expectation {
+	e.body = []byte(body)
+	return e
+}
+
+// Times sets how many times the expectation must be matched. The default is once
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows the expectation to be matched any number of times, including never
+func (e *Expectation) AnyTimes() *Expectation {
+	e.anyTimes = true
+	return e
+}
+
+// Return sets the status and body of the response
+func (e *Expectation) Return(status int, body string) *Expectation {
+	e.status = status
+	e.respBody = []byte(body)
+	return e
+}
+
+// ReturnJSON sets the status of the response and encodes v as its json body
+func (e *Expectation) ReturnJSON(status int, v interface{}) *Expectation {
+	data, err := json.Marshal(v)
+	if err != nil {
+		e.err = err
+		return e
+	}
+	e.status = status
+	e.respBody = data
+	e.respHdr.Set("Content-Type", httpclient.ContentTypeJSON)
+	return e
+}
+
+// ReturnHeader adds a header to the response
+func (e *Expectation) ReturnHeader(key, value string) *Expectation {
+	e.respHdr.Add(key, value)
+	return e
+}
+
+// ReturnError makes the request fail with err instead of returning a response
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) exhausted() bool {
+	return !e.anyTimes && e.calls >= e.times
+}
+
+func (e *Expectation) matches(req *http.Request, body []byte) bool {
+	if req.Method != e.method || req.URL.Path != e.path {
+		return false
+	}
+	for k, v := range e.query {
+		if req.URL.Query().Get(k) != v {
+			return false
+		}
+	}
+	for k := range e.headers {
+		if req.Header.Get(k) != e.headers.Get(k) {
+			return false
+		}
+	}
+	return e.body == nil || bytes.Equal(e.body, body)
+}
+
+func (e *Expectation) response(req *http.Request) (*http.Response, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.respHdr.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.respBody)),
+		ContentLength: int64(len(e.respBody)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	Name string `json:"name"`
+}
+
+type testRecorder struct {
+	errors []string
+}
+
+func (r *testRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestExpectGETReturnJSON(t *testing.T) {
+	m := New()
+	m.ExpectGET("/users/1").WithQuery("expand", "all").ReturnJSON(200, testUser{Name: "lusis"})
+	res, err := httpclient.Get("http://api.example.com/users/1", m.Option(),
+		httpclient.QueryParams(map[string]string{"expand": "all"}))
+	assert.NoError(t, err)
+	user := testUser{}
+	assert.NoError(t, json.Unmarshal(res.Body, &user))
+	assert.Equal(t, "lusis", user.Name)
+	assert.Equal(t, httpclient.ContentTypeJSON, res.Headers.Get("Content-Type"))
+	m.AssertExpectations(t)
+}
+
+func TestExpectPOSTWithBody(t *testing.T) {
+	m := New()
+	m.ExpectPOST("/users").WithHeader("Content-Type", "text/plain").WithBody("lusis").Return(201, "created").Times(2)
+	for i := 0; i < 2; i++ {
+		res, err := httpclient.Post("http://api.example.com/users", m.Option(),
+			httpclient.ContentType("text/plain"), httpclient.WithBody(strings.NewReader("lusis")))
+		assert.NoError(t, err)
+		assert.Equal(t, 201, res.Status)
+	}
+	m.AssertExpectations(t)
+}
+
+func TestUnmatchedRequest(t *testing.T) {
+	m := New()
+	m.ExpectGET("/users/1")
+	_, err := httpclient.Get("http://api.example.com/users/2", m.Option())
+	assert.True(t, errors.Is(err, ErrUnmatchedRequest))
+	rec := &testRecorder{}
+	assert.False(t, m.AssertExpectations(rec))
+	assert.Len(t, rec.errors, 2)
+}
+
+func TestReturnError(t *testing.T) {
+	m := New()
+	boom := errors.New("connection reset")
+	m.ExpectDELETE("/users/1").ReturnError(boom)
+	_, err := httpclient.Delete("http://api.example.com/users/1", m.Option())
+	assert.True(t, errors.Is(err, boom))
+	m.AssertExpectations(t)
+}
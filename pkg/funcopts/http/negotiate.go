@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type negotiateConfig struct {
+	krb5Conf string
+	ccache   string
+	keytab   string
+	user     string
+	realm    string
+	spn      string
+}
+
+// NegotiateOption configures `NegotiateAuth`
+type NegotiateOption func(*negotiateConfig)
+
+// KerberosConfig reads the Kerberos configuration from path instead of `KRB5_CONFIG` or /etc/krb5.conf
+func KerberosConfig(path string) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.krb5Conf = path
+	}
+}
+
+// KerberosCCache takes tickets from the credential cache at path instead of `KRB5CCNAME`
+// or /tmp/krb5cc_<uid>, the cache `kinit` writes
+func KerberosCCache(path string) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.ccache = path
+	}
+}
+
+// KerberosKeytab logs in as user@realm with the keys in the keytab at path, for services
+// that run without a ccache
+func KerberosKeytab(path, user, realm string) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.keytab, c.user, c.realm = path, user, realm
+	}
+}
+
+// ServicePrincipal requests tickets for spn instead of `HTTP/<host>`
+func ServicePrincipal(spn string) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.spn = spn
+	}
+}
+
+func newNegotiateConfig(opts []NegotiateOption) negotiateConfig {
+	c := negotiateConfig{krb5Conf: os.Getenv("KRB5_CONFIG"), ccache: os.Getenv("KRB5CCNAME")}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.krb5Conf == "" {
+		c.krb5Conf = "/etc/krb5.conf"
+	}
+	c.ccache = strings.TrimPrefix(c.ccache, "FILE:")
+	if c.ccache == "" {
+		c.ccache = fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid())
+	}
+	return c
+}
@@ -0,0 +1,115 @@
+//go:build kerberos
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// NegotiateAuth authenticates with Kerberos through the SPNEGO `Negotiate` scheme used by
+// IIS and other Kerberized intranet services. A request that gets a 401 with a
+// `WWW-Authenticate: Negotiate` challenge is sent once more with a service ticket, and
+// later requests to the same host made with the option send one up front. Tickets come
+// from the `kinit` credential cache unless `KerberosKeytab` is set. It needs a build with
+// `-tags kerberos`
+func NegotiateAuth(opts ...NegotiateOption) RequestOption {
+	n := &negotiator{config: newNegotiateConfig(opts)}
+	return func(r *Request) error {
+		r.negotiate = n
+		return nil
+	}
+}
+
+type negotiator struct {
+	config negotiateConfig
+	sync.Mutex
+	client *client.Client
+	// hosts holds the hosts that asked for Negotiate
+	hosts sync.Map
+}
+
+// login returns the Kerberos client, logging in again after a failure
+func (n *negotiator) login() (*client.Client, error) {
+	n.Lock()
+	defer n.Unlock()
+	if n.client != nil {
+		return n.client, nil
+	}
+	cfg, err := config.Load(n.config.krb5Conf)
+	if err != nil {
+		return nil, err
+	}
+	var cl *client.Client
+	if n.config.keytab != "" {
+		kt, err := keytab.Load(n.config.keytab)
+		if err != nil {
+			return nil, err
+		}
+		cl = client.NewWithKeytab(n.config.user, n.config.realm, kt, cfg, client.DisablePAFXFAST(true))
+	} else {
+		ccache, err := credentials.LoadCCache(n.config.ccache)
+		if err != nil {
+			return nil, err
+		}
+		if cl, err = client.NewFromCCache(ccache, cfg, client.DisablePAFXFAST(true)); err != nil {
+			return nil, err
+		}
+	}
+	if err := cl.Login(); err != nil {
+		return nil, err
+	}
+	n.client = cl
+	return cl, nil
+}
+
+// authorize sets the `Authorization` header of req to a SPNEGO token
+func (n *negotiator) authorize(req *http.Request) error {
+	cl, err := n.login()
+	if err == nil {
+		err = spnego.SetSPNEGOHeader(cl, req, n.config.spn)
+	}
+	if err != nil {
+		return fmt.Errorf("negotiate auth: %w", err)
+	}
+	return nil
+}
+
+func (n *negotiator) transport(next http.RoundTripper) http.RoundTripper {
+	return &negotiateTransport{negotiator: n, next: next}
+}
+
+type negotiateTransport struct {
+	*negotiator
+	next http.RoundTripper
+}
+
+func (t *negotiateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := t.hosts.Load(req.URL.Host); ok {
+		authed := req.Clone(req.Context())
+		if err := t.authorize(authed); err != nil {
+			return nil, err
+		}
+		return t.next.RoundTrip(authed)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !challenged(resp, "Negotiate") {
+		return resp, err
+	}
+	retry, ok := resendable(req, resp)
+	if !ok {
+		return resp, nil
+	}
+	if err := t.authorize(retry); err != nil {
+		return nil, err
+	}
+	t.hosts.Store(req.URL.Host, true)
+	return t.next.RoundTrip(retry)
+}
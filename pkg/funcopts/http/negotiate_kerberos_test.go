@@ -0,0 +1,43 @@
+//go:build kerberos
+
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateAuthWithoutChallenge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+	}))
+	defer ts.Close()
+	missing := filepath.Join(t.TempDir(), "missing")
+	// credentials are only loaded once a server asks for them
+	_, err := Get(ts.URL, NegotiateAuth(KerberosConfig(missing), KerberosCCache(missing)))
+	require.NoError(t, err)
+}
+
+func TestNegotiateAuthLoginError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", "Negotiate")
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	missing := filepath.Join(t.TempDir(), "missing")
+	_, err := Get(ts.URL, NegotiateAuth(KerberosConfig(missing), KerberosCCache(missing)))
+	assert.ErrorContains(t, err, "negotiate auth")
+}
+
+func TestNegotiateAuthDescribe(t *testing.T) {
+	r, _, err := New(get(), setURL("https://example.com/items"), NegotiateAuth())
+	require.NoError(t, err)
+	d, err := r.Describe()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"negotiate"}, d.Features)
+}
@@ -0,0 +1,20 @@
+//go:build !kerberos
+
+package httpclient
+
+import "net/http"
+
+// NegotiateAuth authenticates with Kerberos through the SPNEGO `Negotiate` scheme. It
+// needs a build with `-tags kerberos`; without it requests made with the option fail
+// with `ErrKerberosUnavailable`
+func NegotiateAuth(opts ...NegotiateOption) RequestOption {
+	return func(r *Request) error {
+		return ErrKerberosUnavailable
+	}
+}
+
+type negotiator struct{}
+
+func (n *negotiator) transport(next http.RoundTripper) http.RoundTripper {
+	return next
+}
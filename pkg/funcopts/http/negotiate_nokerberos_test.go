@@ -0,0 +1,15 @@
+//go:build !kerberos
+
+package httpclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateAuthUnavailable(t *testing.T) {
+	_, err := Get("http://intranet.example.com", NegotiateAuth())
+	assert.True(t, errors.Is(err, ErrKerberosUnavailable))
+}
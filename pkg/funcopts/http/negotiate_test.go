@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateConfig(t *testing.T) {
+	t.Setenv("KRB5_CONFIG", "")
+	t.Setenv("KRB5CCNAME", "")
+	c := newNegotiateConfig(nil)
+	assert.Equal(t, "/etc/krb5.conf", c.krb5Conf)
+	assert.Equal(t, fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid()), c.ccache)
+
+	t.Setenv("KRB5_CONFIG", "/opt/krb5.conf")
+	t.Setenv("KRB5CCNAME", "FILE:/run/user/krb5cc")
+	c = newNegotiateConfig(nil)
+	assert.Equal(t, "/opt/krb5.conf", c.krb5Conf)
+	assert.Equal(t, "/run/user/krb5cc", c.ccache)
+
+	c = newNegotiateConfig([]NegotiateOption{
+		KerberosConfig("/etc/other.conf"),
+		KerberosKeytab("/etc/svc.keytab", "svc", "CORP.EXAMPLE.COM"),
+		ServicePrincipal("HTTP/intranet.corp.example.com"),
+	})
+	assert.Equal(t, "/etc/other.conf", c.krb5Conf)
+	assert.Equal(t, "/etc/svc.keytab", c.keytab)
+	assert.Equal(t, "svc", c.user)
+	assert.Equal(t, "CORP.EXAMPLE.COM", c.realm)
+	assert.Equal(t, "HTTP/intranet.corp.example.com", c.spn)
+}
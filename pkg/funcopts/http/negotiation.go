@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// AcceptLanguage sets `Accept-Language` to tags in order of preference, e.g.
+// `AcceptLanguage("en-US", "en", "fr")` sends `en-US, en;q=0.9, fr;q=0.8`
+func AcceptLanguage(tags ...string) RequestOption {
+	return func(r *Request) error {
+		r.headers["Accept-Language"] = weighted(tags)
+		return nil
+	}
+}
+
+// AcceptAny accepts any of types in order of preference, weighted like `AcceptLanguage`
+func AcceptAny(types ...string) RequestOption {
+	return func(r *Request) error {
+		r.accept = weighted(types)
+		return nil
+	}
+}
+
+// weighted joins values with decreasing q-values so their order is kept
+func weighted(values []string) string {
+	step := 100
+	if len(values) > 10 {
+		step = 1000 / len(values)
+	}
+	parts := make([]string, 0, len(values))
+	for i, v := range values {
+		if i == 0 {
+			parts = append(parts, v)
+			continue
+		}
+		parts = append(parts, v+";q="+qvalue(1000-i*step))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// qvalue formats thousandths as a q-value
+func qvalue(thousandths int) string {
+	q := strconv.Itoa(thousandths)
+	for len(q) < 3 {
+		q = "0" + q
+	}
+	return strings.TrimRight("0."+q, "0")
+}
+
+// MediaType is a parsed `Content-Type`
+type MediaType struct {
+	// Type is the lowercased media type, e.g. `application/json`
+	Type string
+	// Params are the parameters, e.g. `charset`
+	Params map[string]string
+}
+
+// MediaType parses the `Content-Type` of the response
+func (r *Response) MediaType() (MediaType, error) {
+	t, params, err := mime.ParseMediaType(r.Headers.Get("Content-Type"))
+	if err != nil {
+		return MediaType{}, err
+	}
+	return MediaType{Type: t, Params: params}, nil
+}
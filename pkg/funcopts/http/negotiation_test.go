@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptLanguage(t *testing.T) {
+	_, req, err := New(get(), setURL("http://example.com"), AcceptLanguage("en-US", "en", "fr"))
+	assert.NoError(t, err)
+	assert.Equal(t, "en-US, en;q=0.9, fr;q=0.8", req.Header.Get("Accept-Language"))
+}
+
+func TestAcceptAny(t *testing.T) {
+	_, req, err := New(get(), setURL("http://example.com"), AcceptAny(ContentTypeJSON, ContentTypeXML))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json, application/xml;q=0.9", req.Header.Get("Accept"))
+}
+
+func TestWeightedMany(t *testing.T) {
+	values := make([]string, 20)
+	for i := range values {
+		values[i] = fmt.Sprintf("l%d", i)
+	}
+	w := weighted(values)
+	assert.Contains(t, w, "l1;q=0.95")
+	assert.Contains(t, w, "l19;q=0.05")
+}
+
+func TestMediaType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "Application/JSON; charset=UTF-8")
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL)
+	assert.NoError(t, err)
+	mt, err := res.MediaType()
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, mt.Type)
+	assert.Equal(t, "UTF-8", mt.Params["charset"])
+
+	_, err = (&Response{Headers: http.Header{}}).MediaType()
+	assert.Error(t, err)
+}
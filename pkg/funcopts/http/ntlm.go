@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/go-ntlmssp"
+)
+
+// NTLMAuth authenticates with the NTLM handshake that legacy Windows services and proxies
+// like Exchange EWS still demand. NTLM authenticates a connection rather than a request,
+// so each request gets a connection of its own: the request is sent with the negotiate
+// message and, when the server answers with a challenge, sent again with the authenticate
+// message on the same connection. Leave domain empty for a user given as `user@domain`.
+// The connection can't be pinned for transports that aren't an *http.Transport. Redirects
+// to other hosts are followed without the handshake
+func NTLMAuth(domain, user, password string) RequestOption {
+	if domain != "" {
+		user = domain + `\` + user
+	}
+	return func(r *Request) error {
+		r.ntlm = &ntlmCredentials{user: user, password: password}
+		return nil
+	}
+}
+
+type ntlmCredentials struct {
+	user     string
+	password string
+}
+
+type ntlmTransport struct {
+	creds *ntlmCredentials
+	base  http.RoundTripper
+}
+
+func (t *ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != originalHost(req) {
+		return t.base.RoundTrip(req)
+	}
+	rt := t.base
+	var dedicated *http.Transport
+	if base, ok := t.base.(*http.Transport); ok {
+		dedicated = base.Clone()
+		dedicated.MaxConnsPerHost = 1
+		dedicated.DisableKeepAlives = false
+		rt = dedicated
+	}
+	resp, err := t.handshake(rt, req)
+	if dedicated == nil {
+		return resp, err
+	}
+	if err != nil {
+		dedicated.CloseIdleConnections()
+		return resp, err
+	}
+	resp.Body = &closeIdleBody{ReadCloser: resp.Body, transport: dedicated}
+	return resp, nil
+}
+
+func (t *ntlmTransport) handshake(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+	negotiate, err := ntlmssp.NewNegotiateMessage("", "")
+	if err != nil {
+		return nil, err
+	}
+	first := req.Clone(req.Context())
+	first.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(negotiate))
+	resp, err := rt.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := ntlmChallenge(resp)
+	if challenge == nil {
+		return resp, nil
+	}
+	authenticate, err := ntlmssp.NewAuthenticateMessage(challenge, t.creds.user, t.creds.password, nil)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("ntlm auth: %w", err)
+	}
+	retry, ok := resendable(req, resp)
+	if !ok {
+		return resp, nil
+	}
+	retry.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(authenticate))
+	return rt.RoundTrip(retry)
+}
+
+// ntlmChallenge returns the challenge message of an `WWW-Authenticate: NTLM` header
+func ntlmChallenge(resp *http.Response) []byte {
+	for _, value := range resp.Header.Values("WWW-Authenticate") {
+		scheme, token, _ := strings.Cut(strings.TrimSpace(value), " ")
+		if !strings.EqualFold(scheme, "NTLM") || token == "" {
+			continue
+		}
+		if challenge, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token)); err == nil {
+			return challenge
+		}
+	}
+	return nil
+}
+
+// closeIdleBody closes the connections of a dedicated transport once the body is closed
+type closeIdleBody struct {
+	io.ReadCloser
+	transport *http.Transport
+}
+
+func (b *closeIdleBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.transport.CloseIdleConnections()
+	return err
+}
@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ntlmMessage decodes the message of an `Authorization: NTLM` header and returns its type
+func ntlmMessage(t *testing.T, authz string) ([]byte, uint32) {
+	token, ok := strings.CutPrefix(authz, "NTLM ")
+	require.True(t, ok, authz)
+	msg, err := base64.StdEncoding.DecodeString(token)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(msg, []byte("NTLMSSP\x00")))
+	return msg, binary.LittleEndian.Uint32(msg[8:12])
+}
+
+// ntlmChallengeMessage builds a challenge message with an empty target info
+func ntlmChallengeMessage() string {
+	var b bytes.Buffer
+	b.WriteString("NTLMSSP\x00")
+	binary.Write(&b, binary.LittleEndian, uint32(2))
+	binary.Write(&b, binary.LittleEndian, [8]byte{})
+	// unicode, ntlm, extended session security and target info
+	binary.Write(&b, binary.LittleEndian, uint32(1|1<<9|1<<19|1<<23))
+	b.WriteString("01234567")
+	b.Write(make([]byte, 8))
+	binary.Write(&b, binary.LittleEndian, []uint16{4, 4})
+	binary.Write(&b, binary.LittleEndian, uint32(48))
+	b.Write(make([]byte, 4))
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+func utf16le(s string) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, utf16.Encode([]rune(s)))
+	return b.Bytes()
+}
+
+func TestNTLMAuth(t *testing.T) {
+	var negotiatedOn string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		msg, typ := ntlmMessage(t, r.Header.Get("Authorization"))
+		switch typ {
+		case 1:
+			negotiatedOn = r.RemoteAddr
+			w.Header().Set("WWW-Authenticate", "NTLM "+ntlmChallengeMessage())
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			assert.Equal(t, negotiatedOn, r.RemoteAddr, "authenticated on the negotiating connection")
+			assert.True(t, bytes.Contains(msg, utf16le("jdoe")))
+			assert.True(t, bytes.Contains(msg, utf16le("CORP")))
+			w.Write(body)
+		}
+	}))
+	defer ts.Close()
+	res, err := Post(ts.URL, WithBody(strings.NewReader("<FindItem/>")), NTLMAuth("CORP", "jdoe", "secret"), ExpectSuccess())
+	require.NoError(t, err)
+	assert.Equal(t, "<FindItem/>", string(res.Body))
+}
+
+func TestNTLMAuthNotChallenged(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, typ := ntlmMessage(t, r.Header.Get("Authorization"))
+		assert.Equal(t, uint32(1), typ)
+		w.Write([]byte("anonymous"))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, NTLMAuth("", "jdoe@corp.example.com", "secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", string(res.Body))
+}
+
+func TestNTLMAuthOtherHost(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/other" {
+			http.Redirect(w, r, "http://"+strings.Replace(r.Host, "127.0.0.1", "localhost", 1)+"/echo", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL+"/other", NTLMAuth("CORP", "jdoe", "secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "", string(res.Body))
+}
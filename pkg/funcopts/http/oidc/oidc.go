@@ -0,0 +1,307 @@
+// Package oidc gets access tokens from an OpenID Connect provider with the
+// client_credentials grant and sends them as bearer tokens, renewing them before
+// they expire
+//
+//	tokens := oidc.New("https://login.example.com", clientID, oidc.ClientSecret(secret), oidc.Scopes("orders:read"))
+//	c := httpclient.NewClient(tokens.Option())
+//	res, err := c.Get("https://orders.example.com/v1/orders")
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+const (
+	// DefaultRenewBefore is how long before expiry a token is renewed
+	DefaultRenewBefore = time.Minute
+	// ClientAssertionType is the client_assertion_type of private_key_jwt client auth
+	ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var (
+	// ErrIssuerMismatch is the error for discovery documents with another issuer than the one requested
+	ErrIssuerMismatch = errors.New("discovery document issuer doesn't match")
+	// ErrNoTokenEndpoint is the error for providers that don't advertise a token endpoint
+	ErrNoTokenEndpoint = errors.New("provider has no token endpoint")
+)
+
+// Provider is the part of an OpenID Connect discovery document used to get tokens
+type Provider struct {
+	Issuer                            string   `json:"issuer"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+}
+
+// Discover fetches the discovery document of issuer from its
+// `/.well-known/openid-configuration` and checks that it's for issuer
+func Discover(ctx context.Context, issuer string, opts ...httpclient.RequestOption) (*Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	p, err := httpclient.GetJSON[*Provider](ctx, issuer+"/.well-known/openid-configuration", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: got %q, want %q", ErrIssuerMismatch, p.Issuer, issuer)
+	}
+	if p.TokenEndpoint == "" {
+		return nil, ErrNoTokenEndpoint
+	}
+	return p, nil
+}
+
+// Token is an access token from a token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	// Expiry is when the token expires, zero when the provider didn't say
+	Expiry time.Time `json:"-"`
+}
+
+// TokenError is an error response of a token endpoint
+type TokenError struct {
+	Status      int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("token request failed with %d: %s: %s", e.Status, e.Code, e.Description)
+	}
+	return fmt.Sprintf("token request failed with %d: %s", e.Status, e.Code)
+}
+
+type config struct {
+	secret      string
+	key         crypto.Signer
+	tokenURL    string
+	scopes      []string
+	params      url.Values
+	renewBefore time.Duration
+	opts        []httpclient.RequestOption
+}
+
+// Option configures `Tokens`
+type Option func(*config)
+
+// ClientSecret authenticates the client with client_secret_basic
+func ClientSecret(secret string) Option {
+	return func(cfg *config) {
+		cfg.secret = secret
+	}
+}
+
+// PrivateKeyJWT authenticates the client with a client assertion signed with key
+func PrivateKeyJWT(key crypto.Signer) Option {
+	return func(cfg *config) {
+		cfg.key = key
+	}
+}
+
+// TokenURL sends token requests to u instead of the discovered token endpoint
+func TokenURL(u string) Option {
+	return func(cfg *config) {
+		cfg.tokenURL = u
+	}
+}
+
+// Scopes requests tokens for scopes
+func Scopes(scopes ...string) Option {
+	return func(cfg *config) {
+		cfg.scopes = append(cfg.scopes, scopes...)
+	}
+}
+
+// Param adds a form parameter to token requests, like the `audience` or `resource`
+// some providers require
+func Param(name, value string) Option {
+	return func(cfg *config) {
+		cfg.params.Add(name, value)
+	}
+}
+
+// RenewBefore renews tokens d before they expire instead of `DefaultRenewBefore`
+func RenewBefore(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.renewBefore = d
+	}
+}
+
+// RequestOptions applies opts to discovery and token requests
+func RequestOptions(opts ...httpclient.RequestOption) Option {
+	return func(cfg *config) {
+		cfg.opts = append(cfg.opts, opts...)
+	}
+}
+
+// Tokens gets and caches the access tokens of a client. It's safe for concurrent use
+// and callers waiting on a renewal share its token
+type Tokens struct {
+	issuer   string
+	clientID string
+	cfg      *config
+	sync.Mutex
+	provider *Provider
+	token    *Token
+}
+
+// New returns `Tokens` for the client clientID of the provider issuer. Without
+// `ClientSecret` or `PrivateKeyJWT` the client_id is sent as a public client
+func New(issuer, clientID string, opts ...Option) *Tokens {
+	cfg := &config{params: url.Values{}, renewBefore: DefaultRenewBefore}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &Tokens{issuer: issuer, clientID: clientID, cfg: cfg}
+}
+
+// Token returns the cached token or gets a new one when there is none or it expires
+// within the renewal window. Time is read from the clock of ctx
+func (t *Tokens) Token(ctx context.Context) (*Token, error) {
+	t.Lock()
+	defer t.Unlock()
+	now := clock.FromContext(ctx).Now()
+	if t.token != nil && (t.token.Expiry.IsZero() || now.Before(t.token.Expiry.Add(-t.cfg.renewBefore))) {
+		return t.token, nil
+	}
+	endpoint, err := t.endpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := t.request(ctx, endpoint, now)
+	if err != nil {
+		return nil, err
+	}
+	t.token = tok
+	return tok, nil
+}
+
+// Invalidate drops the cached token so the next call to `Token` gets a new one
+func (t *Tokens) Invalidate() {
+	t.Lock()
+	t.token = nil
+	t.Unlock()
+}
+
+// Option sends the access token as a bearer token with every request, including
+// retries, but not to another host a redirect leads to. The token is dropped when a
+// request is answered with a 401 or 403 so the next one gets a new token
+func (t *Tokens) Option() httpclient.RequestOption {
+	return httpclient.BearerTokenFunc(func(ctx context.Context) (string, error) {
+		tok, err := t.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		return tok.AccessToken, nil
+	}, t.forget)
+}
+
+// forget drops the token with accessToken unless it has already been replaced
+func (t *Tokens) forget(accessToken string) {
+	t.Lock()
+	if t.token != nil && t.token.AccessToken == accessToken {
+		t.token = nil
+	}
+	t.Unlock()
+}
+
+// endpoint returns the token endpoint, discovering it on first use
+func (t *Tokens) endpoint(ctx context.Context) (string, error) {
+	if t.cfg.tokenURL != "" {
+		return t.cfg.tokenURL, nil
+	}
+	if t.provider == nil {
+		p, err := Discover(ctx, t.issuer, t.cfg.opts...)
+		if err != nil {
+			return "", fmt.Errorf("oidc discovery: %w", err)
+		}
+		t.provider = p
+	}
+	return t.provider.TokenEndpoint, nil
+}
+
+func (t *Tokens) request(ctx context.Context, endpoint string, now time.Time) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(t.cfg.scopes) > 0 {
+		form.Set("scope", strings.Join(t.cfg.scopes, " "))
+	}
+	for name, values := range t.cfg.params {
+		form[name] = values
+	}
+	opts := []httpclient.RequestOption{
+		httpclient.WithContext(ctx),
+		httpclient.ContentType("application/x-www-form-urlencoded"),
+		httpclient.Accept(httpclient.ContentTypeJSON),
+	}
+	switch {
+	case t.cfg.key != nil:
+		assertion, err := t.assertion(endpoint, now)
+		if err != nil {
+			return nil, err
+		}
+		form.Set("client_assertion_type", ClientAssertionType)
+		form.Set("client_assertion", assertion)
+	case t.cfg.secret != "":
+		// RFC 6749 section 2.3.1 form encodes the credentials before basic auth
+		opts = append(opts, httpclient.BasicAuth(url.QueryEscape(t.clientID), url.QueryEscape(t.cfg.secret)))
+	default:
+		form.Set("client_id", t.clientID)
+	}
+	opts = append(opts, httpclient.WithBody(strings.NewReader(form.Encode())))
+	res, err := httpclient.Post(endpoint, append(t.cfg.opts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	if res.Status != http.StatusOK {
+		tokenErr := &TokenError{Status: res.Status}
+		if json.Unmarshal(res.Body, tokenErr) != nil || tokenErr.Code == "" {
+			tokenErr.Code = http.StatusText(res.Status)
+		}
+		return nil, tokenErr
+	}
+	tok, err := httpclient.DecodeJSON[*Token](res)
+	if err != nil {
+		return nil, err
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	if tok.ExpiresIn > 0 {
+		tok.Expiry = now.Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// assertion returns a private_key_jwt client assertion for endpoint
+func (t *Tokens) assertion(endpoint string, now time.Time) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	return httpclient.SignJWT(map[string]interface{}{
+		"iss": t.clientID,
+		"sub": t.clientID,
+		"aud": endpoint,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}, t.cfg.key)
+}
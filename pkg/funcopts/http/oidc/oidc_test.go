@@ -0,0 +1,169 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// provider is a minimal OpenID provider issuing numbered tokens
+type provider struct {
+	sync.Mutex
+	t       *testing.T
+	issuer  string
+	tokens  int
+	forms   []map[string][]string
+	headers []http.Header
+}
+
+func newProvider(t *testing.T) (*provider, *httptest.Server) {
+	p := &provider{t: t}
+	ts := httptest.NewServer(p)
+	t.Cleanup(ts.Close)
+	p.issuer = ts.URL
+	return p, ts
+}
+
+func (p *provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.Lock()
+	defer p.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(Provider{Issuer: p.issuer, TokenEndpoint: p.issuer + "/token"})
+	case "/token":
+		require.NoError(p.t, r.ParseForm())
+		p.forms = append(p.forms, r.PostForm)
+		p.headers = append(p.headers, r.Header.Clone())
+		if r.PostForm.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"unsupported_grant_type"}`)
+			return
+		}
+		p.tokens++
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, p.tokens)
+	case "/api":
+		if r.Header.Get("Authorization") == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		fmt.Fprintf(w, `%q`, r.Header.Get("Authorization"))
+	case "/elsewhere":
+		http.Redirect(w, r, strings.Replace(p.issuer, "127.0.0.1", "localhost", 1)+"/api", http.StatusFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	p, ts := newProvider(t)
+	got, err := Discover(context.Background(), ts.URL+"/")
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/token", got.TokenEndpoint)
+
+	p.issuer = "https://other.example.com"
+	_, err = Discover(context.Background(), ts.URL)
+	assert.True(t, errors.Is(err, ErrIssuerMismatch))
+}
+
+func TestTokensRenewal(t *testing.T) {
+	p, ts := newProvider(t)
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	ctx := clock.NewContext(context.Background(), clk)
+	tokens := New(ts.URL, "svc", ClientSecret("s3cr&t"), Scopes("a", "b"), Param("audience", "api"), RenewBefore(5*time.Minute))
+
+	tok, err := tokens.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", tok.AccessToken)
+	assert.Equal(t, clk.Now().Add(time.Hour), tok.Expiry)
+	user, password, ok := (&http.Request{Header: p.headers[0]}).BasicAuth()
+	require.True(t, ok)
+	assert.Equal(t, "svc", user)
+	assert.Equal(t, "s3cr%26t", password)
+	assert.Equal(t, "a b", p.forms[0]["scope"][0])
+	assert.Equal(t, "api", p.forms[0]["audience"][0])
+
+	clk.Advance(54 * time.Minute)
+	tok, err = tokens.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", tok.AccessToken, "cached until the renewal window")
+
+	clk.Advance(2 * time.Minute)
+	tok, err = tokens.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", tok.AccessToken)
+}
+
+func TestPrivateKeyJWT(t *testing.T) {
+	p, ts := newProvider(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, err := New(ts.URL, "svc", PrivateKeyJWT(key)).Token(context.Background())
+	require.NoError(t, err)
+	form := p.forms[0]
+	assert.Equal(t, ClientAssertionType, form["client_assertion_type"][0])
+	assert.Empty(t, p.headers[0].Get("Authorization"))
+	parts := strings.Split(form["client_assertion"][0], ".")
+	require.Len(t, parts, 3)
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &claims))
+	assert.Equal(t, "svc", claims["iss"])
+	assert.Equal(t, "svc", claims["sub"])
+	assert.Equal(t, ts.URL+"/token", claims["aud"])
+	assert.NotEmpty(t, claims["jti"])
+}
+
+func TestTokenError(t *testing.T) {
+	_, ts := newProvider(t)
+	_, err := New(ts.URL, "svc", Param("grant_type", "password")).Token(context.Background())
+	var tokenErr *TokenError
+	require.True(t, errors.As(err, &tokenErr))
+	assert.Equal(t, http.StatusBadRequest, tokenErr.Status)
+	assert.Equal(t, "unsupported_grant_type", tokenErr.Code)
+}
+
+func TestOption(t *testing.T) {
+	p, ts := newProvider(t)
+	tokens := New(ts.URL, "svc", TokenURL(ts.URL+"/token"))
+	c := httpclient.NewClient(tokens.Option())
+	for i := 0; i < 2; i++ {
+		res, err := c.Get(ts.URL + "/api")
+		require.NoError(t, err)
+		assert.Equal(t, `"Bearer token-1"`, string(res.Body))
+	}
+	assert.Equal(t, "svc", p.forms[0]["client_id"][0])
+
+	tokens.Lock()
+	tokens.token = &Token{AccessToken: "revoked"}
+	tokens.Unlock()
+	res, err := c.Get(ts.URL + "/api")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.Status)
+	res, err = c.Get(ts.URL + "/api")
+	require.NoError(t, err)
+	assert.Equal(t, `"Bearer token-2"`, string(res.Body))
+}
+
+func TestOptionCrossHostRedirect(t *testing.T) {
+	_, ts := newProvider(t)
+	c := httpclient.NewClient(New(ts.URL, "svc", TokenURL(ts.URL+"/token")).Option())
+	res, err := c.Get(ts.URL + "/elsewhere")
+	require.NoError(t, err)
+	assert.Equal(t, `""`, string(res.Body))
+}
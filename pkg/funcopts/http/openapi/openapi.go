@@ -0,0 +1,179 @@
+// Package openapi validates requests and responses made with httpclient against an
+// OpenAPI 3 document, to catch drift between a client and the server it calls
+//
+//	spec, _ := ioutil.ReadFile("api.yaml")
+//	res, err := httpclient.Get(url, openapi.ValidateAgainst(spec))
+//	if errors.Is(err, openapi.ErrSpecViolation) { ... }
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// ErrSpecViolation is the error wrapped by `ValidationError`
+var ErrSpecViolation = errors.New("openapi spec violation")
+
+// ValidationError is returned when a request or response doesn't match the document.
+// Err is the error from the validator and lists every problem found
+type ValidationError struct {
+	// Stage is `request` or `response`
+	Stage  string
+	Method string
+	URL    string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s: %s does not match the openapi document: %v", e.Method, e.URL, e.Stage, e.Err)
+}
+
+// Unwrap returns `ErrSpecViolation` and the validator error
+func (e *ValidationError) Unwrap() []error {
+	return []error{ErrSpecViolation, e.Err}
+}
+
+// Validator checks requests and responses against an OpenAPI 3 document
+type Validator struct {
+	router routers.Router
+	// bases are the paths of the servers in the document, matched regardless of host
+	bases []string
+}
+
+// NewValidator parses spec, a json or yaml OpenAPI 3 document
+func NewValidator(spec []byte) (*Validator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	v := &Validator{}
+	for _, server := range doc.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			return nil, err
+		}
+		if base := strings.TrimSuffix(u.Path, "/"); base != "" {
+			v.bases = append(v.bases, base)
+		}
+	}
+	doc.Servers = nil
+	if v.router, err = legacy.NewRouter(doc); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ValidateAgainst checks every request sent with the option and its response
+// against spec, a json or yaml OpenAPI 3 document. The path, parameters and body
+// of requests are checked before they are sent, and the status, headers and body
+// of responses before they are returned. Servers in the document only contribute
+// their base path, so the same document validates staging and production
+func ValidateAgainst(spec []byte) httpclient.RequestOption {
+	v, err := NewValidator(spec)
+	if err != nil {
+		return func(*httpclient.Request) error {
+			return err
+		}
+	}
+	return v.Option()
+}
+
+// Option checks the requests sent with it, see `ValidateAgainst`
+func (v *Validator) Option() httpclient.RequestOption {
+	return httpclient.Use(v.Middleware)
+}
+
+// Middleware checks the requests and responses going through next
+func (v *Validator) Middleware(next http.RoundTripper) http.RoundTripper {
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		input, err := v.ValidateRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if err := v.validateResponse(input, resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		return resp, nil
+	})
+}
+
+var options = &openapi3filter.Options{
+	MultiError:            true,
+	IncludeResponseStatus: true,
+	AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+}
+
+// ValidateRequest checks req against the document. The request body is read and replaced
+func (v *Validator) ValidateRequest(req *http.Request) (*openapi3filter.RequestValidationInput, error) {
+	violation := func(err error) error {
+		return &ValidationError{Stage: "request", Method: req.Method, URL: req.URL.Redacted(), Err: err}
+	}
+	routed := req.Clone(req.Context())
+	routed.URL.Path = v.trimBase(req.URL.Path)
+	route, params, err := v.router.FindRoute(routed)
+	if err != nil {
+		return nil, violation(err)
+	}
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: params,
+		Route:      route,
+		Options:    options,
+	}
+	if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+		return nil, violation(err)
+	}
+	return input, nil
+}
+
+func (v *Validator) validateResponse(input *openapi3filter.RequestValidationInput, resp *http.Response) error {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	out := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 resp.StatusCode,
+		Header:                 resp.Header,
+		Options:                options,
+	}
+	out.SetBodyBytes(body)
+	if err := openapi3filter.ValidateResponse(input.Request.Context(), out); err != nil {
+		req := input.Request
+		return &ValidationError{Stage: "response", Method: req.Method, URL: req.URL.Redacted(), Err: err}
+	}
+	return nil
+}
+
+// trimBase removes the base path of the first matching server from path
+func (v *Validator) trimBase(path string) string {
+	for _, base := range v.bases {
+		if path == base || strings.HasPrefix(path, base+"/") {
+			return strings.TrimPrefix(path, base)
+		}
+	}
+	return path
+}
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func petServer(body string, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestValidateAgainst(t *testing.T) {
+	spec, err := ioutil.ReadFile("testdata/pets.yaml")
+	assert.NoError(t, err)
+	validate := ValidateAgainst(spec)
+	var calls int32
+	ts := petServer(`{"id":1,"name":"rex"}`, &calls)
+	defer ts.Close()
+
+	res, err := httpclient.Get(ts.URL+"/v1/pets/1?verbose=true", validate)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"rex"}`, string(res.Body))
+
+	_, err = httpclient.Post(ts.URL+"/v1/pets", validate, httpclient.JSON(), httpclient.WithBody(strings.NewReader(`{"id":2,"name":"tom"}`)))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	_, err = httpclient.Get(ts.URL+"/v1/pets/rex", validate)
+	assert.True(t, errors.Is(err, ErrSpecViolation))
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "request", verr.Stage)
+
+	_, err = httpclient.Post(ts.URL+"/v1/pets", validate, httpclient.JSON(), httpclient.WithBody(strings.NewReader(`{"id":"two"}`)))
+	assert.True(t, errors.Is(err, ErrSpecViolation))
+	assert.Contains(t, err.Error(), "name")
+
+	_, err = httpclient.Get(ts.URL+"/v1/owners", validate)
+	assert.True(t, errors.Is(err, ErrSpecViolation))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestValidateResponse(t *testing.T) {
+	spec, err := ioutil.ReadFile("testdata/pets.yaml")
+	assert.NoError(t, err)
+	var calls int32
+	ts := petServer(`{"id":"1"}`, &calls)
+	defer ts.Close()
+	_, err = httpclient.Get(ts.URL+"/v1/pets/1", ValidateAgainst(spec))
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "response", verr.Stage)
+	assert.Contains(t, err.Error(), "name")
+}
+
+func TestValidateAgainstInvalidSpec(t *testing.T) {
+	_, err := httpclient.Get("http://example.com", ValidateAgainst([]byte("openapi: [")))
+	assert.Error(t, err)
+}
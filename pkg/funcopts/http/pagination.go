@@ -0,0 +1,185 @@
+package httpclient
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CursorFunc extracts the cursor for the next page from a response.
+// An empty cursor signals that there are no more pages
+type CursorFunc func(*Response) (string, error)
+
+// Pager iterates over the pages of a paginated resource
+//
+//	pages := Paginate("https://api.github.com/users/lusis/repos", JSON())
+//	for pages.Next() {
+//		fmt.Println(string(pages.Page().Body))
+//	}
+//	if err := pages.Err(); err != nil {
+//		...
+//	}
+type Pager struct {
+	url    string
+	cursor string
+	// linked is set once the url is a next link from a response
+	linked bool
+	opts   []RequestOption
+	page   *Response
+	count  int
+	done   bool
+	err    error
+}
+
+// MaxPages sets the maximum number of pages `Paginate` will fetch
+func MaxPages(n int) RequestOption {
+	return func(r *Request) error {
+		r.maxPages = n
+		return nil
+	}
+}
+
+// Cursor configures pagination to pass the cursor returned by `fn` as the query param `param`
+// instead of following `Link` headers
+func Cursor(param string, fn CursorFunc) RequestOption {
+	return func(r *Request) error {
+		r.cursorParam = param
+		r.cursorFunc = fn
+		return nil
+	}
+}
+
+// Paginate returns a `Pager` that performs an http GET for each page of `url`.
+// By default the next page is found via an RFC 5988 `Link` header with `rel="next"`
+func Paginate(url string, opts ...RequestOption) *Pager {
+	return &Pager{url: url, opts: opts}
+}
+
+// Next fetches the next page. It returns false when there are no more pages or an error occurred
+func (p *Pager) Next() bool {
+	if p.done {
+		return false
+	}
+	opts := append([]RequestOption{}, p.opts...)
+	if p.cursor != "" {
+		opts = append(opts, p.cursorOption())
+	}
+	if p.linked {
+		opts = append(opts, p.linkOption())
+	}
+	opts = append(opts, get(), setURL(p.url))
+	cr, res, err := do(opts...)
+	p.page = res
+	if err != nil {
+		return p.fail(err)
+	}
+	p.count++
+	next, cursor, err := p.nextPage(cr, res)
+	if err != nil {
+		return p.fail(err)
+	}
+	max := cr.maxPages
+	if max == 0 {
+		max = DefaultMaxPages
+	}
+	switch {
+	case next == "" && cursor == "":
+		p.done = true
+	case p.count >= max:
+		p.done = true
+		p.err = ErrMaxPagesExceeded
+	default:
+		p.linked = p.linked || next != p.url
+		p.url = next
+		p.cursor = cursor
+	}
+	return true
+}
+
+// Page returns the most recently fetched page
+func (p *Pager) Page() *Response {
+	return p.page
+}
+
+// Err returns the first error encountered while paginating
+func (p *Pager) Err() error {
+	return p.err
+}
+
+func (p *Pager) fail(err error) bool {
+	p.err = err
+	p.done = true
+	return false
+}
+
+// cursorOption adds the current cursor to the query params without clobbering
+// any params provided by the caller
+func (p *Pager) cursorOption() RequestOption {
+	return func(r *Request) error {
+		qp := make(map[string]string, len(r.queryParams)+1)
+		for k, v := range r.queryParams {
+			qp[k] = v
+		}
+		qp[r.cursorParam] = p.cursor
+		r.queryParams = qp
+		return nil
+	}
+}
+
+// linkOption leaves out the caller's query params that the next link already has, so
+// the page or offset of the link isn't overridden by the one of the first request
+func (p *Pager) linkOption() RequestOption {
+	return func(r *Request) error {
+		u, err := url.Parse(p.url)
+		if err != nil {
+			return err
+		}
+		linked := u.Query()
+		qp := make(map[string]string, len(r.queryParams))
+		for k, v := range r.queryParams {
+			if _, ok := linked[k]; !ok {
+				qp[k] = v
+			}
+		}
+		r.queryParams = qp
+		qv := make(url.Values, len(r.queryValues))
+		for k, vs := range r.queryValues {
+			if _, ok := linked[k]; !ok {
+				qv[k] = vs
+			}
+		}
+		r.queryValues = qv
+		return nil
+	}
+}
+
+func (p *Pager) nextPage(cr *Request, res *Response) (string, string, error) {
+	if cr.cursorFunc != nil {
+		cursor, err := cr.cursorFunc(res)
+		if cursor == "" {
+			return "", "", err
+		}
+		return p.url, cursor, err
+	}
+	link, ok := parseLinkHeader(strings.Join(res.Headers["Link"], ","))["next"]
+	if !ok {
+		return "", "", nil
+	}
+	base, err := url.Parse(res.URL)
+	if err != nil {
+		return "", "", err
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return "", "", err
+	}
+	return base.ResolveReference(ref).String(), "", nil
+}
+
+// parseLinkHeader parses an RFC 5988 `Link` header into a map of rel to url
+func parseLinkHeader(h string) map[string]string {
+	links := make(map[string]string)
+	for _, link := range parseLinks(h) {
+		links[link.Rel] = link.URL
+	}
+	return links
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPagedServer(pages int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < pages {
+			w.Header().Set("Link", fmt.Sprintf(`</items?page=%d>; rel="next", </items?page=%d>; rel="last"`, page+1, pages))
+		}
+		fmt.Fprintf(w, "page %d %s", page, r.URL.Query().Get("foo"))
+	}))
+}
+
+func TestPaginateLinkHeader(t *testing.T) {
+	ts := testPagedServer(3)
+	defer ts.Close()
+	pages := Paginate(ts.URL+"/items", QueryParams(map[string]string{"foo": "bar"}))
+	bodies := []string{}
+	for pages.Next() {
+		bodies = append(bodies, string(pages.Page().Body))
+	}
+	assert.NoError(t, pages.Err())
+	assert.Equal(t, []string{"page 1 bar", "page 2 bar", "page 3 bar"}, bodies)
+	assert.False(t, pages.Next())
+}
+
+func TestPaginateLinkKeepsLinkedParams(t *testing.T) {
+	ts := testPagedServer(3)
+	defer ts.Close()
+	pages := Paginate(ts.URL+"/items", QueryParams(map[string]string{"page": "1", "foo": "bar"}))
+	queries := []string{}
+	for pages.Next() {
+		u, _ := url.Parse(pages.Page().URL)
+		queries = append(queries, u.RawQuery)
+	}
+	assert.NoError(t, pages.Err())
+	assert.Equal(t, []string{"foo=bar&page=1", "foo=bar&page=2", "foo=bar&page=3"}, queries)
+}
+
+func TestPaginateMaxPages(t *testing.T) {
+	ts := testPagedServer(5)
+	defer ts.Close()
+	pages := Paginate(ts.URL+"/items", MaxPages(2))
+	count := 0
+	for pages.Next() {
+		count++
+	}
+	assert.Equal(t, 2, count)
+	assert.EqualError(t, pages.Err(), ErrMaxPagesExceeded.Error())
+}
+
+func TestPaginateCursor(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("after")
+		next := map[string]string{"": "abc", "abc": "def"}[cursor]
+		fmt.Fprint(w, next)
+	}))
+	defer ts.Close()
+	pages := Paginate(ts.URL, Cursor("after", func(res *Response) (string, error) {
+		return string(res.Body), nil
+	}))
+	bodies := []string{}
+	for pages.Next() {
+		bodies = append(bodies, string(pages.Page().Body))
+	}
+	assert.NoError(t, pages.Err())
+	assert.Equal(t, []string{"abc", "def", ""}, bodies)
+}
+
+func TestPaginateError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	pages := Paginate(ts.URL, ExpectStatus(200))
+	assert.False(t, pages.Next())
+	assert.True(t, errors.Is(pages.Err(), ErrInvalidStatusCode))
+	assert.Equal(t, 404, pages.Page().Status)
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader(`<https://api.github.com/user/repos?page=3>; rel="next", <https://api.github.com/user/repos?page=50>; rel="last"`)
+	assert.Equal(t, "https://api.github.com/user/repos?page=3", links["next"])
+	assert.Equal(t, "https://api.github.com/user/repos?page=50", links["last"])
+}
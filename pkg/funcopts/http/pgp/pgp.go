@@ -0,0 +1,44 @@
+// Package pgp verifies downloads against detached OpenPGP signatures, like the `.asc`
+// and `.sig` files published next to release artifacts
+//
+//	keyring, err := openpgp.ReadArmoredKeyRing(keyFile)
+//	err = httpclient.Download(artifactURL, "release.tar.gz", pgp.VerifyGPG(artifactURL+".asc", keyring))
+package pgp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// ErrBadSignature is the error wrapped when a download doesn't match its signature or
+// the signature isn't made by a key in the keyring
+var ErrBadSignature = errors.New("invalid signature")
+
+// armorPrefix starts an ascii armored signature
+var armorPrefix = []byte("-----BEGIN PGP SIGNATURE-----")
+
+// VerifyGPG fetches the detached signature at signatureURL with opts and checks that it
+// signs the download with a key in keyring. Armored and binary signatures are accepted
+func VerifyGPG(signatureURL string, keyring openpgp.KeyRing, opts ...httpclient.RequestOption) httpclient.RequestOption {
+	return httpclient.VerifyDownload(func(ctx context.Context, content io.Reader, _ http.Header) error {
+		res, err := httpclient.Get(signatureURL, append(opts, httpclient.WithContext(ctx), httpclient.ExpectSuccess())...)
+		if err != nil {
+			return fmt.Errorf("fetching signature: %w", err)
+		}
+		check := openpgp.CheckDetachedSignature
+		if bytes.HasPrefix(bytes.TrimSpace(res.Body), armorPrefix) {
+			check = openpgp.CheckArmoredDetachedSignature
+		}
+		if _, err := check(keyring, content, bytes.NewReader(res.Body), nil); err != nil {
+			return fmt.Errorf("%w: %v", ErrBadSignature, err)
+		}
+		return nil
+	})
+}
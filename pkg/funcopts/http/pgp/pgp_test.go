@@ -0,0 +1,60 @@
+package pgp
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var artifact = []byte("release contents")
+
+func newEntity(t *testing.T) *openpgp.Entity {
+	t.Helper()
+	e, err := openpgp.NewEntity("release", "", "release@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	require.NoError(t, err)
+	return e
+}
+
+func TestVerifyGPG(t *testing.T) {
+	signer := newEntity(t)
+	var armored, binary bytes.Buffer
+	require.NoError(t, openpgp.ArmoredDetachSign(&armored, signer, bytes.NewReader(artifact), nil))
+	require.NoError(t, openpgp.DetachSign(&binary, signer, bytes.NewReader(artifact), nil))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/release.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(artifact)
+	})
+	mux.HandleFunc("/release.tar.gz.asc", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(armored.Bytes())
+	})
+	mux.HandleFunc("/release.tar.gz.sig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary.Bytes())
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	dir := t.TempDir()
+
+	for _, sig := range []string{".asc", ".sig"} {
+		err := httpclient.Download(ts.URL+"/release.tar.gz", filepath.Join(dir, "release"+sig), verify(ts, sig, openpgp.EntityList{signer}))
+		assert.NoError(t, err, sig)
+	}
+
+	err := httpclient.Download(ts.URL+"/release.tar.gz", filepath.Join(dir, "other"), verify(ts, ".asc", openpgp.EntityList{newEntity(t)}))
+	assert.True(t, errors.Is(err, ErrBadSignature), "got %v", err)
+
+	err = httpclient.Download(ts.URL+"/release.tar.gz", filepath.Join(dir, "missing"), verify(ts, ".minisig", openpgp.EntityList{signer}))
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode), "got %v", err)
+}
+
+func verify(ts *httptest.Server, ext string, keyring openpgp.KeyRing) httpclient.RequestOption {
+	return VerifyGPG(ts.URL+"/release.tar.gz"+ext, keyring, httpclient.SetClient(ts.Client()))
+}
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// PollPredicate reports whether a response from `PollUntil` is the one waited for
+type PollPredicate func(*Response) bool
+
+// MaxPollInterval lets the interval of `PollUntil` grow by half after every poll up to max,
+// so waiting for a slow job doesn't cost as many requests as waiting for a fast one
+func MaxPollInterval(max time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.maxPollInterval = max
+		return nil
+	}
+}
+
+// PollUntil sends GET requests to url every interval, with jitter, until the response
+// passes predicate, and returns it. Failed requests are retried at the next poll. When
+// ctx is done first it returns the last response and the context error, wrapping the
+// error of the last poll if it failed
+//
+//	res, err := PollUntil(ctx, jobURL, func(res *Response) bool {
+//		return strings.Contains(string(res.Body), `"state":"done"`)
+//	}, time.Second, MaxPollInterval(30*time.Second))
+func PollUntil(ctx context.Context, url string, predicate PollPredicate, interval time.Duration, opts ...RequestOption) (*Response, error) {
+	clk := clock.FromContext(ctx)
+	pollOpts := append(append([]RequestOption{}, opts...), WithContext(ctx), get(), setURL(url))
+	var last *Response
+	for {
+		cr, res, err := do(pollOpts...)
+		if cr == nil {
+			return nil, err
+		}
+		if res != nil {
+			last = res
+		}
+		if err == nil && predicate(res) {
+			return res, nil
+		}
+		if sleepErr := clk.Sleep(ctx, jitter(interval)); sleepErr != nil {
+			if err != nil {
+				return last, fmt.Errorf("%w: %w", sleepErr, err)
+			}
+			return last, sleepErr
+		}
+		if cr.maxPollInterval > interval {
+			interval += interval / 2
+			if interval > cr.maxPollInterval {
+				interval = cr.maxPollInterval
+			}
+		}
+	}
+}
+
+// jitter returns d varied by up to a fifth either way so pollers started together spread out
+func jitter(d time.Duration) time.Duration {
+	spread := int64(d / 5)
+	if spread <= 0 {
+		return d
+	}
+	return d - time.Duration(spread) + time.Duration(rand.Int63n(2*spread+1))
+}
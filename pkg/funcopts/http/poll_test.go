@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollUntil(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strconv.Itoa(int(atomic.AddInt32(&calls, 1)))))
+	}))
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	ctx := clock.NewContext(context.Background(), clk)
+	res, err := PollUntil(ctx, ts.URL, func(res *Response) bool {
+		return string(res.Body) == "5"
+	}, time.Second, MaxPollInterval(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(res.Body))
+	sleeps := clk.Sleeps()
+	require.Len(t, sleeps, 4)
+	bounds := []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second, 2 * time.Second}
+	for i, d := range sleeps {
+		assert.True(t, d >= bounds[i]*4/5 && d <= bounds[i]*6/5, "sleep %d was %s", i, d)
+	}
+}
+
+func TestPollUntilContextDone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pending"))
+	}))
+	defer ts.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := PollUntil(ctx, ts.URL, func(res *Response) bool { return false }, 10*time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.NotNil(t, res)
+	assert.Equal(t, "pending", string(res.Body))
+}
+
+func TestPollUntilRetriesFailures(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+	ctx := clock.NewContext(context.Background(), clock.NewFake(time.Now()))
+	res, err := PollUntil(ctx, ts.URL, func(res *Response) bool { return true }, time.Second, ExpectSuccess())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
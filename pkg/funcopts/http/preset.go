@@ -0,0 +1,49 @@
+package httpclient
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Preset bundles opts into a single option. They are applied in order
+func Preset(opts ...RequestOption) RequestOption {
+	return func(r *Request) error {
+		for _, opt := range opts {
+			if err := opt(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// presets are the named option bundles defined on a `Client`
+type presets struct {
+	sync.RWMutex
+	named map[string]RequestOption
+}
+
+// DefinePreset names a bundle of options so requests can apply it with `UsePreset`.
+// Defining a name again replaces it
+func (c *Client) DefinePreset(name string, opts ...RequestOption) {
+	c.presets.Lock()
+	defer c.presets.Unlock()
+	if c.presets.named == nil {
+		c.presets.named = make(map[string]RequestOption)
+	}
+	c.presets.named[name] = Preset(opts...)
+}
+
+// UsePreset applies the options defined for name with `DefinePreset`.
+// The preset is looked up when the request is built
+func (c *Client) UsePreset(name string) RequestOption {
+	return func(r *Request) error {
+		c.presets.RLock()
+		preset, ok := c.presets.named[name]
+		c.presets.RUnlock()
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownPreset, name)
+		}
+		return preset(r)
+	}
+}
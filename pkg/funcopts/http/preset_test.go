@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreset(t *testing.T) {
+	internal := Preset(JSON(), BearerToken("token"))
+	_, req, err := New(get(), setURL("http://example.com"), internal)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+	assert.Equal(t, ContentTypeJSON, req.Header.Get("Content-Type"))
+}
+
+func TestClientPresets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	c := NewClient()
+	c.DefinePreset("internal-json", JSON(), BearerToken("first"))
+	c.DefinePreset("internal-json", JSON(), BearerToken("second"))
+	res, err := c.Get(ts.URL, c.UsePreset("internal-json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer second", string(res.Body))
+
+	_, err = c.Get(ts.URL, c.UsePreset("missing"))
+	assert.True(t, errors.Is(err, ErrUnknownPreset))
+}
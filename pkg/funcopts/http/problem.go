@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"mime"
+)
+
+// ProblemDetails is an RFC 7807 problem details object
+type ProblemDetails struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extensions holds any members beyond the ones defined by the RFC
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// UnmarshalJSON decodes the standard members and collects the rest into `Extensions`
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	type problem ProblemDetails
+	if err := json.Unmarshal(data, (*problem)(p)); err != nil {
+		return err
+	}
+	members := make(map[string]interface{})
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for _, k := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(members, k)
+	}
+	if len(members) > 0 {
+		p.Extensions = members
+	}
+	return nil
+}
+
+func (p *ProblemDetails) Error() string {
+	switch {
+	case p.Title != "" && p.Detail != "":
+		return p.Title + ": " + p.Detail
+	case p.Title != "":
+		return p.Title
+	case p.Detail != "":
+		return p.Detail
+	}
+	return p.Type
+}
+
+// Problem decodes an error response with an `application/problem+json` content type.
+// It returns false for successful responses and other content types
+func (r *Response) Problem() (*ProblemDetails, bool) {
+	if r.Status < 400 {
+		return nil, false
+	}
+	mt, _, err := mime.ParseMediaType(r.Headers.Get("Content-Type"))
+	if err != nil || mt != ContentTypeProblemJSON {
+		return nil, false
+	}
+	problem := &ProblemDetails{}
+	if err := json.Unmarshal(r.Body, problem); err != nil {
+		return nil, false
+	}
+	return problem, true
+}
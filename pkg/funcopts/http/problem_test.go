@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemDetails(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{
+			"type": "https://example.com/probs/out-of-credit",
+			"title": "You do not have enough credit.",
+			"status": 403,
+			"detail": "Your current balance is 30, but that costs 50.",
+			"instance": "/account/12345/msgs/abc",
+			"balance": 30
+		}`))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, ExpectStatus(200))
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+	var problem *ProblemDetails
+	assert.True(t, errors.As(err, &problem))
+	assert.Equal(t, "https://example.com/probs/out-of-credit", problem.Type)
+	assert.Equal(t, 403, problem.Status)
+	assert.Equal(t, "/account/12345/msgs/abc", problem.Instance)
+	assert.Equal(t, float64(30), problem.Extensions["balance"])
+	assert.Contains(t, err.Error(), "You do not have enough credit.: Your current balance is 30, but that costs 50.")
+
+	fromResponse, ok := res.Problem()
+	assert.True(t, ok)
+	assert.Equal(t, problem, fromResponse)
+}
+
+func TestProblemDetailsIgnored(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"title": "not a problem document"}`))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, ExpectStatus(200))
+	assert.Nil(t, err.(*StatusError).Problem)
+	_, ok := res.Problem()
+	assert.False(t, ok)
+}
@@ -0,0 +1,68 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceHeaders are the W3C trace context and B3 headers copied by `PropagateTraceHeaders`
+var TraceHeaders = []string{
+	"traceparent", "tracestate",
+	"b3", "X-B3-TraceId", "X-B3-SpanId", "X-B3-ParentSpanId", "X-B3-Sampled", "X-B3-Flags",
+}
+
+type traceHeadersKey struct{}
+
+// ContextWithTraceHeaders returns a copy of ctx carrying the `TraceHeaders` found in h,
+// e.g. the headers of an incoming request, for `PropagateTraceHeaders` to send on
+func ContextWithTraceHeaders(ctx context.Context, h http.Header) context.Context {
+	found := http.Header{}
+	for _, name := range TraceHeaders {
+		if v := h.Get(name); v != "" {
+			found.Set(name, v)
+		}
+	}
+	if len(found) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, traceHeadersKey{}, found)
+}
+
+// TraceHeadersFromContext returns the trace headers stored in ctx by `ContextWithTraceHeaders`
+func TraceHeadersFromContext(ctx context.Context) (http.Header, bool) {
+	h, ok := ctx.Value(traceHeadersKey{}).(http.Header)
+	return h.Clone(), ok
+}
+
+// CaptureTraceHeaders stores the trace headers of incoming requests in their context, so
+// handlers can pass it to `PropagateTraceHeaders`
+//
+//	http.Handle("/orders", CaptureTraceHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		res, err := c.Get(inventoryURL, PropagateTraceHeaders(r.Context()))
+//	})))
+func CaptureTraceHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ContextWithTraceHeaders(r.Context(), r.Header)))
+	})
+}
+
+// PropagateTraceHeaders sends the trace headers stored in ctx by `ContextWithTraceHeaders`
+// so a trace continues through services without OpenTelemetry set up. Without stored
+// headers the span of ctx, if any, is sent as `traceparent` and `tracestate`. With
+// `WithTracing` each attempt's own span replaces the W3C headers
+func PropagateTraceHeaders(ctx context.Context) RequestOption {
+	return func(r *Request) error {
+		h, ok := TraceHeadersFromContext(ctx)
+		if !ok && trace.SpanContextFromContext(ctx).IsValid() {
+			h = http.Header{}
+			propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(h))
+		}
+		for name := range h {
+			r.headers[name] = h.Get(name)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagateTraceHeaders(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer upstream.Close()
+	front := httptest.NewServer(CaptureTraceHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := Get(upstream.URL, PropagateTraceHeaders(r.Context()))
+		assert.NoError(t, err)
+	})))
+	defer front.Close()
+	_, err := Get(front.URL, AddHeaders(map[string]string{
+		"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"tracestate":   "congo=t61rcWkgMzE",
+		"X-B3-TraceId": "80f198ee56343ba864fe8b2a57d3eff7",
+		"X-B3-SpanId":  "e457b5a2e4d86bd1",
+		"X-B3-Sampled": "1",
+		"X-Other":      "not copied",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", got.Get("traceparent"))
+	assert.Equal(t, "congo=t61rcWkgMzE", got.Get("tracestate"))
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", got.Get("X-B3-TraceId"))
+	assert.Equal(t, "e457b5a2e4d86bd1", got.Get("X-B3-SpanId"))
+	assert.Equal(t, "1", got.Get("X-B3-Sampled"))
+	assert.Empty(t, got.Get("X-Other"))
+}
+
+func TestPropagateTraceHeadersFromSpan(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer ts.Close()
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+	_, err := Get(ts.URL, PropagateTraceHeaders(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", got.Get("traceparent"))
+
+	_, err = Get(ts.URL, PropagateTraceHeaders(context.Background()))
+	require.NoError(t, err)
+	assert.Empty(t, got.Get("traceparent"))
+}
@@ -0,0 +1,229 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryStruct encodes the exported fields of the struct v as query params.
+// Fields are named by their `url` tag, which also accepts options:
+//
+//	omitempty  skip the field when it has its zero value
+//	comma      encode slices as a single comma separated value (tags=a,b)
+//	brackets   encode slices with brackets after the name (tags[]=a&tags[]=b)
+//	unix       encode a time.Time as unix seconds instead of RFC 3339
+//
+// Slices are otherwise encoded by repeating the name (tags=a&tags=b).
+// Nested structs are encoded as `parent[child]` and a tag of "-" skips the field
+//
+//	type ListOptions struct {
+//		State string   `url:"state,omitempty"`
+//		Tags  []string `url:"tags,comma"`
+//	}
+func QueryStruct(v interface{}) RequestOption {
+	return func(r *Request) error {
+		values := url.Values{}
+		if err := encodeQueryStruct(values, "", reflect.ValueOf(v)); err != nil {
+			return err
+		}
+		r.addQueryValues(values)
+		return nil
+	}
+}
+
+// QueryValues adds all of values as query params, allowing repeated keys
+func QueryValues(values url.Values) RequestOption {
+	return func(r *Request) error {
+		r.addQueryValues(values)
+		return nil
+	}
+}
+
+// QueryParam adds a query param. Passing multiple values repeats the key (?tag=a&tag=b)
+func QueryParam(key string, values ...string) RequestOption {
+	return func(r *Request) error {
+		r.addQueryValues(url.Values{key: values})
+		return nil
+	}
+}
+
+// QueryInt adds an integer query param. Passing multiple values repeats the key
+func QueryInt(key string, values ...int) RequestOption {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	return QueryParam(key, strs...)
+}
+
+// QueryBool adds a boolean query param encoded as `true` or `false`
+func QueryBool(key string, value bool) RequestOption {
+	return QueryParam(key, strconv.FormatBool(value))
+}
+
+// QueryTime adds a time query param formatted with layout
+func QueryTime(key string, t time.Time, layout string) RequestOption {
+	return QueryParam(key, t.Format(layout))
+}
+
+func (cr *Request) addQueryValues(values url.Values) {
+	if cr.queryValues == nil {
+		cr.queryValues = url.Values{}
+	}
+	for k, vs := range values {
+		cr.queryValues[k] = append(cr.queryValues[k], vs...)
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func encodeQueryStruct(values url.Values, prefix string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("QueryStruct expects a struct, got %s", v.Kind())
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseQueryTag(tag)
+		fv := v.Field(i)
+		if opts["omitempty"] && isEmptyValue(fv) {
+			continue
+		}
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr {
+			continue
+		}
+		if field.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			if err := encodeQueryStruct(values, prefix, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "[" + name + "]"
+		}
+		if err := encodeQueryField(values, name, fv, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeQueryField(values url.Values, name string, v reflect.Value, opts map[string]bool) error {
+	if v.Kind() == reflect.Struct && v.Type() != timeType {
+		return encodeQueryStruct(values, name, v)
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s, err := queryValueString(v.Index(i), opts)
+			if err != nil {
+				return err
+			}
+			items = append(items, s)
+		}
+		switch {
+		case opts["comma"]:
+			values.Add(name, strings.Join(items, ","))
+		case opts["brackets"]:
+			values[name+"[]"] = append(values[name+"[]"], items...)
+		default:
+			values[name] = append(values[name], items...)
+		}
+		return nil
+	}
+	s, err := queryValueString(v, opts)
+	if err != nil {
+		return err
+	}
+	values.Add(name, s)
+	return nil
+}
+
+func queryValueString(v reflect.Value, opts map[string]bool) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if opts["unix"] {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	return "", fmt.Errorf("QueryStruct can't encode values of type %s", v.Type())
+}
+
+func parseQueryTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	opts := make(map[string]bool, len(parts)-1)
+	for _, opt := range parts[1:] {
+		opts[opt] = true
+	}
+	return parts[0], opts
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testQueryPage struct {
+	Page    int `url:"page,omitempty"`
+	PerPage int `url:"per_page,omitempty"`
+}
+
+type testQueryOptions struct {
+	testQueryPage
+	State  string    `url:"state"`
+	Labels []string  `url:"labels,comma"`
+	IDs    []int     `url:"ids,brackets"`
+	Tags   []string  `url:"tag"`
+	Since  time.Time `url:"since,omitempty"`
+	Before time.Time `url:"before,unix"`
+	Draft  *bool     `url:"draft,omitempty"`
+	Sort   struct {
+		Field string `url:"field"`
+	} `url:"sort"`
+	Ignored string `url:"-"`
+	hidden  string
+}
+
+func TestQueryStruct(t *testing.T) {
+	draft := false
+	opts := testQueryOptions{
+		testQueryPage: testQueryPage{Page: 2},
+		State:         "open",
+		Labels:        []string{"bug", "ui"},
+		IDs:           []int{1, 2},
+		Tags:          []string{"a", "b"},
+		Before:        time.Unix(1500000000, 0),
+		Draft:         &draft,
+		Ignored:       "x",
+		hidden:        "y",
+	}
+	opts.Sort.Field = "created"
+	_, r, err := New(setURL("https://example.com/issues?fixed=1"), QueryStruct(&opts))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"fixed":       {"1"},
+		"page":        {"2"},
+		"state":       {"open"},
+		"labels":      {"bug,ui"},
+		"ids[]":       {"1", "2"},
+		"tag":         {"a", "b"},
+		"before":      {"1500000000"},
+		"draft":       {"false"},
+		"sort[field]": {"created"},
+	}, r.URL.Query())
+}
+
+func TestQueryStructInvalid(t *testing.T) {
+	_, _, err := New(QueryStruct("not a struct"))
+	assert.Error(t, err)
+	_, _, err = New(QueryStruct(struct{ C chan int }{}))
+	assert.Error(t, err)
+}
+
+func TestQueryValues(t *testing.T) {
+	_, r, err := New(setURL("https://example.com/search"),
+		QueryValues(url.Values{"tag": {"a", "b"}}),
+		QueryParam("tag", "c"),
+		QueryParam("q", "go"),
+		QueryInt("page", 3),
+		QueryInt("id", 1, 2),
+		QueryBool("archived", false),
+		QueryTime("since", time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC), "2006-01-02"),
+		QueryParams(map[string]string{"sort": "asc"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"tag":      {"a", "b", "c"},
+		"q":        {"go"},
+		"page":     {"3"},
+		"id":       {"1", "2"},
+		"archived": {"false"},
+		"since":    {"2017-10-01"},
+		"sort":     {"asc"},
+	}, r.URL.Query())
+}
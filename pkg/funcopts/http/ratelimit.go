@@ -0,0 +1,39 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit limits requests to rps per second with bursts of up to burst requests.
+// The limit is shared by every request made with the option, so set it on a `Client`
+// or in a `HostConfig`. Requests wait for a token, with the clock set by `WithClock`, until their context is done
+func RateLimit(rps float64, burst int) RequestOption {
+	limiter := rate.NewLimiter(rate.Limit(rps), burst)
+	return func(r *Request) error {
+		r.limiter = limiter
+		return nil
+	}
+}
+
+type rateLimitTransport struct {
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clk := clock.FromContext(req.Context())
+	now := clk.Now()
+	reservation := t.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return nil, fmt.Errorf("rate limit burst of %d allows no requests", t.limiter.Burst())
+	}
+	if err := clk.Sleep(req.Context(), reservation.DelayFrom(now)); err != nil {
+		reservation.CancelAt(now)
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
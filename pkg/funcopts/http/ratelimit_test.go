@@ -0,0 +1,35 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	c := NewClient(RateLimit(20, 1))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+	}
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+}
+
+func TestRateLimitContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	limit := RateLimit(0.1, 1)
+	_, err := Get(ts.URL, limit)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = Get(ts.URL, limit, WithContext(ctx))
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// HeaderRequestID is the header used by `RequestID`
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id, e.g. the id of an incoming
+// request, for `RequestID` to propagate
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored in ctx by `ContextWithRequestID`
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// RequestID sends an `X-Request-ID` header taken from the request context or generated
+// when there is none. The id is recorded on the `Response`, in logs and in `StatusError`
+func RequestID() RequestOption {
+	return func(r *Request) error {
+		r.requestID = true
+		return nil
+	}
+}
+
+func (cr *Request) setRequestID(req *http.Request) error {
+	if !cr.requestID || req.Header.Get(HeaderRequestID) != "" {
+		return nil
+	}
+	id, ok := RequestIDFromContext(req.Context())
+	if !ok {
+		var err error
+		if id, err = newUUID(); err != nil {
+			return err
+		}
+	}
+	req.Header.Set(HeaderRequestID, id)
+	return nil
+}
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(HeaderRequestID)))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, RequestID())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, res.RequestID)
+	assert.Equal(t, res.RequestID, string(res.Body))
+
+	ctx := ContextWithRequestID(context.Background(), "incoming-1")
+	res, err = Get(ts.URL, RequestID(), WithContext(ctx))
+	assert.NoError(t, err)
+	assert.Equal(t, "incoming-1", res.RequestID)
+	assert.Equal(t, "incoming-1", string(res.Body))
+}
+
+func TestRequestIDLogsAndErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+	buf := &bytes.Buffer{}
+	ctx := ContextWithRequestID(context.Background(), "corr-7")
+	_, err := Get(ts.URL, RequestID(), WithContext(ctx), ExpectSuccess(), WithLogger(slog.New(slog.NewTextHandler(buf, nil))))
+	assert.Error(t, err)
+	assert.Equal(t, "corr-7", err.(*StatusError).RequestID)
+	assert.Contains(t, err.Error(), "(request id corr-7)")
+	assert.Contains(t, buf.String(), "request_id=corr-7")
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	_, ok := RequestIDFromContext(context.Background())
+	assert.False(t, ok)
+	id, ok := RequestIDFromContext(ContextWithRequestID(context.Background(), "abc"))
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+}
@@ -0,0 +1,69 @@
+package httpclient
+
+import "context"
+
+// Result is the outcome of a typed request: the decoded value, the response it came
+// from and any error. It lets dependent calls be chained with `Map` and `Then`
+type Result[T any] struct {
+	Value    T
+	Response *Response
+	Err      error
+}
+
+// Unwrap returns the value and error
+func (r Result[T]) Unwrap() (T, error) {
+	return r.Value, r.Err
+}
+
+// OrElse returns the value, or fallback if the request failed
+func (r Result[T]) OrElse(fallback T) T {
+	if r.Err != nil {
+		return fallback
+	}
+	return r.Value
+}
+
+// Must returns the value and panics if the request failed
+func (r Result[T]) Must() T {
+	if r.Err != nil {
+		panic(r.Err)
+	}
+	return r.Value
+}
+
+// Map transforms the value of r with fn. Failed results are passed through
+func Map[T, U any](r Result[T], fn func(T) (U, error)) Result[U] {
+	if r.Err != nil {
+		return Result[U]{Response: r.Response, Err: r.Err}
+	}
+	v, err := fn(r.Value)
+	return Result[U]{Value: v, Response: r.Response, Err: err}
+}
+
+// Then makes a dependent call with the value of r. Failed results are passed through
+func Then[T, U any](r Result[T], fn func(T) Result[U]) Result[U] {
+	if r.Err != nil {
+		return Result[U]{Response: r.Response, Err: r.Err}
+	}
+	return fn(r.Value)
+}
+
+// GetResult is `GetJSON` returning a `Result`
+func GetResult[T any](ctx context.Context, url string, opts ...RequestOption) Result[T] {
+	return doJSON[T](ctx, Get, url, nil, opts)
+}
+
+// DeleteResult is `DeleteJSON` returning a `Result`
+func DeleteResult[T any](ctx context.Context, url string, opts ...RequestOption) Result[T] {
+	return doJSON[T](ctx, Delete, url, nil, opts)
+}
+
+// PostResult is `PostJSON` returning a `Result`
+func PostResult[T any](ctx context.Context, url string, body interface{}, opts ...RequestOption) Result[T] {
+	return doJSON[T](ctx, Post, url, body, opts)
+}
+
+// PutResult is `PutJSON` returning a `Result`
+func PutResult[T any](ctx context.Context, url string, body interface{}, opts ...RequestOption) Result[T] {
+	return doJSON[T](ctx, Put, url, body, opts)
+}
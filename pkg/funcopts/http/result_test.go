@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultChain(t *testing.T) {
+	ts := typedServer()
+	defer ts.Close()
+	ctx := context.Background()
+	created := Then(GetResult[typedUser](ctx, ts.URL+"/users/1"), func(u typedUser) Result[typedUser] {
+		return PostResult[typedUser](ctx, ts.URL+"/users", typedUser{Name: u.Name + " copy"})
+	})
+	name := Map(created, func(u typedUser) (string, error) {
+		return fmt.Sprintf("%d:%s", u.ID, u.Name), nil
+	})
+	assert.Equal(t, "2:ada copy", name.Must())
+	assert.Equal(t, http.StatusOK, name.Response.Status)
+}
+
+func TestResultFailure(t *testing.T) {
+	ts := typedServer()
+	defer ts.Close()
+	ctx := context.Background()
+	calls := 0
+	r := Then(GetResult[typedUser](ctx, ts.URL+"/missing"), func(u typedUser) Result[typedUser] {
+		calls++
+		return Result[typedUser]{Value: u}
+	})
+	assert.Equal(t, 0, calls)
+	assert.True(t, errors.Is(r.Err, ErrInvalidStatusCode))
+	assert.Equal(t, http.StatusNotFound, r.Response.Status)
+	assert.Equal(t, "fallback", r.OrElse(typedUser{Name: "fallback"}).Name)
+	assert.Panics(t, func() { r.Must() })
+
+	m := Map(GetResult[typedUser](ctx, ts.URL+"/users/1"), func(u typedUser) (int, error) {
+		return 0, errors.New("bad user")
+	})
+	_, err := m.Unwrap()
+	assert.EqualError(t, err, "bad user")
+}
@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// retryPolicy is how often and how long to wait before retrying a failed attempt
+type retryPolicy struct {
+	max     int
+	backoff time.Duration
+}
+
+// Retry retries a request up to max times when it fails with a connection error
+// or a 429, 502, 503 or 504 response. The delay starts at backoff and doubles for
+// every attempt, with jitter, unless the response has a `Retry-After` header. Delays are
+// capped at `transport.MaxRetryBackoff`.
+// Only idempotent requests are retried, see `RetryIdempotent`, unless `RetryIf` or
+// `RetryMethod` say otherwise.
+// Requests with a body are only retried when it can be replayed, see `WithBodyFunc`
+func Retry(max int, backoff time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.retry = &retryPolicy{max: max, backoff: backoff}
+		return nil
+	}
+}
+
+// RetryBudget limits retries to a share of the requests over a sliding window
+type RetryBudget = transport.RetryBudget
+
+// NewRetryBudget returns a `RetryBudget` allowing ratio retries per request sent over
+// window, e.g. 0.2 for one retry every five requests, plus minRetries so a client that
+// sends few requests can still retry
+func NewRetryBudget(ratio float64, minRetries int, window time.Duration) *RetryBudget {
+	return transport.NewRetryBudget(ratio, minRetries, window)
+}
+
+// WithRetryBudget only lets `Retry` retry when budget allows it, so retry storms don't
+// amplify an outage. Share one budget between requests by setting it on a `Client`.
+// Requests that are refused a retry return their last response or error and are
+// counted by budget.Exhausted
+func WithRetryBudget(budget *RetryBudget) RequestOption {
+	return func(r *Request) error {
+		r.retryBudget = budget
+		return nil
+	}
+}
+
+// RetryPredicate decides whether a request is retried after attempt, starting at 1,
+// got resp or failed with err. The Body of resp is only read for error responses,
+// status 400 and up, so successful responses can still be streamed
+type RetryPredicate func(attempt int, resp *Response, err error) bool
+
+// RetryableError retries connection errors and 429, 502, 503 and 504 responses
+// whatever the method of the request
+func RetryableError(attempt int, resp *Response, err error) bool {
+	return err != nil || transport.RetryableStatus(resp.Status)
+}
+
+// RetryIf has `Retry` retry the attempts fn returns true for, whatever their method.
+// It replaces the default policy of `RetryIdempotent`
+func RetryIf(fn RetryPredicate) RequestOption {
+	return func(r *Request) error {
+		r.retryIf = fn
+		return nil
+	}
+}
+
+// RetryMethod has `Retry` decide with fn for requests with method, taking precedence
+// over `RetryIf` and the default policy, e.g. to retry every failed POST
+//
+//	httpclient.RetryMethod("POST", httpclient.RetryableError)
+func RetryMethod(method string, fn RetryPredicate) RequestOption {
+	return func(r *Request) error {
+		if r.retryMethods == nil {
+			r.retryMethods = map[string]RetryPredicate{}
+		}
+		r.retryMethods[method] = fn
+		return nil
+	}
+}
+
+// RetryIdempotent reports whether req can be sent more than once without side effects:
+// its method is idempotent or it has an `Idempotency-Key`, see `IdempotencyKey` and
+// `AutoIdempotencyKey`. The default policy of `Retry` retries errors accepted by
+// `RetryableError` for these requests only
+func RetryIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+// retryable decides whether `Retry` retries attempt of req
+func (cr *Request) retryable(attempt int, req *http.Request, resp *http.Response, err error) bool {
+	fn, ok := cr.retryMethods[req.Method]
+	if !ok {
+		fn = cr.retryIf
+	}
+	if fn == nil {
+		if !RetryIdempotent(req) {
+			return false
+		}
+		fn = RetryableError
+	}
+	var response *Response
+	if resp != nil {
+		response = &Response{
+			Headers:  resp.Header,
+			Cookies:  resp.Cookies(),
+			Status:   resp.StatusCode,
+			URL:      req.URL.String(),
+			Proto:    resp.Proto,
+			Trailers: resp.Trailer,
+		}
+		if resp.StatusCode >= 400 {
+			// reads no more than `MaxResponseBytes` allows, leaving the rest for the
+			// caller to report the response as too large
+			limit := cr.maxResponseBytes
+			if limit <= 0 {
+				limit = -1
+			}
+			body, restored, _, readErr := peekBody(resp.Body, limit)
+			if readErr != nil {
+				resp.Body = ioutil.NopCloser(bytes.NewReader(nil))
+				err = readErr
+			} else {
+				resp.Body = restored
+			}
+			response.Body = body
+		}
+	}
+	return fn(attempt, response, err)
+}
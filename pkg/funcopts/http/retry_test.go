@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func flakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	return ts, &calls
+}
+
+func TestRetry(t *testing.T) {
+	ts, calls := flakyServer(2, http.StatusServiceUnavailable)
+	defer ts.Close()
+	retries := 0
+	res, err := Get(ts.URL, Retry(3, time.Millisecond), OnRetry(func(req *http.Request, attempt int, err error) {
+		retries++
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	assert.Equal(t, 2, retries)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	ts, calls := flakyServer(5, http.StatusBadGateway)
+	defer ts.Close()
+	res, err := Get(ts.URL, Retry(2, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	ts, calls := flakyServer(1, http.StatusInternalServerError)
+	defer ts.Close()
+	_, err := Get(ts.URL, Retry(2, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	ts, calls := flakyServer(1, http.StatusTooManyRequests)
+	defer ts.Close()
+	res, err := Post(ts.URL, Retry(1, time.Millisecond), IdempotencyKey("key"), WithBodyFunc(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("payload")), nil
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(res.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+
+	ts2, calls2 := flakyServer(1, http.StatusTooManyRequests)
+	defer ts2.Close()
+	res, err = Post(ts2.URL, Retry(1, time.Millisecond), IdempotencyKey("key"), WithBody(io.MultiReader(strings.NewReader("once"))))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, res.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls2))
+}
+
+func TestRetryBudget(t *testing.T) {
+	ts, calls := flakyServer(100, http.StatusServiceUnavailable)
+	defer ts.Close()
+	budget := NewRetryBudget(0.5, 0, time.Minute)
+	c := NewClient(Retry(3, time.Millisecond), WithRetryBudget(budget))
+	for i := 0; i < 4; i++ {
+		res, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.Status)
+	}
+	assert.Equal(t, uint64(4), budget.Requests())
+	assert.Equal(t, uint64(2), budget.Retries())
+	assert.Equal(t, int32(6), atomic.LoadInt32(calls))
+	assert.Equal(t, uint64(2), c.Stats().Retries)
+	assert.True(t, budget.Exhausted() > 0)
+}
+
+func TestRetryIdempotentOnly(t *testing.T) {
+	ts, calls := flakyServer(2, http.StatusServiceUnavailable)
+	defer ts.Close()
+	res, err := Post(ts.URL, Retry(2, time.Millisecond), WithBody(strings.NewReader("payload")))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	res, err = Post(ts.URL, Retry(2, time.Millisecond), AutoIdempotencyKey(), WithBody(strings.NewReader("payload")))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRetryIf(t *testing.T) {
+	ts, calls := flakyServer(2, http.StatusInternalServerError)
+	defer ts.Close()
+	var attempts []int
+	res, err := Post(ts.URL, Retry(3, time.Millisecond), WithBody(strings.NewReader("payload")), RetryIf(func(attempt int, resp *Response, err error) bool {
+		attempts = append(attempts, attempt)
+		return err == nil && resp.Status == http.StatusInternalServerError
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestRetryMethod(t *testing.T) {
+	ts, calls := flakyServer(2, http.StatusServiceUnavailable)
+	defer ts.Close()
+	c := NewClient(Retry(2, time.Millisecond), RetryMethod("POST", RetryableError), RetryMethod("GET", func(int, *Response, error) bool {
+		return false
+	}))
+	res, err := c.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Status)
+	res, err = c.Post(ts.URL, WithBody(strings.NewReader("payload")))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+	assert.Equal(t, "payload", string(res.Body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRetryPredicateReadsErrorBody(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("try_again"))
+			return
+		}
+		w.Write([]byte("done"))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, Retry(1, time.Millisecond), RetryIf(func(attempt int, resp *Response, err error) bool {
+		return resp != nil && string(resp.Body) == "try_again"
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "done", string(res.Body))
+}
+
+func TestRetryPredicateMaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.(http.Flusher).Flush()
+		w.Write([]byte(strings.Repeat("x", 1<<20)))
+	}))
+	defer ts.Close()
+	var seen int
+	_, err := Get(ts.URL, MaxResponseBytes(10), Retry(1, time.Millisecond), RetryIf(func(attempt int, resp *Response, err error) bool {
+		seen = len(resp.Body)
+		return false
+	}))
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+	assert.Equal(t, 10, seen)
+}
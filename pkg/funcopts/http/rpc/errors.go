@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// Error codes shared by Twirp and Connect
+const (
+	CodeCanceled           = "canceled"
+	CodeUnknown            = "unknown"
+	CodeInvalidArgument    = "invalid_argument"
+	CodeDeadlineExceeded   = "deadline_exceeded"
+	CodeNotFound           = "not_found"
+	CodeAlreadyExists      = "already_exists"
+	CodePermissionDenied   = "permission_denied"
+	CodeResourceExhausted  = "resource_exhausted"
+	CodeFailedPrecondition = "failed_precondition"
+	CodeAborted            = "aborted"
+	CodeOutOfRange         = "out_of_range"
+	CodeUnimplemented      = "unimplemented"
+	CodeInternal           = "internal"
+	CodeUnavailable        = "unavailable"
+	CodeDataLoss           = "data_loss"
+	CodeUnauthenticated    = "unauthenticated"
+)
+
+// Error is an error returned by a service
+type Error struct {
+	Code    string
+	Message string
+	// Meta is the metadata of a Twirp error
+	Meta map[string]string
+	// Details are the raw details of a Connect error
+	Details []json.RawMessage
+	// HTTPStatus is the status of the response carrying the error
+	HTTPStatus int
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Code returns the code of err if it is or wraps an *Error, or `CodeUnknown`
+func Code(err error) string {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+	return CodeUnknown
+}
+
+// Retryable reports whether a call that failed with err may succeed when retried
+func Retryable(err error) bool {
+	switch Code(err) {
+	case CodeUnavailable, CodeResourceExhausted, CodeAborted, CodeDeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+type errorEnvelope struct {
+	Code    string            `json:"code"`
+	Msg     string            `json:"msg"`
+	Message string            `json:"message"`
+	Meta    map[string]string `json:"meta"`
+	Details []json.RawMessage `json:"details"`
+}
+
+// decodeError decodes the error envelope of res, falling back to a code derived from the status
+func (c *Client) decodeError(res *httpclient.Response) error {
+	var env errorEnvelope
+	if err := json.Unmarshal(res.Body, &env); err != nil || env.Code == "" {
+		return &Error{Code: codeFromStatus(res.Status), Message: http.StatusText(res.Status), HTTPStatus: res.Status}
+	}
+	msg := env.Message
+	if c.protocol == Twirp {
+		msg = env.Msg
+	}
+	return &Error{Code: env.Code, Message: msg, Meta: env.Meta, Details: env.Details, HTTPStatus: res.Status}
+}
+
+func codeFromStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeUnimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeUnavailable
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,136 @@
+// Package rpc makes unary Twirp and Connect calls with JSON payloads on top of httpclient,
+// without generated code
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// Protocol is the wire protocol used by a `Client`
+type Protocol int
+
+const (
+	// Twirp calls `POST {base}/twirp/{service}/{method}`
+	Twirp Protocol = iota
+	// Connect calls `POST {base}/{service}/{method}` using the Connect unary protocol
+	Connect
+)
+
+// Client calls the methods of services served at a base url
+type Client struct {
+	protocol Protocol
+	baseURL  string
+	prefix   string
+	client   *httpclient.Client
+}
+
+// NewTwirp returns a `Client` for Twirp services at baseURL using the default `/twirp` prefix.
+// opts are applied to every call
+func NewTwirp(baseURL string, opts ...httpclient.RequestOption) *Client {
+	return &Client{protocol: Twirp, baseURL: baseURL, prefix: "/twirp", client: newClient(opts)}
+}
+
+// NewConnect returns a `Client` for Connect services at baseURL. opts are applied to every call
+func NewConnect(baseURL string, opts ...httpclient.RequestOption) *Client {
+	return &Client{protocol: Connect, baseURL: baseURL, client: newClient(opts)}
+}
+
+func newClient(opts []httpclient.RequestOption) *httpclient.Client {
+	return httpclient.NewClient(append([]httpclient.RequestOption{httpclient.AutoIdempotencyKey()}, opts...)...)
+}
+
+// WithPrefix changes the path prefix of the service routes, e.g. for Twirp servers
+// mounted without `/twirp`
+func (c *Client) WithPrefix(prefix string) *Client {
+	copied := *c
+	copied.prefix = prefix
+	return &copied
+}
+
+// CallOption configures a single call
+type CallOption func(*callConfig)
+
+type callConfig struct {
+	idempotent bool
+	opts       []httpclient.RequestOption
+}
+
+// Idempotent marks the method as free of side effects. Connect calls are sent as GET
+// requests so they can be cached and safely retried
+func Idempotent() CallOption {
+	return func(c *callConfig) {
+		c.idempotent = true
+	}
+}
+
+// WithOptions applies opts to the call
+func WithOptions(opts ...httpclient.RequestOption) CallOption {
+	return func(c *callConfig) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// Call invokes method of service, e.g. `Call(ctx, "acme.payments.v1.Payments", "Charge", in, &out)`.
+// in is encoded as json and the response decoded into out. Errors returned by the service are *Error.
+// POST calls carry an `Idempotency-Key` so they can be retried safely
+func (c *Client) Call(ctx context.Context, service, method string, in, out interface{}, opts ...CallOption) error {
+	cfg := &callConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	url := strings.TrimRight(c.baseURL, "/") + c.prefix + "/" + service + "/" + method
+	reqOpts := []httpclient.RequestOption{httpclient.WithContext(ctx), httpclient.JSON()}
+	if c.protocol == Connect {
+		reqOpts = append(reqOpts, httpclient.AddHeaders(map[string]string{"Connect-Protocol-Version": "1"}))
+		if deadline, ok := ctx.Deadline(); ok {
+			ms := time.Until(deadline).Milliseconds()
+			if ms < 1 {
+				ms = 1
+			}
+			reqOpts = append(reqOpts, httpclient.AddHeaders(map[string]string{"Connect-Timeout-Ms": strconv.FormatInt(ms, 10)}))
+		}
+	}
+	reqOpts = append(reqOpts, cfg.opts...)
+
+	var res *httpclient.Response
+	var err error
+	if c.protocol == Connect && cfg.idempotent {
+		message, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		res, err = c.client.Get(url, append(reqOpts,
+			httpclient.QueryParam("connect", "v1"),
+			httpclient.QueryParam("encoding", "json"),
+			httpclient.QueryParam("message", string(message)),
+		)...)
+		if err != nil {
+			return err
+		}
+	} else {
+		res, err = c.client.Post(url, append(reqOpts, httpclient.WithTypedBody(in))...)
+		if err != nil {
+			return err
+		}
+	}
+	if res.Status != http.StatusOK {
+		return c.decodeError(res)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Headers.Get("Content-Type"))
+	if mediaType != httpclient.ContentTypeJSON {
+		return &Error{Code: CodeInternal, Message: fmt.Sprintf("unexpected content type %q", mediaType), HTTPStatus: res.Status}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Body, out)
+}
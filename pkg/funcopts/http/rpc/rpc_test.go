@@ -0,0 +1,127 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type chargeRequest struct {
+	Amount int `json:"amount"`
+}
+
+type chargeResponse struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+func rpcServer(t *testing.T, check func(r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		var in chargeRequest
+		if r.Method == "GET" {
+			json.Unmarshal([]byte(r.URL.Query().Get("message")), &in)
+		} else {
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &in)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if in.Amount < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			if r.URL.Path[:7] == "/twirp/" {
+				w.Write([]byte(`{"code":"invalid_argument","msg":"negative amount","meta":{"field":"amount"}}`))
+			} else {
+				w.Write([]byte(`{"code":"invalid_argument","message":"negative amount","details":[{"type":"x"}]}`))
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(chargeResponse{ID: "ch_1", Amount: in.Amount})
+	}))
+}
+
+func TestTwirp(t *testing.T) {
+	ts := rpcServer(t, func(r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/twirp/acme.payments.v1.Payments/Charge", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NotEmpty(t, r.Header.Get(httpclient.HeaderIdempotencyKey))
+	})
+	defer ts.Close()
+	c := NewTwirp(ts.URL)
+	var out chargeResponse
+	err := c.Call(context.Background(), "acme.payments.v1.Payments", "Charge", chargeRequest{Amount: 5}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, chargeResponse{ID: "ch_1", Amount: 5}, out)
+
+	err = c.Call(context.Background(), "acme.payments.v1.Payments", "Charge", chargeRequest{Amount: -1}, &out)
+	var rpcErr *Error
+	if assert.True(t, errors.As(err, &rpcErr)) {
+		assert.Equal(t, CodeInvalidArgument, rpcErr.Code)
+		assert.Equal(t, "negative amount", rpcErr.Message)
+		assert.Equal(t, "amount", rpcErr.Meta["field"])
+		assert.Equal(t, http.StatusBadRequest, rpcErr.HTTPStatus)
+	}
+	assert.False(t, Retryable(err))
+}
+
+func TestConnect(t *testing.T) {
+	ts := rpcServer(t, func(r *http.Request) {
+		assert.Equal(t, "/acme.payments.v1.Payments/Charge", r.URL.Path)
+		assert.Equal(t, "1", r.Header.Get("Connect-Protocol-Version"))
+		assert.NotEmpty(t, r.Header.Get("Connect-Timeout-Ms"))
+	})
+	defer ts.Close()
+	c := NewConnect(ts.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var out chargeResponse
+	err := c.Call(ctx, "acme.payments.v1.Payments", "Charge", chargeRequest{Amount: 7}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, out.Amount)
+
+	err = c.Call(ctx, "acme.payments.v1.Payments", "Charge", chargeRequest{Amount: -1}, &out)
+	assert.Equal(t, CodeInvalidArgument, Code(err))
+	assert.EqualError(t, err, "invalid_argument: negative amount")
+	assert.Len(t, err.(*Error).Details, 1)
+}
+
+func TestConnectIdempotent(t *testing.T) {
+	ts := rpcServer(t, func(r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "v1", r.URL.Query().Get("connect"))
+		assert.Equal(t, "json", r.URL.Query().Get("encoding"))
+	})
+	defer ts.Close()
+	var out chargeResponse
+	err := NewConnect(ts.URL).Call(context.Background(), "acme.payments.v1.Payments", "Charge", chargeRequest{Amount: 3}, &out, Idempotent())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, out.Amount)
+}
+
+func TestErrorWithoutEnvelope(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	err := NewTwirp(ts.URL).WithPrefix("").Call(context.Background(), "svc", "M", struct{}{}, nil)
+	assert.Equal(t, CodeUnavailable, Code(err))
+	assert.True(t, Retryable(err))
+}
+
+func TestUnexpectedContentType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>proxy page</html>"))
+	}))
+	defer ts.Close()
+	err := NewConnect(ts.URL).Call(context.Background(), "svc", "M", struct{}{}, nil)
+	assert.Equal(t, CodeInternal, Code(err))
+}
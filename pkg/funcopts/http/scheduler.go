@@ -0,0 +1,205 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority orders the requests waiting in a `Scheduler`
+type Priority int
+
+const (
+	// PriorityLow is for bulk and background work
+	PriorityLow Priority = iota - 1
+	// PriorityNormal is the priority of requests without `WithPriority`
+	PriorityNormal
+	// PriorityHigh is for interactive calls that someone is waiting on
+	PriorityHigh
+)
+
+// priorities are the priorities of a `Scheduler` from highest to lowest
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// DefaultShares are the shares of concurrency given to each priority by `NewScheduler`
+var DefaultShares = map[Priority]int{PriorityHigh: 50, PriorityNormal: 30, PriorityLow: 20}
+
+// DefaultSchedulerQueue is the number of requests a `Scheduler` queues by default
+const DefaultSchedulerQueue = 1000
+
+// SchedulerOption configures a `Scheduler`
+type SchedulerOption func(*Scheduler)
+
+// Share sets the share of concurrency of requests with priority p, relative to the
+// shares of the other priorities
+func Share(p Priority, share int) SchedulerOption {
+	return func(s *Scheduler) {
+		s.shares[p.clamp()] = share
+	}
+}
+
+// QueueSize sets the number of requests that can wait for the scheduler, across
+// all priorities, before new ones fail with `ErrQueueFull`
+func QueueSize(n int) SchedulerOption {
+	return func(s *Scheduler) {
+		s.queueSize = n
+	}
+}
+
+// Scheduler dispatches requests from a bounded queue, highest priority first. Each
+// priority can only use its share of the concurrency, so bulk background requests
+// can't take every slot and starve interactive ones sharing the client
+type Scheduler struct {
+	concurrency int
+	queueSize   int
+	shares      map[Priority]int
+	limits      map[Priority]int
+	sync.Mutex
+	total    int
+	inFlight map[Priority]int
+	waiting  map[Priority][]chan struct{}
+	queued   int
+}
+
+// NewScheduler returns a `Scheduler` running up to concurrency requests at once,
+// shared between the priorities with `DefaultShares`
+func NewScheduler(concurrency int, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		concurrency: concurrency,
+		queueSize:   DefaultSchedulerQueue,
+		shares:      map[Priority]int{},
+		limits:      map[Priority]int{},
+		inFlight:    map[Priority]int{},
+		waiting:     map[Priority][]chan struct{}{},
+	}
+	for p, share := range DefaultShares {
+		s.shares[p] = share
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	sum := 0
+	for _, share := range s.shares {
+		sum += share
+	}
+	for _, p := range priorities {
+		limit := 1
+		if sum > 0 && concurrency*s.shares[p]/sum > 1 {
+			limit = concurrency * s.shares[p] / sum
+		}
+		s.limits[p] = limit
+	}
+	return s
+}
+
+// InFlight returns the number of requests with priority p being sent
+func (s *Scheduler) InFlight(p Priority) int {
+	s.Lock()
+	defer s.Unlock()
+	return s.inFlight[p.clamp()]
+}
+
+// Queued returns the number of requests with priority p waiting to be sent
+func (s *Scheduler) Queued(p Priority) int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.waiting[p.clamp()])
+}
+
+// Schedule sends requests through s. Set it on a `Client` so its requests share the
+// scheduler, and give them a priority with `WithPriority`. A request holds its slot
+// until its response has been read, or until it is returned with `IncludeRawResponse`
+func Schedule(s *Scheduler) RequestOption {
+	return func(r *Request) error {
+		r.scheduler = s
+		return nil
+	}
+}
+
+// WithPriority sets the priority of the request in a `Scheduler`
+func WithPriority(p Priority) RequestOption {
+	return func(r *Request) error {
+		r.priority = p
+		return nil
+	}
+}
+
+// clamp maps p to the closest priority known to the scheduler
+func (p Priority) clamp() Priority {
+	if p < PriorityLow {
+		return PriorityLow
+	}
+	if p > PriorityHigh {
+		return PriorityHigh
+	}
+	return p
+}
+
+// runnable reports whether a request with priority p can start now
+func (s *Scheduler) runnable(p Priority) bool {
+	return s.total < s.concurrency && s.inFlight[p] < s.limits[p]
+}
+
+func (s *Scheduler) start(p Priority) {
+	s.total++
+	s.inFlight[p]++
+}
+
+// acquire waits for a slot for a request with priority p and returns the function
+// releasing it
+func (s *Scheduler) acquire(ctx context.Context, p Priority) (func(), error) {
+	p = p.clamp()
+	release := func() { s.release(p) }
+	s.Lock()
+	if len(s.waiting[p]) == 0 && s.runnable(p) {
+		s.start(p)
+		s.Unlock()
+		return release, nil
+	}
+	if s.queued >= s.queueSize {
+		s.Unlock()
+		return nil, ErrQueueFull
+	}
+	ready := make(chan struct{})
+	s.waiting[p] = append(s.waiting[p], ready)
+	s.queued++
+	s.Unlock()
+	select {
+	case <-ready:
+		return release, nil
+	case <-ctx.Done():
+		s.Lock()
+		defer s.Unlock()
+		for i, ch := range s.waiting[p] {
+			if ch == ready {
+				s.waiting[p] = append(s.waiting[p][:i], s.waiting[p][i+1:]...)
+				s.queued--
+				return nil, ctx.Err()
+			}
+		}
+		// the slot was granted while the context was done
+		s.finish(p)
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Scheduler) release(p Priority) {
+	s.Lock()
+	defer s.Unlock()
+	s.finish(p)
+}
+
+// finish frees the slot of a request with priority p and hands slots to the waiting
+// requests, highest priority first
+func (s *Scheduler) finish(p Priority) {
+	s.total--
+	s.inFlight[p]--
+	for _, p := range priorities {
+		for len(s.waiting[p]) > 0 && s.runnable(p) {
+			ready := s.waiting[p][0]
+			s.waiting[p] = s.waiting[p][1:]
+			s.queued--
+			s.start(p)
+			close(ready)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerShares(t *testing.T) {
+	s := NewScheduler(4)
+	assert.Equal(t, map[Priority]int{PriorityHigh: 2, PriorityNormal: 1, PriorityLow: 1}, s.limits)
+
+	releaseLow, err := s.acquire(context.Background(), PriorityLow)
+	require.NoError(t, err)
+	lowDone := make(chan func())
+	go func() {
+		release, err := s.acquire(context.Background(), PriorityLow)
+		assert.NoError(t, err)
+		lowDone <- release
+	}()
+	assert.Eventually(t, func() bool { return s.Queued(PriorityLow) == 1 }, time.Second, time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		_, err := s.acquire(context.Background(), PriorityHigh)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, s.InFlight(PriorityHigh))
+
+	releaseLow()
+	release := <-lowDone
+	assert.Equal(t, 1, s.InFlight(PriorityLow))
+	assert.Equal(t, 0, s.Queued(PriorityLow))
+	release()
+	assert.Equal(t, 0, s.InFlight(PriorityLow))
+}
+
+func TestSchedulerPriorityOrder(t *testing.T) {
+	s := NewScheduler(1, Share(PriorityHigh, 1), Share(PriorityNormal, 1), Share(PriorityLow, 1))
+	release, err := s.acquire(context.Background(), PriorityNormal)
+	require.NoError(t, err)
+	order := make(chan Priority, 2)
+	for _, p := range []Priority{PriorityLow, PriorityHigh} {
+		go func(p Priority) {
+			release, err := s.acquire(context.Background(), p)
+			assert.NoError(t, err)
+			order <- p
+			release()
+		}(p)
+		assert.Eventually(t, func() bool { return s.Queued(p) == 1 }, time.Second, time.Millisecond)
+	}
+	release()
+	assert.Equal(t, PriorityHigh, <-order)
+	assert.Equal(t, PriorityLow, <-order)
+}
+
+func TestSchedulerQueue(t *testing.T) {
+	s := NewScheduler(1, QueueSize(1))
+	release, err := s.acquire(context.Background(), PriorityNormal)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	waited := make(chan error)
+	go func() {
+		_, err := s.acquire(ctx, PriorityNormal)
+		waited <- err
+	}()
+	assert.Eventually(t, func() bool { return s.Queued(PriorityNormal) == 1 }, time.Second, time.Millisecond)
+	_, err = s.acquire(context.Background(), PriorityHigh)
+	assert.True(t, errors.Is(err, ErrQueueFull))
+
+	cancel()
+	assert.True(t, errors.Is(<-waited, context.Canceled))
+	assert.Equal(t, 0, s.Queued(PriorityNormal))
+	release()
+	assert.Equal(t, 0, s.InFlight(PriorityNormal))
+}
+
+func TestSchedule(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	s := NewScheduler(1, QueueSize(0))
+	c := NewClient(Schedule(s))
+	done := make(chan error)
+	go func() {
+		_, err := c.Get(ts.URL, WithPriority(PriorityLow))
+		done <- err
+	}()
+	assert.Eventually(t, func() bool { return s.InFlight(PriorityLow) == 1 }, time.Second, time.Millisecond)
+	_, err := c.Get(ts.URL, WithPriority(PriorityHigh))
+	assert.True(t, errors.Is(err, ErrQueueFull))
+	close(unblock)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, s.InFlight(PriorityLow))
+	assert.Equal(t, uint64(1), c.Stats().Errors)
+}
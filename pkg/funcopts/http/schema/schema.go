@@ -0,0 +1,159 @@
+// Package schema checks response bodies against a JSON Schema before they are returned,
+// for integrations with apis that may not send what they document
+//
+//	res, err := httpclient.Get(url, schema.ExpectSchema(userSchema))
+//	var serr *schema.Error
+//	if errors.As(err, &serr) {
+//		for _, v := range serr.Violations { ... }
+//	}
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ErrSchemaViolation is the error wrapped by `Error`
+var ErrSchemaViolation = errors.New("response does not match schema")
+
+// resource is the name the schema is compiled under
+const resource = "schema.json"
+
+// Violation is one way the body failed to match the schema
+type Violation struct {
+	// Path is the json pointer to the value in the body, empty for the body itself
+	Path string
+	// Keyword is the location of the schema keyword that failed, like `/properties/id/type`
+	Keyword string
+	Message string
+}
+
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + v.Message
+}
+
+// Error is returned when a response body doesn't match the schema. It lists every violation found
+type Error struct {
+	Method     string
+	URL        string
+	Status     int
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Sprintf("%s %s: %s: %s", e.Method, e.URL, ErrSchemaViolation, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns `ErrSchemaViolation`
+func (e *Error) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// Compile parses schemaJSON. Documents without `$schema` are treated as draft 2020-12
+func Compile(schemaJSON []byte) (*Schema, error) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(resource, bytes.NewReader(schemaJSON)); err != nil {
+		return nil, err
+	}
+	s, err := c.Compile(resource)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: s}, nil
+}
+
+// ExpectSchema checks the body of successful responses against schemaJSON before they are
+// returned. A body that doesn't match fails the request with an `*Error`. Responses with
+// other statuses are left to `httpclient.ExpectStatus`. Responses without a body, such as
+// `204 No Content` or the response to a HEAD request, aren't checked
+func ExpectSchema(schemaJSON []byte) httpclient.RequestOption {
+	s, err := Compile(schemaJSON)
+	if err != nil {
+		return func(*httpclient.Request) error {
+			return fmt.Errorf("compiling schema: %w", err)
+		}
+	}
+	return s.Option()
+}
+
+// Option checks the responses to requests sent with it, see `ExpectSchema`
+func (s *Schema) Option() httpclient.RequestOption {
+	return httpclient.Use(s.Middleware)
+}
+
+// Middleware checks the successful responses coming back through next
+func (s *Schema) Middleware(next http.RoundTripper) http.RoundTripper {
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 ||
+			resp.StatusCode == http.StatusNoContent || req.Method == http.MethodHead {
+			return resp, err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if len(body) == 0 {
+			return resp, nil
+		}
+		if violations := s.Validate(body); len(violations) > 0 {
+			return nil, &Error{Method: req.Method, URL: req.URL.Redacted(), Status: resp.StatusCode, Violations: violations}
+		}
+		return resp, nil
+	})
+}
+
+// Validate returns the violations of body against the schema, or nil if it matches
+func (s *Schema) Validate(body []byte) []Violation {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []Violation{{Message: "invalid json: " + err.Error()}}
+	}
+	err := s.schema.Validate(v)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []Violation{{Message: err.Error()}}
+	}
+	return leaves(verr, nil)
+}
+
+// leaves flattens the tree of validation errors to the ones without causes,
+// which are the actual failures
+func leaves(err *jsonschema.ValidationError, out []Violation) []Violation {
+	if len(err.Causes) == 0 {
+		return append(out, Violation{Path: err.InstanceLocation, Keyword: err.KeywordLocation, Message: err.Message})
+	}
+	for _, cause := range err.Causes {
+		out = leaves(cause, out)
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userSchema = []byte(`{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string"},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}
+}`)
+
+func serve(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestExpectSchemaValid(t *testing.T) {
+	ts := serve(http.StatusOK, `{"id":1,"name":"lusis","tags":["a"]}`)
+	defer ts.Close()
+	res, err := httpclient.Get(ts.URL, ExpectSchema(userSchema))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"lusis","tags":["a"]}`, string(res.Body))
+}
+
+func TestExpectSchemaViolations(t *testing.T) {
+	ts := serve(http.StatusOK, `{"id":"1","tags":[1]}`)
+	defer ts.Close()
+	_, err := httpclient.Get(ts.URL, ExpectSchema(userSchema))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrSchemaViolation))
+	var serr *Error
+	require.True(t, errors.As(err, &serr))
+	assert.Equal(t, http.StatusOK, serr.Status)
+	assert.Equal(t, "GET", serr.Method)
+	paths := map[string]string{}
+	for _, v := range serr.Violations {
+		paths[v.Path] = v.Keyword
+	}
+	assert.Len(t, serr.Violations, 3)
+	assert.Equal(t, "/required", paths[""])
+	assert.Equal(t, "/properties/id/type", paths["/id"])
+	assert.Equal(t, "/properties/tags/items/type", paths["/tags/0"])
+}
+
+func TestExpectSchemaInvalidJSON(t *testing.T) {
+	ts := serve(http.StatusOK, `not json`)
+	defer ts.Close()
+	_, err := httpclient.Get(ts.URL, ExpectSchema(userSchema))
+	var serr *Error
+	require.True(t, errors.As(err, &serr))
+	assert.Contains(t, serr.Violations[0].Message, "invalid json")
+}
+
+func TestExpectSchemaSkipsErrorResponses(t *testing.T) {
+	ts := serve(http.StatusNotFound, `{"error":"missing"}`)
+	defer ts.Close()
+	_, err := httpclient.Get(ts.URL, ExpectSchema(userSchema), httpclient.ExpectSuccess())
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode))
+	assert.False(t, errors.Is(err, ErrSchemaViolation))
+}
+
+func TestExpectSchemaSkipsEmptyBodies(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusNoContent} {
+		ts := serve(status, "")
+		_, err := httpclient.Get(ts.URL, ExpectSchema(userSchema))
+		assert.NoError(t, err, status)
+		ts.Close()
+	}
+	ts := serve(http.StatusOK, `{"id":1,"name":"lusis"}`)
+	defer ts.Close()
+	_, err := httpclient.Head(ts.URL, ExpectSchema(userSchema))
+	assert.NoError(t, err)
+}
+
+func TestExpectSchemaBadSchema(t *testing.T) {
+	_, err := httpclient.Get("http://example.com", ExpectSchema([]byte(`{"type": 12}`)))
+	assert.Error(t, err)
+}
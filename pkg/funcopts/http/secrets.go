@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// SecretProvider looks up secrets like api keys and tokens by key
+type SecretProvider interface {
+	Secret(ctx context.Context, key string) (string, error)
+}
+
+// SecretProviderFunc is a `SecretProvider` implemented by a function
+type SecretProviderFunc func(ctx context.Context, key string) (string, error)
+
+// Secret calls f
+func (f SecretProviderFunc) Secret(ctx context.Context, key string) (string, error) {
+	return f(ctx, key)
+}
+
+// EnvSecrets reads secrets from the environment variable named by the key
+var EnvSecrets SecretProvider = SecretProviderFunc(func(_ context.Context, key string) (string, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("%w: environment variable %s", ErrSecretNotFound, key)
+	}
+	return v, nil
+})
+
+// FileSecrets reads secrets from the file at the path given by the key, like the secrets
+// kubernetes and docker mount. Surrounding whitespace is trimmed
+var FileSecrets SecretProvider = SecretProviderFunc(func(_ context.Context, key string) (string, error) {
+	b, err := ioutil.ReadFile(key)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: file %s", ErrSecretNotFound, key)
+	}
+	return strings.TrimSpace(string(b)), err
+})
+
+var secretProviders = struct {
+	byName map[string]SecretProvider
+	sync.RWMutex
+}{byName: map[string]SecretProvider{
+	"env":  EnvSecrets,
+	"file": FileSecrets,
+}}
+
+// RegisterSecretProvider makes p available to `HeaderFromSecret` as name, replacing any
+// provider with the name. `env` and `file` are registered by default
+//
+//	RegisterSecretProvider("vault", CacheSecrets(vault.New(vaultAddr), 5*time.Minute))
+//	c := NewClient(HeaderFromSecret("X-API-Key", "vault:secret/data/billing#api_key"))
+func RegisterSecretProvider(name string, p SecretProvider) {
+	secretProviders.Lock()
+	defer secretProviders.Unlock()
+	secretProviders.byName[name] = p
+}
+
+// SecretCache is a `SecretProvider` that keeps the secrets of another provider for a time
+type SecretCache struct {
+	provider SecretProvider
+	ttl      time.Duration
+	sync.Mutex
+	entries map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value   string
+	fetched time.Time
+}
+
+// CacheSecrets keeps each secret of p for ttl, so rotated secrets are picked up within
+// ttl. Secrets are also looked up again after `Forget`, which `HeaderFromSecret` calls
+// when a request using the secret is rejected
+func CacheSecrets(p SecretProvider, ttl time.Duration) *SecretCache {
+	return &SecretCache{provider: p, ttl: ttl, entries: map[string]cachedSecret{}}
+}
+
+// Secret returns the cached secret for key or looks it up when it's older than the ttl.
+// Time is read from the clock of ctx
+func (c *SecretCache) Secret(ctx context.Context, key string) (string, error) {
+	now := clock.FromContext(ctx).Now()
+	c.Lock()
+	entry, ok := c.entries[key]
+	c.Unlock()
+	if ok && now.Sub(entry.fetched) < c.ttl {
+		return entry.value, nil
+	}
+	v, err := c.provider.Secret(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	c.Lock()
+	c.entries[key] = cachedSecret{value: v, fetched: now}
+	c.Unlock()
+	return v, nil
+}
+
+// Forget drops the cached secret for key
+func (c *SecretCache) Forget(key string) {
+	c.Lock()
+	delete(c.entries, key)
+	c.Unlock()
+}
+
+// HeaderFromSecret sets the header name to a secret looked up when each request is sent,
+// retries included, instead of when the option is created. providerKey is the name of a
+// registered provider and the key of the secret joined by a colon, like `env:API_KEY`.
+// The header is redacted from logs and dumps and, like with `HeaderFunc`, only sent to the
+// host of the original request. When a request is answered with a 401 or 403 the secret
+// is forgotten by providers that cache, so a rotated secret is used next
+func HeaderFromSecret(name, providerKey string) RequestOption {
+	return headerFromSecret(name, "", providerKey)
+}
+
+// BearerTokenFromSecret sets the `Authorization` header to a bearer token looked up like
+// `HeaderFromSecret`
+func BearerTokenFromSecret(providerKey string) RequestOption {
+	return headerFromSecret("Authorization", "Bearer ", providerKey)
+}
+
+func headerFromSecret(name, prefix, providerKey string) RequestOption {
+	return func(r *Request) error {
+		providerName, key, ok := strings.Cut(providerKey, ":")
+		if !ok {
+			return fmt.Errorf("secret reference %q must be provider:key", providerKey)
+		}
+		secretProviders.RLock()
+		p, ok := secretProviders.byName[providerName]
+		secretProviders.RUnlock()
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownSecretProvider, providerName)
+		}
+		value := func(ctx context.Context) (string, error) {
+			secret, err := p.Secret(ctx, key)
+			if err != nil {
+				return "", fmt.Errorf("secret %s: %w", providerKey, err)
+			}
+			return prefix + secret, nil
+		}
+		var reject func(string)
+		if forgetter, ok := p.(interface{ Forget(string) }); ok {
+			reject = func(string) { forgetter.Forget(key) }
+		}
+		return HeaderFunc(name, value, reject)(r)
+	}
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderFromSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-API-Key") + "|" + r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	t.Setenv("TEST_API_KEY", "k1")
+	token := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("t1\n"), 0600))
+	c := NewClient(HeaderFromSecret("X-API-Key", "env:TEST_API_KEY"), BearerTokenFromSecret("file:"+token))
+	res, err := c.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "k1|Bearer t1", string(res.Body))
+
+	t.Setenv("TEST_API_KEY", "k2")
+	res, err = c.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "k2|Bearer t1", string(res.Body), "looked up on each request")
+}
+
+func TestHeaderFromSecretCrossHostRedirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://"+strings.Replace(r.Host, "127.0.0.1", "localhost", 1)+"/echo", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	t.Setenv("TEST_TOKEN", "s3cret")
+	res, err := Get(ts.URL+"/redirect", BearerTokenFromSecret("env:TEST_TOKEN"))
+	require.NoError(t, err)
+	assert.Equal(t, "", string(res.Body))
+}
+
+func TestHeaderFromSecretErrors(t *testing.T) {
+	_, err := Get("http://example.com", HeaderFromSecret("X-API-Key", "nope:key"))
+	assert.True(t, errors.Is(err, ErrUnknownSecretProvider))
+	_, err = Get("http://example.com", HeaderFromSecret("X-API-Key", "API_KEY"))
+	assert.Error(t, err)
+	_, err = Get("http://example.com", HeaderFromSecret("X-API-Key", "env:HTTPCLIENT_TEST_UNSET"))
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+}
+
+func TestCacheSecrets(t *testing.T) {
+	current, lookups := "old", 0
+	cache := CacheSecrets(SecretProviderFunc(func(ctx context.Context, key string) (string, error) {
+		lookups++
+		return current, nil
+	}), time.Minute)
+	RegisterSecretProvider("test-rotating", cache)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != current {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	c := NewClient(HeaderFromSecret("X-API-Key", "test-rotating:api"), WithClock(clk))
+	status := func() int {
+		res, err := c.Get(ts.URL)
+		require.NoError(t, err)
+		return res.Status
+	}
+	assert.Equal(t, http.StatusOK, status())
+	assert.Equal(t, http.StatusOK, status())
+	assert.Equal(t, 1, lookups)
+
+	clk.Advance(time.Minute)
+	assert.Equal(t, http.StatusOK, status())
+	assert.Equal(t, 2, lookups, "looked up again after the ttl")
+
+	current = "new"
+	assert.Equal(t, http.StatusUnauthorized, status())
+	assert.Equal(t, http.StatusOK, status(), "a rejected secret is looked up again")
+	assert.Equal(t, 3, lookups)
+}
@@ -0,0 +1,169 @@
+// Package sitemap fetches and parses sitemaps (https://www.sitemaps.org/protocol.html),
+// following sitemap index files and decompressing gzipped sitemaps
+//
+//	urls, err := sitemap.Fetch(ctx, nil, "https://example.com/sitemap.xml")
+//	f := httpclient.NewFetcher(nil, httpclient.Workers(8))
+//	for res := range f.Run(ctx, sitemap.Jobs(ctx, urls)) { ... }
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+// DefaultPriority is the priority of urls that don't set one
+const DefaultPriority = 0.5
+
+// MaxSitemaps is the number of sitemaps `Fetch` reads before giving up on an index
+var MaxSitemaps = 1000
+
+// ErrTooManySitemaps is the error returned when an index links more than `MaxSitemaps` sitemaps
+var ErrTooManySitemaps = errors.New("too many sitemaps")
+
+// MaxSize is the largest uncompressed sitemap allowed by the protocol, 50MB
+const MaxSize = 50 << 20
+
+// ErrTooLarge is the error returned for a gzipped sitemap larger than `MaxSize` uncompressed
+var ErrTooLarge = errors.New("sitemap too large")
+
+// URL is an entry of a sitemap
+type URL struct {
+	Loc string
+	// LastMod is zero when the sitemap doesn't say when the page changed
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+type document struct {
+	XMLName  xml.Name
+	URLs     []entry `xml:"url"`
+	Sitemaps []entry `xml:"sitemap"`
+}
+
+type entry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// lastModFormats are the W3C datetime formats allowed for lastmod
+var lastModFormats = []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"}
+
+// Parse reads a sitemap, returning the urls of a urlset or the sitemaps of an index.
+// Gzipped and plain text sitemaps with one url per line are read too. Gzipped sitemaps
+// are decompressed up to `MaxSize`
+func Parse(data []byte) (urls []URL, sitemaps []string, err error) {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		if data, err = ioutil.ReadAll(io.LimitReader(zr, MaxSize+1)); err != nil {
+			return nil, nil, err
+		}
+		if len(data) > MaxSize {
+			return nil, nil, ErrTooLarge
+		}
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] != '<' {
+		return parseText(trimmed), nil, nil
+	}
+	var doc document
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing sitemap: %w", err)
+	}
+	for _, e := range doc.Sitemaps {
+		sitemaps = append(sitemaps, strings.TrimSpace(e.Loc))
+	}
+	for _, e := range doc.URLs {
+		u := URL{Loc: strings.TrimSpace(e.Loc), ChangeFreq: strings.TrimSpace(e.ChangeFreq), Priority: DefaultPriority}
+		if p, err := strconv.ParseFloat(strings.TrimSpace(e.Priority), 64); err == nil {
+			u.Priority = p
+		}
+		for _, layout := range lastModFormats {
+			if t, err := time.Parse(layout, strings.TrimSpace(e.LastMod)); err == nil {
+				u.LastMod = t
+				break
+			}
+		}
+		urls = append(urls, u)
+	}
+	return urls, sitemaps, nil
+}
+
+func parseText(data []byte) []URL {
+	var urls []URL
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			urls = append(urls, URL{Loc: line, Priority: DefaultPriority})
+		}
+	}
+	return urls
+}
+
+// Fetch reads the sitemap at sitemapURL with c, or a new client when c is nil, and
+// returns its urls. The sitemaps of an index are fetched in turn, each one once
+func Fetch(ctx context.Context, c *httpclient.Client, sitemapURL string) ([]URL, error) {
+	if c == nil {
+		c = httpclient.NewClient()
+	}
+	var urls []URL
+	queue := []string{sitemapURL}
+	seen := map[string]bool{sitemapURL: true}
+	for fetched := 0; len(queue) > 0; fetched++ {
+		if fetched >= MaxSitemaps {
+			return urls, ErrTooManySitemaps
+		}
+		next := queue[0]
+		queue = queue[1:]
+		res, err := c.Get(next, httpclient.WithContext(ctx), httpclient.ExpectSuccess())
+		if err != nil {
+			return urls, err
+		}
+		found, sitemaps, err := Parse(res.Body)
+		if err != nil {
+			return urls, fmt.Errorf("%s: %w", next, err)
+		}
+		urls = append(urls, found...)
+		for _, s := range sitemaps {
+			if !seen[s] {
+				seen[s] = true
+				queue = append(queue, s)
+			}
+		}
+	}
+	return urls, nil
+}
+
+// Jobs sends a GET `httpclient.Job` for each url on the returned channel, to feed an
+// `httpclient.Fetcher`. The channel is closed after the last url or when ctx is done
+func Jobs(ctx context.Context, urls []URL, opts ...httpclient.RequestOption) <-chan httpclient.Job {
+	jobs := make(chan httpclient.Job)
+	go func() {
+		defer close(jobs)
+		for _, u := range urls {
+			select {
+			case jobs <- httpclient.Job{URL: u.Loc, Options: opts}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return jobs
+}
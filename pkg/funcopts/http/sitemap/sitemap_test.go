@@ -0,0 +1,132 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>{{base}}/a</loc>
+    <lastmod>2024-03-01</lastmod>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc> {{base}}/b </loc>
+    <lastmod>2024-03-02T10:30:00+01:00</lastmod>
+  </url>
+</urlset>`
+
+const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>{{base}}/pages.xml</loc></sitemap>
+  <sitemap><loc>{{base}}/more.xml.gz</loc></sitemap>
+  <sitemap><loc>{{base}}/sitemap.xml</loc></sitemap>
+</sitemapindex>`
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func sitemapServer() *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fill := func(s string) string { return strings.ReplaceAll(s, "{{base}}", ts.URL) }
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Write([]byte(fill(index)))
+		case "/pages.xml":
+			w.Write([]byte(fill(urlset)))
+		case "/more.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(gzipped(fill("{{base}}/c\n\n{{base}}/d\n")))
+		case "/a", "/b", "/c", "/d":
+			w.Write([]byte(r.URL.Path))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return ts
+}
+
+func TestParse(t *testing.T) {
+	urls, sitemaps, err := Parse([]byte(strings.ReplaceAll(urlset, "{{base}}", "https://example.com")))
+	require.NoError(t, err)
+	assert.Empty(t, sitemaps)
+	require.Len(t, urls, 2)
+	assert.Equal(t, URL{
+		Loc:        "https://example.com/a",
+		LastMod:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		ChangeFreq: "daily",
+		Priority:   0.8,
+	}, urls[0])
+	assert.Equal(t, "https://example.com/b", urls[1].Loc)
+	assert.True(t, urls[1].LastMod.Equal(time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC)))
+	assert.Equal(t, DefaultPriority, urls[1].Priority)
+
+	_, sitemaps, err = Parse(gzipped(index))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"{{base}}/pages.xml", "{{base}}/more.xml.gz", "{{base}}/sitemap.xml"}, sitemaps)
+
+	_, _, err = Parse([]byte("<urlset><url>"))
+	assert.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	ts := sitemapServer()
+	defer ts.Close()
+	urls, err := Fetch(context.Background(), nil, ts.URL+"/sitemap.xml")
+	require.NoError(t, err)
+	var locs []string
+	for _, u := range urls {
+		locs = append(locs, strings.TrimPrefix(u.Loc, ts.URL))
+	}
+	assert.Equal(t, []string{"/a", "/b", "/c", "/d"}, locs)
+
+	f := httpclient.NewFetcher(nil, httpclient.Workers(2))
+	bodies := map[string]bool{}
+	for res := range f.Run(context.Background(), Jobs(context.Background(), urls)) {
+		require.NoError(t, res.Err)
+		bodies[string(res.Response.Body)] = true
+	}
+	assert.Equal(t, map[string]bool{"/a": true, "/b": true, "/c": true, "/d": true}, bodies)
+}
+
+func TestFetchErrors(t *testing.T) {
+	ts := sitemapServer()
+	defer ts.Close()
+	_, err := Fetch(context.Background(), nil, ts.URL+"/missing.xml")
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode))
+
+	defer func(n int) { MaxSitemaps = n }(MaxSitemaps)
+	MaxSitemaps = 2
+	urls, err := Fetch(context.Background(), nil, ts.URL+"/sitemap.xml")
+	assert.True(t, errors.Is(err, ErrTooManySitemaps))
+	assert.Len(t, urls, 2)
+}
+
+func TestParseTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(bytes.Repeat([]byte(" "), MaxSize+1))
+	zw.Close()
+	_, _, err := Parse(buf.Bytes())
+	assert.True(t, errors.Is(err, ErrTooLarge), "got %v", err)
+}
@@ -0,0 +1,55 @@
+package ssm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// amzDate is the layout of the `X-Amz-Date` header
+const amzDate = "20060102T150405Z"
+
+// signV4 returns the `Authorization` header of an AWS signature version 4 for a request
+// with the lowercase headers, which must include `host` and `x-amz-date`. All headers are
+// signed
+func signV4(method string, u *url.URL, headers map[string]string, payload []byte, creds credentials, region, service string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signed := strings.Join(names, ";")
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// url.Values.Encode sorts by key and escapes spaces as + which sigv4 wants as %20
+	query := strings.ReplaceAll(u.Query().Encode(), "+", "%20")
+	payloadHash := sha256.Sum256(payload)
+	canonical := strings.Join([]string{method, path, query, canonicalHeaders.String(), signed, hex.EncodeToString(payloadHash[:])}, "\n")
+
+	date := headers["x-amz-date"]
+	scope := date[:8] + "/" + region + "/" + service + "/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + creds.secretAccessKey)
+	for _, part := range []string{date[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return "AWS4-HMAC-SHA256 Credential=" + creds.accessKeyID + "/" + scope +
+		", SignedHeaders=" + signed + ", Signature=" + hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,18 @@
+package ssm
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignV4 checks the get-vanilla case of the AWS signature version 4 test suite
+func TestSignV4(t *testing.T) {
+	u, _ := url.Parse("https://example.amazonaws.com/")
+	creds := credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	headers := map[string]string{"host": "example.amazonaws.com", "x-amz-date": "20150830T123600Z"}
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		signV4("GET", u, headers, nil, creds, "us-east-1", "service"))
+}
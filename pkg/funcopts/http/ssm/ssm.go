@@ -0,0 +1,149 @@
+// Package ssm reads secrets from AWS Systems Manager Parameter Store so they can be sent
+// with httpclient.HeaderFromSecret. Requests are signed with the credentials in the
+// standard AWS environment variables unless others are given
+//
+//	httpclient.RegisterSecretProvider("ssm", httpclient.CacheSecrets(ssm.New(), 5*time.Minute))
+//	c := httpclient.NewClient(httpclient.HeaderFromSecret("X-API-Key", "ssm:/billing/api-key"))
+package ssm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// ErrNoCredentials is the error for requests without AWS credentials or a region
+var ErrNoCredentials = errors.New("aws credentials and region are required")
+
+type config struct {
+	region   string
+	creds    credentials
+	endpoint string
+	opts     []httpclient.RequestOption
+}
+
+type credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// Option configures a `Provider`
+type Option func(*config)
+
+// Region reads parameters in region instead of `AWS_REGION` or `AWS_DEFAULT_REGION`
+func Region(region string) Option {
+	return func(cfg *config) {
+		cfg.region = region
+	}
+}
+
+// Credentials signs requests with the access key and optional session token instead of
+// `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` and `AWS_SESSION_TOKEN`
+func Credentials(accessKeyID, secretAccessKey, sessionToken string) Option {
+	return func(cfg *config) {
+		cfg.creds = credentials{accessKeyID: accessKeyID, secretAccessKey: secretAccessKey, sessionToken: sessionToken}
+	}
+}
+
+// Endpoint sends requests to u instead of the regional endpoint, e.g. for a VPC endpoint
+func Endpoint(u string) Option {
+	return func(cfg *config) {
+		cfg.endpoint = u
+	}
+}
+
+// RequestOptions applies opts to requests to parameter store
+func RequestOptions(opts ...httpclient.RequestOption) Option {
+	return func(cfg *config) {
+		cfg.opts = append(cfg.opts, opts...)
+	}
+}
+
+// Provider is an httpclient.SecretProvider for parameter store
+type Provider struct {
+	cfg *config
+}
+
+// New returns a `Provider` configured from the environment and opts
+func New(opts ...Option) *Provider {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	cfg := &config{region: region, creds: credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.endpoint == "" {
+		cfg.endpoint = "https://ssm." + cfg.region + ".amazonaws.com"
+	}
+	return &Provider{cfg: cfg}
+}
+
+// Secret returns the decrypted value of the parameter named key
+func (p *Provider) Secret(ctx context.Context, key string) (string, error) {
+	if p.cfg.region == "" || p.cfg.creds.accessKeyID == "" || p.cfg.creds.secretAccessKey == "" {
+		return "", ErrNoCredentials
+	}
+	body, err := json.Marshal(map[string]interface{}{"Name": key, "WithDecryption": true})
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(p.cfg.endpoint)
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{
+		"content-type": "application/x-amz-json-1.1",
+		"host":         u.Host,
+		"x-amz-date":   clock.FromContext(ctx).Now().UTC().Format(amzDate),
+		"x-amz-target": "AmazonSSM.GetParameter",
+	}
+	if p.cfg.creds.sessionToken != "" {
+		headers["x-amz-security-token"] = p.cfg.creds.sessionToken
+	}
+	headers["authorization"] = signV4(http.MethodPost, u, headers, body, p.cfg.creds, p.cfg.region, "ssm")
+	delete(headers, "host")
+	opts := append([]httpclient.RequestOption{
+		httpclient.WithContext(ctx),
+		httpclient.AddHeaders(headers),
+		httpclient.WithBody(strings.NewReader(string(body))),
+	}, p.cfg.opts...)
+	res, err := httpclient.Post(p.cfg.endpoint, opts...)
+	if err != nil {
+		return "", err
+	}
+	if res.Status != http.StatusOK {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(res.Body, &awsErr)
+		if strings.HasSuffix(awsErr.Type, "ParameterNotFound") {
+			return "", fmt.Errorf("%w: ssm parameter %s", httpclient.ErrSecretNotFound, key)
+		}
+		return "", fmt.Errorf("ssm parameter %s: %w: %d %s %s", key, httpclient.ErrInvalidStatusCode, res.Status, awsErr.Type, awsErr.Message)
+	}
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := json.Unmarshal(res.Body, &out); err != nil {
+		return "", err
+	}
+	return out.Parameter.Value, nil
+}
@@ -0,0 +1,52 @@
+package ssm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AmazonSSM.GetParameter", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "20231114T221320Z", r.Header.Get("X-Amz-Date"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/20231114/eu-west-1/ssm/aws4_request, "))
+		var in struct {
+			Name           string
+			WithDecryption bool
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.True(t, in.WithDecryption)
+		if in.Name != "/billing/api-key" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ParameterNotFound","message":""}`))
+			return
+		}
+		w.Write([]byte(`{"Parameter":{"Name":"/billing/api-key","Type":"SecureString","Value":"k1"}}`))
+	}))
+	defer ts.Close()
+	p := New(Region("eu-west-1"), Credentials("AKID", "secret", "session"), Endpoint(ts.URL))
+	ctx := clock.NewContext(context.Background(), clock.NewFake(time.Unix(1700000000, 0)))
+	v, err := p.Secret(ctx, "/billing/api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", v)
+	_, err = p.Secret(ctx, "/billing/missing")
+	assert.True(t, errors.Is(err, httpclient.ErrSecretNotFound))
+}
+
+func TestNoCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err := New(Region("eu-west-1")).Secret(context.Background(), "/x")
+	assert.True(t, errors.Is(err, ErrNoCredentials))
+}
@@ -0,0 +1,196 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// privateNetworks are ranges not covered by the net.IP helpers that should never be reached
+// when fetching untrusted urls
+var privateNetworks = mustParseCIDRs("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "64:ff9b:1::/48")
+
+// nat64 and sixToFour are IPv6 ranges embedding an IPv4 address, which is checked in their place
+var (
+	nat64     = mustParseCIDRs("64:ff9b::/96")[0]
+	sixToFour = mustParseCIDRs("2002::/16")[0]
+)
+
+// hostPolicy restricts which hosts a request may connect to
+type hostPolicy struct {
+	hosts       []string
+	networks    []*net.IPNet
+	allowList   bool
+	denyPrivate bool
+}
+
+type hostAllowedKey struct{}
+
+type hostPolicyKey struct{}
+
+// AllowHosts only allows requests to hosts matching one of patterns.
+// Patterns are host names with optional wildcards like `*.example.com`, ip addresses or CIDR ranges.
+// Addresses and ranges are checked against the resolved address when connecting
+func AllowHosts(patterns ...string) RequestOption {
+	return func(r *Request) error {
+		p := r.getHostPolicy()
+		p.allowList = true
+		for _, pattern := range patterns {
+			if _, network, err := net.ParseCIDR(pattern); err == nil {
+				p.networks = append(p.networks, network)
+				continue
+			}
+			if ip := net.ParseIP(pattern); ip != nil {
+				p.networks = append(p.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+				continue
+			}
+			p.hosts = append(p.hosts, strings.ToLower(pattern))
+		}
+		return nil
+	}
+}
+
+// DenyPrivateNetworks blocks connections to loopback, private, link-local (including cloud
+// metadata endpoints) and other non-public addresses. It is checked after DNS resolution so
+// names pointing at internal addresses are blocked too. Requests sent through a proxy have
+// their target resolved and checked as well as the proxy
+func DenyPrivateNetworks() RequestOption {
+	return func(r *Request) error {
+		r.getHostPolicy().denyPrivate = true
+		return nil
+	}
+}
+
+func (cr *Request) getHostPolicy() *hostPolicy {
+	if cr.hostPolicy == nil {
+		cr.hostPolicy = &hostPolicy{}
+		cr.dialControls = append(cr.dialControls, checkHostPolicy)
+	}
+	return cr.hostPolicy
+}
+
+// checkHostPolicy applies the policy of the request being dialed so transports can be shared
+func checkHostPolicy(ctx context.Context, network, address string) error {
+	p, ok := ctx.Value(hostPolicyKey{}).(*hostPolicy)
+	if !ok {
+		return nil
+	}
+	return p.checkDial(ctx, network, address)
+}
+
+func (p *hostPolicy) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range p.hosts {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *hostPolicy) checkDial(ctx context.Context, network, address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%s: %w", address, ErrHostNotAllowed)
+	}
+	return p.checkIP(ctx, address, ip)
+}
+
+// checkTarget resolves host and checks its addresses. The dial check only sees the
+// proxy when the request is proxied
+func (p *hostPolicy) checkTarget(ctx context.Context, host string) error {
+	if !p.denyPrivate && (!p.allowList || ctx.Value(hostAllowedKey{}) != nil) {
+		return nil
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return err
+		}
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if err := p.checkIP(ctx, host, ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *hostPolicy) checkIP(ctx context.Context, address string, ip net.IP) error {
+	if p.denyPrivate && isPrivateIP(ip) {
+		return fmt.Errorf("%s: %w", address, ErrHostNotAllowed)
+	}
+	if !p.allowList || ctx.Value(hostAllowedKey{}) != nil {
+		return nil
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", address, ErrHostNotAllowed)
+}
+
+func isPrivateIP(ip net.IP) bool {
+	if nat64.Contains(ip) {
+		return isPrivateIP(ip[12:16])
+	}
+	if sixToFour.Contains(ip) {
+		return isPrivateIP(ip[2:6])
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostPolicyTransport checks host names before the request is sent, including on redirects
+type hostPolicyTransport struct {
+	policy *hostPolicy
+	next   http.RoundTripper
+}
+
+func (t *hostPolicyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.WithContext(context.WithValue(req.Context(), hostPolicyKey{}, t.policy))
+	if t.policy.allowList {
+		if t.policy.hostAllowed(req.URL.Hostname()) {
+			req = req.WithContext(context.WithValue(req.Context(), hostAllowedKey{}, true))
+		} else if len(t.policy.networks) == 0 {
+			return nil, fmt.Errorf("%s: %w", req.URL.Hostname(), ErrHostNotAllowed)
+		}
+	}
+	if err := t.policy.checkTarget(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
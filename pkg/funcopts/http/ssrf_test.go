@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDenyPrivateNetworks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	_, err := Get(ts.URL, DenyPrivateNetworks())
+	assert.True(t, errors.Is(err, ErrHostNotAllowed))
+
+	_, err = Get(ts.URL)
+	assert.NoError(t, err)
+}
+
+func TestDenyPrivateNetworksCustomTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	custom := &http.Client{Transport: transport.RoundTripperFunc(http.DefaultTransport.RoundTrip)}
+	_, err := Get(ts.URL, SetClient(custom), DenyPrivateNetworks())
+	assert.True(t, errors.Is(err, ErrOptionConflict), "got %v", err)
+
+	_, err = NewClient(SetClient(custom), DenyPrivateNetworks()).Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrOptionConflict), "got %v", err)
+}
+
+func TestAllowHosts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	_, err := Get(ts.URL, AllowHosts("*.example.com"))
+	assert.True(t, errors.Is(err, ErrHostNotAllowed))
+
+	_, err = Get(ts.URL, AllowHosts("127.0.0.0/8"))
+	assert.NoError(t, err)
+
+	_, err = Get(ts.URL, AllowHosts("127.0.0.1"))
+	assert.NoError(t, err)
+
+	_, err = Get(ts.URL, AllowHosts("10.0.0.0/8"))
+	assert.True(t, errors.Is(err, ErrHostNotAllowed))
+}
+
+func TestAllowHostsProxy(t *testing.T) {
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+	}))
+	defer proxy.Close()
+	_, err := Get("http://10.0.0.1/latest", AllowHosts("127.0.0.0/8"), Proxy(proxy.URL, ""))
+	assert.True(t, errors.Is(err, ErrHostNotAllowed), "got %v", err)
+	assert.False(t, proxied)
+
+	_, err = Get("http://192.0.2.1/latest", AllowHosts("127.0.0.0/8", "192.0.2.0/24"), Proxy(proxy.URL, ""))
+	assert.NoError(t, err)
+	assert.True(t, proxied)
+}
+
+func TestAllowHostsRedirect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://192.0.2.1/latest", http.StatusFound)
+	}))
+	defer ts.Close()
+	_, err := Get(ts.URL, AllowHosts("127.0.0.1"))
+	assert.True(t, errors.Is(err, ErrHostNotAllowed))
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "::1", "fd00::1", "fe80::1", "0.0.0.0",
+		"64:ff9b::a9fe:a9fe", "64:ff9b::7f00:1", "2002:a9fe:a9fe::1", "2002:7f00:1::"} {
+		assert.True(t, isPrivateIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"8.8.8.8", "1.1.1.1", "2606:4700:4700::1111", "64:ff9b::808:808", "2002:808:808::1"} {
+		assert.False(t, isPrivateIP(net.ParseIP(ip)), ip)
+	}
+}
@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// StatsSink receives counters and timers for every attempt sent, keyed by host
+type StatsSink interface {
+	Count(name, host string, n int64)
+	Timing(name, host string, d time.Duration)
+}
+
+// Names of the stats published by `PublishStats`
+const (
+	// StatRequests counts attempts
+	StatRequests = "requests"
+	// StatErrors counts attempts that failed with a connection error or a 5xx status
+	StatErrors = "errors"
+	// StatLatency times attempts
+	StatLatency = "latency"
+)
+
+// PublishStats sends a `StatRequests` count, a `StatErrors` count for failures and a
+// `StatLatency` timer to sink for every attempt, including retries and redirects
+func PublishStats(sink StatsSink) RequestOption {
+	return Metrics(func(o Observation) {
+		sink.Count(StatRequests, o.Host, 1)
+		if o.Err != nil || o.Status >= 500 {
+			sink.Count(StatErrors, o.Host, 1)
+		}
+		sink.Timing(StatLatency, o.Host, o.Duration)
+	})
+}
+
+// ExpvarStats is a `StatsSink` publishing an expvar map with a map per host. Counts are
+// kept as is and timers as the total milliseconds, so `latency / requests` is the mean
+//
+//	{"api.example.com": {"requests": 12, "errors": 1, "latency": 843.2}}
+type ExpvarStats struct {
+	hosts *expvar.Map
+	sync.Mutex
+}
+
+// NewExpvarStats returns an `ExpvarStats` published as name. Stats with the name of an
+// existing expvar map are added to it
+func NewExpvarStats(name string) *ExpvarStats {
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		return &ExpvarStats{hosts: m}
+	}
+	return &ExpvarStats{hosts: expvar.NewMap(name)}
+}
+
+// Count adds n to the counter name of host
+func (s *ExpvarStats) Count(name, host string, n int64) {
+	s.host(host).Add(name, n)
+}
+
+// Timing adds d in milliseconds to the timer name of host
+func (s *ExpvarStats) Timing(name, host string, d time.Duration) {
+	s.host(host).AddFloat(name, float64(d)/float64(time.Millisecond))
+}
+
+func (s *ExpvarStats) host(host string) *expvar.Map {
+	if m, ok := s.hosts.Get(host).(*expvar.Map); ok {
+		return m
+	}
+	s.Lock()
+	defer s.Unlock()
+	if m, ok := s.hosts.Get(host).(*expvar.Map); ok {
+		return m
+	}
+	m := new(expvar.Map).Init()
+	s.hosts.Set(host, m)
+	return m
+}
@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	counts  map[string]int64
+	timings int
+}
+
+func (s *recordingSink) Count(name, host string, n int64)          { s.counts[name+" "+host] += n }
+func (s *recordingSink) Timing(name, host string, d time.Duration) { s.timings++ }
+
+func TestPublishStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	host := ts.Listener.Addr().String()
+	sink := &recordingSink{counts: map[string]int64{}}
+	c := NewClient(PublishStats(sink))
+	for _, path := range []string{"/", "/fail", "/"} {
+		_, err := c.Get(ts.URL + path)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, map[string]int64{"requests " + host: 3, "errors " + host: 1}, sink.counts)
+	assert.Equal(t, 3, sink.timings)
+}
+
+func TestExpvarStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	stats := NewExpvarStats("httpclient_test")
+	assert.Same(t, stats.hosts, NewExpvarStats("httpclient_test").hosts)
+	_, err := Get(ts.URL, PublishStats(stats))
+	require.NoError(t, err)
+	stats.Timing(StatLatency, "other:80", 1500*time.Microsecond)
+
+	var published map[string]map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("httpclient_test").String()), &published))
+	assert.Equal(t, float64(1), published[u.Host][StatRequests])
+	assert.Equal(t, float64(0), published[u.Host][StatErrors])
+	assert.Equal(t, 1.5, published["other:80"][StatLatency])
+}
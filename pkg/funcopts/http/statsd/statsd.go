@@ -0,0 +1,85 @@
+// Package statsd sends httpclient stats to a statsd server over UDP
+//
+//	sink, err := statsd.New("127.0.0.1:8125", statsd.Prefix("billing.http"), statsd.Tagged())
+//	c := httpclient.NewClient(httpclient.PublishStats(sink))
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+type config struct {
+	prefix string
+	tagged bool
+}
+
+// Option configures a `Sink`
+type Option func(*config)
+
+// Prefix starts every metric name with prefix and a dot
+func Prefix(prefix string) Option {
+	return func(cfg *config) {
+		cfg.prefix = strings.TrimSuffix(prefix, ".") + "."
+	}
+}
+
+// Tagged sends the host as a DogStatsD `host` tag instead of as the last part of the
+// metric name
+func Tagged() Option {
+	return func(cfg *config) {
+		cfg.tagged = true
+	}
+}
+
+// Sink is an httpclient.StatsSink writing statsd lines. Sending is fire and forget,
+// write errors are dropped like statsd clients usually do
+type Sink struct {
+	conn net.Conn
+	cfg  *config
+}
+
+// New returns a `Sink` sending to the statsd server at addr
+func New(addr string, opts ...Option) (*Sink, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{conn: conn, cfg: cfg}, nil
+}
+
+// Count sends a counter
+func (s *Sink) Count(name, host string, n int64) {
+	s.send(name, host, fmt.Sprintf("%d|c", n))
+}
+
+// Timing sends a timer in milliseconds
+func (s *Sink) Timing(name, host string, d time.Duration) {
+	s.send(name, host, fmt.Sprintf("%g|ms", float64(d)/float64(time.Millisecond)))
+}
+
+// Close closes the connection to the server
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Sink) send(name, host, value string) {
+	var line string
+	if s.cfg.tagged {
+		line = s.cfg.prefix + name + ":" + value + "|#host:" + host
+	} else {
+		line = s.cfg.prefix + name + "." + metricPart(host) + ":" + value
+	}
+	s.conn.Write([]byte(line))
+}
+
+// metricPart replaces the characters of host that statsd treats as separators
+func metricPart(host string) string {
+	return strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_").Replace(host)
+}
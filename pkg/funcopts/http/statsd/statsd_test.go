@@ -0,0 +1,65 @@
+package statsd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listen returns a udp listener and a function reading n lines from it
+func listen(t *testing.T) (string, func(n int) []string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func(n int) []string {
+		var lines []string
+		buf := make([]byte, 512)
+		for len(lines) < n {
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			m, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			lines = append(lines, string(buf[:m]))
+		}
+		sort.Strings(lines)
+		return lines
+	}
+}
+
+func TestSink(t *testing.T) {
+	addr, read := listen(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	host := strings.NewReplacer(".", "_", ":", "_").Replace(u.Host)
+	sink, err := New(addr, Prefix("svc.http."))
+	require.NoError(t, err)
+	defer sink.Close()
+
+	_, err = httpclient.Get(ts.URL, httpclient.PublishStats(sink))
+	require.NoError(t, err)
+	lines := read(3)
+	assert.Equal(t, "svc.http.errors."+host+":1|c", lines[0])
+	assert.Regexp(t, `^svc\.http\.latency\.`+host+`:[0-9.e+-]+\|ms$`, lines[1])
+	assert.Equal(t, "svc.http.requests."+host+":1|c", lines[2])
+}
+
+func TestSinkTagged(t *testing.T) {
+	addr, read := listen(t)
+	sink, err := New(addr, Tagged())
+	require.NoError(t, err)
+	defer sink.Close()
+	sink.Count("requests", "api.example.com:443", 2)
+	sink.Timing("latency", "api.example.com:443", 1500*time.Microsecond)
+	assert.Equal(t, []string{"latency:1.5|ms|#host:api.example.com:443", "requests:2|c|#host:api.example.com:443"}, read(2))
+}
@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"io"
+)
+
+// DefaultStreamChunkSize is the chunk size of `StreamBody` without `StreamChunkSize`
+const DefaultStreamChunkSize = 32 << 10
+
+type streamConfig struct {
+	chunkSize int
+	flush     bool
+}
+
+// StreamOption configures `StreamBody`
+type StreamOption func(*streamConfig)
+
+// StreamChunkSize collects up to n bytes from the reader into each chunk sent
+func StreamChunkSize(n int) StreamOption {
+	return func(c *streamConfig) {
+		c.chunkSize = n
+	}
+}
+
+// FlushChunks sends whatever each read returns as its own chunk right away, so data
+// produced live, like the output of a running command, reaches the server without
+// waiting for a full chunk
+func FlushChunks() StreamOption {
+	return func(c *streamConfig) {
+		c.flush = true
+	}
+}
+
+// StreamBody sends r as the body with chunked transfer encoding and no `Content-Length`,
+// without buffering it. By default reads are collected into chunks of
+// `DefaultStreamChunkSize` bytes. The body is read once, so it isn't resent on
+// redirects or retries. HTTP/2 has no chunked encoding and streams r in frames instead
+func StreamBody(r io.Reader, opts ...StreamOption) RequestOption {
+	cfg := &streamConfig{chunkSize: DefaultStreamChunkSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(req *Request) error {
+		req.claim("body", "StreamBody", "")
+		// the wrappers hide the type of r so its length is never sniffed
+		if cfg.flush || cfg.chunkSize < 1 {
+			req.body = struct{ io.Reader }{r}
+		} else {
+			req.body = &chunkReader{r: r, size: cfg.chunkSize}
+		}
+		req.bodyFunc = nil
+		req.streamBody = true
+		return nil
+	}
+}
+
+// chunkReader fills each read up to size bytes so every chunk written is full except the last
+type chunkReader struct {
+	r    io.Reader
+	size int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > c.size {
+		p = p[:c.size]
+	}
+	n, err := io.ReadFull(c.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamBody(t *testing.T) {
+	body := strings.Repeat("streamed ", 10000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"chunked"}, r.TransferEncoding)
+		assert.Equal(t, int64(-1), r.ContentLength)
+		got, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(got))
+	}))
+	defer ts.Close()
+	// a strings.Reader would otherwise be sent with a Content-Length
+	_, err := Post(ts.URL, StreamBody(strings.NewReader(body)))
+	require.NoError(t, err)
+}
+
+func TestStreamBodyFlushChunks(t *testing.T) {
+	received := make(chan string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Body.Read(buf)
+			if n > 0 {
+				received <- string(buf[:n])
+			}
+			if err != nil {
+				close(received)
+				return
+			}
+		}
+	}))
+	defer ts.Close()
+	pr, pw := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := Post(ts.URL, StreamBody(pr, FlushChunks()))
+		done <- err
+	}()
+	// each line must reach the server before the next one is written
+	for _, line := range []string{"line one\n", "line two\n"} {
+		_, err := pw.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, line, <-received)
+	}
+	pw.Close()
+	_, open := <-received
+	assert.False(t, open)
+	assert.NoError(t, <-done)
+}
+
+func TestChunkReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 25)
+	r := &chunkReader{r: iotest.OneByteReader(bytes.NewReader(data)), size: 10}
+	var sizes []int
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			sizes = append(sizes, n)
+		}
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int{10, 10, 5}, sizes)
+}
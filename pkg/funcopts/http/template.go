@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// templateOperator describes how an RFC 6570 expression operator expands
+type templateOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// expandTemplate expands the RFC 6570 (level 3) expressions in tmpl with vars.
+// Undefined variables are omitted per the RFC. Literal text is passed through
+// escapeLiteral when it is not nil. Values that would expand to a `.` or `..` path
+// segment are rejected so they can't move the request to another path
+func expandTemplate(tmpl string, vars map[string]string, escapeLiteral func(string) string) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated expression in uri template %q", tmpl)
+		}
+		end += start
+		out.WriteString(literal(tmpl[:start], escapeLiteral))
+		inPath := !strings.ContainsAny(out.String(), "?#")
+		expanded, err := expandExpression(tmpl[start+1:end], vars, inPath)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(expanded)
+		tmpl = tmpl[end+1:]
+	}
+	out.WriteString(literal(tmpl, escapeLiteral))
+	return out.String(), nil
+}
+
+func literal(s string, escape func(string) string) string {
+	if escape == nil || s == "" {
+		return s
+	}
+	return escape(s)
+}
+
+func expandExpression(expr string, vars map[string]string, inPath bool) (string, error) {
+	if expr == "" {
+		return "", fmt.Errorf("empty uri template expression")
+	}
+	op, ok := templateOperators[expr[0]]
+	if ok {
+		expr = expr[1:]
+	} else {
+		op = templateOperator{sep: ","}
+	}
+	parts := []string{}
+	for _, spec := range strings.Split(expr, ",") {
+		name := strings.TrimSuffix(spec, "*")
+		prefix := -1
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			n, err := strconv.Atoi(name[i+1:])
+			if err != nil || n <= 0 || n >= 10000 {
+				return "", fmt.Errorf("invalid prefix modifier in uri template expression %q", spec)
+			}
+			name, prefix = name[:i], n
+		}
+		value, defined := vars[name]
+		if !defined {
+			continue
+		}
+		if prefix >= 0 {
+			if runes := []rune(value); len(runes) > prefix {
+				value = string(runes[:prefix])
+			}
+		}
+		value = encodeTemplateValue(value, op.reserved)
+		// only simple, reserved and path expansions can form a segment of their own
+		if inPath && (op.first == "" || op.first == "/") && hasDotSegment(value) {
+			return "", fmt.Errorf("uri template variable %q expands to a dot segment", name)
+		}
+		switch {
+		case op.named && value == "":
+			parts = append(parts, name+op.ifEmpty)
+		case op.named:
+			parts = append(parts, name+"="+value)
+		default:
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return op.first + strings.Join(parts, op.sep), nil
+}
+
+// hasDotSegment reports whether an expanded value has a `.` or `..` segment. Slashes
+// are only left unencoded by reserved expansion, so others are checked whole
+func hasDotSegment(value string) bool {
+	for _, segment := range strings.Split(value, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeTemplateValue percent-encodes everything but unreserved characters.
+// Reserved characters and existing percent-encoded triplets are also kept when reserved is true
+func encodeTemplateValue(s string, reserved bool) string {
+	const hex = "0123456789ABCDEF"
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			out.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			out.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			out.WriteString(s[i : i+3])
+			i += 2
+		default:
+			out.WriteByte('%')
+			out.WriteByte(hex[c>>4])
+			out.WriteByte(hex[c&15])
+		}
+	}
+	return out.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
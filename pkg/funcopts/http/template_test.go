@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"x":     "1024",
+		"y":     "768",
+		"empty": "",
+	}
+	tests := map[string]string{
+		"{var}":             "value",
+		"{hello}":           "Hello%20World%21",
+		"{+hello}":          "Hello%20World!",
+		"{+path}/here":      "/foo/bar/here",
+		"{#path}":           "#/foo/bar",
+		"map?{x,y}":         "map?1024,768",
+		"{var:3}":           "val",
+		"X{.var}":           "X.value",
+		"{/var,x}/here":     "/value/1024/here",
+		"{;x,y,empty}":      ";x=1024;y=768;empty",
+		"{?x,y,empty}":      "?x=1024&y=768&empty=",
+		"?fixed=yes{&x}":    "?fixed=yes&x=1024",
+		"{?x,undef}":        "?x=1024",
+		"/users/{undef}/x":  "/users//x",
+		"/repos/{path}/tag": "/repos/%2Ffoo%2Fbar/tag",
+	}
+	for tmpl, want := range tests {
+		got, err := expandTemplate(tmpl, vars, nil)
+		assert.NoError(t, err, tmpl)
+		assert.Equal(t, want, got, tmpl)
+	}
+	_, err := expandTemplate("/users/{id", vars, nil)
+	assert.Error(t, err)
+}
+
+func TestExpandTemplateDotSegments(t *testing.T) {
+	for _, tmpl := range []string{"/repos/{repo}/issues", "/repos{/repo}", "/repos/{+repo}", "/files/{+path}"} {
+		for _, value := range []string{".", ".."} {
+			_, err := expandTemplate(tmpl, map[string]string{"repo": value, "path": "a/" + value + "/b"}, nil)
+			assert.Error(t, err, "%s %s", tmpl, value)
+		}
+	}
+	for tmpl, want := range map[string]string{
+		"/search?q={repo}":  "/search?q=..",
+		"/search{?repo}":    "/search?repo=..",
+		"/files{.repo}":     "/files...",
+		"/repos/x/{+other}": "/repos/x/a/..b",
+		"/repos/{other}/x":  "/repos/a%2F..b/x",
+	} {
+		got, err := expandTemplate(tmpl, map[string]string{"repo": "..", "other": "a/..b"}, nil)
+		assert.NoError(t, err, tmpl)
+		assert.Equal(t, want, got, tmpl)
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	}))
+	defer ts.Close()
+	c := NewClient(BaseURL(ts.URL))
+	params := map[string]string{"owner": "lusis", "repo": "../../admin"}
+	res, err := c.Get("/repos/{owner}/{repo}", PathParams(params))
+	assert.NoError(t, err)
+	assert.Equal(t, "/repos/lusis/..%2F..%2Fadmin", string(res.Body))
+
+	res, err = c.Get("", Path("/users/{owner}/files with space"), PathParams(params))
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/lusis/files%20with%20space", string(res.Body))
+}
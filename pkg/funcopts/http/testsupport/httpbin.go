@@ -0,0 +1,163 @@
+// Package testsupport provides local test servers so tests don't depend on
+// external services
+//
+//	bin := testsupport.HTTPBin(t)
+//	res, err := httpclient.Get(bin.URL + "/get")
+package testsupport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MaxDelay is the longest delay served by the `/delay/{seconds}` endpoint
+const MaxDelay = 10 * time.Second
+
+// Echo is the json body returned by the echo endpoints of `HTTPBin`,
+// in the format of httpbin.org
+type Echo struct {
+	Args    map[string]string `json:"args"`
+	Data    string            `json:"data"`
+	Form    map[string]string `json:"form"`
+	Headers map[string]string `json:"headers"`
+	JSON    interface{}       `json:"json"`
+	Method  string            `json:"method"`
+	Origin  string            `json:"origin"`
+	URL     string            `json:"url"`
+}
+
+// HTTPBin starts a server with the httpbin.org endpoints used in tests and closes
+// it when the test finishes. See `NewHTTPBinHandler` for the endpoints
+func HTTPBin(t testing.TB) *httptest.Server {
+	ts := httptest.NewServer(NewHTTPBinHandler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// NewHTTPBinHandler returns a handler mimicking these httpbin.org endpoints:
+//
+//	/anything/...            echoes any request
+//	/get /post /put /patch /delete  echo requests with that method
+//	/headers                 the request headers
+//	/ip                      the client address
+//	/status/{code}           responds with code
+//	/delay/{seconds}         echoes the request after a delay of up to `MaxDelay`
+func NewHTTPBinHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/anything", echo)
+	mux.HandleFunc("/anything/", echo)
+	for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+		mux.HandleFunc("/"+method, onlyMethod(strings.ToUpper(method), echo))
+	}
+	mux.HandleFunc("/headers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"headers": headers(r)})
+	})
+	mux.HandleFunc("/ip", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"origin": origin(r)})
+	})
+	mux.HandleFunc("/status/", func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/status/"))
+		if err != nil || code < 100 || code > 599 {
+			http.Error(w, "invalid status code", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(code)
+	})
+	mux.HandleFunc("/delay/", func(w http.ResponseWriter, r *http.Request) {
+		seconds, err := strconv.ParseFloat(strings.TrimPrefix(r.URL.Path, "/delay/"), 64)
+		if err != nil {
+			http.Error(w, "invalid delay", http.StatusBadRequest)
+			return
+		}
+		delay := time.Duration(seconds * float64(time.Second))
+		if delay > MaxDelay {
+			delay = MaxDelay
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		echo(w, r)
+	})
+	return mux
+}
+
+func onlyMethod(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func echo(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	e := Echo{
+		Args:    first(r.URL.Query()),
+		Data:    string(body),
+		Form:    map[string]string{},
+		Headers: headers(r),
+		Method:  r.Method,
+		Origin:  origin(r),
+		URL:     "http://" + r.Host + r.URL.RequestURI(),
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		r.Body = ioutil.NopCloser(strings.NewReader(e.Data))
+		if err := r.ParseForm(); err == nil {
+			e.Form = first(r.PostForm)
+		}
+	}
+	if len(body) > 0 {
+		var v interface{}
+		if json.Unmarshal(body, &v) == nil {
+			e.JSON = v
+		}
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func headers(r *http.Request) map[string]string {
+	h := map[string]string{"Host": r.Host}
+	for name, values := range r.Header {
+		h[name] = strings.Join(values, ",")
+	}
+	return h
+}
+
+func first(values map[string][]string) map[string]string {
+	m := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			m[k] = v[0]
+		}
+	}
+	return m
+}
+
+func origin(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
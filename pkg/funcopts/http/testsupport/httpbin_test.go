@@ -0,0 +1,68 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPBinEcho(t *testing.T) {
+	bin := HTTPBin(t)
+	req, _ := http.NewRequest("POST", bin.URL+"/post?foo=bar", strings.NewReader(`{"a":1}`))
+	req.Header.Set("X-Test", "yes")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var e Echo
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+	assert.Equal(t, "bar", e.Args["foo"])
+	assert.Equal(t, `{"a":1}`, e.Data)
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, e.JSON)
+	assert.Equal(t, "yes", e.Headers["X-Test"])
+	assert.Equal(t, "POST", e.Method)
+	assert.Equal(t, "127.0.0.1", e.Origin)
+	assert.Equal(t, bin.URL+"/post?foo=bar", e.URL)
+}
+
+func TestHTTPBinForm(t *testing.T) {
+	bin := HTTPBin(t)
+	resp, err := http.PostForm(bin.URL+"/anything/form", url.Values{"name": {"value"}})
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var e Echo
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+	assert.Equal(t, "value", e.Form["name"])
+}
+
+func TestHTTPBinMethods(t *testing.T) {
+	bin := HTTPBin(t)
+	resp, err := http.Post(bin.URL+"/get", "text/plain", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Head(bin.URL + "/get")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestHTTPBinStatusAndDelay(t *testing.T) {
+	bin := HTTPBin(t)
+	resp, err := http.Get(bin.URL + "/status/418")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	start := time.Now()
+	resp, err = http.Get(bin.URL + "/delay/0.05")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+}
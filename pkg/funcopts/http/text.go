@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Text returns the body decoded to UTF-8. The encoding is taken from a byte order mark,
+// then the `Content-Type` charset, and defaults to UTF-8. Legacy encodings such as
+// ISO-8859-1, Windows-1252 and Shift_JIS are converted
+func (r *Response) Text() (string, error) {
+	body := r.Body
+	var enc encoding.Encoding
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return string(body[3:]), nil
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		enc = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	default:
+		mt, err := r.MediaType()
+		charset := strings.ToLower(mt.Params["charset"])
+		if err != nil || charset == "" || charset == "utf-8" || charset == "utf8" {
+			return string(body), nil
+		}
+		if enc, err = htmlindex.Get(charset); err != nil {
+			return "", fmt.Errorf("charset %q: %w", charset, err)
+		}
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,42 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func textResponse(contentType string, body []byte) *Response {
+	return &Response{Headers: http.Header{"Content-Type": {contentType}}, Body: body}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{"utf-8", "text/plain; charset=utf-8", []byte("héllo"), "héllo"},
+		{"no charset", "text/plain", []byte("héllo"), "héllo"},
+		{"latin1", "text/plain; charset=ISO-8859-1", []byte{'h', 0xE9, 'l', 'l', 'o'}, "héllo"},
+		{"windows-1252", "text/html; charset=windows-1252", []byte{0x80, '5'}, "€5"},
+		{"shift_jis", "text/plain; charset=Shift_JIS", []byte{0x82, 0xA0}, "あ"},
+		{"utf-8 bom", "text/plain; charset=ISO-8859-1", []byte{0xEF, 0xBB, 0xBF, 'h', 'i'}, "hi"},
+		{"utf-16le bom", "text/plain", []byte{0xFF, 0xFE, 'h', 0, 'i', 0}, "hi"},
+		{"utf-16be bom", "", []byte{0xFE, 0xFF, 0, 'h', 0, 'i'}, "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := textResponse(tt.contentType, tt.body).Text()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, text)
+		})
+	}
+}
+
+func TestTextUnknownCharset(t *testing.T) {
+	_, err := textResponse("text/plain; charset=klingon", []byte("qapla'")).Text()
+	assert.Error(t, err)
+}
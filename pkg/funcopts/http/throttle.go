@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"golang.org/x/time/rate"
+)
+
+// Bandwidth is a byte rate shared by every request throttled with it, so several
+// clients can be kept under one limit with `SharedThrottle`
+type Bandwidth struct {
+	limiter *rate.Limiter
+}
+
+// NewBandwidth returns a limit of bytesPerSec with bursts of up to burst bytes. A burst
+// of 0 allows one second worth of bytes
+func NewBandwidth(bytesPerSec int64, burst int) *Bandwidth {
+	if burst <= 0 {
+		burst = int(min(max(bytesPerSec, 1), math.MaxInt32))
+	}
+	return &Bandwidth{limiter: rate.NewLimiter(rate.Limit(bytesPerSec), burst)}
+}
+
+// Throttle limits the request and response bodies to bytesPerSec, counted together.
+// Like `RateLimit` the limit is shared by every request made with the option, so a
+// `Client` with it stays under the limit however many requests are in flight
+func Throttle(bytesPerSec int64) RequestOption {
+	return SharedThrottle(NewBandwidth(bytesPerSec, 0))
+}
+
+// ThrottleBurst is `Throttle` allowing bursts of up to burst bytes
+func ThrottleBurst(bytesPerSec int64, burst int) RequestOption {
+	return SharedThrottle(NewBandwidth(bytesPerSec, burst))
+}
+
+// SharedThrottle limits the request and response bodies to the rate of b. Requests
+// wait with the clock set by `WithClock` until their context is done
+func SharedThrottle(b *Bandwidth) RequestOption {
+	return func(r *Request) error {
+		r.bandwidth = b
+		return nil
+	}
+}
+
+type throttleTransport struct {
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.WithContext(ctx)
+		req.Body = &throttledReader{ReadCloser: req.Body, limiter: t.limiter, ctx: ctx}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = &throttledReader{ReadCloser: resp.Body, limiter: t.limiter, ctx: ctx}
+	return resp, nil
+}
+
+// throttledReader waits for the bytes it reads to fit under the limit
+type throttledReader struct {
+	io.ReadCloser
+	limiter *rate.Limiter
+	ctx     context.Context
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		clk := clock.FromContext(r.ctx)
+		now := clk.Now()
+		reservation := r.limiter.ReserveN(now, n)
+		if serr := clk.Sleep(r.ctx, reservation.DelayFrom(now)); serr != nil {
+			return n, serr
+		}
+	}
+	return n, err
+}
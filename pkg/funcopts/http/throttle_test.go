@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func slept(c *clock.Fake) time.Duration {
+	var total time.Duration
+	for _, d := range c.Sleeps() {
+		total += d
+	}
+	return total
+}
+
+func TestThrottleDownload(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 5000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	res, err := Get(ts.URL, Throttle(1000), WithClock(clk))
+	require.NoError(t, err)
+	assert.Equal(t, body, res.Body)
+	assert.InDelta(t, 4.0, slept(clk).Seconds(), 0.01)
+}
+
+func TestThrottleUpload(t *testing.T) {
+	body := bytes.Repeat([]byte("x"), 3000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, got)
+	}))
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	_, err := Post(ts.URL, WithBody(bytes.NewReader(body)), ThrottleBurst(1000, 500), WithClock(clk))
+	require.NoError(t, err)
+	assert.InDelta(t, 2.5, slept(clk).Seconds(), 0.01)
+	for _, d := range clk.Sleeps() {
+		assert.True(t, d <= 500*time.Millisecond, "slept %s for one burst", d)
+	}
+}
+
+func TestSharedThrottle(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 2000))
+	}))
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	shared := NewBandwidth(1000, 1000)
+	for _, c := range []*Client{NewClient(SharedThrottle(shared)), NewClient(SharedThrottle(shared))} {
+		_, err := c.Get(ts.URL, WithClock(clk))
+		require.NoError(t, err)
+	}
+	assert.InDelta(t, 3.0, slept(clk).Seconds(), 0.01)
+}
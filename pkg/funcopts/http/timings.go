@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ResponseTimings is a breakdown of where time was spent during a request.
+// Phases that didn't happen, like DNS for an ip address or connecting on a reused connection, are zero
+type ResponseTimings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// TTFB is the time from starting the request until the first response byte
+	TTFB time.Duration
+	// Total is the time from starting the request until the body was read
+	Total time.Duration
+}
+
+// ConnectionInfo describes the connection of the last attempt of a request
+type ConnectionInfo struct {
+	// Reused is true when the connection had been used for an earlier request
+	Reused bool
+	// IdleTime is how long a reused connection sat idle in the pool
+	IdleTime   time.Duration
+	RemoteAddr string
+	LocalAddr  string
+	// TLSVersion, CipherSuite and NegotiatedProtocol are set for https, like `TLS 1.3`,
+	// `TLS_AES_128_GCM_SHA256` and `h2`
+	TLSVersion         string
+	CipherSuite        string
+	NegotiatedProtocol string
+	// TLS is the full state of a tls connection
+	TLS *tls.ConnectionState
+}
+
+// Timings populates `Response.Timings` and `Response.Connection` for the request
+func Timings() RequestOption {
+	return func(r *Request) error {
+		r.timings = true
+		return nil
+	}
+}
+
+type timingTrace struct {
+	start     time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	timings   ResponseTimings
+	conn      httptrace.GotConnInfo
+	sync.Mutex
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{start: time.Now()}
+}
+
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.Lock()
+			defer t.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.Lock()
+			defer t.Unlock()
+			t.timings.DNS = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.Lock()
+			defer t.Unlock()
+			t.connStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			t.Lock()
+			defer t.Unlock()
+			t.timings.Connect = time.Since(t.connStart)
+		},
+		TLSHandshakeStart: func() {
+			t.Lock()
+			defer t.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.Lock()
+			defer t.Unlock()
+			t.timings.TLSHandshake = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.Lock()
+			defer t.Unlock()
+			t.conn = info
+		},
+		GotFirstResponseByte: func() {
+			t.Lock()
+			defer t.Unlock()
+			t.timings.TTFB = time.Since(t.start)
+		},
+	}
+}
+
+func (t *timingTrace) finish() *ResponseTimings {
+	t.Lock()
+	defer t.Unlock()
+	timings := t.timings
+	timings.Total = time.Since(t.start)
+	return &timings
+}
+
+// connection describes the last connection used, with the tls state of the response
+func (t *timingTrace) connection(state *tls.ConnectionState) *ConnectionInfo {
+	t.Lock()
+	defer t.Unlock()
+	info := &ConnectionInfo{Reused: t.conn.Reused}
+	if t.conn.WasIdle {
+		info.IdleTime = t.conn.IdleTime
+	}
+	if t.conn.Conn != nil {
+		info.RemoteAddr = t.conn.Conn.RemoteAddr().String()
+		info.LocalAddr = t.conn.Conn.LocalAddr().String()
+	}
+	if state != nil {
+		info.TLS = state
+		info.TLSVersion = tls.VersionName(state.Version)
+		info.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+		info.NegotiatedProtocol = state.NegotiatedProtocol
+	}
+	return info
+}
@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimings(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL, SetClient(ts.Client()), Timings())
+	assert.NoError(t, err)
+	assert.NotNil(t, res.Timings)
+	assert.True(t, res.Timings.Connect > 0)
+	assert.True(t, res.Timings.TLSHandshake > 0)
+	assert.True(t, res.Timings.TTFB >= 10*time.Millisecond)
+	assert.True(t, res.Timings.Total >= res.Timings.TTFB)
+}
+
+func TestTimingsDisabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	res, err := Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Nil(t, res.Timings)
+}
+
+func TestTimingsConnection(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	c := NewClient(SetClient(ts.Client()), Timings())
+	res, err := c.Get(ts.URL)
+	assert.NoError(t, err)
+	conn := res.Connection
+	assert.NotNil(t, conn)
+	assert.False(t, conn.Reused)
+	assert.Equal(t, ts.Listener.Addr().String(), conn.RemoteAddr)
+	assert.NotEmpty(t, conn.LocalAddr)
+	assert.Equal(t, "TLS 1.3", conn.TLSVersion)
+	assert.NotEmpty(t, conn.CipherSuite)
+	assert.NotNil(t, conn.TLS)
+
+	time.Sleep(5 * time.Millisecond)
+	res, err = c.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.True(t, res.Connection.Reused)
+	assert.True(t, res.Connection.IdleTime > 0)
+}
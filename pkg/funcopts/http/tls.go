@@ -0,0 +1,40 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+)
+
+// WithCA trusts the PEM encoded certificates in pem in addition to the system roots
+func WithCA(pem []byte) RequestOption {
+	return func(r *Request) error {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return ErrInvalidCertificate
+		}
+		r.transportTuning = append(r.transportTuning, func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			t.TLSClientConfig.RootCAs = pool
+		})
+		return nil
+	}
+}
+
+// WithClientCertificate presents cert to servers that ask for a client certificate
+func WithClientCertificate(cert tls.Certificate) RequestOption {
+	return func(r *Request) error {
+		r.transportTuning = append(r.transportTuning, func(t *http.Transport) {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+		})
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCA(ts *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+}
+
+func TestWithCA(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	_, err := Get(ts.URL)
+	assert.Error(t, err)
+	res, err := Get(ts.URL, WithCA(testCA(ts)))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.Status)
+}
+
+func TestWithCAInvalid(t *testing.T) {
+	_, err := Get("https://example.com", WithCA([]byte("not a certificate")))
+	assert.Equal(t, ErrInvalidCertificate, err)
+}
+
+func TestWithClientCertificate(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.Organization[0]))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+	_, err := Get(ts.URL, WithCA(testCA(ts)))
+	assert.Error(t, err)
+	res, err := Get(ts.URL, WithCA(testCA(ts)), WithClientCertificate(ts.TLS.Certificates[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme Co", string(res.Body))
+}
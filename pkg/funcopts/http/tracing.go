@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name reported to the tracer provider
+const tracerName = "github.com/lusis/go-experiments/pkg/funcopts/http"
+
+// WithTracing creates a span for each request using tp.
+// Every attempt sent over the wire gets a child span and W3C `traceparent`/`tracestate` headers
+func WithTracing(tp trace.TracerProvider) RequestOption {
+	return func(r *Request) error {
+		r.tracer = tp.Tracer(tracerName)
+		return nil
+	}
+}
+
+// startSpan starts the span covering the whole request and returns a function to end it
+func (cr *Request) startSpan(req *http.Request) (*http.Request, func(*Response, error)) {
+	if cr.tracer == nil {
+		return req, func(*Response, error) {}
+	}
+	ctx, span := cr.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.template", cr.urlTemplate()),
+		),
+	)
+	return req.WithContext(ctx), func(res *Response, err error) {
+		if res != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", res.Status))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// urlTemplate returns the url of the request before path params are expanded, joined to
+// the base url and `Path` like `resolveURL` does. Credentials, the query and the fragment
+// are left out since they may hold secrets the template doesn't describe
+func (cr *Request) urlTemplate() string {
+	tmpl := withoutUserinfo(withoutQuery(cr.url))
+	if cr.baseURL != "" && !strings.Contains(tmpl, "://") {
+		if base, err := url.Parse(cr.baseURL); err == nil {
+			base.User, base.RawQuery, base.Fragment = nil, "", ""
+			tmpl = strings.TrimRight(base.String(), "/") + "/" + strings.TrimLeft(tmpl, "/")
+		}
+	}
+	if path := withoutQuery(cr.path); path != "" {
+		tmpl = strings.TrimRight(tmpl, "/") + "/" + strings.TrimLeft(path, "/")
+	}
+	return tmpl
+}
+
+// withoutQuery cuts tmpl at the first `?` or `#` outside a template expression
+func withoutQuery(tmpl string) string {
+	depth := 0
+	for i := 0; i < len(tmpl); i++ {
+		switch tmpl[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '?', '#':
+			if depth == 0 {
+				return tmpl[:i]
+			}
+		}
+	}
+	return tmpl
+}
+
+// withoutUserinfo drops the user and password from an absolute url
+func withoutUserinfo(tmpl string) string {
+	i := strings.Index(tmpl, "://")
+	if i < 0 {
+		return tmpl
+	}
+	authority := tmpl[i+3:]
+	if end := strings.IndexByte(authority, '/'); end >= 0 {
+		authority = authority[:end]
+	}
+	if at := strings.LastIndexByte(authority, '@'); at >= 0 {
+		return tmpl[:i+3] + tmpl[i+3+at+1:]
+	}
+	return tmpl
+}
+
+// tracingTransport creates a client span for every attempt and propagates it to the server
+type tracingTransport struct {
+	tracer trace.Tracer
+	next   http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method+" attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.Redacted()),
+			attribute.String("server.address", req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+	req = req.Clone(ctx)
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
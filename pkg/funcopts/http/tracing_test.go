@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracing(t *testing.T) {
+	traceparent := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, err := Get(ts.URL, WithTracing(tp), ExpectStatus(200))
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	attempt, request := spans[0], spans[1]
+	assert.Equal(t, "HTTP GET attempt", attempt.Name())
+	assert.Equal(t, "HTTP GET", request.Name())
+	assert.Equal(t, request.SpanContext().SpanID(), attempt.Parent().SpanID())
+	assert.Contains(t, traceparent, attempt.SpanContext().TraceID().String())
+	assert.Contains(t, traceparent, attempt.SpanContext().SpanID().String())
+	assert.Equal(t, codes.Error, request.Status().Code)
+	assert.Contains(t, request.Attributes(), attribute.Int("http.response.status_code", 503))
+	assert.Contains(t, request.Attributes(), attribute.String("url.template", ts.URL))
+}
+
+func TestTracingURLTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	params := PathParams(map[string]string{"owner": "lusis", "repo": "go-experiments"})
+	withUser := strings.Replace(ts.URL, "http://", "http://lusis:s3cret@", 1)
+
+	_, err := Get(withUser+"/repos/{owner}/{repo}?token=s3cret{&page}", WithTracing(tp), params)
+	assert.NoError(t, err)
+	_, err = Get("/repos/{owner}", BaseURL(ts.URL+"/v1?token=s3cret"), Path("/{repo}/issues"), WithTracing(tp), params)
+	assert.NoError(t, err)
+
+	var templates []string
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "url.template" {
+				templates = append(templates, attr.Value.AsString())
+			}
+		}
+	}
+	assert.Equal(t, []string{ts.URL + "/repos/{owner}/{repo}", ts.URL + "/v1/repos/{owner}/{repo}/issues"}, templates)
+}
@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// TrailerFunc computes the value of a request trailer from the body as it is sent
+type TrailerFunc func(body io.Reader) (string, error)
+
+type trailer struct {
+	key string
+	fn  TrailerFunc
+}
+
+// WithTrailer sends the trailer key with the value fn returns. fn reads the body while
+// it is being sent, so a checksum of a streamed upload can follow it without buffering
+// it. The body is sent with chunked transfer encoding and an error from fn fails the request
+//
+//	WithTrailer("X-Checksum-SHA256", func(body io.Reader) (string, error) {
+//		h := sha256.New()
+//		_, err := io.Copy(h, body)
+//		return hex.EncodeToString(h.Sum(nil)), err
+//	})
+func WithTrailer(key string, fn TrailerFunc) RequestOption {
+	return func(r *Request) error {
+		r.trailers = append(r.trailers, trailer{key: http.CanonicalHeaderKey(key), fn: fn})
+		return nil
+	}
+}
+
+// trailerValuesKey is the context key of the trailer values computed for a request
+type trailerValuesKey struct{}
+
+// addTrailers declares the trailers on req and computes them as the body is read. The
+// values are set on the request that is sent by `trailerTransport`, since layers in between
+// may send a clone of req
+func addTrailers(req *http.Request, trailers []trailer) *http.Request {
+	req.Trailer = make(http.Header, len(trailers))
+	for _, t := range trailers {
+		req.Trailer[t.key] = nil
+	}
+	values := make(http.Header, len(trailers))
+	body := req.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	req.Body = newTrailerBody(body, values, trailers)
+	req.ContentLength = -1
+	req.TransferEncoding = []string{"chunked"}
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return newTrailerBody(body, values, trailers), nil
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), trailerValuesKey{}, values))
+}
+
+// trailerTransport sets the trailer values computed by `addTrailers` on the request it
+// sends once its body is read. It is the innermost layer so it sees the request that is sent
+type trailerTransport struct {
+	next http.RoundTripper
+}
+
+func (t *trailerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	values, ok := req.Context().Value(trailerValuesKey{}).(http.Header)
+	if !ok || req.Body == nil {
+		return t.next.RoundTrip(req)
+	}
+	out := req.Clone(req.Context())
+	out.Body = &trailerSink{ReadCloser: req.Body, values: values, trailer: out.Trailer}
+	return t.next.RoundTrip(out)
+}
+
+// trailerSink copies the trailer values into trailer when the body is done
+type trailerSink struct {
+	io.ReadCloser
+	values  http.Header
+	trailer http.Header
+}
+
+func (s *trailerSink) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if err == io.EOF {
+		for k, v := range s.values {
+			s.trailer[k] = v
+		}
+	}
+	return n, err
+}
+
+type trailerResult struct {
+	value string
+	err   error
+}
+
+// trailerBody copies what is read from the body to every trailer func and sets the
+// trailers when the body is done
+type trailerBody struct {
+	io.ReadCloser
+	values  http.Header
+	keys    []string
+	pipes   []*io.PipeWriter
+	results []chan trailerResult
+	done    bool
+}
+
+func newTrailerBody(body io.ReadCloser, values http.Header, trailers []trailer) *trailerBody {
+	tb := &trailerBody{ReadCloser: body, values: values}
+	for _, t := range trailers {
+		pr, pw := io.Pipe()
+		result := make(chan trailerResult, 1)
+		go func(fn TrailerFunc) {
+			value, err := fn(pr)
+			// a func that stops reading early mustn't block the body
+			pr.Close()
+			result <- trailerResult{value: value, err: err}
+		}(t.fn)
+		tb.keys = append(tb.keys, t.key)
+		tb.pipes = append(tb.pipes, pw)
+		tb.results = append(tb.results, result)
+	}
+	return tb
+}
+
+func (tb *trailerBody) Read(p []byte) (int, error) {
+	if tb.done {
+		return 0, io.EOF
+	}
+	n, err := tb.ReadCloser.Read(p)
+	if n > 0 {
+		for _, pw := range tb.pipes {
+			pw.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		tb.done = true
+		for i, pw := range tb.pipes {
+			pw.Close()
+			res := <-tb.results[i]
+			if res.err != nil {
+				return n, res.err
+			}
+			tb.values.Set(tb.keys[i], res.value)
+		}
+	}
+	return n, err
+}
+
+// Close stops the trailer funcs of a body that wasn't read to the end
+func (tb *trailerBody) Close() error {
+	for _, pw := range tb.pipes {
+		pw.CloseWithError(io.ErrClosedPipe)
+	}
+	return tb.ReadCloser.Close()
+}
@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Trailer(body io.Reader) (string, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, body)
+	return hex.EncodeToString(h.Sum(nil)), err
+}
+
+func TestWithTrailer(t *testing.T) {
+	body := strings.Repeat("checksummed ", 5000)
+	sum := sha256.Sum256([]byte(body))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(got))
+		assert.Equal(t, hex.EncodeToString(sum[:]), r.Trailer.Get("X-Checksum-Sha256"))
+		assert.Equal(t, "done", r.Trailer.Get("X-Status"))
+	}))
+	defer ts.Close()
+	_, err := Post(ts.URL, StreamBody(strings.NewReader(body)),
+		WithTrailer("X-Checksum-SHA256", sha256Trailer),
+		// a func that doesn't read the body doesn't hold it up
+		WithTrailer("X-Status", func(io.Reader) (string, error) { return "done", nil }))
+	require.NoError(t, err)
+}
+
+func TestWithTrailerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+	failed := errors.New("checksum failed")
+	_, err := Post(ts.URL, WithBody(strings.NewReader("body")), WithTrailer("X-Sum", func(body io.Reader) (string, error) {
+		io.Copy(ioutil.Discard, body)
+		return "", failed
+	}))
+	assert.True(t, errors.Is(err, failed), "got %v", err)
+}
+
+func TestResponseTrailers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Declared")
+		w.Write([]byte("body"))
+		w.Header().Set("X-Declared", "declared")
+		w.Header().Set(http.TrailerPrefix+"X-Undeclared", "undeclared")
+	}))
+	defer ts.Close()
+
+	res, err := Get(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "declared", res.Trailers.Get("X-Declared"))
+	assert.Equal(t, "undeclared", res.Trailers.Get("X-Undeclared"))
+
+	res, err = Get(ts.URL, IncludeRawResponse())
+	require.NoError(t, err)
+	defer res.Raw.Body.Close()
+	assert.Empty(t, res.Trailers.Get("X-Declared"))
+	_, err = ioutil.ReadAll(res.Raw.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "declared", res.Trailers.Get("X-Declared"))
+	assert.Equal(t, "undeclared", res.Trailers.Get("X-Undeclared"))
+}
+
+func TestWithTrailerClonedRequest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte(r.Trailer.Get("X-Count")))
+	}))
+	defer ts.Close()
+	count := WithTrailer("X-Count", func(body io.Reader) (string, error) {
+		n, err := io.Copy(ioutil.Discard, body)
+		return fmt.Sprintf("n%d", n), err
+	})
+	tests := map[string]RequestOption{
+		"plain":           func(*Request) error { return nil },
+		"OnRequest":       OnRequest(func(*http.Request, int) error { return nil }),
+		"BearerTokenFunc": BearerTokenFunc(func(context.Context) (string, error) { return "token", nil }, nil),
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := Post(ts.URL, WithBody(strings.NewReader("abc")), count, opt)
+			require.NoError(t, err)
+			assert.Equal(t, "n3", string(res.Body))
+		})
+	}
+}
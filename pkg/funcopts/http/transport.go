@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// dialControl checks a connection to a resolved address before it is made
+type dialControl func(ctx context.Context, network, address string) error
+
+// WithDialer uses d to open connections. Its Resolver and Control hooks are kept
+func WithDialer(d *net.Dialer) RequestOption {
+	return func(r *Request) error {
+		r.dialer = d
+		return nil
+	}
+}
+
+// WithResolver uses res to look up hosts when opening connections
+func WithResolver(res *net.Resolver) RequestOption {
+	return func(r *Request) error {
+		r.resolver = res
+		return nil
+	}
+}
+
+// StaticHosts pins host names to ip addresses, like entries in /etc/hosts.
+// The request keeps its original `Host` header and TLS server name
+func StaticHosts(hosts map[string]string) RequestOption {
+	return func(r *Request) error {
+		if r.staticHosts == nil {
+			r.staticHosts = make(map[string]string)
+		}
+		for host, ip := range hosts {
+			r.staticHosts[host] = ip
+		}
+		return nil
+	}
+}
+
+// MaxIdleConnsPerHost sets the number of idle connections kept open per host
+func MaxIdleConnsPerHost(n int) RequestOption {
+	return transportOption(func(t *http.Transport) { t.MaxIdleConnsPerHost = n })
+}
+
+// MaxConnsPerHost limits the number of connections per host, including those in use.
+// Zero means no limit
+func MaxConnsPerHost(n int) RequestOption {
+	return transportOption(func(t *http.Transport) { t.MaxConnsPerHost = n })
+}
+
+// IdleConnTimeout sets how long an idle connection is kept open before it is closed
+func IdleConnTimeout(d time.Duration) RequestOption {
+	return transportOption(func(t *http.Transport) { t.IdleConnTimeout = d })
+}
+
+// DisableKeepAlives opens a new connection for every request
+func DisableKeepAlives() RequestOption {
+	return transportOption(func(t *http.Transport) { t.DisableKeepAlives = true })
+}
+
+// TLSHandshakeTimeout sets how long to wait for a TLS handshake
+func TLSHandshakeTimeout(d time.Duration) RequestOption {
+	return transportOption(func(t *http.Transport) { t.TLSHandshakeTimeout = d })
+}
+
+func transportOption(fn func(*http.Transport)) RequestOption {
+	return func(r *Request) error {
+		r.transportTuning = append(r.transportTuning, fn)
+		return nil
+	}
+}
+
+// tuneTransport returns a clone of rt with any transport level options applied.
+// Options that need an *http.Transport are ignored for other http.RoundTrippers,
+// except dial controls which `validate` rejects for them
+func (cr *Request) tuneTransport(rt http.RoundTripper) http.RoundTripper {
+	t, ok := rt.(*http.Transport)
+	if !ok || (len(cr.transportTuning) == 0 && !cr.customDial() && !cr.h2c) {
+		return rt
+	}
+	t = t.Clone()
+	for _, tune := range cr.transportTuning {
+		tune(t)
+	}
+	if cr.customDial() {
+		t.DialContext = cr.dialContext()
+	}
+	if cr.h2c {
+		h2c := newH2CTransport(t)
+		cr.tunedTransport = h2c
+		return h2c
+	}
+	cr.tunedTransport = t
+	return t
+}
+
+func (cr *Request) customDial() bool {
+	return len(cr.dialControls) > 0 || cr.dialer != nil || cr.resolver != nil || len(cr.staticHosts) > 0
+}
+
+// dialContext builds the dial function from `WithDialer`, `WithResolver`, `StaticHosts`
+// and any dial controls
+func (cr *Request) dialContext() func(ctx context.Context, network, address string) (net.Conn, error) {
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if cr.dialer != nil {
+		copied := *cr.dialer
+		d = &copied
+	}
+	if cr.resolver != nil {
+		d.Resolver = cr.resolver
+	}
+	if len(cr.dialControls) > 0 {
+		controls := cr.dialControls
+		control, controlContext := d.Control, d.ControlContext
+		d.Control = nil
+		d.ControlContext = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+			for _, check := range controls {
+				if err := check(ctx, network, address); err != nil {
+					return err
+				}
+			}
+			if controlContext != nil {
+				return controlContext(ctx, network, address, c)
+			}
+			if control != nil {
+				return control(network, address, c)
+			}
+			return nil
+		}
+	}
+	hosts := cr.staticHosts
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(address); err == nil {
+			if ip, ok := hosts[host]; ok {
+				address = net.JoinHostPort(ip, port)
+			}
+		}
+		return d.DialContext(ctx, network, address)
+	}
+}
+
+// sharedTransport builds a tuned transport once and reuses it for later requests
+// so connections are pooled across them
+type sharedTransport struct {
+	once sync.Once
+	rt   http.RoundTripper
+}
+
+// apply replaces the transport options set on r with the shared transport
+func (s *sharedTransport) apply(r *Request) {
+	s.once.Do(func() {
+		rt := r.httpClient.Transport
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		s.rt = r.tuneTransport(rt)
+		r.tunedTransport = nil
+	})
+	hc := *r.httpClient
+	hc.Transport = s.rt
+	r.httpClient = &hc
+	r.transportTuning = nil
+	r.h2c = false
+	// dial controls are kept for other transports so validation reports they weren't installed
+	switch s.rt.(type) {
+	case *http.Transport, *h2cTransport:
+		r.dialControls = nil
+	}
+	r.dialer = nil
+	r.resolver = nil
+	r.staticHosts = nil
+}
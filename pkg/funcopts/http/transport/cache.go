@@ -0,0 +1,473 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// CacheStore is the interface for storage backends used by `Cache`
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// CacheEntry is a cached response along with the metadata needed to revalidate it
+type CacheEntry struct {
+	Status       int               `json:"status"`
+	Headers      http.Header       `json:"headers"`
+	Body         []byte            `json:"body"`
+	Vary         map[string]string `json:"vary,omitempty"`
+	RequestTime  time.Time         `json:"request_time"`
+	ResponseTime time.Time         `json:"response_time"`
+}
+
+// CacheStats tracks cache hits and misses.
+// Embed it in a `CacheStore` to have requests record stats against the store
+type CacheStats struct {
+	hits          uint64
+	misses        uint64
+	revalidations uint64
+}
+
+// Hits returns the number of requests served from the cache
+func (s *CacheStats) Hits() uint64 {
+	return atomic.LoadUint64(&s.hits)
+}
+
+// Misses returns the number of requests that could not be served from the cache
+func (s *CacheStats) Misses() uint64 {
+	return atomic.LoadUint64(&s.misses)
+}
+
+// Revalidations returns the number of hits that required a conditional request to the origin
+func (s *CacheStats) Revalidations() uint64 {
+	return atomic.LoadUint64(&s.revalidations)
+}
+
+func (s *CacheStats) recordHit(revalidated bool) {
+	atomic.AddUint64(&s.hits, 1)
+	if revalidated {
+		atomic.AddUint64(&s.revalidations, 1)
+	}
+}
+
+func (s *CacheStats) recordMiss() {
+	atomic.AddUint64(&s.misses, 1)
+}
+
+type cacheStatsRecorder interface {
+	recordHit(revalidated bool)
+	recordMiss()
+}
+
+// MemoryCache is an in-memory `CacheStore`
+type MemoryCache struct {
+	CacheStats
+	entries map[string]*CacheEntry
+	sync.RWMutex
+}
+
+// NewMemoryCache returns an empty `MemoryCache`
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*CacheEntry)}
+}
+
+// Get returns the entry stored under key
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+// Set stores entry under key
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.Lock()
+	defer c.Unlock()
+	c.entries[key] = entry
+}
+
+// Delete removes the entry stored under key
+func (c *MemoryCache) Delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, key)
+}
+
+// ErrNotCached is the error returned when `OfflineOnly` is set and there is no cached response
+var ErrNotCached = errors.New("no cached response available")
+
+// CacheOption configures `Cache`
+type CacheOption func(*cacheTransport)
+
+// OfflineOnly serves responses from the cache regardless of freshness without contacting the origin.
+// Requests without a cached response fail with `ErrNotCached`
+func OfflineOnly() CacheOption {
+	return func(t *cacheTransport) {
+		t.offline = true
+	}
+}
+
+// StaleIfError serves a stale cached response when the origin can't be reached
+// or responds with a 5xx status
+func StaleIfError() CacheOption {
+	return func(t *cacheTransport) {
+		t.staleIfError = true
+	}
+}
+
+// DefaultMaxCacheBodySize is the largest response body `Cache` stores
+const DefaultMaxCacheBodySize = 10 << 20
+
+// MaxCacheBodySize stores responses with bodies up to n bytes instead of
+// `DefaultMaxCacheBodySize`. Larger responses are passed through without being cached
+func MaxCacheBodySize(n int64) CacheOption {
+	return func(t *cacheTransport) {
+		t.maxBody = n
+	}
+}
+
+// CacheKeyHeaders keys entries on the values of the headers names as well as
+// `DefaultRedactedHeaders`, so requests made with different credentials don't share responses
+func CacheKeyHeaders(names ...string) CacheOption {
+	return func(t *cacheTransport) {
+		t.credentials = append(t.credentials, names...)
+	}
+}
+
+// SharedCache marks the store as shared between users. Responses with
+// `Cache-Control: private` aren't stored, nor are responses to requests with
+// `Authorization` unless they are marked `public`, `s-maxage` or `must-revalidate`
+func SharedCache() CacheOption {
+	return func(t *cacheTransport) {
+		t.shared = true
+	}
+}
+
+// Cache caches GET responses in store following RFC 7234.
+// Fresh responses are served without contacting the origin and stale responses
+// are revalidated with `If-None-Match`/`If-Modified-Since`. Freshness is judged
+// with the `clock.Clock` of the request context. Entries are keyed on the url and
+// the credentials sent, see `CacheKeyHeaders`
+func Cache(store CacheStore, opts ...CacheOption) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		t := &cacheTransport{
+			store:       store,
+			maxBody:     DefaultMaxCacheBodySize,
+			credentials: append([]string{}, DefaultRedactedHeaders...),
+			next:        next,
+		}
+		for _, opt := range opts {
+			opt(t)
+		}
+		return t
+	}
+}
+
+// cacheableStatus are the status codes that are cacheable by default
+var cacheableStatus = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+type cacheTransport struct {
+	store        CacheStore
+	offline      bool
+	staleIfError bool
+	maxBody      int64
+	credentials  []string
+	shared       bool
+	next         http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil && req.Method != "HEAD" && resp.StatusCode < 400 {
+			t.store.Delete(t.key(req))
+		}
+		return resp, err
+	}
+	clk := clock.FromContext(req.Context())
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok || isConditional(req) {
+		return t.next.RoundTrip(req)
+	}
+	key := t.key(req)
+	entry, ok := t.store.Get(key)
+	if ok && !entry.varyMatches(req) {
+		ok = false
+	}
+	if t.offline {
+		if !ok {
+			t.recordMiss()
+			return nil, ErrNotCached
+		}
+		t.recordHit(false)
+		return entry.response(req, clk.Now()), nil
+	}
+	if _, noCache := reqCC["no-cache"]; ok && !noCache && entry.fresh(clk.Now()) {
+		t.recordHit(false)
+		return entry.response(req, clk.Now()), nil
+	}
+	outbound := req
+	if ok {
+		outbound = req.Clone(req.Context())
+		if etag := entry.Headers.Get("ETag"); etag != "" {
+			outbound.Header.Set("If-None-Match", etag)
+		}
+		if lm := entry.Headers.Get("Last-Modified"); lm != "" {
+			outbound.Header.Set("If-Modified-Since", lm)
+		}
+	}
+	requestTime := clk.Now()
+	resp, err := t.next.RoundTrip(outbound)
+	if ok && t.staleIfError && (err != nil || resp.StatusCode >= 500) {
+		if err == nil {
+			resp.Body.Close()
+		}
+		t.recordHit(false)
+		return entry.response(req, clk.Now()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	responseTime := clk.Now()
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		entry = entry.updated(resp.Header, requestTime, responseTime)
+		t.store.Set(key, entry)
+		t.recordHit(true)
+		return entry.response(req, clk.Now()), nil
+	}
+	t.recordMiss()
+	if !cacheableResponse(resp) || !t.storable(req, resp) || resp.ContentLength > t.maxBody {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, t.maxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.maxBody {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	t.store.Set(key, &CacheEntry{
+		Status:       resp.StatusCode,
+		Headers:      resp.Header.Clone(),
+		Body:         body,
+		Vary:         varyValues(req, resp.Header),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+	})
+	return resp, nil
+}
+
+func (t *cacheTransport) recordHit(revalidated bool) {
+	if s, ok := t.store.(cacheStatsRecorder); ok {
+		s.recordHit(revalidated)
+	}
+}
+
+func (t *cacheTransport) recordMiss() {
+	if s, ok := t.store.(cacheStatsRecorder); ok {
+		s.recordMiss()
+	}
+}
+
+// key is the url of req and a hash of the credentials it sends
+func (t *cacheTransport) key(req *http.Request) string {
+	h := sha256.New()
+	sent := false
+	for _, name := range t.credentials {
+		for _, v := range req.Header.Values(name) {
+			h.Write([]byte(http.CanonicalHeaderKey(name) + ": " + v + "\n"))
+			sent = true
+		}
+	}
+	if !sent {
+		return req.URL.String()
+	}
+	return req.URL.String() + " " + hex.EncodeToString(h.Sum(nil))
+}
+
+// storable reports whether a shared store may keep resp
+func (t *cacheTransport) storable(req *http.Request, resp *http.Response) bool {
+	if !t.shared {
+		return true
+	}
+	cc := parseCacheControl(resp.Header)
+	if _, ok := cc["private"]; ok {
+		return false
+	}
+	if req.Header.Get("Authorization") == "" {
+		return true
+	}
+	for _, directive := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := cc[directive]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isConditional(req *http.Request) bool {
+	return req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+}
+
+func cacheableResponse(resp *http.Response) bool {
+	if !cacheableStatus[resp.StatusCode] {
+		return false
+	}
+	if _, ok := parseCacheControl(resp.Header)["no-store"]; ok {
+		return false
+	}
+	return strings.TrimSpace(resp.Header.Get("Vary")) != "*"
+}
+
+func varyValues(req *http.Request, h http.Header) map[string]string {
+	vary := make(map[string]string)
+	for _, v := range h["Vary"] {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name != "" {
+				vary[name] = req.Header.Get(name)
+			}
+		}
+	}
+	return vary
+}
+
+func (e *CacheEntry) varyMatches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// updated returns a copy of the entry with the headers from a 304 response merged in.
+// The entry itself is left alone since the store may share it with other requests
+func (e *CacheEntry) updated(h http.Header, requestTime, responseTime time.Time) *CacheEntry {
+	u := *e
+	u.Headers = e.Headers.Clone()
+	for k, v := range h {
+		if k == "Content-Length" {
+			continue
+		}
+		u.Headers[k] = v
+	}
+	u.RequestTime = requestTime
+	u.ResponseTime = responseTime
+	return &u
+}
+
+// age returns the current age of the entry per RFC 7234 section 4.2.3
+func (e *CacheEntry) age(now time.Time) time.Duration {
+	date := e.date()
+	apparent := e.ResponseTime.Sub(date)
+	if apparent < 0 {
+		apparent = 0
+	}
+	ageValue, _ := strconv.Atoi(e.Headers.Get("Age"))
+	corrected := time.Duration(ageValue)*time.Second + e.ResponseTime.Sub(e.RequestTime)
+	if apparent > corrected {
+		corrected = apparent
+	}
+	return corrected + now.Sub(e.ResponseTime)
+}
+
+// lifetime returns the freshness lifetime of the entry per RFC 7234 section 4.2.1
+func (e *CacheEntry) lifetime() time.Duration {
+	cc := parseCacheControl(e.Headers)
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if maxAge, ok := cc["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if expires := e.Headers.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(e.date())
+	}
+	if lm, err := http.ParseTime(e.Headers.Get("Last-Modified")); err == nil {
+		return e.date().Sub(lm) / 10
+	}
+	return 0
+}
+
+func (e *CacheEntry) fresh(now time.Time) bool {
+	return e.lifetime() > e.age(now)
+}
+
+func (e *CacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Headers.Get("Date")); err == nil {
+		return date
+	}
+	return e.ResponseTime
+}
+
+func (e *CacheEntry) response(req *http.Request, now time.Time) *http.Response {
+	h := e.Headers.Clone()
+	h.Set("Age", strconv.Itoa(int(e.age(now).Seconds())))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// parseCacheControl parses the `Cache-Control` header into a map of directives
+func parseCacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range h["Cache-Control"] {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			kv := strings.SplitN(directive, "=", 2)
+			key := strings.ToLower(kv[0])
+			if len(kv) == 2 {
+				cc[key] = strings.Trim(kv[1], `"`)
+			} else {
+				cc[key] = ""
+			}
+		}
+	}
+	return cc
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DiskCache is a `CacheStore` that persists entries as json files in a directory
+type DiskCache struct {
+	CacheStats
+	dir string
+	sync.RWMutex
+}
+
+// NewDiskCache returns a `DiskCache` storing entries in dir, creating it if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the entry stored under key.
+// Entries that can't be read or decoded are treated as missing
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	entry := &CacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores entry under key. Write failures are ignored as the entry can always be refetched
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	tmp, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Delete removes the entry stored under key
+func (c *DiskCache) Delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+	os.Remove(c.path(key))
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(client *http.Client, url string) (*http.Response, error) {
+	resp, err := client.Get(url)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestCache(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "cached")
+	}))
+	store := NewMemoryCache()
+	client := &http.Client{Transport: Cache(store)(http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		_, err := get(client, ts.URL)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(1), store.Hits())
+	ts.Close()
+
+	offline := &http.Client{Transport: Cache(store, OfflineOnly())(http.DefaultTransport)}
+	resp, err := get(offline, ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = get(offline, ts.URL+"/missing")
+	assert.True(t, errors.Is(err, ErrNotCached))
+}
+
+func TestCacheMaxBodySize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "too large")
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	client := &http.Client{Transport: Cache(store, MaxCacheBodySize(4))(http.DefaultTransport)}
+	for _, path := range []string{"/", "/chunked"} {
+		resp, err := client.Get(ts.URL + path)
+		assert.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "too large", string(body))
+		_, ok := store.Get(ts.URL + path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestCacheRevalidationLeavesStoredEntry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "2")
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer ts.Close()
+	store := NewMemoryCache()
+	old := &CacheEntry{Status: http.StatusOK, Headers: http.Header{"Etag": {`"v1"`}, "X-Version": {"1"}}, Body: []byte("cached")}
+	store.Set(ts.URL, old)
+	client := &http.Client{Transport: Cache(store)(http.DefaultTransport)}
+	resp, err := get(client, ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", resp.Header.Get("X-Version"))
+	assert.Equal(t, "1", old.Headers.Get("X-Version"))
+	entry, _ := store.Get(ts.URL)
+	assert.Equal(t, "2", entry.Headers.Get("X-Version"))
+}
+
+func TestCacheCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, r.Header.Get("Authorization"), r.Header.Get("X-Api-Key"))
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: Cache(NewMemoryCache(), CacheKeyHeaders("X-Api-Key"))(http.DefaultTransport)}
+	for _, h := range []map[string]string{
+		{"Authorization": "alice"}, {"Authorization": "bob"}, {"X-Api-Key": "carol"}, {"X-Api-Key": "dave"},
+	} {
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		var want string
+		for k, v := range h {
+			req.Header.Set(k, v)
+			want = v
+		}
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, want, string(body))
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: Cache(NewMemoryCache(), SharedCache())(http.DefaultTransport)}
+	send := func(path, auth string) {
+		req, _ := http.NewRequest("GET", ts.URL+path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	for _, c := range []struct {
+		path, auth string
+		calls      int32
+	}{
+		{"/private", "", 2},
+		{"/", "secret", 2},
+		{"/public", "secret", 1},
+		{"/", "", 1},
+	} {
+		atomic.StoreInt32(&calls, 0)
+		send(c.path, c.auth)
+		send(c.path, c.auth)
+		assert.Equal(t, c.calls, atomic.LoadInt32(&calls), c.path+" "+c.auth)
+	}
+}
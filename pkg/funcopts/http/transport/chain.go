@@ -0,0 +1,35 @@
+// Package transport provides http.RoundTripper decorators for retries, metrics,
+// logging and caching that work with any http.Client
+//
+//	client := &http.Client{Transport: transport.Chain(
+//		transport.Logging(slog.Default()),
+//		transport.Retry(3, 100*time.Millisecond),
+//	)(http.DefaultTransport)}
+package transport
+
+import "net/http"
+
+// Middleware decorates an http.RoundTripper
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an http.RoundTripper implemented by a function
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain composes middleware into one. The first sees each request first and
+// the response last. A nil http.RoundTripper is http.DefaultTransport
+func Chain(middleware ...Middleware) Middleware {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+		for i := len(middleware) - 1; i >= 0; i-- {
+			rt = middleware[i](rt)
+		}
+		return rt
+	}
+}
@@ -0,0 +1,29 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tag(name string, order *[]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*order = append(*order, name)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	var order []string
+	client := &http.Client{Transport: Chain(tag("outer", &order), tag("inner", &order))(nil)}
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"outer", "inner"}, order)
+}
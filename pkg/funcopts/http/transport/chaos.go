@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// ErrConnectionDropped is the error returned for a request dropped by `Chaos`
+var ErrConnectionDropped = errors.New("chaos: connection dropped")
+
+type faultKind int
+
+const (
+	faultNone faultKind = iota
+	faultLatency
+	faultDrop
+	faultTruncate
+	faultStatus
+)
+
+// Fault is a failure injected by `Chaos`
+type Fault struct {
+	kind    faultKind
+	latency time.Duration
+	status  int
+}
+
+// NoFault lets a request through unchanged, e.g. to end a `Schedule`
+func NoFault() Fault {
+	return Fault{}
+}
+
+// Latency delays a request by d before it is sent
+func Latency(d time.Duration) Fault {
+	return Fault{kind: faultLatency, latency: d}
+}
+
+// DropConnection fails a request with `ErrConnectionDropped` without sending it
+func DropConnection() Fault {
+	return Fault{kind: faultDrop}
+}
+
+// TruncateBody sends a request but cuts the response body in half, failing the
+// read with io.ErrUnexpectedEOF
+func TruncateBody() Fault {
+	return Fault{kind: faultTruncate}
+}
+
+// ErrorStatus responds with code without sending the request
+func ErrorStatus(code int) Fault {
+	return Fault{kind: faultStatus, status: code}
+}
+
+// ChaosOption configures `Chaos`
+type ChaosOption func(*chaos)
+
+// Randomly injects each of faults into a request with probability p, between 0 and 1
+func Randomly(p float64, faults ...Fault) ChaosOption {
+	return func(c *chaos) {
+		for _, f := range faults {
+			c.random = append(c.random, randomFault{p: p, fault: f})
+		}
+	}
+}
+
+// Schedule injects faults in order, one per request. Requests after the last
+// fault only get the faults set with `Randomly`
+func Schedule(faults ...Fault) ChaosOption {
+	return func(c *chaos) {
+		c.schedule = append(c.schedule, faults...)
+	}
+}
+
+// ChaosSeed seeds the random source so the faults injected by `Randomly` repeat between runs
+func ChaosSeed(seed int64) ChaosOption {
+	return func(c *chaos) {
+		c.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+type randomFault struct {
+	p     float64
+	fault Fault
+}
+
+type chaos struct {
+	random   []randomFault
+	schedule []Fault
+	requests int
+	rand     *rand.Rand
+	sync.Mutex
+}
+
+// Chaos injects latency, dropped connections, truncated bodies and error statuses
+// into requests, to test how callers cope with them. The schedule is shared by
+// every transport the returned middleware decorates
+func Chaos(opts ...ChaosOption) Middleware {
+	c := &chaos{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &chaosTransport{chaos: c, next: next}
+	}
+}
+
+// faults returns the faults for the next request
+func (c *chaos) faults() []Fault {
+	c.Lock()
+	defer c.Unlock()
+	var faults []Fault
+	if c.requests < len(c.schedule) {
+		faults = append(faults, c.schedule[c.requests])
+	}
+	c.requests++
+	for _, rf := range c.random {
+		if c.rand.Float64() < rf.p {
+			faults = append(faults, rf.fault)
+		}
+	}
+	return faults
+}
+
+type chaosTransport struct {
+	chaos *chaos
+	next  http.RoundTripper
+}
+
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	truncate := false
+	for _, f := range t.chaos.faults() {
+		switch f.kind {
+		case faultLatency:
+			if err := clock.FromContext(req.Context()).Sleep(req.Context(), f.latency); err != nil {
+				closeRequestBody(req)
+				return nil, err
+			}
+		case faultDrop:
+			closeRequestBody(req)
+			return nil, ErrConnectionDropped
+		case faultStatus:
+			closeRequestBody(req)
+			return &http.Response{
+				Status:     fmt.Sprintf("%d %s", f.status, http.StatusText(f.status)),
+				StatusCode: f.status,
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Header:     make(http.Header),
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+				Request:    req,
+			}, nil
+		case faultTruncate:
+			truncate = true
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !truncate {
+		return resp, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &truncatedBody{r: strings.NewReader(string(body[:len(body)/2]))}
+	return resp, nil
+}
+
+// closeRequestBody closes the body of a request that won't be sent, as an
+// http.RoundTripper must
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// truncatedBody fails with io.ErrUnexpectedEOF once its content is read
+type truncatedBody struct {
+	r io.Reader
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *truncatedBody) Close() error {
+	return nil
+}
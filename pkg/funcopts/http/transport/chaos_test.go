@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChaosSchedule(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: Chaos(Schedule(
+		ErrorStatus(http.StatusServiceUnavailable),
+		DropConnection(),
+		TruncateBody(),
+		Latency(20*time.Millisecond),
+	))(http.DefaultTransport)}
+
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	_, err = client.Get(ts.URL)
+	assert.True(t, errors.Is(err, ErrConnectionDropped))
+
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	assert.Equal(t, "01234", string(body))
+
+	start := time.Now()
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	body, _ = ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "0123456789", string(body))
+
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestChaosRandomly(t *testing.T) {
+	next := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	run := func() []int {
+		rt := Chaos(ChaosSeed(42), Randomly(0.5, ErrorStatus(http.StatusBadGateway)))(next)
+		var statuses []int
+		for i := 0; i < 20; i++ {
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			resp, err := rt.RoundTrip(req)
+			assert.NoError(t, err)
+			statuses = append(statuses, resp.StatusCode)
+		}
+		return statuses
+	}
+	first := run()
+	assert.Equal(t, first, run())
+	assert.Contains(t, first, http.StatusOK)
+	assert.Contains(t, first, http.StatusBadGateway)
+
+	rt := Chaos(Randomly(0, DropConnection()))(next)
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	_, err := rt.RoundTrip(req)
+	assert.NoError(t, err)
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestChaosClosesRequestBody(t *testing.T) {
+	for name, fault := range map[string]Fault{
+		"drop":   DropConnection(),
+		"status": ErrorStatus(http.StatusServiceUnavailable),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rt := Chaos(Schedule(fault))(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				t.Fatal("request was sent")
+				return nil, nil
+			}))
+			body := &closeTracker{Reader: strings.NewReader("unsent")}
+			req, _ := http.NewRequest("POST", "http://example.com", body)
+			resp, _ := rt.RoundTrip(req)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			assert.True(t, body.closed)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+// DefaultRedactedHeaders are the headers that are always redacted from logs
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Redacted is the value logged in place of sensitive header values
+const Redacted = "[REDACTED]"
+
+// headerRequestID is logged as `request_id` when it is set on a request
+const headerRequestID = "X-Request-ID"
+
+// Logger is the interface used to log requests. *slog.Logger satisfies it
+type Logger interface {
+	Info(msg string, args ...interface{})
+	Debug(msg string, args ...interface{})
+}
+
+// LogOption configures `Logging`
+type LogOption func(*loggingTransport)
+
+// Dump additionally logs full request and response dumps at debug level
+func Dump() LogOption {
+	return func(t *loggingTransport) {
+		t.dump = true
+	}
+}
+
+// SkipRequestBody leaves request bodies out of dumps so they are sent as they are read,
+// for streamed bodies and requests waiting for `100 Continue`
+func SkipRequestBody() LogOption {
+	return func(t *loggingTransport) {
+		t.skipReqBody = true
+	}
+}
+
+// Redact redacts headers from dumps in addition to `DefaultRedactedHeaders`
+func Redact(names ...string) LogOption {
+	return func(t *loggingTransport) {
+		t.redact = append(t.redact, names...)
+	}
+}
+
+// RedactQuery redacts the values of query params from logged urls and dumps
+func RedactQuery(names ...string) LogOption {
+	return func(t *loggingTransport) {
+		t.redactQuery = append(t.redactQuery, names...)
+	}
+}
+
+// Logging logs a line for every request with the method, url, status, latency and sizes
+func Logging(logger Logger, opts ...LogOption) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		t := &loggingTransport{
+			logger: logger,
+			redact: append([]string{}, DefaultRedactedHeaders...),
+			next:   next,
+		}
+		for _, opt := range opts {
+			opt(t)
+		}
+		return t
+	}
+}
+
+type loggingTransport struct {
+	logger      Logger
+	dump        bool
+	redact      []string
+	redactQuery []string
+	skipReqBody bool
+	next        http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.dump {
+		var err error
+		if req, err = t.dumpRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+	var id []interface{}
+	if requestID := req.Header.Get(headerRequestID); requestID != "" {
+		id = []interface{}{"request_id", requestID}
+	}
+	if err != nil {
+		t.logger.Info("http request failed", append([]interface{}{
+			"method", req.Method,
+			"url", RedactURL(req.URL, t.redactQuery...),
+			"latency", latency,
+			"error", err,
+		}, id...)...)
+		return nil, err
+	}
+	t.logger.Info("http request", append([]interface{}{
+		"method", req.Method,
+		"url", RedactURL(req.URL, t.redactQuery...),
+		"status", resp.StatusCode,
+		"latency", latency,
+		"request_bytes", req.ContentLength,
+		"response_bytes", resp.ContentLength,
+	}, id...)...)
+	if t.dump {
+		t.dumpResponse(resp)
+	}
+	return resp, nil
+}
+
+// dumpRequest logs a dump of req and returns the request to send in its place, a clone
+// whose body still reads from the start
+func (t *loggingTransport) dumpRequest(req *http.Request) (*http.Request, error) {
+	req = req.Clone(req.Context())
+	var body []byte
+	if !t.skipReqBody && req.Body != nil && req.Body != http.NoBody {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+		body = data
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	}
+	out := req.Clone(req.Context())
+	out.Header = RedactHeader(req.Header, t.redact...)
+	if len(t.redactQuery) > 0 {
+		out.URL, _ = url.Parse(RedactURL(req.URL, t.redactQuery...))
+	}
+	out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	dump, err := httputil.DumpRequestOut(out, !t.skipReqBody)
+	if err != nil {
+		t.logger.Debug("http request dump failed", "error", err)
+		return req, nil
+	}
+	if t.skipReqBody && req.Body != nil && req.Body != http.NoBody {
+		dump = append(dump, "[body not dumped]"...)
+	}
+	t.logger.Debug("http request dump", "dump", string(dump))
+	return req, nil
+}
+
+func (t *loggingTransport) dumpResponse(resp *http.Response) {
+	out := *resp
+	out.Header = RedactHeader(resp.Header, t.redact...)
+	dump, err := httputil.DumpResponse(&out, true)
+	resp.Body = out.Body
+	if err != nil {
+		t.logger.Debug("http response dump failed", "error", err)
+		return
+	}
+	t.logger.Debug("http response dump", "dump", string(dump))
+}
+
+// RedactHeader returns a copy of h with the values of names replaced by `Redacted`
+func RedactHeader(h http.Header, names ...string) http.Header {
+	h = h.Clone()
+	for _, name := range names {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+// RedactURL returns u with its password and the values of the query params names
+// replaced by `Redacted`
+func RedactURL(u *url.URL, names ...string) string {
+	if len(names) == 0 || u.RawQuery == "" {
+		return u.Redacted()
+	}
+	query := u.Query()
+	for _, name := range names {
+		if _, ok := query[name]; ok {
+			query.Set(name, Redacted)
+		}
+	}
+	out := *u
+	out.RawQuery = query.Encode()
+	return out.Redacted()
+}
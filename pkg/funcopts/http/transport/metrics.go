@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observation describes one round trip for `Metrics`
+type Observation struct {
+	Method string
+	Host   string
+	// Status is zero when the round trip failed with Err
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+// Metrics calls observe after every round trip, e.g. to update counters and histograms
+func Metrics(observe func(Observation)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			o := Observation{
+				Method:   req.Method,
+				Host:     req.URL.Host,
+				Duration: time.Since(start),
+				Err:      err,
+			}
+			if err == nil {
+				o.Status = resp.StatusCode
+			}
+			observe(o)
+			return resp, err
+		})
+	}
+}
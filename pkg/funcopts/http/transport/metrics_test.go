@@ -0,0 +1,39 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer ts.Close()
+	var observed []Observation
+	client := &http.Client{Transport: Metrics(func(o Observation) {
+		observed = append(observed, o)
+	})(http.DefaultTransport)}
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	failing := Metrics(func(o Observation) {
+		observed = append(observed, o)
+	})(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("refused")
+	}))
+	_, err = (&http.Client{Transport: failing}).Get(ts.URL)
+	assert.Error(t, err)
+
+	assert.Len(t, observed, 2)
+	assert.Equal(t, "GET", observed[0].Method)
+	assert.Equal(t, http.StatusTeapot, observed[0].Status)
+	assert.Equal(t, ts.Listener.Addr().String(), observed[0].Host)
+	assert.Equal(t, 0, observed[1].Status)
+	assert.EqualError(t, observed[1].Err, "refused")
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// MaxRetryBackoff caps the delay between retries
+const MaxRetryBackoff = 30 * time.Second
+
+// Retry retries a request up to max times when it fails with a connection error
+// or a 429, 502, 503 or 504 response. The delay starts at backoff and doubles for
+// every attempt, with jitter, unless the response has a `Retry-After` header. Delays,
+// including those asked for with `Retry-After`, are capped at `MaxRetryBackoff`.
+// Requests with a body are only retried when it can be replayed with GetBody.
+// It waits with the `clock.Clock` of the request context
+func Retry(max int, backoff time.Duration, opts ...RetryOption) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		t := &retryTransport{max: max, backoff: backoff, next: next}
+		for _, opt := range opts {
+			opt(t)
+		}
+		return t
+	}
+}
+
+// RetryOption configures `Retry`
+type RetryOption func(*retryTransport)
+
+// WithBudget only retries when budget allows it. A refused retry returns the
+// response or error of the last attempt
+func WithBudget(budget *RetryBudget) RetryOption {
+	return func(t *retryTransport) {
+		t.budget = budget
+	}
+}
+
+// RetryIf retries the attempts for which fn returns true instead of those that failed
+// with a connection error or a status accepted by `RetryableStatus`. attempt is the
+// number of the attempt that got resp or err, starting at 1
+func RetryIf(fn func(attempt int, req *http.Request, resp *http.Response, err error) bool) RetryOption {
+	return func(t *retryTransport) {
+		t.retryIf = fn
+	}
+}
+
+// RetryableStatus reports whether a response with code is retried by `Retry`
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+type retryTransport struct {
+	max     int
+	backoff time.Duration
+	budget  *RetryBudget
+	retryIf func(attempt int, req *http.Request, resp *http.Response, err error) bool
+	next    http.RoundTripper
+}
+
+// delay returns how long to wait before retry number attempt, starting at 1, at now
+func (t *retryTransport) delay(attempt int, resp *http.Response, now time.Time) time.Duration {
+	if resp != nil {
+		if d, ok := RetryAfter(resp.Header, now); ok {
+			if d > MaxRetryBackoff {
+				d = MaxRetryBackoff
+			}
+			return d
+		}
+	}
+	if t.backoff <= 0 {
+		return 0
+	}
+	shift := uint(attempt - 1)
+	d := t.backoff << shift
+	if shift >= 63 || d>>shift != t.backoff || d > MaxRetryBackoff {
+		d = MaxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// RetryAfter parses the `Retry-After` header in h, in seconds or as an http date
+// relative to now. It reports false when h has no valid `Retry-After`
+func RetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// retryable reports whether attempt is retried after it got resp or err
+func (t *retryTransport) retryable(attempt int, req *http.Request, resp *http.Response, err error) bool {
+	if t.retryIf != nil {
+		return t.retryIf(attempt, req, resp, err)
+	}
+	return err != nil || RetryableStatus(resp.StatusCode)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	clk := clock.FromContext(req.Context())
+	if t.budget != nil {
+		t.budget.recordRequest(clk.Now())
+	}
+	for attempt := 0; ; attempt++ {
+		out := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			out = req.Clone(req.Context())
+			out.Body = body
+		}
+		resp, err := t.next.RoundTrip(out)
+		if attempt >= t.max || !replayable || !t.retryable(attempt+1, out, resp, err) {
+			return resp, err
+		}
+		if err != nil && req.Context().Err() != nil {
+			return resp, err
+		}
+		if t.budget != nil && !t.budget.withdraw(clk.Now()) {
+			return resp, err
+		}
+		delay := t.delay(attempt+1, resp, clk.Now())
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := clk.Sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: Retry(3, time.Millisecond)(http.DefaultTransport)}
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rt := &retryTransport{max: 1, backoff: time.Hour}
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+	assert.Equal(t, 2*time.Second, rt.delay(1, resp, now))
+	resp.Header.Set("Retry-After", now.Add(10*time.Second).Format(http.TimeFormat))
+	assert.Equal(t, 10*time.Second, rt.delay(1, resp, now))
+	resp.Header.Set("Retry-After", "3600")
+	assert.Equal(t, MaxRetryBackoff, rt.delay(1, resp, now))
+	d := rt.delay(1, nil, now)
+	assert.True(t, d >= MaxRetryBackoff/2 && d <= MaxRetryBackoff)
+	d = rt.delay(100, nil, now)
+	assert.True(t, d >= MaxRetryBackoff/2 && d <= MaxRetryBackoff, "overflowing shifts are capped")
+
+	rt.backoff = 100 * time.Millisecond
+	d = rt.delay(3, nil, now)
+	assert.True(t, d >= 200*time.Millisecond && d <= 400*time.Millisecond)
+
+	rt.backoff = 0
+	assert.Equal(t, time.Duration(0), rt.delay(3, nil, now), "zero backoff retries immediately")
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, ok := RetryAfter(http.Header{"Retry-After": {"7"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+	d, ok = RetryAfter(http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+	_, ok = RetryAfter(http.Header{}, now)
+	assert.False(t, ok)
+}
+
+func TestRetryIf(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer ts.Close()
+	retryIf := RetryIf(func(attempt int, req *http.Request, resp *http.Response, err error) bool {
+		return err == nil && resp.StatusCode == http.StatusConflict
+	})
+	client := &http.Client{Transport: Retry(3, time.Millisecond, retryIf)(http.DefaultTransport)}
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
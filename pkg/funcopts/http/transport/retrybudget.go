@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// retryBudgetBuckets is the number of buckets the window of a `RetryBudget` is split into
+const retryBudgetBuckets = 10
+
+// RetryBudget limits the retries of `Retry` to a share of the requests over a sliding
+// window, so retrying against a failing server doesn't multiply its load. The budget is
+// shared by every request retried with it, e.g. all the requests of a client
+type RetryBudget struct {
+	ratio      float64
+	minRetries int
+	width      time.Duration
+	buckets    [retryBudgetBuckets]retryBudgetBucket
+	sync.Mutex
+	requests  atomic.Uint64
+	retries   atomic.Uint64
+	exhausted atomic.Uint64
+}
+
+type retryBudgetBucket struct {
+	start    time.Time
+	requests int
+	retries  int
+}
+
+// NewRetryBudget returns a `RetryBudget` allowing ratio retries per request sent over
+// window, e.g. 0.2 for one retry every five requests, plus minRetries so a client that
+// sends few requests can still retry
+func NewRetryBudget(ratio float64, minRetries int, window time.Duration) *RetryBudget {
+	width := window / retryBudgetBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &RetryBudget{ratio: ratio, minRetries: minRetries, width: width}
+}
+
+// Requests returns the number of requests counted by the budget
+func (b *RetryBudget) Requests() uint64 {
+	return b.requests.Load()
+}
+
+// Retries returns the number of retries the budget allowed
+func (b *RetryBudget) Retries() uint64 {
+	return b.retries.Load()
+}
+
+// Exhausted returns the number of retries the budget refused
+func (b *RetryBudget) Exhausted() uint64 {
+	return b.exhausted.Load()
+}
+
+// bucket returns the bucket for now, emptying it when it last held an older slot
+func (b *RetryBudget) bucket(now time.Time) *retryBudgetBucket {
+	start := now.Truncate(b.width)
+	bucket := &b.buckets[(start.UnixNano()/int64(b.width))%retryBudgetBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = retryBudgetBucket{start: start}
+	}
+	return bucket
+}
+
+// recordRequest counts a request sent at now
+func (b *RetryBudget) recordRequest(now time.Time) {
+	b.requests.Add(1)
+	b.Lock()
+	defer b.Unlock()
+	b.bucket(now).requests++
+}
+
+// withdraw reports whether a retry at now fits in the budget and counts it if so
+func (b *RetryBudget) withdraw(now time.Time) bool {
+	b.Lock()
+	defer b.Unlock()
+	current := b.bucket(now)
+	oldest := current.start.Add(-b.width * (retryBudgetBuckets - 1))
+	var requests, retries int
+	for _, bucket := range b.buckets {
+		if !bucket.start.Before(oldest) {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	if float64(retries+1) > b.ratio*float64(requests)+float64(b.minRetries) {
+		b.exhausted.Add(1)
+		return false
+	}
+	current.retries++
+	b.retries.Add(1)
+	return true
+}
@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := NewRetryBudget(0.5, 0, 10*time.Second)
+	for i := 0; i < 4; i++ {
+		b.recordRequest(now)
+	}
+	assert.True(t, b.withdraw(now))
+	assert.True(t, b.withdraw(now.Add(time.Second)))
+	assert.False(t, b.withdraw(now.Add(2*time.Second)))
+
+	now = now.Add(11 * time.Second)
+	assert.False(t, b.withdraw(now))
+	b.recordRequest(now)
+	b.recordRequest(now)
+	assert.True(t, b.withdraw(now))
+	assert.Equal(t, uint64(6), b.Requests())
+	assert.Equal(t, uint64(3), b.Retries())
+	assert.Equal(t, uint64(2), b.Exhausted())
+}
+
+func TestRetryBudgetMinRetries(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := NewRetryBudget(0, 2, time.Minute)
+	assert.True(t, b.withdraw(now))
+	assert.True(t, b.withdraw(now))
+	assert.False(t, b.withdraw(now))
+}
+
+func TestRetryWithBudget(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	budget := NewRetryBudget(0, 1, time.Minute)
+	client := &http.Client{Transport: Retry(3, time.Millisecond, WithBudget(budget))(http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(1), budget.Retries())
+	assert.Equal(t, uint64(2), budget.Exhausted())
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticHosts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	res, err := Get("http://staging.example.com:"+u.Port()+"/", StaticHosts(map[string]string{"staging.example.com": "127.0.0.1"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "staging.example.com:"+u.Port(), string(res.Body))
+}
+
+func TestWithResolver(t *testing.T) {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("resolver called")
+		},
+	}
+	_, err := Get("http://resolve.example.com/", WithResolver(resolver))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resolver called")
+}
+
+func TestWithDialer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	called := false
+	d := &net.Dialer{Control: func(network, address string, c syscall.RawConn) error {
+		called = true
+		return nil
+	}}
+	_, err := Get(ts.URL, WithDialer(d), AllowHosts("127.0.0.1"))
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func countConns(ts *httptest.Server) *int32 {
+	var n int32
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&n, 1)
+		}
+	}
+	return &n
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	conns := countConns(ts)
+	ts.Start()
+	defer ts.Close()
+	c := NewClient(MaxIdleConnsPerHost(4), IdleConnTimeout(time.Minute), TLSHandshakeTimeout(time.Second))
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(conns))
+}
+
+func TestDisableKeepAlives(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	conns := countConns(ts)
+	ts.Start()
+	defer ts.Close()
+	c := NewClient(DisableKeepAlives())
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ts.URL)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(conns))
+}
+
+func TestMaxConnsPerHost(t *testing.T) {
+	var active, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer ts.Close()
+	c := NewClient(MaxConnsPerHost(1))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(ts.URL)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+}
+
+func TestClientSharedTransportHostPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	c := NewClient(MaxIdleConnsPerHost(2))
+	_, err := c.Get(ts.URL)
+	assert.NoError(t, err)
+	_, err = c.Get(ts.URL, DenyPrivateNetworks())
+	assert.True(t, errors.Is(err, ErrHostNotAllowed))
+}
@@ -0,0 +1,254 @@
+// Package tus uploads large files to servers implementing the tus 1.0 resumable upload
+// protocol (https://tus.io/protocols/resumable-upload). Files are sent in chunks and an
+// interrupted upload continues from the offset the server reports
+//
+//	f, _ := os.Open("backup.tar")
+//	info, _ := f.Stat()
+//	uploadURL, err := tus.Upload(ctx, "https://files.example.com/files/", f, info.Size(),
+//		tus.Metadata(map[string]string{"filename": "backup.tar"}), tus.ChunkSize(16<<20))
+//	// later, after a crash
+//	err = tus.Resume(ctx, uploadURL, f, info.Size())
+package tus
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+const (
+	// Version is the protocol version sent in the `Tus-Resumable` header
+	Version = "1.0.0"
+	// DefaultChunkSize is the size of the PATCH requests sent without `ChunkSize`
+	DefaultChunkSize = 4 << 20
+	// contentType is the content type of PATCH requests
+	contentType = "application/offset+octet-stream"
+)
+
+var (
+	// ErrOffsetMismatch is the error returned when the server reports an offset that
+	// doesn't match the data sent or is past the end of the upload
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrNoLocation is the error returned when the server creates an upload without a `Location`
+	ErrNoLocation = errors.New("created upload has no location")
+)
+
+type config struct {
+	client    *httpclient.Client
+	chunkSize int64
+	metadata  map[string]string
+	retries   int
+	delay     time.Duration
+}
+
+// Option configures an upload
+type Option func(*config)
+
+// Client sends the requests with c, which can carry auth, timeouts and other options
+func Client(c *httpclient.Client) Option {
+	return func(cfg *config) {
+		cfg.client = c
+	}
+}
+
+// ChunkSize sets the number of bytes sent with each PATCH request. The default is
+// `DefaultChunkSize`. A chunk is held in memory while it is sent
+func ChunkSize(n int64) Option {
+	return func(cfg *config) {
+		cfg.chunkSize = n
+	}
+}
+
+// Metadata is sent as `Upload-Metadata` when the upload is created
+func Metadata(md map[string]string) Option {
+	return func(cfg *config) {
+		cfg.metadata = md
+	}
+}
+
+// Retries sets how many times a failed chunk is resumed from the offset on the server,
+// waiting delay before each attempt. The default is 3 retries a second apart
+func Retries(n int, delay time.Duration) Option {
+	return func(cfg *config) {
+		cfg.retries = n
+		cfg.delay = delay
+	}
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{chunkSize: DefaultChunkSize, retries: 3, delay: time.Second}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.client == nil {
+		cfg.client = httpclient.NewClient()
+	}
+	if cfg.chunkSize < 1 {
+		cfg.chunkSize = DefaultChunkSize
+	}
+	return cfg
+}
+
+// Create creates an upload of size bytes at endpoint and returns its url
+func Create(ctx context.Context, endpoint string, size int64, opts ...Option) (string, error) {
+	return newConfig(opts).create(ctx, endpoint, size)
+}
+
+// Offset returns the number of bytes of the upload at uploadURL the server has received
+func Offset(ctx context.Context, uploadURL string, opts ...Option) (int64, error) {
+	return newConfig(opts).offset(ctx, uploadURL)
+}
+
+// Upload creates an upload of size bytes at endpoint and sends r in chunks. The url of
+// the upload is returned even when sending fails, so it can be continued with `Resume`
+func Upload(ctx context.Context, endpoint string, r io.ReadSeeker, size int64, opts ...Option) (string, error) {
+	cfg := newConfig(opts)
+	uploadURL, err := cfg.create(ctx, endpoint, size)
+	if err != nil {
+		return "", err
+	}
+	return uploadURL, cfg.send(ctx, uploadURL, r, size, 0)
+}
+
+// Resume continues the upload at uploadURL from the offset the server has, reading the
+// rest from r, which holds the whole file
+func Resume(ctx context.Context, uploadURL string, r io.ReadSeeker, size int64, opts ...Option) error {
+	cfg := newConfig(opts)
+	offset, err := cfg.offset(ctx, uploadURL)
+	if err != nil {
+		return err
+	}
+	return cfg.send(ctx, uploadURL, r, size, offset)
+}
+
+func (cfg *config) request(ctx context.Context, method, target string, headers map[string]string, opts ...httpclient.RequestOption) (*httpclient.Response, error) {
+	headers["Tus-Resumable"] = Version
+	req, _, err := cfg.client.New(append([]httpclient.RequestOption{
+		httpclient.Method(method),
+		httpclient.URL(target),
+		httpclient.AddHeaders(headers),
+	}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return req.Send(ctx)
+}
+
+func (cfg *config) create(ctx context.Context, endpoint string, size int64) (string, error) {
+	headers := map[string]string{"Upload-Length": strconv.FormatInt(size, 10)}
+	if md := encodeMetadata(cfg.metadata); md != "" {
+		headers["Upload-Metadata"] = md
+	}
+	res, err := cfg.request(ctx, http.MethodPost, endpoint, headers, httpclient.ExpectStatus(http.StatusCreated))
+	if err != nil {
+		return "", err
+	}
+	location := res.Headers.Get("Location")
+	if location == "" {
+		return "", ErrNoLocation
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (cfg *config) offset(ctx context.Context, uploadURL string) (int64, error) {
+	res, err := cfg.request(ctx, http.MethodHead, uploadURL, map[string]string{}, httpclient.ExpectSuccess())
+	if err != nil {
+		return 0, err
+	}
+	return parseOffset(res)
+}
+
+// send uploads r from offset to size, resuming from the server offset after a failure
+func (cfg *config) send(ctx context.Context, uploadURL string, r io.ReadSeeker, size, offset int64) error {
+	failures := 0
+	for offset != size {
+		if offset > size {
+			return fmt.Errorf("server has %d of %d bytes: %w", offset, size, ErrOffsetMismatch)
+		}
+		next, err := cfg.patch(ctx, uploadURL, r, size, offset)
+		for err != nil {
+			if ctx.Err() != nil || failures >= cfg.retries {
+				return err
+			}
+			failures++
+			if err := clock.FromContext(ctx).Sleep(ctx, cfg.delay); err != nil {
+				return err
+			}
+			next, err = cfg.offset(ctx, uploadURL)
+		}
+		if next > offset {
+			failures = 0
+		}
+		offset = next
+	}
+	return nil
+}
+
+// patch sends the chunk at offset and returns the new offset
+func (cfg *config) patch(ctx context.Context, uploadURL string, r io.ReadSeeker, size, offset int64) (int64, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	chunk := make([]byte, min(cfg.chunkSize, size-offset))
+	if _, err := io.ReadFull(r, chunk); err != nil {
+		return offset, err
+	}
+	headers := map[string]string{"Upload-Offset": strconv.FormatInt(offset, 10)}
+	res, err := cfg.request(ctx, http.MethodPatch, uploadURL, headers,
+		httpclient.ContentType(contentType),
+		httpclient.WithBody(bytes.NewReader(chunk)),
+		httpclient.ExpectStatus(http.StatusNoContent, http.StatusOK))
+	if err != nil {
+		return offset, err
+	}
+	next, err := parseOffset(res)
+	if err != nil {
+		return offset, err
+	}
+	if next <= offset || next > size {
+		return offset, fmt.Errorf("offset %d after sending %d bytes at %d: %w", next, len(chunk), offset, ErrOffsetMismatch)
+	}
+	return next, nil
+}
+
+func parseOffset(res *httpclient.Response) (int64, error) {
+	offset, err := strconv.ParseInt(res.Headers.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid Upload-Offset %q: %w", res.Headers.Get("Upload-Offset"), ErrOffsetMismatch)
+	}
+	return offset, nil
+}
+
+// encodeMetadata renders md as `Upload-Metadata`, comma separated keys with base64 values
+func encodeMetadata(md map[string]string) string {
+	pairs := make([]string, 0, len(md))
+	for k, v := range md {
+		pair := k
+		if v != "" {
+			pair += " " + base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
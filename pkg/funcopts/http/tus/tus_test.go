@@ -0,0 +1,153 @@
+package tus
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// server is a minimal tus server keeping a single upload in memory
+type server struct {
+	sync.Mutex
+	t        *testing.T
+	data     []byte
+	length   int64
+	metadata string
+	patches  int
+	// failAt makes the nth PATCH store half of its chunk and fail
+	failAt int
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	assert.Equal(s.t, Version, r.Header.Get("Tus-Resumable"))
+	w.Header().Set("Tus-Resumable", Version)
+	switch r.Method {
+	case http.MethodPost:
+		s.length, _ = strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		s.metadata = r.Header.Get("Upload-Metadata")
+		w.Header().Set("Location", "/files/1")
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.Header().Set("Upload-Length", strconv.FormatInt(s.length, 10))
+	case http.MethodPatch:
+		assert.Equal(s.t, contentType, r.Header.Get("Content-Type"))
+		if r.Header.Get("Upload-Offset") != strconv.Itoa(len(s.data)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		chunk, _ := ioutil.ReadAll(r.Body)
+		s.patches++
+		if s.patches == s.failAt {
+			s.data = append(s.data, chunk[:len(chunk)/2]...)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		s.data = append(s.data, chunk...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testContext() (context.Context, *clock.Fake) {
+	clk := clock.NewFake(time.Now())
+	return clock.NewContext(context.Background(), clk), clk
+}
+
+func TestUpload(t *testing.T) {
+	srv := &server{t: t}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	file := bytes.Repeat([]byte("0123456789"), 100)
+	ctx, _ := testContext()
+	uploadURL, err := Upload(ctx, ts.URL+"/files/", bytes.NewReader(file), int64(len(file)),
+		Client(httpclient.NewClient(httpclient.SetClient(ts.Client()))),
+		ChunkSize(300),
+		Metadata(map[string]string{"filename": "data.bin", "private": ""}))
+	require.NoError(t, err)
+	assert.Equal(t, ts.URL+"/files/1", uploadURL)
+	assert.Equal(t, file, srv.data)
+	assert.Equal(t, 4, srv.patches)
+	assert.Equal(t, "filename "+base64.StdEncoding.EncodeToString([]byte("data.bin"))+",private", srv.metadata)
+
+	offset, err := Offset(ctx, uploadURL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(file)), offset)
+}
+
+func TestUploadResumesAfterFailure(t *testing.T) {
+	srv := &server{t: t, failAt: 2}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	file := bytes.Repeat([]byte("abcdefghij"), 100)
+	ctx, clk := testContext()
+	_, err := Upload(ctx, ts.URL+"/files/", bytes.NewReader(file), int64(len(file)), ChunkSize(400), Retries(1, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, file, srv.data)
+	assert.Equal(t, []time.Duration{time.Minute}, clk.Sleeps())
+}
+
+func TestUploadGivesUp(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "/files/1")
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodHead:
+			w.Header().Set("Upload-Offset", "0")
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+	ctx, clk := testContext()
+	uploadURL, err := Upload(ctx, ts.URL+"/files/", strings.NewReader("data"), 4, Retries(2, time.Second))
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode), "got %v", err)
+	assert.Equal(t, ts.URL+"/files/1", uploadURL)
+	assert.Len(t, clk.Sleeps(), 2)
+}
+
+func TestResume(t *testing.T) {
+	file := []byte("the whole file")
+	srv := &server{t: t, data: append([]byte(nil), file[:5]...), length: int64(len(file))}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	ctx, _ := testContext()
+	require.NoError(t, Resume(ctx, ts.URL+"/files/1", bytes.NewReader(file), int64(len(file))))
+	assert.Equal(t, file, srv.data)
+	assert.Equal(t, 1, srv.patches)
+}
+
+func TestResumeOffsetPastEnd(t *testing.T) {
+	srv := &server{t: t, data: []byte("longer than the file")}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	ctx, _ := testContext()
+	err := Resume(ctx, ts.URL+"/files/1", strings.NewReader("short"), 5)
+	assert.True(t, errors.Is(err, ErrOffsetMismatch), "got %v", err)
+}
+
+func TestCreateWithoutLocation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	_, err := Create(context.Background(), ts.URL, 10)
+	assert.True(t, errors.Is(err, ErrNoLocation))
+}
@@ -0,0 +1,48 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// GetJSON performs an http GET expecting a 2xx json response and decodes it into a T
+func GetJSON[T any](ctx context.Context, url string, opts ...RequestOption) (T, error) {
+	return doJSON[T](ctx, Get, url, nil, opts).Unwrap()
+}
+
+// DeleteJSON performs an http DELETE expecting a 2xx json response and decodes it into a T
+func DeleteJSON[T any](ctx context.Context, url string, opts ...RequestOption) (T, error) {
+	return doJSON[T](ctx, Delete, url, nil, opts).Unwrap()
+}
+
+// PostJSON performs an http POST of body encoded as json, expecting a 2xx json response
+// and decodes it into a T
+func PostJSON[T any](ctx context.Context, url string, body interface{}, opts ...RequestOption) (T, error) {
+	return doJSON[T](ctx, Post, url, body, opts).Unwrap()
+}
+
+// PutJSON performs an http PUT of body encoded as json, expecting a 2xx json response
+// and decodes it into a T
+func PutJSON[T any](ctx context.Context, url string, body interface{}, opts ...RequestOption) (T, error) {
+	return doJSON[T](ctx, Put, url, body, opts).Unwrap()
+}
+
+// DecodeJSON decodes the body of res into a T
+func DecodeJSON[T any](res *Response) (T, error) {
+	var v T
+	err := json.Unmarshal(res.Body, &v)
+	return v, err
+}
+
+func doJSON[T any](ctx context.Context, method func(string, ...RequestOption) (*Response, error), url string, body interface{}, opts []RequestOption) Result[T] {
+	all := []RequestOption{WithContext(ctx), Accept(ContentTypeJSON), ExpectSuccess()}
+	if body != nil {
+		all = append(all, WithTypedBody(body))
+	}
+	res, err := method(url, append(all, opts...)...)
+	if err != nil || len(res.Body) == 0 {
+		return Result[T]{Response: res, Err: err}
+	}
+	v, err := DecodeJSON[T](res)
+	return Result[T]{Value: v, Response: res, Err: err}
+}
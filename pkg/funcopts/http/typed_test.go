@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func typedServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(typedUser{ID: 1, Name: "ada"})
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			body, _ := ioutil.ReadAll(r.Body)
+			var u typedUser
+			json.Unmarshal(body, &u)
+			u.ID = 2
+			json.NewEncoder(w).Encode(u)
+		}
+	}))
+}
+
+func TestGetJSON(t *testing.T) {
+	ts := typedServer()
+	defer ts.Close()
+	u, err := GetJSON[typedUser](context.Background(), ts.URL+"/users/1")
+	assert.NoError(t, err)
+	assert.Equal(t, typedUser{ID: 1, Name: "ada"}, u)
+
+	m, err := GetJSON[map[string]interface{}](context.Background(), ts.URL+"/users/1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ada", m["name"])
+
+	_, err = GetJSON[typedUser](context.Background(), ts.URL+"/missing")
+	assert.True(t, errors.Is(err, ErrInvalidStatusCode))
+}
+
+func TestPostPutJSON(t *testing.T) {
+	ts := typedServer()
+	defer ts.Close()
+	u, err := PostJSON[typedUser](context.Background(), ts.URL+"/users", typedUser{Name: "grace"})
+	assert.NoError(t, err)
+	assert.Equal(t, typedUser{ID: 2, Name: "grace"}, u)
+	u, err = PutJSON[typedUser](context.Background(), ts.URL+"/users/2", typedUser{Name: "linus"})
+	assert.NoError(t, err)
+	assert.Equal(t, "linus", u.Name)
+}
+
+func TestDeleteJSONEmpty(t *testing.T) {
+	ts := typedServer()
+	defer ts.Close()
+	u, err := DeleteJSON[*typedUser](context.Background(), ts.URL+"/users/1")
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+}
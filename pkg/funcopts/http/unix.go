@@ -0,0 +1,24 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// UnixSocket sends requests over the unix domain socket at path instead of tcp.
+// The host in the request url is ignored, so any placeholder works
+//
+//	Get("http://unix/v1.41/containers/json", UnixSocket("/var/run/docker.sock"))
+func UnixSocket(path string) RequestOption {
+	return func(r *Request) error {
+		r.transportTuning = append(r.transportTuning, func(t *http.Transport) {
+			t.Proxy = nil
+			t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			}
+		})
+		return nil
+	}
+}
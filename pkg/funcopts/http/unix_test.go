@@ -0,0 +1,25 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", sock)
+	assert.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	res, err := Get("http://unix/v1.41/containers/json", UnixSocket(sock))
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1.41/containers/json", string(res.Body))
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"net/url"
+	"strings"
+)
+
+// BaseURL sets the url that relative request urls and `Path` are joined to
+//
+//	c := NewClient(BaseURL("https://api.github.com"))
+//	c.Get("/users/lusis")
+func BaseURL(u string) RequestOption {
+	return func(r *Request) error {
+		r.baseURL = u
+		return nil
+	}
+}
+
+// Path appends p to the request url. Each segment of p is escaped and
+// duplicate slashes at the join are removed
+func Path(p string) RequestOption {
+	return func(r *Request) error {
+		r.path = p
+		return nil
+	}
+}
+
+// PathParams expands RFC 6570 URI template expressions like `{owner}` in the request url and `Path`.
+// Values are escaped so they can't change the structure of the url
+//
+//	c.Get("/repos/{owner}/{repo}", PathParams(map[string]string{"owner": "lusis", "repo": "go-experiments"}))
+func PathParams(params map[string]string) RequestOption {
+	return func(r *Request) error {
+		if r.pathParams == nil {
+			r.pathParams = make(map[string]string)
+		}
+		for k, v := range params {
+			r.pathParams[k] = v
+		}
+		return nil
+	}
+}
+
+// resolveURL combines the base url, request url and path into the url to request
+func (cr *Request) resolveURL() (*url.URL, error) {
+	rawURL, path := cr.url, escapePath(cr.path)
+	if cr.pathParams != nil {
+		var err error
+		if rawURL, err = expandTemplate(cr.url, cr.pathParams, nil); err != nil {
+			return nil, err
+		}
+		if path, err = expandTemplate(cr.path, cr.pathParams, escapePath); err != nil {
+			return nil, err
+		}
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if cr.baseURL != "" && !u.IsAbs() {
+		base, err := url.Parse(cr.baseURL)
+		if err != nil {
+			return nil, err
+		}
+		rel := u
+		if u, err = joinPath(base, rel.EscapedPath()); err != nil {
+			return nil, err
+		}
+		if rel.RawQuery != "" {
+			u.RawQuery = rel.RawQuery
+		}
+	}
+	if path != "" {
+		return joinPath(u, path)
+	}
+	return u, nil
+}
+
+// joinPath returns a copy of base with the already escaped path p appended
+func joinPath(base *url.URL, p string) (*url.URL, error) {
+	u := *base
+	if p == "" {
+		return &u, nil
+	}
+	joined := strings.TrimRight(base.EscapedPath(), "/") + "/" + strings.TrimLeft(p, "/")
+	unescaped, err := url.PathUnescape(joined)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = unescaped
+	u.RawPath = joined
+	return &u, nil
+}
+
+// escapePath escapes each segment of p
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []RequestOption
+		want string
+	}{
+		{"absolute", []RequestOption{setURL("https://example.com/a")}, "https://example.com/a"},
+		{"base relative", []RequestOption{BaseURL("https://example.com/api/"), setURL("/v1/users")}, "https://example.com/api/v1/users"},
+		{"base ignored for absolute", []RequestOption{BaseURL("https://example.com/api"), setURL("https://other.com/x")}, "https://other.com/x"},
+		{"base with query", []RequestOption{BaseURL("https://example.com/api"), setURL("users?active=1")}, "https://example.com/api/users?active=1"},
+		{"path", []RequestOption{BaseURL("https://example.com/api//"), Path("//v1/users")}, "https://example.com/api/v1/users"},
+		{"path escaping", []RequestOption{setURL("https://example.com"), Path("/files/a b/c?d")}, "https://example.com/files/a%20b/c%3Fd"},
+		{"path with relative url", []RequestOption{BaseURL("https://example.com"), setURL("/v1"), Path("users")}, "https://example.com/v1/users"},
+	}
+	for _, tt := range tests {
+		_, r, err := New(tt.opts...)
+		assert.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, r.URL.String(), tt.name)
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer ts.Close()
+	c := NewClient(BaseURL(ts.URL + "/api"))
+	res, err := c.Get("/users")
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/users", string(res.Body))
+	res, err = c.Get("", Path("/users/lusis"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/users/lusis", string(res.Body))
+}
@@ -0,0 +1,33 @@
+package httpclient
+
+import (
+	"sync"
+)
+
+var defaultUserAgent = struct {
+	ua string
+	sync.RWMutex
+}{ua: "go-experiments-httpclient/" + Version}
+
+// DefaultUserAgent returns the `User-Agent` sent by requests that don't set one
+func DefaultUserAgent() string {
+	defaultUserAgent.RLock()
+	defer defaultUserAgent.RUnlock()
+	return defaultUserAgent.ua
+}
+
+// SetDefaultUserAgent changes the `User-Agent` sent by requests that don't set one.
+// An empty ua falls back to Go's default
+func SetDefaultUserAgent(ua string) {
+	defaultUserAgent.Lock()
+	defer defaultUserAgent.Unlock()
+	defaultUserAgent.ua = ua
+}
+
+// UserAgent sets the `User-Agent` header of the request
+func UserAgent(ua string) RequestOption {
+	return func(r *Request) error {
+		r.userAgent = ua
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAgent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent()))
+	}))
+	defer ts.Close()
+	res, err := Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "go-experiments-httpclient/"+Version, string(res.Body))
+
+	res, err = Get(ts.URL, UserAgent("billing/2.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "billing/2.1", string(res.Body))
+
+	c := NewClient(UserAgent("client/1.0"))
+	res, err = c.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "client/1.0", string(res.Body))
+	res, err = c.Get(ts.URL, UserAgent("override/1.0"))
+	assert.NoError(t, err)
+	assert.Equal(t, "override/1.0", string(res.Body))
+}
+
+func TestSetDefaultUserAgent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent()))
+	}))
+	defer ts.Close()
+	previous := DefaultUserAgent()
+	defer SetDefaultUserAgent(previous)
+
+	SetDefaultUserAgent("custom-default/3")
+	res, err := Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom-default/3", string(res.Body))
+
+	SetDefaultUserAgent("")
+	res, err = Get(ts.URL)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(res.Body), "Go-http-client"))
+}
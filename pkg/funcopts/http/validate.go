@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// claim records that option set field to value. Different options setting the same field
+// to different values, or to an empty value, are reported by `validate`; the last one
+// applied wins otherwise
+func (cr *Request) claim(field, option, value string) {
+	if cr.claims == nil {
+		cr.claims = make(map[string][2]string)
+	}
+	if prev, ok := cr.claims[field]; ok && prev[0] != option && (value == "" || prev[1] != value) {
+		cr.conflicts = append(cr.conflicts, fmt.Errorf("%s and %s both set the %s: %w", prev[0], option, field, ErrOptionConflict))
+	}
+	cr.claims[field] = [2]string{option, value}
+}
+
+// resetClaims forgets the options applied so far so they act as overridable defaults,
+// e.g. the options of a `Client`
+func (cr *Request) resetClaims() {
+	cr.claims = nil
+}
+
+// validate returns every problem with the applied options joined in one error.
+// The url and method are only required when the request is about to be sent
+func (cr *Request) validate(send bool) error {
+	errs := append([]error{}, cr.conflicts...)
+	if err := cr.validateDialControls(); err != nil {
+		errs = append(errs, err)
+	}
+	if send {
+		if cr.url == "" && cr.baseURL == "" && cr.path == "" {
+			errs = append(errs, fmt.Errorf("no url set: %w", ErrMissingField))
+		}
+		if cr.method == "" {
+			errs = append(errs, fmt.Errorf("no method set: %w", ErrMissingField))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateDialControls reports dial controls, like the address checks of `AllowHosts` and
+// `DenyPrivateNetworks`, that can't be installed because the transport set with
+// `SetClient` isn't an *http.Transport
+func (cr *Request) validateDialControls() error {
+	if len(cr.dialControls) == 0 || cr.httpClient == nil || cr.httpClient.Transport == nil {
+		return nil
+	}
+	if _, ok := cr.httpClient.Transport.(*http.Transport); ok {
+		return nil
+	}
+	return fmt.Errorf("host checks need an *http.Transport to check the dialed address, got %T: %w", cr.httpClient.Transport, ErrOptionConflict)
+}
@@ -0,0 +1,37 @@
+package httpclient
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConflicts(t *testing.T) {
+	_, _, err := New(JSON(), ContentType("text/plain"), WithBody(strings.NewReader("a")), WithTypedBody(1))
+	assert.True(t, errors.Is(err, ErrOptionConflict))
+	assert.Contains(t, err.Error(), "JSON and ContentType both set the content type")
+	assert.Contains(t, err.Error(), "WithBody and WithTypedBody both set the body")
+}
+
+func TestValidateCompatible(t *testing.T) {
+	_, _, err := New(JSON(), ContentType(ContentTypeJSON), WithBody(strings.NewReader("a")), WithBody(strings.NewReader("b")))
+	assert.NoError(t, err)
+}
+
+func TestValidateClientDefaults(t *testing.T) {
+	c := NewClient(JSON(), WithBody(strings.NewReader("default")))
+	_, _, err := c.New(ContentType("application/merge-patch+json"), WithTypedBody(1))
+	assert.NoError(t, err)
+}
+
+func TestValidateRequired(t *testing.T) {
+	_, err := Get("")
+	assert.True(t, errors.Is(err, ErrMissingField))
+	assert.Contains(t, err.Error(), "no url set")
+
+	_, err = Get("", JSON(), RequestXML())
+	assert.True(t, errors.Is(err, ErrMissingField))
+	assert.True(t, errors.Is(err, ErrOptionConflict))
+}
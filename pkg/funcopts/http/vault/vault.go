@@ -0,0 +1,114 @@
+// Package vault reads secrets from HashiCorp Vault's key/value engine so they can be
+// sent with httpclient.HeaderFromSecret
+//
+//	httpclient.RegisterSecretProvider("vault", httpclient.CacheSecrets(vault.New(""), 5*time.Minute))
+//	c := httpclient.NewClient(httpclient.HeaderFromSecret("X-API-Key", "vault:secret/data/billing#api_key"))
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+)
+
+type config struct {
+	token     string
+	namespace string
+	opts      []httpclient.RequestOption
+}
+
+// Option configures a `Provider`
+type Option func(*config)
+
+// Token authenticates with token instead of `VAULT_TOKEN`
+func Token(token string) Option {
+	return func(cfg *config) {
+		cfg.token = token
+	}
+}
+
+// Namespace sends requests to the enterprise namespace ns instead of `VAULT_NAMESPACE`
+func Namespace(ns string) Option {
+	return func(cfg *config) {
+		cfg.namespace = ns
+	}
+}
+
+// RequestOptions applies opts to requests to vault
+func RequestOptions(opts ...httpclient.RequestOption) Option {
+	return func(cfg *config) {
+		cfg.opts = append(cfg.opts, opts...)
+	}
+}
+
+// Provider is an httpclient.SecretProvider for vault
+type Provider struct {
+	addr string
+	cfg  *config
+}
+
+// New returns a `Provider` for the vault at addr, or `VAULT_ADDR` when addr is empty
+func New(addr string, opts ...Option) *Provider {
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	cfg := &config{token: os.Getenv("VAULT_TOKEN"), namespace: os.Getenv("VAULT_NAMESPACE")}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &Provider{addr: strings.TrimSuffix(addr, "/"), cfg: cfg}
+}
+
+// Secret reads the field of the secret at path for a key of the form `path#field`, like
+// `secret/data/billing#api_key`. Both version 1 and version 2 key/value engines are read
+func (p *Provider) Secret(ctx context.Context, key string) (string, error) {
+	path, field, ok := strings.Cut(key, "#")
+	if !ok || field == "" {
+		return "", fmt.Errorf("vault secret %q must be path#field", key)
+	}
+	headers := map[string]string{"X-Vault-Token": p.cfg.token}
+	if p.cfg.namespace != "" {
+		headers["X-Vault-Namespace"] = p.cfg.namespace
+	}
+	opts := append([]httpclient.RequestOption{
+		httpclient.WithContext(ctx),
+		httpclient.AddHeaders(headers),
+		httpclient.RedactHeaders("X-Vault-Token"),
+	}, p.cfg.opts...)
+	res, err := httpclient.Get(p.addr+"/v1/"+strings.TrimPrefix(path, "/"), opts...)
+	if err != nil {
+		return "", err
+	}
+	if res.Status == http.StatusNotFound {
+		return "", fmt.Errorf("%w: vault %s", httpclient.ErrSecretNotFound, path)
+	}
+	if res.Status != http.StatusOK {
+		return "", fmt.Errorf("vault %s: %w: %d", path, httpclient.ErrInvalidStatusCode, res.Status)
+	}
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(res.Body, &secret); err != nil {
+		return "", err
+	}
+	data := secret.Data
+	// version 2 engines nest the fields with the metadata of the version
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("%w: vault %s has no field %s", httpclient.ErrSecretNotFound, path, field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
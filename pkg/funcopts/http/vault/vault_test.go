@@ -0,0 +1,62 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/secret/data/billing":
+			w.Write([]byte(`{"data":{"data":{"api_key":"k2","port":8080},"metadata":{"version":3}}}`))
+		case "/v1/kv/billing":
+			w.Write([]byte(`{"data":{"api_key":"k1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	p := New(ts.URL, Token("root"), Namespace("team"))
+	ctx := context.Background()
+	for key, want := range map[string]string{"secret/data/billing#api_key": "k2", "secret/data/billing#port": "8080", "kv/billing#api_key": "k1"} {
+		got, err := p.Secret(ctx, key)
+		require.NoError(t, err, key)
+		assert.Equal(t, want, got, key)
+	}
+	for _, key := range []string{"secret/data/missing#api_key", "kv/billing#password"} {
+		_, err := p.Secret(ctx, key)
+		assert.True(t, errors.Is(err, httpclient.ErrSecretNotFound), key)
+	}
+	_, err := p.Secret(ctx, "kv/billing")
+	assert.Error(t, err)
+	_, err = New(ts.URL, Token("wrong"), Namespace("team")).Secret(ctx, "kv/billing#api_key")
+	assert.True(t, errors.Is(err, httpclient.ErrInvalidStatusCode))
+}
+
+func TestHeaderFromSecret(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/kv/api" {
+			w.Write([]byte(`{"data":{"token":"s3cret"}}`))
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+	httpclient.RegisterSecretProvider("vault-test", New(ts.URL, Token("root")))
+	res, err := httpclient.Get(ts.URL+"/api", httpclient.BearerTokenFromSecret("vault-test:kv/api#token"))
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer s3cret", string(res.Body))
+}
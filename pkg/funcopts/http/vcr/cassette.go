@@ -0,0 +1,124 @@
+package vcr
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Base64 is the `BodyEncoding` of bodies that aren't valid UTF-8, which are stored base64 encoded
+const Base64 = "base64"
+
+// Request is the recorded form of an http request
+type Request struct {
+	Method  string      `json:"method" yaml:"method"`
+	URL     string      `json:"url" yaml:"url"`
+	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string      `json:"body,omitempty" yaml:"body,omitempty"`
+	// BodyEncoding is `Base64` for binary bodies and empty for text
+	BodyEncoding string `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Response is the recorded form of an http response
+type Response struct {
+	Status  int         `json:"status" yaml:"status"`
+	Headers http.Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string      `json:"body,omitempty" yaml:"body,omitempty"`
+	// BodyEncoding is `Base64` for binary bodies and empty for text
+	BodyEncoding string `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+// Cassette is a collection of interactions persisted to a file.
+// Files ending in `.yaml` or `.yml` are stored as yaml, everything else as json
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+	path         string
+	sync.Mutex
+}
+
+// Load reads the cassette stored at path
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{path: path}
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, c)
+	} else {
+		err = json.Unmarshal(data, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the cassette to its path
+func (c *Cassette) Save() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.save()
+}
+
+func (c *Cassette) save() error {
+	var data []byte
+	var err error
+	if isYAML(c.path) {
+		data, err = yaml.Marshal(c)
+	} else {
+		data, err = json.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0644)
+}
+
+func (c *Cassette) add(i *Interaction) {
+	c.Lock()
+	defer c.Unlock()
+	c.Interactions = append(c.Interactions, i)
+}
+
+// encodeBody returns body as text, base64 encoded when it isn't valid UTF-8
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), Base64
+}
+
+// decodeBody returns the bytes of a recorded body
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case Base64:
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return nil, fmt.Errorf("unknown body encoding %q", encoding)
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,90 @@
+package vcr
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// DefaultRedactedHeaders are the headers redacted from every recording, `transport.DefaultRedactedHeaders`
+var DefaultRedactedHeaders = transport.DefaultRedactedHeaders
+
+// Redacted is the value recorded in place of redacted headers
+const Redacted = transport.Redacted
+
+// MatchField is a part of a request used to match it against recorded interactions
+type MatchField int
+
+const (
+	// MatchMethod matches on the request method
+	MatchMethod MatchField = iota
+	// MatchURL matches on the full request url
+	MatchURL
+	// MatchBody matches on the request body
+	MatchBody
+)
+
+type config struct {
+	redact    []string
+	matchOn   []MatchField
+	transport http.RoundTripper
+}
+
+// Option is a type for functional options
+type Option func(*config)
+
+// Redact adds headers to be redacted from recordings
+func Redact(headers ...string) Option {
+	return func(c *config) {
+		c.redact = append(c.redact, headers...)
+	}
+}
+
+// MatchOn sets which parts of a request must match a recorded interaction.
+// The default is `MatchMethod` and `MatchURL`
+func MatchOn(fields ...MatchField) Option {
+	return func(c *config) {
+		c.matchOn = fields
+	}
+}
+
+// Transport sets the http.RoundTripper used by a `RecordingTransport` to make real requests
+func Transport(rt http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = rt
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{
+		redact:    append([]string{}, DefaultRedactedHeaders...),
+		matchOn:   []MatchField{MatchMethod, MatchURL},
+		transport: http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *config) matches(req *http.Request, body []byte, i *Interaction) bool {
+	for _, field := range c.matchOn {
+		switch field {
+		case MatchMethod:
+			if req.Method != i.Request.Method {
+				return false
+			}
+		case MatchURL:
+			if req.URL.String() != i.Request.URL {
+				return false
+			}
+		case MatchBody:
+			recorded, err := decodeBody(i.Request.Body, i.Request.BodyEncoding)
+			if err != nil || !bytes.Equal(body, recorded) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,151 @@
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/transport"
+)
+
+// ErrInteractionNotFound is the error returned when a request doesn't match any recorded interaction
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+// RecordingTransport is an http.RoundTripper that records every interaction to a cassette.
+// Interactions are kept in memory until `Stop` writes the cassette
+type RecordingTransport struct {
+	cassette *Cassette
+	config   *config
+}
+
+// NewRecorder returns a `RecordingTransport` that writes interactions to the cassette at path
+// when it is stopped
+func NewRecorder(path string, opts ...Option) *RecordingTransport {
+	return &RecordingTransport{
+		cassette: &Cassette{path: path},
+		config:   newConfig(opts...),
+	}
+}
+
+// Cassette returns the cassette being recorded
+func (t *RecordingTransport) Cassette() *Cassette {
+	return t.cassette
+}
+
+// Stop writes the interactions recorded so far to the cassette
+func (t *RecordingTransport) Stop() error {
+	return t.cassette.Save()
+}
+
+// RoundTrip performs the request and records the interaction
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.config.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	i := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: transport.RedactHeader(req.Header, t.config.redact...),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: transport.RedactHeader(resp.Header, t.config.redact...),
+		},
+	}
+	i.Request.Body, i.Request.BodyEncoding = encodeBody(reqBody)
+	i.Response.Body, i.Response.BodyEncoding = encodeBody(respBody)
+	t.cassette.add(i)
+	return resp, nil
+}
+
+// ReplayTransport is an http.RoundTripper that serves responses from a cassette
+// without making any real requests
+type ReplayTransport struct {
+	cassette *Cassette
+	config   *config
+	used     map[*Interaction]bool
+}
+
+// NewReplayer returns a `ReplayTransport` serving the cassette at path
+func NewReplayer(path string, opts ...Option) (*ReplayTransport, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{
+		cassette: c,
+		config:   newConfig(opts...),
+		used:     make(map[*Interaction]bool),
+	}, nil
+}
+
+// RoundTrip returns the recorded response for the first matching interaction.
+// Interactions that haven't been replayed yet are preferred so repeated requests
+// are served in recording order
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	t.cassette.Lock()
+	defer t.cassette.Unlock()
+	var match *Interaction
+	for _, i := range t.cassette.Interactions {
+		if !t.config.matches(req, body, i) {
+			continue
+		}
+		if !t.used[i] {
+			match = i
+			break
+		}
+		if match == nil {
+			match = i
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrInteractionNotFound)
+	}
+	respBody, err := decodeBody(match.Response.Body, match.Response.BodyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, err)
+	}
+	t.used[match] = true
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Response.Status, http.StatusText(match.Response.Status)),
+		StatusCode:    match.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        match.Response.Headers.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// readBody reads and replaces body so it can still be consumed by the caller
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,99 @@
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecord(t *testing.T, path string) string {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	}))
+	defer ts.Close()
+	recorder := NewRecorder(path)
+	client := &http.Client{Transport: recorder}
+	_, err := httpclient.Get(ts.URL+"/first", httpclient.SetClient(client),
+		httpclient.AddHeaders(map[string]string{"Authorization": "Bearer secret"}))
+	assert.NoError(t, err)
+	_, err = httpclient.Post(ts.URL+"/second", httpclient.SetClient(client),
+		httpclient.WithBody(strings.NewReader("payload")))
+	assert.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "the cassette is only written on Stop")
+	assert.NoError(t, recorder.Stop())
+	return ts.URL
+}
+
+func TestRecordReplay(t *testing.T) {
+	for _, name := range []string{"cassette.json", "cassette.yaml"} {
+		path := filepath.Join(t.TempDir(), name)
+		url := testRecord(t, path)
+
+		c, err := Load(path)
+		assert.NoError(t, err)
+		assert.Len(t, c.Interactions, 2)
+		assert.Equal(t, Redacted, c.Interactions[0].Request.Headers.Get("Authorization"))
+
+		replayer, err := NewReplayer(path)
+		assert.NoError(t, err)
+		client := &http.Client{Transport: replayer}
+		res, err := httpclient.Post(url+"/second", httpclient.SetClient(client))
+		assert.NoError(t, err)
+		assert.Equal(t, "POST /second payload", string(res.Body))
+		res, err = httpclient.Get(url+"/first", httpclient.SetClient(client))
+		assert.NoError(t, err)
+		assert.Equal(t, "GET /first ", string(res.Body))
+	}
+}
+
+func TestReplayMatchOnBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	url := testRecord(t, path)
+	replayer, err := NewReplayer(path, MatchOn(MatchMethod, MatchURL, MatchBody))
+	assert.NoError(t, err)
+	client := &http.Client{Transport: replayer}
+	_, err = httpclient.Post(url+"/second", httpclient.SetClient(client),
+		httpclient.WithBody(strings.NewReader("other payload")))
+	assert.True(t, errors.Is(err, ErrInteractionNotFound))
+}
+
+func TestRecordReplayBinary(t *testing.T) {
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(append(body, 0x80))
+	}))
+	defer ts.Close()
+	for _, name := range []string{"cassette.json", "cassette.yaml"} {
+		path := filepath.Join(t.TempDir(), name)
+		recorder := NewRecorder(path)
+		_, err := httpclient.Post(ts.URL, httpclient.SetClient(&http.Client{Transport: recorder}),
+			httpclient.WithBody(bytes.NewReader(binary)))
+		assert.NoError(t, err)
+		assert.NoError(t, recorder.Stop())
+
+		c, err := Load(path)
+		assert.NoError(t, err)
+		assert.Equal(t, Base64, c.Interactions[0].Request.BodyEncoding)
+		assert.Equal(t, Base64, c.Interactions[0].Response.BodyEncoding)
+
+		replayer, err := NewReplayer(path, MatchOn(MatchMethod, MatchURL, MatchBody))
+		assert.NoError(t, err)
+		res, err := httpclient.Post(ts.URL, httpclient.SetClient(&http.Client{Transport: replayer}),
+			httpclient.WithBody(bytes.NewReader(binary)))
+		assert.NoError(t, err)
+		assert.Equal(t, append(binary, 0x80), res.Body)
+	}
+}
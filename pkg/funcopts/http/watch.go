@@ -0,0 +1,179 @@
+package httpclient
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+const (
+	// DefaultWatchBackoff is how long `Watch` waits before polling again after a failure
+	DefaultWatchBackoff = time.Second
+	// DefaultMaxWatchBackoff caps the wait of `Watch` after repeated failures
+	DefaultMaxWatchBackoff = 30 * time.Second
+)
+
+// WatchEvent is a response, or the failure of a poll, sent by `Watch`
+type WatchEvent struct {
+	Response *Response
+	// Token is the resume token the next poll is sent with
+	Token string
+	Err   error
+}
+
+// watchConfig is how `Watch` long-polls a resource
+type watchConfig struct {
+	timeoutParam string
+	timeout      string
+	tokenParam   string
+	tokenFunc    CursorFunc
+	backoff      time.Duration
+	maxBackoff   time.Duration
+}
+
+// WatchTimeout asks the server to hold each poll of `Watch` for up to d with the query
+// param param, in whole seconds as Kubernetes' timeoutSeconds expects. Use `WatchWait`
+// for APIs expecting a duration. The `Timeout` of the request must be longer than d
+func WatchTimeout(param string, d time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.watch.timeoutParam = param
+		r.watch.timeout = strconv.Itoa(int(d / time.Second))
+		return nil
+	}
+}
+
+// WatchWait asks the server to hold each poll of `Watch` for up to d with the query
+// param param, as a duration such as `5m0s` that Consul's wait expects
+func WatchWait(param string, d time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.watch.timeoutParam = param
+		r.watch.timeout = d.String()
+		return nil
+	}
+}
+
+// ResumeToken has `Watch` send the token fn extracts from each response as the query
+// param param of the next poll, so the server only answers once something changed.
+// An empty token keeps the previous one
+//
+//	httpclient.ResumeToken("index", func(res *httpclient.Response) (string, error) {
+//		return res.Headers.Get("X-Consul-Index"), nil
+//	})
+func ResumeToken(param string, fn CursorFunc) RequestOption {
+	return func(r *Request) error {
+		r.watch.tokenParam = param
+		r.watch.tokenFunc = fn
+		return nil
+	}
+}
+
+// WatchBackoff sets how long `Watch` waits after a failed poll. The wait doubles, with
+// jitter, for every failure in a row up to max
+func WatchBackoff(initial, max time.Duration) RequestOption {
+	return func(r *Request) error {
+		r.watch.backoff = initial
+		r.watch.maxBackoff = max
+		return nil
+	}
+}
+
+// Watch long-polls url with GET requests until ctx is done, sending each response on the
+// returned channel and polling again straight away. Polls that fail, or get a 4xx or 5xx
+// status, are sent too and retried after a backoff, see `WatchBackoff`. A 410 Gone response drops the
+// resume token, so the watch starts over as Kubernetes expects when a resourceVersion
+// is too old. The channel is closed when ctx is done or the options are invalid
+//
+//	events := Watch(ctx, "http://127.0.0.1:8500/v1/kv/app?recurse",
+//		WatchWait("wait", 5*time.Minute), Timeout(6*time.Minute),
+//		ResumeToken("index", consulIndex))
+//	for ev := range events {
+//		...
+//	}
+func Watch(ctx context.Context, url string, opts ...RequestOption) <-chan WatchEvent {
+	ch := make(chan WatchEvent)
+	clk := clock.FromContext(ctx)
+	go func() {
+		defer close(ch)
+		var token string
+		failures := 0
+		for {
+			pollOpts := append(append([]RequestOption{}, opts...), WithContext(ctx), watchParams(token), get(), setURL(url))
+			cr, res, err := do(pollOpts...)
+			if ctx.Err() != nil {
+				return
+			}
+			if cr == nil {
+				select {
+				case ch <- WatchEvent{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			event := WatchEvent{Response: res, Err: err}
+			switch {
+			case res != nil && res.Status == http.StatusGone:
+				token = ""
+			case err == nil && cr.watch.tokenFunc != nil:
+				next, tokenErr := cr.watch.tokenFunc(res)
+				if next != "" {
+					token = next
+				}
+				event.Err = tokenErr
+			}
+			event.Token = token
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+			if event.Err == nil && (res.Status < 400 || res.Status == http.StatusGone) {
+				failures = 0
+				continue
+			}
+			failures++
+			if err := clk.Sleep(ctx, cr.watch.delay(failures)); err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// watchParams adds the timeout and resume token to the query params without
+// clobbering any params provided by the caller
+func watchParams(token string) RequestOption {
+	return func(r *Request) error {
+		qp := make(map[string]string, len(r.queryParams)+2)
+		for k, v := range r.queryParams {
+			qp[k] = v
+		}
+		if r.watch.timeoutParam != "" {
+			qp[r.watch.timeoutParam] = r.watch.timeout
+		}
+		if r.watch.tokenParam != "" && token != "" {
+			qp[r.watch.tokenParam] = token
+		}
+		r.queryParams = qp
+		return nil
+	}
+}
+
+// delay returns how long to wait after failures polls failed in a row
+func (w watchConfig) delay(failures int) time.Duration {
+	initial, max := w.backoff, w.maxBackoff
+	if initial <= 0 {
+		initial = DefaultWatchBackoff
+	}
+	if max <= 0 {
+		max = DefaultMaxWatchBackoff
+	}
+	d := initial << uint(failures-1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
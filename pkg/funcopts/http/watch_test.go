@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		n := len(queries)
+		mu.Unlock()
+		switch n {
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case 4:
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.Header().Set("X-Index", strconv.Itoa(n*10))
+		w.Write([]byte("state " + strconv.Itoa(n)))
+	}))
+	defer ts.Close()
+	clk := clock.NewFake(time.Now())
+	ctx, cancel := context.WithCancel(clock.NewContext(context.Background(), clk))
+	defer cancel()
+	events := Watch(ctx, ts.URL, WatchWait("wait", 5*time.Minute), QueryParams(map[string]string{"recurse": "true"}),
+		ResumeToken("index", func(res *Response) (string, error) {
+			return res.Headers.Get("X-Index"), nil
+		}))
+
+	ev := <-events
+	require.NoError(t, ev.Err)
+	assert.Equal(t, "state 1", string(ev.Response.Body))
+	assert.Equal(t, "10", ev.Token)
+	ev = <-events
+	assert.Equal(t, http.StatusServiceUnavailable, ev.Response.Status)
+	assert.Equal(t, "10", ev.Token)
+	ev = <-events
+	assert.Equal(t, "30", ev.Token)
+	ev = <-events
+	assert.Equal(t, http.StatusGone, ev.Response.Status)
+	assert.Equal(t, "", ev.Token)
+	ev = <-events
+	assert.Equal(t, "50", ev.Token)
+	cancel()
+	for range events {
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"recurse=true&wait=5m0s",
+		"index=10&recurse=true&wait=5m0s",
+		"index=10&recurse=true&wait=5m0s",
+		"index=30&recurse=true&wait=5m0s",
+		"recurse=true&wait=5m0s",
+	}, queries[:5])
+	require.Len(t, clk.Sleeps(), 1)
+	assert.True(t, clk.Sleeps()[0] <= DefaultWatchBackoff)
+}
+
+func TestWatchTimeout(t *testing.T) {
+	var r Request
+	require.NoError(t, WatchTimeout("timeoutSeconds", 5*time.Minute)(&r))
+	assert.Equal(t, "300", r.watch.timeout)
+}
+
+func TestWatchBackoff(t *testing.T) {
+	w := watchConfig{backoff: time.Second, maxBackoff: 4 * time.Second}
+	d := w.delay(2)
+	assert.True(t, d >= time.Second && d <= 2*time.Second)
+	d = w.delay(10)
+	assert.True(t, d >= 2*time.Second && d <= 4*time.Second)
+}
+
+func TestWatchInvalidOptions(t *testing.T) {
+	events := Watch(context.Background(), "", JSON(), ContentType("text/plain"))
+	ev := <-events
+	assert.True(t, errors.Is(ev.Err, ErrOptionConflict))
+	_, open := <-events
+	assert.False(t, open)
+}
@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	httpclient "github.com/lusis/go-experiments/pkg/funcopts/http"
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+// ErrDeliveryFailed is the error returned when a webhook isn't accepted after all attempts
+// or is rejected with a status that isn't retried
+var ErrDeliveryFailed = errors.New("webhook delivery failed")
+
+// Attempt records one try to deliver a webhook
+type Attempt struct {
+	Number   int
+	Time     time.Time
+	Duration time.Duration
+	// Status is the response status, 0 when no response was received
+	Status int
+	Err    error
+}
+
+// Delivery records a webhook and the attempts to deliver it
+type Delivery struct {
+	// ID identifies the webhook across attempts so receivers can drop duplicates
+	ID        string
+	URL       string
+	Payload   []byte
+	Attempts  []Attempt
+	Delivered bool
+}
+
+type config struct {
+	scheme      Scheme
+	client      *httpclient.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	deadLetter  func(*Delivery)
+	onAttempt   func(*Delivery, Attempt)
+	opts        []httpclient.RequestOption
+}
+
+// Option configures a `Sender`
+type Option func(*config)
+
+// WithScheme signs webhooks with s instead of `Svix`
+func WithScheme(s Scheme) Option {
+	return func(cfg *config) {
+		cfg.scheme = s
+	}
+}
+
+// Client sends webhooks with c
+func Client(c *httpclient.Client) Option {
+	return func(cfg *config) {
+		cfg.client = c
+	}
+}
+
+// MaxAttempts sets how many times a webhook is sent before it's given up on. The default
+// is 5, n below 1 sends it once
+func MaxAttempts(n int) Option {
+	return func(cfg *config) {
+		if n < 1 {
+			n = 1
+		}
+		cfg.maxAttempts = n
+	}
+}
+
+// Backoff waits base after the first failed attempt, doubling for every further attempt
+// up to max. The default is 1s up to 5m
+func Backoff(base, max time.Duration) Option {
+	return func(cfg *config) {
+		cfg.backoff, cfg.maxBackoff = base, max
+	}
+}
+
+// DeadLetter calls fn with webhooks that couldn't be delivered, e.g. to store them for
+// a later replay
+func DeadLetter(fn func(*Delivery)) Option {
+	return func(cfg *config) {
+		cfg.deadLetter = fn
+	}
+}
+
+// OnAttempt calls fn after every attempt, e.g. to persist delivery logs
+func OnAttempt(fn func(*Delivery, Attempt)) Option {
+	return func(cfg *config) {
+		cfg.onAttempt = fn
+	}
+}
+
+// RequestOptions applies opts to every webhook request
+func RequestOptions(opts ...httpclient.RequestOption) Option {
+	return func(cfg *config) {
+		cfg.opts = append(cfg.opts, opts...)
+	}
+}
+
+// Sender signs and delivers webhooks
+type Sender struct {
+	secret []byte
+	cfg    *config
+}
+
+// NewSender returns a `Sender` signing webhooks with secret
+func NewSender(secret []byte, opts ...Option) *Sender {
+	cfg := &config{scheme: Svix, maxAttempts: 5, backoff: time.Second, maxBackoff: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.client == nil {
+		cfg.client = httpclient.NewClient()
+	}
+	return &Sender{secret: secret, cfg: cfg}
+}
+
+// Deliver posts event as json to url until it's answered with a 2xx status. Connection
+// errors and 408, 429 and 5xx responses are retried with backoff, other responses fail
+// the delivery right away. Every attempt is signed again with its own timestamp. The
+// returned `Delivery` records the attempts even when delivery fails, in which case the
+// dead letter callback has been called
+func (s *Sender) Deliver(ctx context.Context, url string, event interface{}) (*Delivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	d := &Delivery{ID: "msg_" + hex.EncodeToString(id), URL: url, Payload: payload}
+	clk := clock.FromContext(ctx)
+	delay := s.cfg.backoff
+	for n := 1; n <= s.cfg.maxAttempts; n++ {
+		attempt := s.attempt(ctx, clk, d, n)
+		d.Attempts = append(d.Attempts, attempt)
+		if s.cfg.onAttempt != nil {
+			s.cfg.onAttempt(d, attempt)
+		}
+		if attempt.Err == nil && attempt.Status >= 200 && attempt.Status < 300 {
+			d.Delivered = true
+			return d, nil
+		}
+		if !retryable(attempt) || n == s.cfg.maxAttempts || ctx.Err() != nil {
+			break
+		}
+		if err := clk.Sleep(ctx, delay); err != nil {
+			break
+		}
+		if delay *= 2; delay > s.cfg.maxBackoff {
+			delay = s.cfg.maxBackoff
+		}
+	}
+	if s.cfg.deadLetter != nil {
+		s.cfg.deadLetter(d)
+	}
+	last := d.Attempts[len(d.Attempts)-1]
+	if last.Err != nil {
+		return d, fmt.Errorf("%w after %d attempts: %w", ErrDeliveryFailed, len(d.Attempts), last.Err)
+	}
+	return d, fmt.Errorf("%w after %d attempts: status %d", ErrDeliveryFailed, len(d.Attempts), last.Status)
+}
+
+func (s *Sender) attempt(ctx context.Context, clk clock.Clock, d *Delivery, n int) Attempt {
+	start := clk.Now()
+	headers := s.cfg.scheme.sign(s.secret, d.ID, start, d.Payload)
+	opts := append([]httpclient.RequestOption{
+		httpclient.WithContext(ctx),
+		httpclient.ContentType(httpclient.ContentTypeJSON),
+		httpclient.AddHeaders(headers),
+		httpclient.WithBody(bytes.NewReader(d.Payload)),
+	}, s.cfg.opts...)
+	res, err := s.cfg.client.Post(d.URL, opts...)
+	attempt := Attempt{Number: n, Time: start, Duration: clk.Now().Sub(start), Err: err}
+	if res != nil {
+		attempt.Status = res.Status
+	}
+	return attempt
+}
+
+// retryable reports whether a failed attempt is worth repeating
+func retryable(a Attempt) bool {
+	switch {
+	case a.Status == 0:
+		return true
+	case a.Status == http.StatusRequestTimeout, a.Status == http.StatusTooManyRequests:
+		return true
+	}
+	return a.Status >= 500
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver answers with the statuses in order, then 200
+type receiver struct {
+	sync.Mutex
+	statuses []int
+	ids      []string
+	stamps   []string
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+	body, _ := ioutil.ReadAll(req.Body)
+	r.ids = append(r.ids, req.Header.Get("svix-id"))
+	r.stamps = append(r.stamps, req.Header.Get("svix-timestamp"))
+	r.bodies = append(r.bodies, string(body))
+	if len(r.statuses) > 0 {
+		w.WriteHeader(r.statuses[0])
+		r.statuses = r.statuses[1:]
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	rec := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	var logged []int
+	s := NewSender([]byte("secret"), Backoff(time.Second, time.Minute), OnAttempt(func(d *Delivery, a Attempt) {
+		logged = append(logged, a.Status)
+	}))
+	d, err := s.Deliver(clock.NewContext(context.Background(), clk), ts.URL, map[string]string{"type": "invoice.paid"})
+	require.NoError(t, err)
+	assert.True(t, d.Delivered)
+	assert.Equal(t, []int{503, 429, 200}, logged)
+	require.Len(t, d.Attempts, 3)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clk.Sleeps())
+	assert.Equal(t, []string{d.ID, d.ID, d.ID}, rec.ids, "the id is kept across attempts")
+	assert.Equal(t, []string{"1700000000", "1700000001", "1700000003"}, rec.stamps, "each attempt is signed again")
+	assert.Equal(t, `{"type":"invoice.paid"}`, rec.bodies[2])
+}
+
+func TestDeliverDeadLetter(t *testing.T) {
+	tests := map[string]struct {
+		statuses []int
+		attempts int
+	}{
+		"exhausted": {statuses: []int{500, 502, 504}, attempts: 3},
+		"rejected":  {statuses: []int{410}, attempts: 1},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(&receiver{statuses: tc.statuses})
+			defer ts.Close()
+			var dead *Delivery
+			s := NewSender([]byte("secret"), MaxAttempts(3), DeadLetter(func(d *Delivery) { dead = d }))
+			ctx := clock.NewContext(context.Background(), clock.NewFake(time.Now()))
+			d, err := s.Deliver(ctx, ts.URL, "event")
+			assert.True(t, errors.Is(err, ErrDeliveryFailed))
+			assert.Same(t, d, dead)
+			assert.False(t, d.Delivered)
+			assert.Len(t, d.Attempts, tc.attempts)
+			assert.Equal(t, tc.statuses[tc.attempts-1], d.Attempts[tc.attempts-1].Status)
+		})
+	}
+}
+
+func TestDeliverMaxAttemptsBelowOne(t *testing.T) {
+	ts := httptest.NewServer(&receiver{statuses: []int{500}})
+	defer ts.Close()
+	d, err := NewSender([]byte("secret"), MaxAttempts(0)).Deliver(context.Background(), ts.URL, "event")
+	assert.True(t, errors.Is(err, ErrDeliveryFailed))
+	assert.Len(t, d.Attempts, 1)
+}
@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+)
+
+var (
+	// ErrMissingSignature is the error for webhooks without the signature headers of the scheme
+	ErrMissingSignature = errors.New("webhook signature missing")
+	// ErrInvalidSignature is the error for webhooks whose signature doesn't match any secret
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+	// ErrTimestampOutOfRange is the error for webhooks signed longer ago, or further in the
+	// future, than the tolerance
+	ErrTimestampOutOfRange = errors.New("webhook timestamp outside tolerance")
+	// ErrReplayed is the error for webhooks that have been received before
+	ErrReplayed = errors.New("webhook replayed")
+)
+
+const (
+	// DefaultTolerance is how far the timestamp of a webhook may be from now
+	DefaultTolerance = 5 * time.Minute
+	// DefaultMaxBodySize is the largest body `Verify` reads
+	DefaultMaxBodySize = 1 << 20
+)
+
+// ReplayCache remembers the webhooks received
+type ReplayCache interface {
+	// Seen records key until expires and reports whether it was already recorded
+	Seen(key string, expires time.Time) bool
+}
+
+// MemoryReplayCache is a `ReplayCache` in memory, for services with a single instance
+type MemoryReplayCache struct {
+	clock clock.Clock
+	sync.Mutex
+	keys map[string]time.Time
+}
+
+// NewMemoryReplayCache returns an empty `MemoryReplayCache` that tells the time with c,
+// or the real clock when c is nil
+func NewMemoryReplayCache(c clock.Clock) *MemoryReplayCache {
+	if c == nil {
+		c = clock.Real
+	}
+	return &MemoryReplayCache{clock: c, keys: map[string]time.Time{}}
+}
+
+// Seen records key until expires and reports whether it was already recorded. Expired
+// keys are dropped
+func (m *MemoryReplayCache) Seen(key string, expires time.Time) bool {
+	m.Lock()
+	defer m.Unlock()
+	now := m.clock.Now()
+	for k, exp := range m.keys {
+		if !now.Before(exp) {
+			delete(m.keys, k)
+		}
+	}
+	if _, ok := m.keys[key]; ok {
+		return true
+	}
+	m.keys[key] = expires
+	return false
+}
+
+// Verifier checks the signatures of received webhooks
+type Verifier struct {
+	secrets   [][]byte
+	scheme    Scheme
+	tolerance time.Duration
+	maxBody   int64
+	replay    ReplayCache
+	clock     clock.Clock
+}
+
+// VerifierOption configures a `Verifier`
+type VerifierOption func(*Verifier)
+
+// VerifyScheme checks signatures of scheme s instead of `Svix`
+func VerifyScheme(s Scheme) VerifierOption {
+	return func(v *Verifier) {
+		v.scheme = s
+	}
+}
+
+// Secrets also accepts webhooks signed with secrets, e.g. while a secret is rotated
+func Secrets(secrets ...[]byte) VerifierOption {
+	return func(v *Verifier) {
+		v.secrets = append(v.secrets, secrets...)
+	}
+}
+
+// Tolerance accepts timestamps up to d from now instead of `DefaultTolerance`
+func Tolerance(d time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.tolerance = d
+	}
+}
+
+// MaxBodySize rejects webhooks with a body over n bytes instead of `DefaultMaxBodySize`
+func MaxBodySize(n int64) VerifierOption {
+	return func(v *Verifier) {
+		v.maxBody = n
+	}
+}
+
+// Replay rejects webhooks already recorded in c instead of in a `MemoryReplayCache`.
+// Services with more than one instance need a shared cache
+func Replay(c ReplayCache) VerifierOption {
+	return func(v *Verifier) {
+		v.replay = c
+	}
+}
+
+// VerifyClock tells the time with c
+func VerifyClock(c clock.Clock) VerifierOption {
+	return func(v *Verifier) {
+		v.clock = c
+	}
+}
+
+// NewVerifier returns a `Verifier` for webhooks signed with secret
+func NewVerifier(secret []byte, opts ...VerifierOption) *Verifier {
+	v := &Verifier{secrets: [][]byte{secret}, scheme: Svix, tolerance: DefaultTolerance, maxBody: DefaultMaxBodySize, clock: clock.Real}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if v.replay == nil {
+		v.replay = NewMemoryReplayCache(v.clock)
+	}
+	return v
+}
+
+// Verify reads the body of r and checks its signature, timestamp and that it hasn't
+// been received before. Signatures are compared in constant time. The body is returned
+// and put back on r so handlers can read it again. Schemes without a timestamp, like
+// `GitHub`, only have their delivery id checked for replays. Bodies over `MaxBodySize`
+// fail with an `*http.MaxBytesError`
+func (v *Verifier) Verify(r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, v.maxBody))
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	id, ts, err := v.scheme.verify(v.secrets, r.Header, body)
+	if err != nil {
+		return nil, err
+	}
+	now := v.clock.Now()
+	expires := now.Add(v.tolerance)
+	if !ts.IsZero() {
+		if ts.Before(now.Add(-v.tolerance)) || ts.After(now.Add(v.tolerance)) {
+			return nil, ErrTimestampOutOfRange
+		}
+		expires = ts.Add(v.tolerance)
+	}
+	if id != "" && v.replay.Seen(v.scheme.name+":"+id, expires) {
+		return nil, ErrReplayed
+	}
+	return body, nil
+}
+
+// Middleware answers webhooks that fail `Verify` with a 401, or a 413 when the body is
+// too large, and passes the others to next
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := v.Verify(r); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lusis/go-experiments/pkg/funcopts/http/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signed returns a request for body signed with scheme at ts
+func signed(scheme Scheme, secret string, ts time.Time, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader([]byte(body)))
+	for k, v := range scheme.sign([]byte(secret), "msg_1", ts, []byte(body)) {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	for _, scheme := range []Scheme{GitHub, Stripe, Svix} {
+		t.Run(scheme.String(), func(t *testing.T) {
+			var got []byte
+			v := NewVerifier([]byte("secret"), VerifyScheme(scheme))
+			ts := httptest.NewServer(v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = ioutil.ReadAll(r.Body)
+			})))
+			defer ts.Close()
+			s := NewSender([]byte("secret"), WithScheme(scheme), MaxAttempts(1))
+			_, err := s.Deliver(context.Background(), ts.URL, map[string]int{"n": 1})
+			require.NoError(t, err)
+			assert.Equal(t, `{"n":1}`, string(got))
+
+			_, err = NewSender([]byte("wrong"), WithScheme(scheme), MaxAttempts(1)).Deliver(context.Background(), ts.URL, "x")
+			assert.True(t, errors.Is(err, ErrDeliveryFailed))
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clk := clock.NewFake(now)
+	tests := map[string]struct {
+		req  *http.Request
+		opts []VerifierOption
+		err  error
+	}{
+		"valid":            {req: signed(Svix, "secret", now, "{}")},
+		"rotated secret":   {req: signed(Svix, "old", now, "{}"), opts: []VerifierOption{Secrets([]byte("old"))}},
+		"wrong secret":     {req: signed(Svix, "old", now, "{}"), err: ErrInvalidSignature},
+		"too old":          {req: signed(Svix, "secret", now.Add(-6*time.Minute), "{}"), err: ErrTimestampOutOfRange},
+		"within tolerance": {req: signed(Stripe, "secret", now.Add(-6*time.Minute), "{}"), opts: []VerifierOption{VerifyScheme(Stripe), Tolerance(10 * time.Minute)}},
+		"in the future":    {req: signed(Stripe, "secret", now.Add(6*time.Minute), "{}"), opts: []VerifierOption{VerifyScheme(Stripe)}, err: ErrTimestampOutOfRange},
+		"unsigned":         {req: httptest.NewRequest(http.MethodPost, "/hook", nil), opts: []VerifierOption{VerifyScheme(GitHub)}, err: ErrMissingSignature},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			v := NewVerifier([]byte("secret"), append(tc.opts, VerifyClock(clk))...)
+			_, err := v.Verify(tc.req)
+			if tc.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.err), "got %v", err)
+		})
+	}
+}
+
+func TestVerifyTamperedBody(t *testing.T) {
+	r := signed(GitHub, "secret", time.Time{}, `{"amount":1}`)
+	r.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"amount":100}`)))
+	_, err := NewVerifier([]byte("secret"), VerifyScheme(GitHub)).Verify(r)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+}
+
+func TestVerifyMaxBodySize(t *testing.T) {
+	v := NewVerifier([]byte("secret"), VerifyScheme(GitHub), MaxBodySize(4))
+	_, err := v.Verify(signed(GitHub, "secret", time.Time{}, `{"a":1}`))
+	var tooLarge *http.MaxBytesError
+	assert.True(t, errors.As(err, &tooLarge), "got %v", err)
+
+	w := httptest.NewRecorder()
+	v.Middleware(http.NotFoundHandler()).ServeHTTP(w, signed(GitHub, "secret", time.Time{}, `{"a":1}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestVerifyReplay(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	v := NewVerifier([]byte("secret"), VerifyClock(clk))
+	body, err := v.Verify(signed(Svix, "secret", clk.Now(), "{}"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(body))
+	_, err = v.Verify(signed(Svix, "secret", clk.Now(), "{}"))
+	assert.True(t, errors.Is(err, ErrReplayed))
+
+	clk.Advance(DefaultTolerance)
+	_, err = v.Verify(signed(Svix, "secret", clk.Now(), "{}"))
+	assert.NoError(t, err, "expired ids are forgotten")
+}
+
+func TestVerifyStripeReplayReorderedSignatures(t *testing.T) {
+	clk := clock.NewFake(time.Unix(1700000000, 0))
+	v := NewVerifier([]byte("secret"), VerifyScheme(Stripe), VerifyClock(clk))
+	r := signed(Stripe, "secret", clk.Now(), "{}")
+	header := r.Header.Get("Stripe-Signature")
+	_, err := v.Verify(r)
+	require.NoError(t, err)
+
+	r = signed(Stripe, "secret", clk.Now(), "{}")
+	t0, v1, _ := strings.Cut(header, ",")
+	r.Header.Set("Stripe-Signature", t0+",v1="+strings.Repeat("0", 64)+","+v1)
+	_, err = v.Verify(r)
+	assert.True(t, errors.Is(err, ErrReplayed), "got %v", err)
+}
+
+func TestVerifyStandardWebhooksHeaders(t *testing.T) {
+	r := signed(Svix, "secret", time.Now(), "{}")
+	for _, name := range []string{"id", "timestamp", "signature"} {
+		r.Header.Set("webhook-"+name, r.Header.Get("svix-"+name))
+		r.Header.Del("svix-" + name)
+	}
+	_, err := NewVerifier([]byte("secret")).Verify(r)
+	assert.NoError(t, err)
+}
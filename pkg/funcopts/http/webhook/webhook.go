@@ -0,0 +1,175 @@
+// Package webhook delivers signed webhooks with retries and verifies the webhooks a
+// service receives
+//
+//	s := webhook.NewSender(secret, webhook.WithScheme(webhook.Stripe), webhook.DeadLetter(func(d *webhook.Delivery) {
+//		log.Printf("giving up on %s after %d attempts", d.ID, len(d.Attempts))
+//	}))
+//	delivery, err := s.Deliver(ctx, endpoint, event)
+//
+//	v := webhook.NewVerifier(secret, webhook.VerifyScheme(webhook.GitHub))
+//	http.Handle("/hooks/github", v.Middleware(handler))
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scheme is how a webhook is signed
+type Scheme struct {
+	name string
+	sign func(secret []byte, id string, ts time.Time, body []byte) map[string]string
+	// verify checks the signature of a received webhook with any of the secrets and
+	// returns its id and timestamp, zero when the scheme doesn't send them
+	verify func(secrets [][]byte, h http.Header, body []byte) (string, time.Time, error)
+}
+
+// String returns the name of the scheme
+func (s Scheme) String() string {
+	return s.name
+}
+
+var (
+	// GitHub signs the body in an `X-Hub-Signature-256` header and sends the delivery id
+	// as `X-GitHub-Delivery`
+	GitHub = Scheme{name: "github", sign: func(secret []byte, id string, _ time.Time, body []byte) map[string]string {
+		return map[string]string{
+			"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(mac(secret, body)),
+			"X-GitHub-Delivery":   id,
+		}
+	}, verify: func(secrets [][]byte, h http.Header, body []byte) (string, time.Time, error) {
+		hexSig, ok := strings.CutPrefix(h.Get("X-Hub-Signature-256"), "sha256=")
+		if !ok {
+			return "", time.Time{}, ErrMissingSignature
+		}
+		sig, err := hex.DecodeString(hexSig)
+		if err != nil || !anyMAC(secrets, [][]byte{sig}, body) {
+			return "", time.Time{}, ErrInvalidSignature
+		}
+		return h.Get("X-GitHub-Delivery"), time.Time{}, nil
+	}}
+	// Stripe signs the timestamp and body in a `Stripe-Signature` header
+	Stripe = Scheme{name: "stripe", sign: func(secret []byte, _ string, ts time.Time, body []byte) map[string]string {
+		t := strconv.FormatInt(ts.Unix(), 10)
+		return map[string]string{
+			"Stripe-Signature": "t=" + t + ",v1=" + hex.EncodeToString(mac(secret, []byte(t+"."), body)),
+		}
+	}, verify: func(secrets [][]byte, h http.Header, body []byte) (string, time.Time, error) {
+		var t string
+		var sigs [][]byte
+		for _, part := range strings.Split(h.Get("Stripe-Signature"), ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "t":
+				t = v
+			case "v1":
+				if sig, err := hex.DecodeString(v); err == nil {
+					sigs = append(sigs, sig)
+				}
+			}
+		}
+		if t == "" || len(sigs) == 0 {
+			return "", time.Time{}, ErrMissingSignature
+		}
+		ts, err := parseUnix(t)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if !anyMAC(secrets, sigs, []byte(t+"."), body) {
+			return "", time.Time{}, ErrInvalidSignature
+		}
+		// stripe doesn't send an id outside the body, key replays on the timestamp and body
+		// so reordering or adding v1 signatures doesn't make a new delivery
+		sum := sha256.Sum256(body)
+		return t + "." + hex.EncodeToString(sum[:]), ts, nil
+	}}
+	// Svix signs the delivery id, timestamp and body in the `svix-id`, `svix-timestamp`
+	// and `svix-signature` headers of the Standard Webhooks spec. Secrets starting with
+	// `whsec_` are base64 decoded
+	Svix = Scheme{name: "svix", sign: func(secret []byte, id string, ts time.Time, body []byte) map[string]string {
+		t := strconv.FormatInt(ts.Unix(), 10)
+		sig := mac(svixKey(secret), []byte(id+"."+t+"."), body)
+		return map[string]string{
+			"svix-id":        id,
+			"svix-timestamp": t,
+			"svix-signature": "v1," + base64.StdEncoding.EncodeToString(sig),
+		}
+	}, verify: func(secrets [][]byte, h http.Header, body []byte) (string, time.Time, error) {
+		prefix := "svix-"
+		if h.Get("svix-signature") == "" {
+			prefix = "webhook-"
+		}
+		id, t := h.Get(prefix+"id"), h.Get(prefix+"timestamp")
+		var sigs [][]byte
+		for _, part := range strings.Fields(h.Get(prefix + "signature")) {
+			if b64, ok := strings.CutPrefix(part, "v1,"); ok {
+				if sig, err := base64.StdEncoding.DecodeString(b64); err == nil {
+					sigs = append(sigs, sig)
+				}
+			}
+		}
+		if id == "" || t == "" || len(sigs) == 0 {
+			return "", time.Time{}, ErrMissingSignature
+		}
+		ts, err := parseUnix(t)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		keys := make([][]byte, len(secrets))
+		for i, secret := range secrets {
+			keys[i] = svixKey(secret)
+		}
+		if !anyMAC(keys, sigs, []byte(id+"."+t+"."), body) {
+			return "", time.Time{}, ErrInvalidSignature
+		}
+		return id, ts, nil
+	}}
+)
+
+// mac returns the HMAC-SHA256 of the parts with key
+func mac(key []byte, parts ...[]byte) []byte {
+	h := hmac.New(sha256.New, key)
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// anyMAC reports whether any of sigs is the HMAC-SHA256 of the parts with any of the
+// keys, comparing in constant time
+func anyMAC(keys, sigs [][]byte, parts ...[]byte) bool {
+	for _, key := range keys {
+		want := mac(key, parts...)
+		for _, sig := range sigs {
+			if hmac.Equal(sig, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseUnix(s string) (time.Time, error) {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: timestamp %q", ErrInvalidSignature, s)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// svixKey decodes `whsec_` prefixed secrets
+func svixKey(secret []byte) []byte {
+	if s, ok := strings.CutPrefix(string(secret), "whsec_"); ok {
+		if key, err := base64.StdEncoding.DecodeString(s); err == nil {
+			return key
+		}
+	}
+	return secret
+}
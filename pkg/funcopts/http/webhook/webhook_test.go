@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemes(t *testing.T) {
+	t.Run("github", func(t *testing.T) {
+		// the example from GitHub's webhook validation docs
+		h := GitHub.sign([]byte("It's a Secret to Everybody"), "d1", time.Time{}, []byte("Hello, World!"))
+		assert.Equal(t, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", h["X-Hub-Signature-256"])
+		assert.Equal(t, "d1", h["X-GitHub-Delivery"])
+	})
+	t.Run("svix", func(t *testing.T) {
+		// the example from the Standard Webhooks spec
+		h := Svix.sign([]byte("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"), "msg_p5jXN8AQM9LWM0D4loKWxJek", time.Unix(1614265330, 0), []byte(`{"test": 2432232314}`))
+		assert.Equal(t, "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=", h["svix-signature"])
+		assert.Equal(t, "1614265330", h["svix-timestamp"])
+	})
+	t.Run("stripe", func(t *testing.T) {
+		h := Stripe.sign([]byte("whsec_test"), "", time.Unix(1700000000, 0), []byte(`{}`))
+		assert.Regexp(t, `^t=1700000000,v1=[0-9a-f]{64}$`, h["Stripe-Signature"])
+	})
+}